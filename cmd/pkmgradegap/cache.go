@@ -0,0 +1,339 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/report"
+)
+
+const cacheUsage = `Usage: pkmgradegap cache <ls|show|invalidate|vacuum|stats> [flags]
+
+Inspect and maintain the provider cache.
+
+  ls          List namespaces with their entry counts
+  show        Show the age and TTL of a cache entry
+  invalidate  Remove every provider's entries for a set or card
+  vacuum      Remove expired entries and compact the store
+  stats       Show hit rates and predicted lookups
+
+Run "pkmgradegap cache <subcommand> --help" for flags.
+`
+
+func (c *cli) runCache(args []string) error {
+	subcommands := map[string]func(*options, []string) error{
+		"ls":         c.cacheList,
+		"show":       c.cacheShow,
+		"invalidate": c.cacheInvalidate,
+		"vacuum":     c.cacheVacuum,
+		"stats":      c.cacheStats,
+	}
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Fprint(c.stderr, cacheUsage)
+		if len(args) == 0 {
+			return usageErrorf("cache needs a subcommand")
+		}
+		return flag.ErrHelp
+	}
+	sub, ok := subcommands[args[0]]
+	if !ok {
+		fmt.Fprint(c.stderr, cacheUsage)
+		return usageErrorf("unknown cache subcommand %q", args[0])
+	}
+	return sub(defaultOptions(), args[1:])
+}
+
+// cacheFlagSet creates the flag set shared by every cache subcommand
+func (c *cli) cacheFlagSet(name, summary string, o *options) *flag.FlagSet {
+	fs := newFlagSet("cache "+name, summary, c.stderr)
+	fs.StringVar(&o.cachePath, "cache", o.cachePath, "Cache store directory")
+	return fs
+}
+
+// openCacheStore opens the cache without sweeping it, so expired entries
+// stay visible until vacuumed
+func openCacheStore(o *options) (*cache.Cache, error) {
+	store, err := cache.OpenKVStore(cache.StorePath(o.cachePath))
+	if err != nil {
+		return nil, err
+	}
+	return cache.NewWithBackend(store), nil
+}
+
+// openHotCache opens PriceCharting's hot layer in front of store if a run
+// with --hot-cache left one behind, and returns nil otherwise
+func openHotCache(o *options, store *cache.Cache) (*cache.MultiLayerCache, error) {
+	if _, err := os.Stat(hotCachePath(o)); err != nil {
+		return nil, nil
+	}
+	config := hotCacheConfig(o)
+	config.Backing = store
+	return cache.NewMultiLayerCache(config)
+}
+
+func (c *cli) cacheList(o *options, args []string) error {
+	fs := c.cacheFlagSet("ls", "List namespaces with their entry counts.", o)
+	addOutputFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(o.format); err != nil {
+		return err
+	}
+	store, err := openCacheStore(o)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	t := &report.Table{Columns: []report.Column{
+		{Name: "Namespace"},
+		{Name: "Entries", Kind: report.Integer},
+		{Name: "Expired", Kind: report.Integer},
+	}}
+	now := time.Now()
+	for _, ns := range cache.Namespaces {
+		keys, err := store.Backend().Keys(ns)
+		if err != nil {
+			return err
+		}
+		expired := 0
+		for _, key := range keys {
+			if e, ok, err := store.Backend().Get(ns, key); err == nil && ok && e.Expired(now) {
+				expired++
+			}
+		}
+		t.AddRow(ns, len(keys), expired)
+	}
+	return report.Write(c.stdout, o.format, t)
+}
+
+func (c *cli) cacheShow(o *options, args []string) error {
+	fs := c.cacheFlagSet("show", "Show the age and TTL of a cache entry.", o)
+	key := fs.String("key", "", "Cache key to show")
+	addSetFlags(fs, o)
+	card := fs.String("card", "", "Card name; with --set and --number, shows its PriceCharting entry")
+	number := fs.String("number", "", "Card number in the set")
+	variant := fs.String("variant", "", "PriceCharting variant, e.g. \"reverse holo\"")
+	addOutputFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(o.format); err != nil {
+		return err
+	}
+	switch {
+	case *key != "":
+	case o.set != "" && *card != "" && *number != "":
+		*key = cache.PriceChartingKey(o.set, *card, *number)
+		if *variant != "" {
+			*key = cache.BuildKey("pc", o.set, *card, *number, strings.ToLower(*variant))
+		}
+	default:
+		return usageErrorf("--key, or --set, --card and --number, are required")
+	}
+
+	store, err := openCacheStore(o)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	e, ok, err := store.Info(*key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no cache entry for %q", *key)
+	}
+
+	now := time.Now()
+	ttl, expires, status := "none", "never", "fresh"
+	if e.TTL > 0 {
+		ttl = e.TTL.String()
+		expires = e.Timestamp.Add(e.TTL).Format(time.RFC3339)
+	}
+	if e.Expired(now) {
+		status = "expired"
+	}
+	t := &report.Table{Columns: []report.Column{
+		{Name: "Key"}, {Name: "Namespace"}, {Name: "Stored"}, {Name: "Age"},
+		{Name: "TTL"}, {Name: "Expires"}, {Name: "Status"}, {Name: "Bytes", Kind: report.Integer},
+	}}
+	t.AddRow(*key, cache.NamespaceOf(*key), e.Timestamp.Format(time.RFC3339),
+		now.Sub(e.Timestamp).Round(time.Second).String(), ttl, expires, status, len(e.Data))
+	return report.Write(c.stdout, o.format, t)
+}
+
+func (c *cli) cacheInvalidate(o *options, args []string) error {
+	fs := c.cacheFlagSet("invalidate", "Remove every provider's entries for a set or card.", o)
+	addSetFlags(fs, o)
+	card := fs.String("card", "", "Only this card")
+	number := fs.String("number", "", "Only this card number (with --card)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if o.set == "" {
+		return usageErrorf("--set is required")
+	}
+	if *number != "" && *card == "" {
+		return usageErrorf("--number needs --card")
+	}
+
+	store, err := openCacheStore(o)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	removed, err := store.InvalidateCard(o.set, *card, *number)
+	if err != nil {
+		return err
+	}
+
+	hot, err := openHotCache(o, store)
+	if err != nil {
+		return err
+	}
+	if hot != nil {
+		for _, key := range cache.CardKeys(o.set, *card, *number) {
+			_ = hot.Delete(key)
+			n, err := hot.InvalidatePrefix(key + "|")
+			if err != nil {
+				return err
+			}
+			removed += n
+		}
+	}
+
+	target := o.set
+	switch {
+	case *number != "":
+		target = fmt.Sprintf("%s #%s in %s", *card, *number, o.set)
+	case *card != "":
+		target = fmt.Sprintf("%s in %s", *card, o.set)
+	}
+	fmt.Fprintf(c.stdout, "Removed %d cache entries for %s\n", removed, target)
+	return nil
+}
+
+func (c *cli) cacheVacuum(o *options, args []string) error {
+	fs := c.cacheFlagSet("vacuum", "Remove expired entries and compact the store.", o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	store, err := openCacheStore(o)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	removed, err := store.Sweep()
+	if err != nil {
+		return err
+	}
+	hot, err := openHotCache(o, store)
+	if err != nil {
+		return err
+	}
+	if hot != nil {
+		if err := hot.Optimize(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(c.stdout, "Removed %d expired cache entries\n", removed)
+	return nil
+}
+
+// cacheStatsExport is the JSON form of "cache stats"
+type cacheStatsExport struct {
+	Since       time.Time         `json:"since"`
+	Updated     time.Time         `json:"updated"`
+	Stats       cache.Stats       `json:"stats"`
+	Predictions []cachePrediction `json:"predictions"`
+}
+
+// cachePrediction is a key the predictor expects to be looked up next
+type cachePrediction struct {
+	Key         string  `json:"key"`
+	Probability float64 `json:"probability"`
+}
+
+func (c *cli) cacheStats(o *options, args []string) error {
+	fs := c.cacheFlagSet("stats", "Show hit rates and predicted lookups across runs.", o)
+	addOutputFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(o.format); err != nil {
+		return err
+	}
+	store, err := openCacheStore(o)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	usage, err := store.LoadUsage()
+	if err != nil {
+		return err
+	}
+	predictions := []cachePrediction{}
+	for _, t := range usage.Predictor().GetPredictions() {
+		predictions = append(predictions, cachePrediction{Key: t.Key, Probability: t.Probability})
+	}
+
+	if o.format == report.FormatJSON || o.format == report.FormatNDJSON {
+		enc := json.NewEncoder(c.stdout)
+		if o.format == report.FormatJSON {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(cacheStatsExport{
+			Since:       usage.Since,
+			Updated:     usage.Updated,
+			Stats:       usage.Stats,
+			Predictions: predictions,
+		})
+	}
+
+	if usage.Updated.IsZero() {
+		fmt.Fprintln(c.stdout, "No cache usage recorded yet")
+		return nil
+	}
+	fmt.Fprintf(c.stdout, "Cache usage since %s (last run %s)\n\n",
+		usage.Since.Format(time.RFC3339), usage.Updated.Format(time.RFC3339))
+
+	counts := &report.Table{Columns: []report.Column{
+		{Name: "Namespace"},
+		{Name: "Hits", Kind: report.Integer},
+		{Name: "Misses", Kind: report.Integer},
+		{Name: "Writes", Kind: report.Integer},
+		{Name: "Stale", Kind: report.Integer},
+		{Name: "HitRate", Kind: report.Percent, Precision: 1},
+	}}
+	addCounts := func(name string, n cache.Counts) {
+		counts.AddRow(name, int(n.Hits), int(n.Misses), int(n.Writes), int(n.Stale), n.HitRate()*100)
+	}
+	for _, ns := range usage.Stats.NamespaceNames() {
+		addCounts(ns, usage.Stats.Namespaces[ns])
+	}
+	addCounts("total", usage.Stats.Counts)
+	if err := report.Write(c.stdout, o.format, counts); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.stdout, "\nPredicted next lookups")
+	next := &report.Table{Columns: []report.Column{{Name: "Key"}, {Name: "Probability", Kind: report.Percent, Precision: 1}}}
+	for _, p := range predictions {
+		next.AddRow(p.Key, p.Probability*100)
+	}
+	if len(predictions) == 0 {
+		next.Notice = "Not enough recorded lookups to predict from"
+	}
+	return report.Write(c.stdout, o.format, next)
+}
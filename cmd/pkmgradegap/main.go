@@ -0,0 +1,165 @@
+// Command pkmgradegap finds Pokemon cards whose graded value is far enough
+// above the raw price to justify grading, and hosts the monitoring,
+// submission planning and web tooling built on that analysis.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/joho/godotenv"
+)
+
+const usageText = `Usage:
+  pkmgradegap <command> [flags]
+  pkmgradegap --set "Surging Sparks" [flags]   (same as "rank")
+
+Commands:
+  rank        Rank a set's cards by grading opportunity
+  list-sets   List all available sets
+  snapshot    Save a point-in-time price snapshot for a set
+  alerts      Compare two snapshots and report price and population alerts
+  history     Analyze trends in the picks history file
+  optimize    Plan bulk PSA submissions for a set
+  portfolio   Track owned cards through grading and sale, with P&L
+  refresh     Rebuild the pre-computed web cache
+  cache       Inspect, invalidate and vacuum the provider cache
+  server      Start the web interface
+
+Run "pkmgradegap <command> --help" for command flags.
+
+Legacy flags (no command):
+`
+
+// cli carries the output streams so commands can be exercised from tests
+type cli struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// usageError marks problems with the command line itself (exit code 2)
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// errFlagParse is returned for malformed flags; the flag package has already
+// printed the problem and the command usage.
+var errFlagParse = errors.New("invalid flags")
+
+// parseFlags parses args and maps flag errors onto errFlagParse
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return errFlagParse
+	}
+	return nil
+}
+
+func main() {
+	// A .env file is optional; variables already in the environment win
+	_ = godotenv.Load()
+
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run dispatches to a subcommand, or to the legacy flag-only interface when
+// the first argument is a flag, and returns the process exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	c := &cli{stdout: stdout, stderr: stderr}
+
+	commands := map[string]func([]string) error{
+		"rank":      c.runRank,
+		"list-sets": c.runListSets,
+		"snapshot":  c.runSnapshot,
+		"alerts":    c.runAlerts,
+		"history":   c.runHistory,
+		"optimize":  c.runOptimize,
+		"portfolio": c.runPortfolio,
+		"refresh":   c.runRefresh,
+		"cache":     c.runCache,
+		"server":    c.runServer,
+	}
+
+	var err error
+	if len(args) > 0 && len(args[0]) > 0 && args[0][0] != '-' {
+		cmd, ok := commands[args[0]]
+		if !ok {
+			fmt.Fprintf(stderr, "pkmgradegap: unknown command %q\n\n%s", args[0], usageText)
+			return 2
+		}
+		err = cmd(args[1:])
+	} else {
+		err = c.runLegacy(args)
+	}
+
+	var uerr *usageError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, flag.ErrHelp):
+		return 0
+	case errors.Is(err, errFlagParse):
+		return 2
+	case errors.As(err, &uerr):
+		fmt.Fprintf(stderr, "pkmgradegap: %v\n", err)
+		return 2
+	default:
+		fmt.Fprintf(stderr, "pkmgradegap: %v\n", err)
+		return 1
+	}
+}
+
+// runLegacy supports the original single-command interface documented in the
+// README, e.g. `pkmgradegap --set X --analysis crossgrade` or `--web`.
+func (c *cli) runLegacy(args []string) error {
+	o := defaultOptions()
+	fs := newFlagSet("pkmgradegap", "", c.stderr)
+	addSetFlags(fs, o)
+	fs.StringVar(&o.analysis, "analysis", o.analysis, "Mode: rank|raw-vs-psa10|psa9-cgc95-bgs95-vs-psa10|crossgrade|graders|alerts|trends|bulk-optimize|market-timing")
+	fs.BoolVar(&o.listSets, "list-sets", o.listSets, "List all available sets and exit")
+	fs.BoolVar(&o.web, "web", o.web, "Start the web interface (same as \"server\")")
+	addFilterFlags(fs, o)
+	addCostFlags(fs, o)
+	addScoringFlags(fs, o)
+	addSourceFlags(fs, o)
+	addDataFlags(fs, o)
+	addAlertFlags(fs, o)
+	addServerFlags(fs, o)
+	addWebCacheFlags(fs, o)
+	addOutputFlags(fs, o)
+	addSimulationFlags(fs, o)
+	addSubmissionFlags(fs, o)
+	addLogFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	switch {
+	case o.web:
+		return c.serve(o)
+	case o.listSets:
+		return c.listSets(o)
+	case o.analysis == "alerts":
+		return c.alerts(o, fs.Args())
+	case o.analysis == "trends":
+		return c.history(o, "")
+	case o.set == "" && o.snapshotIn == "":
+		fs.Usage()
+		return usageErrorf("--set is required (or use a command such as \"server\")")
+	default:
+		return c.rank(o)
+	}
+}
@@ -0,0 +1,625 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/analysis"
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/currency"
+	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/monitoring"
+	"github.com/guarzo/pkmgradegap/internal/population"
+)
+
+func writeTestSnapshot(t *testing.T, path string, ts time.Time, rawScale float64) {
+	t.Helper()
+	snap := &monitoring.Snapshot{
+		Timestamp: ts,
+		SetName:   "Surging Sparks",
+		Cards: map[string]*monitoring.SnapshotCardData{
+			"238-Pikachu ex": {
+				Card:       model.Card{Name: "Pikachu ex", Number: "238", SetName: "Surging Sparks"},
+				RawUSD:     100 * rawScale,
+				PSA10Price: 500,
+				PSA9Price:  200,
+			},
+			"001-Sprigatito": {
+				Card:       model.Card{Name: "Sprigatito", Number: "001", SetName: "Surging Sparks"},
+				RawUSD:     1,
+				PSA10Price: 10,
+			},
+		},
+	}
+	if err := monitoring.SaveSnapshot(path, snap); err != nil {
+		t.Fatalf("save snapshot: %v", err)
+	}
+}
+
+func TestRun_ExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"help", []string{"--help"}, 0},
+		{"command help", []string{"rank", "--help"}, 0},
+		{"unknown command", []string{"bogus"}, 2},
+		{"missing set", []string{}, 2},
+		{"bad flag", []string{"rank", "--no-such-flag"}, 2},
+		{"bad analysis mode", []string{"rank", "--set", "x", "--analysis", "nope"}, 2},
+		{"bad scoring model", []string{"rank", "--set", "x", "--scoring", "nope"}, 2},
+		{"bad output format", []string{"rank", "--set", "x", "--format", "xml"}, 2},
+		{"alerts without snapshots", []string{"alerts"}, 2},
+		{"missing snapshot file", []string{"rank", "--snapshot-in", "does-not-exist.json"}, 1},
+		{"bad fees date", []string{"rank", "--set", "x", "--fees-as-of", "last year"}, 2},
+		{"missing fee schedule", []string{"rank", "--set", "x", "--fee-schedule", "does-not-exist.json"}, 1},
+		{"unknown currency", []string{"rank", "--set", "x", "--currency", "XYZ"}, 2},
+		{"missing rates file", []string{"rank", "--set", "x", "--fx-rates", "does-not-exist.json"}, 1},
+		{"bad max staleness", []string{"rank", "--set", "x", "--max-stale", "soon"}, 2},
+		{"unknown census company", []string{"rank", "--set", "x", "--census", "psa"}, 2},
+		{"census CSV without a path", []string{"rank", "--set", "x", "--census-csv", "cgc"}, 2},
+		{"unknown population source", []string{"rank", "--set", "x", "--pop-sources", "api,psa"}, 2},
+		{"population priority out of range", []string{"rank", "--set", "x", "--pop-min-priority", "2"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got := run(tt.args, &stdout, &stderr); got != tt.want {
+				t.Errorf("run(%v) = %d, want %d (stderr: %s)", tt.args, got, tt.want, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRun_RankFromSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	snapPath := filepath.Join(dir, "snap.json")
+	historyPath := filepath.Join(dir, "history", "targets.csv")
+	writeTestSnapshot(t, snapPath, time.Now(), 1)
+
+	for _, args := range [][]string{
+		{"rank", "--snapshot-in", snapPath, "--history", historyPath},
+		{"--snapshot-in", snapPath, "--history", historyPath}, // legacy form
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("run(%v) = %d, stderr: %s", args, code, stderr.String())
+		}
+
+		out := stdout.String()
+		if !strings.HasPrefix(out, "Card,No,RawUSD,PSA10USD") {
+			t.Errorf("expected rank CSV header, got:\n%s", out)
+		}
+		if !strings.Contains(out, "Pikachu ex,238,$100.00,$500.00") {
+			t.Errorf("expected Pikachu row in output, got:\n%s", out)
+		}
+		// Sprigatito fails the default --min-raw-usd and --min-delta-usd filters
+		if strings.Contains(out, "Sprigatito") {
+			t.Errorf("expected Sprigatito to be filtered out, got:\n%s", out)
+		}
+	}
+
+	ha := monitoring.NewHistoryAnalyzer()
+	if err := ha.LoadHistory(historyPath); err != nil {
+		t.Fatalf("load history: %v", err)
+	}
+	report := ha.AnalyzeTrends()
+	if report.TotalEntries != 2 {
+		t.Errorf("expected 2 history entries (one per run), got %d", report.TotalEntries)
+	}
+}
+
+func TestRun_RankPrintings(t *testing.T) {
+	snapPath := filepath.Join(t.TempDir(), "snap.json")
+	card := model.Card{Name: "Pikachu ex", Number: "238", SetName: "Surging Sparks"}
+	rows := []analysis.Row{
+		{Card: card, Printing: "Holo", RawUSD: 100, Grades: analysis.Grades{PSA10: 500}},
+		{Card: card, Printing: "Reverse Holo", RawUSD: 150, Grades: analysis.Grades{PSA10: 900}},
+	}
+	if err := monitoring.SaveSnapshot(snapPath, monitoring.CreateSnapshotFromRows("Surging Sparks", rows)); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"rank", "--snapshot-in", snapPath, "--history", ""}, &stdout, &stderr); code != 0 {
+		t.Fatalf("rank = %d, stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Pikachu ex (Holo),238,$100.00,$500.00", "Pikachu ex (Reverse Holo),238,$150.00,$900.00"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected a row per printing with %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestRun_AlertsAndTiming(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.json")
+	newPath := filepath.Join(dir, "new.json")
+	writeTestSnapshot(t, oldPath, time.Now().Add(-48*time.Hour), 1)
+	writeTestSnapshot(t, newPath, time.Now(), 0.5)
+
+	var stdout, stderr bytes.Buffer
+	csvPath := filepath.Join(dir, "alerts.csv")
+	args := []string{"alerts", "--alert-csv", csvPath, oldPath, newPath}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("alerts exit %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Pikachu ex") {
+		t.Errorf("expected Pikachu price drop alert, got:\n%s", stdout.String())
+	}
+	if _, err := os.Stat(csvPath); err != nil {
+		t.Errorf("expected alert CSV to be written: %v", err)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	args = []string{"rank", "--set", "surging sparks", "--analysis", "market-timing", "--snapshot-dir", dir}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("market-timing exit %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "MARKET TIMING ANALYSIS - surging sparks") {
+		t.Errorf("unexpected timing report:\n%s", stdout.String())
+	}
+}
+
+func TestRun_PopulationAlerts(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.json")
+	newPath := filepath.Join(dir, "new.json")
+	writeTestSnapshot(t, oldPath, time.Now().Add(-48*time.Hour), 1)
+	writeTestSnapshot(t, newPath, time.Now(), 1)
+
+	history, err := population.OpenHistory(filepath.Join(dir, "pop.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := model.Card{Name: "Pikachu ex", Number: "238", SetName: "Surging Sparks"}
+	for _, obs := range []struct {
+		ago   time.Duration
+		psa10 int
+	}{{72 * time.Hour, 100}, {time.Hour, 150}} {
+		data := &population.PopulationData{PSA10Population: obs.psa10, TotalGraded: 400, LastUpdated: time.Now().Add(-obs.ago)}
+		if err := history.Record(card, data, "PSA"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"alerts", "--pop-history", filepath.Join(dir, "pop.jsonl"), oldPath, newPath}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("alerts exit %d, stderr: %s", code, stderr.String())
+	}
+	for _, want := range []string{"POPULATION_JUMP", "PSA 10 population jumped 50.0% (100 to 150)"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Errorf("expected %q in the report, got:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestHistoryEntries(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	rows := []analysis.ScoredRow{{
+		Row: analysis.Row{
+			Card:       model.Card{Name: "Pikachu ex", Number: "238"},
+			RawUSD:     45,
+			RawNote:    "USD",
+			Grades:     analysis.Grades{PSA10: 125},
+			Population: &model.PSAPopulation{PSA10: 900, Source: "Population Mock", Estimated: true},
+		},
+		Score:        42.5,
+		IsJapanese:   true,
+		NetProfitUSD: 30,
+		TotalCostUSD: 120,
+	}}
+
+	entries := historyEntries("Surging Sparks", rows, now)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+	e := entries[0]
+	if e.Card != "Pikachu ex" || e.Number != "238" || e.Set != "Surging Sparks" || e.Notes != "USD [JPN] [EST POP]" {
+		t.Errorf("unexpected identity fields: %+v", e)
+	}
+	if e.RawUSD != 45 || e.PSA10USD != 125 || e.DeltaUSD != 80 || e.Score != 42.5 {
+		t.Errorf("unexpected price fields: %+v", e)
+	}
+	if e.NetROI == nil || *e.NetROI != 0.25 {
+		t.Errorf("expected the net ROI on the all-in cost recorded, got %v", e.NetROI)
+	}
+	if !e.Timestamp.Equal(now) {
+		t.Errorf("expected timestamp %v, got %v", now, e.Timestamp)
+	}
+}
+
+func TestRun_RankFormats(t *testing.T) {
+	dir := t.TempDir()
+	snapPath := filepath.Join(dir, "snap.json")
+	writeTestSnapshot(t, snapPath, time.Now(), 1)
+
+	render := func(format string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		args := []string{"rank", "--snapshot-in", snapPath, "--history", "", "--format", format}
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("--format %s exit %d, stderr: %s", format, code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	var doc struct {
+		Columns []string         `json:"columns"`
+		Rows    []map[string]any `json:"rows"`
+	}
+	if err := json.Unmarshal([]byte(render("json")), &doc); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	if len(doc.Rows) != 1 || doc.Rows[0]["RawUSD"] != 100.0 || doc.Rows[0]["Card"] != "Pikachu ex" {
+		t.Errorf("unexpected json rows: %+v", doc.Rows)
+	}
+
+	lines := strings.Split(strings.TrimSpace(render("ndjson")), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"PSA10USD":500`) {
+		t.Errorf("unexpected ndjson output: %q", lines)
+	}
+
+	table := render("table")
+	if !strings.HasPrefix(table, "Card ") || !strings.Contains(table, "$100.00") {
+		t.Errorf("unexpected table output:\n%s", table)
+	}
+}
+
+func TestRun_RankSimulate(t *testing.T) {
+	snapPath := filepath.Join(t.TempDir(), "snap.json")
+	writeTestSnapshot(t, snapPath, time.Now(), 1)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"rank", "--snapshot-in", snapPath, "--history", "", "--simulate", "--trials", "200"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "SUBMISSION RISK (1 cards, 200 trials)") {
+		t.Errorf("expected the top picks' risk on stderr, got:\n%s", stderr.String())
+	}
+	if strings.Contains(stdout.String(), "SUBMISSION RISK") {
+		t.Error("expected the report on stdout left as a table")
+	}
+}
+
+func TestRun_RankInEUR(t *testing.T) {
+	dir := t.TempDir()
+	snapPath := filepath.Join(dir, "snap.json")
+	ratesPath := filepath.Join(dir, "rates.json")
+	writeTestSnapshot(t, snapPath, time.Now(), 1)
+	if err := os.WriteFile(ratesPath, []byte(`{"base": "USD", "asOf": "2024-05-01", "rates": {"EUR": 0.9}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"rank", "--snapshot-in", snapPath, "--history", "", "--currency", "eur", "--fx-rates", ratesPath}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d, stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "Card,No,RawEUR,PSA10EUR") || !strings.Contains(out, "Pikachu ex,238,€90.00,€450.00") {
+		t.Errorf("expected prices in EUR, got:\n%s", out)
+	}
+
+	// The loaded rates belong to that run; the next one without a file
+	// uses the fixed rates
+	stdout.Reset()
+	args = []string{"rank", "--snapshot-in", snapPath, "--history", "", "--currency", "eur"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d, stderr: %s", code, stderr.String())
+	}
+	if want := "Pikachu ex,238," + currency.Format(100*currency.Fixed().Rates[currency.EUR], currency.EUR); !strings.Contains(stdout.String(), want) {
+		t.Errorf("expected %q at the fixed rate, got:\n%s", want, stdout.String())
+	}
+}
+
+func TestRun_GradersMode(t *testing.T) {
+	dir := t.TempDir()
+	snapPath := filepath.Join(dir, "snap.json")
+	writeTestSnapshot(t, snapPath, time.Now(), 1)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"rank", "--snapshot-in", snapPath, "--history", "", "--analysis", "graders"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d, stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "Card,No,RawUSD,Best,") || !strings.Contains(out, "Pikachu ex") {
+		t.Errorf("unexpected graders report:\n%s", out)
+	}
+}
+
+func TestRowsFromSnapshot_GraderRecommendation(t *testing.T) {
+	// A gem-heavy card whose CGC 10s outsell its PSA 10s
+	row := analysis.Row{
+		Card:       model.Card{Name: "Umbreon", Number: "95", SetName: "Evolving Skies"},
+		RawUSD:     40,
+		Grades:     analysis.Grades{PSA10: 150, Grade9: 60, Grade95: 140, Grade8: 45, BGS10: 160, CGC10: 400, SGC10: 180},
+		Population: &model.PSAPopulation{TotalGraded: 1000, PSA10: 700, PSA9: 250, PSA8: 40},
+	}
+	path := filepath.Join(t.TempDir(), "snap.json")
+	if err := saveSnapshot(path, "Evolving Skies", []analysis.Row{row}); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := monitoring.LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := rowsFromSnapshot(snap)
+	if len(rows) != 1 {
+		t.Fatalf("expected 1 row, got %d", len(rows))
+	}
+
+	config := analysis.Config{FeePct: 0.13, ShippingCost: 20}
+	want := analysis.RecommendGrader(row, config, analysis.Graders())
+	got := analysis.RecommendGrader(rows[0], config, analysis.Graders())
+	if want.Best().Grader != "CGC" {
+		t.Fatalf("expected CGC to win before the round trip, got %+v", want.Options)
+	}
+	if !reflect.DeepEqual(got.Options, want.Options) {
+		t.Errorf("expected the same recommendation from the snapshot\ngot  %+v\nwant %+v", got.Options, want.Options)
+	}
+}
+
+func TestRun_OptimizeReportsLeftOut(t *testing.T) {
+	dir := t.TempDir()
+	snapPath := filepath.Join(dir, "snap.json")
+	writeTestSnapshot(t, snapPath, time.Now(), 1)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"optimize", "--snapshot-in", snapPath, "--trials", "0", "--max-cards", "5"}
+	if code := run(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d, stderr: %s", code, stderr.String())
+	}
+	// A single card can't fill any service level minimum
+	out := stdout.String()
+	if !strings.Contains(out, "LEFT OUT (1 cards)") || !strings.Contains(out, "Pikachu ex") {
+		t.Errorf("expected left-out report, got:\n%s", out)
+	}
+}
+
+func TestRun_FeeScheduleAtSnapshotDate(t *testing.T) {
+	dir := t.TempDir()
+	snapPath := filepath.Join(dir, "snap.json")
+	writeTestSnapshot(t, snapPath, time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), 1)
+
+	feePath := filepath.Join(dir, "fees.json")
+	feeJSON := `{"version": 1, "schedules": [
+	  {"grader": "PSA", "effective": "2020-01-01", "levels": [{"name": "Any", "costPerCard": 10, "turnaroundDays": 30}]},
+	  {"grader": "PSA", "effective": "2022-01-01", "levels": [{"name": "Any", "costPerCard": 40, "turnaroundDays": 30}]}
+	]}`
+	if err := os.WriteFile(feePath, []byte(feeJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cost := func(extra ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"rank", "--snapshot-in", snapPath, "--history", "", "--fee-schedule", feePath}, extra...)
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("exit %d, stderr: %s", code, stderr.String())
+		}
+		// Card,No,RawUSD,PSA10USD,DeltaUSD,CostUSD,...
+		lines := strings.Split(stdout.String(), "\n")
+		return strings.Split(lines[1], ",")[5]
+	}
+
+	// Raw $100 plus shipping $20 plus the fee in effect on the snapshot date
+	if got := cost(); got != "$130.00" {
+		t.Errorf("expected the 2021 fee, got cost %s", got)
+	}
+	if got := cost("--fees-as-of", "2024-01-01"); got != "$160.00" {
+		t.Errorf("expected the 2024 fee with --fees-as-of, got cost %s", got)
+	}
+	if got := cost("--grading-cost", "25"); got != "$145.00" {
+		t.Errorf("expected --grading-cost to override the schedule, got cost %s", got)
+	}
+}
+
+func TestExpectedValue(t *testing.T) {
+	tests := []struct {
+		name               string
+		psa10, psa9, grade float64
+		want               float64
+	}{
+		{"grade 9 is PSA 9 price", 100, 40, 9.0, 40},
+		{"grade 9.5 is midpoint", 100, 40, 9.5, 70},
+		{"grade capped at 10", 100, 40, 10.5, 100},
+		{"missing PSA 9 assumes half", 100, 0, 9.0, 50},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expectedValue(tt.psa10, tt.psa9, tt.grade); got != tt.want {
+				t.Errorf("expectedValue(%v, %v, %v) = %v, want %v", tt.psa10, tt.psa9, tt.grade, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRun_Portfolio(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portfolio.json")
+	t.Setenv("PRICECHARTING_TOKEN", "")
+	cachePath := filepath.Join(t.TempDir(), "cache.json")
+
+	steps := [][]string{
+		{"add", "--set", "Surging Sparks", "--card", "Pikachu ex", "--number", "238", "--cost", "100", "--date", "2024-11-10"},
+		{"submit", "--id", "1", "--tier", "value", "--date", "2024-11-12"},
+		{"return", "--id", "1", "--grade", "PSA 10", "--date", "2025-01-20"},
+		{"sell", "--id", "1", "--price", "500", "--fees", "65"},
+	}
+	for _, step := range steps {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"portfolio"}, append(step, "--portfolio", path)...)
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: exit %d, stderr: %s", step, code, stderr.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"portfolio", "list", "--portfolio", path}, &stdout, &stderr); code != 0 {
+		t.Fatalf("list: exit %d, stderr: %s", code, stderr.String())
+	}
+	// The Value fee comes from the schedule: $100 + $19
+	if out := stdout.String(); !strings.Contains(out, "Pikachu ex,238,Surging Sparks,sold,2024-11-10,,PSA,Value,,PSA 10,$119.00,$500.00") {
+		t.Errorf("unexpected list output:\n%s", out)
+	}
+
+	// Everything is sold, so marking needs no price lookups
+	stdout.Reset()
+	stderr.Reset()
+	if code := run([]string{"portfolio", "mark", "--portfolio", path, "--cache", cachePath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("mark: exit %d, stderr: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "316.00") || !strings.Contains(stderr.String(), "realised P&L $316.00") {
+		t.Errorf("expected realised P&L of $316, got:\n%s\n%s", stdout.String(), stderr.String())
+	}
+
+	for _, tt := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"portfolio"}, 2},
+		{[]string{"portfolio", "bogus"}, 2},
+		{[]string{"portfolio", "add", "--portfolio", path}, 2},
+		{[]string{"portfolio", "submit", "--portfolio", path, "--id", "1", "--tier", "Platinum"}, 2},
+		{[]string{"portfolio", "sell", "--portfolio", path, "--id", "1", "--price", "10"}, 1},
+	} {
+		if code := run(tt.args, &stdout, &stderr); code != tt.want {
+			t.Errorf("run(%v) = %d, want %d", tt.args, code, tt.want)
+		}
+	}
+}
+
+func TestRun_Cache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	store, err := cache.New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	_ = store.Put(cache.PriceChartingKey("Surging Sparks", "Pikachu ex", "238"), 1, time.Hour)
+	_ = store.Put(cache.BuildKey("sales", "Surging Sparks", "Pikachu ex", "238"), 2, time.Nanosecond)
+	_ = store.Put(cache.PriceChartingKey("151", "Mew", "151"), 3, time.Hour)
+	_, _ = store.Get(cache.PriceChartingKey("Surging Sparks", "Pikachu ex", "238"), &v)
+	if err := store.SaveUsage(); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	time.Sleep(time.Millisecond)
+
+	cacheRun := func(args ...string) string {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"cache"}, append(args, "--cache", path)...)
+		if code := run(args, &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: exit %d, stderr: %s", args, code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	if out := cacheRun("ls"); !strings.Contains(out, "pricecharting,2,0") || !strings.Contains(out, "sales,1,1") {
+		t.Errorf("unexpected ls output:\n%s", out)
+	}
+	out := cacheRun("show", "--set", "Surging Sparks", "--card", "Pikachu ex", "--number", "238")
+	if !strings.Contains(out, "pricecharting") || !strings.Contains(out, "1h0m0s") || !strings.Contains(out, "fresh") {
+		t.Errorf("unexpected show output:\n%s", out)
+	}
+	if out := cacheRun("stats", "--format", "json"); !strings.Contains(out, `"hits": 1`) {
+		t.Errorf("unexpected stats output:\n%s", out)
+	}
+	if out := cacheRun("invalidate", "--set", "Surging Sparks", "--card", "Pikachu ex"); !strings.Contains(out, "Removed 2 cache entries for Pikachu ex in Surging Sparks") {
+		t.Errorf("unexpected invalidate output:\n%s", out)
+	}
+	if out := cacheRun("vacuum"); !strings.Contains(out, "Removed 0 expired") {
+		t.Errorf("unexpected vacuum output:\n%s", out)
+	}
+	if out := cacheRun("ls"); !strings.Contains(out, "pricecharting,1,0") || !strings.Contains(out, "sales,0,0") {
+		t.Errorf("expected the set's other card to survive:\n%s", out)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"cache", "invalidate", "--cache", path}, &stdout, &stderr); code != 2 {
+		t.Errorf("invalidate without --set: exit %d, want 2", code)
+	}
+}
+
+func TestNewTargeting_LearnsFromHistory(t *testing.T) {
+	o := defaultOptions()
+	o.historyPath = filepath.Join(t.TempDir(), "targets.csv")
+	// A history started before the net ROI was recorded
+	legacy := "Timestamp,Card,Number,Set,RawUSD,PSA10USD,DeltaUSD,Score,Notes\n" +
+		"2025-01-01T00:00:00Z,Weedle,69,Base Set,2.00,60.00,58.00,10.00,USD\n"
+	if err := os.WriteFile(o.historyPath, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	netROI := 0.4
+	var entries []monitoring.HistoryEntry
+	for i := 1; i < 3; i++ {
+		entries = append(entries, monitoring.HistoryEntry{
+			Timestamp: time.Date(2025, 1, 1+7*i, 0, 0, 0, 0, time.UTC),
+			Card:      "Weedle",
+			Number:    "69",
+			Set:       "Base Set",
+			RawUSD:    2,
+			PSA10USD:  60,
+			DeltaUSD:  58,
+			NetROI:    &netROI,
+		})
+	}
+	if err := monitoring.NewHistoryAnalyzer().AppendHistory(o.historyPath, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	engine, err := newTargeting(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp := engine.FetchPriority(model.Card{SetName: "Base Set", Name: "Weedle", Number: "69"})
+	if !fp.Fetch || fp.TopPicks != 3 || fp.ROI != 0.4 {
+		t.Errorf("expected the past top pick prioritized at its net ROI, got %+v", fp)
+	}
+
+	// 0 is a minimum like any other, not a request for the default
+	o.popMinPriority = 0
+	if all, _ := newTargeting(o); !all.FetchPriority(model.Card{SetName: "Base Set", Name: "Caterpie", Number: "45"}).Fetch {
+		t.Error("expected --pop-min-priority 0 to look up every card")
+	}
+	if fp := engine.FetchPriority(model.Card{SetName: "Base Set", Name: "Caterpie", Number: "45"}); fp.Fetch {
+		t.Errorf("expected a card missing every run skipped, got %+v", fp)
+	}
+}
+
+func TestTargetingSavings(t *testing.T) {
+	rows := []analysis.Row{
+		{Card: model.Card{Name: "Charizard", Number: "4"}, PopPriority: 0.9},
+		{Card: model.Card{Name: "Weedle", Number: "69"}, PopPriority: 0.1, PopSkipped: true},
+		{Card: model.Card{Name: "Caterpie", Number: "45"}, PopPriority: 0.1, PopSkipped: true},
+		// A second printing shares its card's lookup
+		{Card: model.Card{Name: "Caterpie", Number: "45"}, Printing: "Reverse Holo", PopPriority: 0.1, PopSkipped: true},
+	}
+	top := []analysis.ScoredRow{{Row: rows[0]}, {Row: rows[1]}}
+
+	s, ok := targetingSavings(rows, top)
+	if !ok || s.Cards != 3 || s.Skipped != 2 || s.TopPicks != 2 {
+		t.Fatalf("unexpected savings %+v", s)
+	}
+	if len(s.SkippedTopPicks) != 1 || s.SkippedTopPicks[0] != "Weedle #69" {
+		t.Errorf("expected Weedle as the skipped top pick, got %v", s.SkippedTopPicks)
+	}
+
+	if _, ok := targetingSavings([]analysis.Row{{Card: model.Card{Name: "Pikachu"}}}, nil); ok {
+		t.Error("expected no savings for rows without targeting")
+	}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/analysis"
+	"github.com/guarzo/pkmgradegap/internal/monitoring"
+	"github.com/guarzo/pkmgradegap/internal/population"
+	"github.com/guarzo/pkmgradegap/internal/report"
+)
+
+func (c *cli) runListSets(args []string) error {
+	o := defaultOptions()
+	fs := newFlagSet("list-sets", "List all available sets.", c.stderr)
+	addCacheFlags(fs, o)
+	addOutputFlags(fs, o)
+	addLogFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return c.listSets(o)
+}
+
+func (c *cli) listSets(o *options) error {
+	p, err := newProviders(o, c.stderr)
+	if err != nil {
+		return err
+	}
+	defer p.close()
+	sets, err := p.cards.ListSets()
+	if err != nil {
+		return fmt.Errorf("list sets: %w", err)
+	}
+
+	t := &report.Table{Columns: []report.Column{{Name: "ID"}, {Name: "Name"}, {Name: "ReleaseDate"}}}
+	for _, s := range sets {
+		t.AddRow(s.ID, s.Name, s.ReleaseDate)
+	}
+	return c.writeReport(o, t)
+}
+
+func (c *cli) runSnapshot(args []string) error {
+	o := defaultOptions()
+	fs := newFlagSet("snapshot", "Save a point-in-time price snapshot for a set.", c.stderr)
+	addSetFlags(fs, o)
+	addSourceFlags(fs, o)
+	addCacheFlags(fs, o)
+	fs.StringVar(&o.snapshotOut, "out", o.snapshotOut, "Snapshot path (default <snapshot-dir>/<set>_<date>.json)")
+	fs.StringVar(&o.snapshotDir, "snapshot-dir", o.snapshotDir, "Directory for snapshots when --out is not given")
+	addLogFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	set, rows, err := c.loadRows(ctx, o)
+	if err != nil {
+		return err
+	}
+
+	path := o.snapshotOut
+	if path == "" {
+		slug := strings.ToLower(strings.Join(strings.Fields(set.Name), "_"))
+		path = filepath.Join(o.snapshotDir, fmt.Sprintf("%s_%s.json", slug, time.Now().Format("20060102")))
+	}
+	if err := saveSnapshot(path, set.Name, rows); err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "Saved %d cards from %s to %s\n", len(rows), set.Name, path)
+	return nil
+}
+
+func (c *cli) runAlerts(args []string) error {
+	o := defaultOptions()
+	fs := newFlagSet("alerts", "Compare two snapshots and report price and population alerts.\nSnapshots may also be given as two positional arguments: OLD NEW.", c.stderr)
+	addAlertFlags(fs, o)
+	fs.StringVar(&o.popHistoryPath, "pop-history", o.popHistoryPath, "Population history to check for supply shocks between the snapshots (empty=disable)")
+	addCostFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return c.alerts(o, fs.Args())
+}
+
+// alerts compares an old and a new snapshot, and the population history
+// between them, and prints the alert report
+func (c *cli) alerts(o *options, positional []string) error {
+	if err := useFees(o); err != nil {
+		return err
+	}
+	var paths []string
+	if o.compareSnapshots != "" {
+		paths = strings.Split(o.compareSnapshots, ",")
+	} else {
+		paths = positional
+	}
+	if len(paths) != 2 {
+		return usageErrorf("alerts needs exactly two snapshots (--compare-snapshots OLD,NEW)")
+	}
+	oldPath, newPath := strings.TrimSpace(paths[0]), strings.TrimSpace(paths[1])
+
+	oldSnap, err := monitoring.LoadSnapshot(oldPath)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", oldPath, err)
+	}
+	newSnap, err := monitoring.LoadSnapshot(newPath)
+	if err != nil {
+		return fmt.Errorf("load %s: %w", newPath, err)
+	}
+
+	config := monitoring.AlertConfig{
+		PriceDropThresholdPct:   o.alertThresholdPct,
+		PriceDropThresholdUSD:   o.alertThresholdUSD,
+		OpportunityThresholdROI: 20.0,
+		VolatilityHighThreshold: 25.0,
+		VolatilityLowThreshold:  2.0,
+		PopJumpThresholdPct:     o.popJumpPct,
+		GemRateDropThresholdPts: o.gemDropPts,
+		MinSeverity:             strings.ToUpper(o.minSeverity),
+	}
+	engine := monitoring.NewAlertEngine(config, o.feeBook)
+
+	deltas := monitoring.CompareSnapshots(oldSnap, newSnap, o.alertThresholdPct, o.alertThresholdUSD)
+	alerts := engine.GenerateAlerts(deltas)
+	alerts = append(alerts, engine.CheckNewOpportunities(oldSnap, newSnap, o.gradingCost, o.shipping, o.feePct)...)
+	alerts = append(alerts, engine.CheckVolatilityAlerts(oldSnap, newSnap)...)
+	if o.popHistoryPath != "" {
+		history, err := population.OpenHistory(o.popHistoryPath)
+		if err != nil {
+			return err
+		}
+		alerts = append(alerts, engine.CheckPopulationAlerts(monitoring.PopulationChanges(history, oldSnap, newSnap))...)
+	}
+
+	report := monitoring.GenerateAlertReport(alerts, oldSnap, newSnap, oldPath, newPath, config)
+	fmt.Fprint(c.stdout, monitoring.FormatAlertReport(report))
+
+	if o.alertCSV != "" {
+		if err := report.ExportToCSV(o.alertCSV); err != nil {
+			return fmt.Errorf("export alerts: %w", err)
+		}
+		fmt.Fprintf(c.stderr, "Exported %d alerts to %s\n", len(alerts), o.alertCSV)
+	}
+	return nil
+}
+
+func (c *cli) runHistory(args []string) error {
+	o := defaultOptions()
+	fs := newFlagSet("history", "Analyze trends in the picks history file.", c.stderr)
+	fs.StringVar(&o.historyPath, "history", o.historyPath, "History CSV written by rank")
+	export := fs.String("export", "", "Also write the trend report to this CSV file")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return c.history(o, *export)
+}
+
+func (c *cli) history(o *options, exportPath string) error {
+	if _, err := os.Stat(o.historyPath); err != nil {
+		return fmt.Errorf("history file %s: %w", o.historyPath, err)
+	}
+
+	ha := monitoring.NewHistoryAnalyzer()
+	if err := ha.LoadHistory(o.historyPath); err != nil {
+		return err
+	}
+	report := ha.AnalyzeTrends()
+	fmt.Fprint(c.stdout, monitoring.FormatTrendReport(report))
+
+	if exportPath != "" {
+		if err := monitoring.ExportTrendReportToCSV(report, exportPath); err != nil {
+			return fmt.Errorf("export trends: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *cli) runOptimize(args []string) error {
+	o := defaultOptions()
+	fs := newFlagSet("optimize", "Plan bulk PSA submissions for a set.\n--shipping is charged once per submission batch.", c.stderr)
+	addSetFlags(fs, o)
+	addFilterFlags(fs, o)
+	addCostFlags(fs, o)
+	addSourceFlags(fs, o)
+	addDataFlags(fs, o)
+	addSimulationFlags(fs, o)
+	addSubmissionFlags(fs, o)
+	addLogFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	o.analysis = "bulk-optimize"
+	return c.rank(o)
+}
+
+// printOptimization groups profitable candidates into PSA service-level batches
+func (c *cli) printOptimization(o *options, rows []analysis.Row) error {
+	var candidates []monitoring.SubmissionCard
+	for _, r := range rows {
+		if r.RawUSD <= 0 || r.Grades.PSA10 <= 0 || r.RawUSD < o.minRawUSD {
+			continue
+		}
+		if r.Grades.PSA10-r.RawUSD < o.minDeltaUSD {
+			continue
+		}
+
+		var psa10Rate, psa9Rate float64
+		if r.Population != nil && r.Population.TotalGraded > 0 {
+			psa10Rate = float64(r.Population.PSA10) / float64(r.Population.TotalGraded)
+			psa9Rate = float64(r.Population.PSA9) / float64(r.Population.TotalGraded)
+		}
+		grade := monitoring.EstimateExpectedGrade(psa10Rate, psa9Rate)
+
+		card := monitoring.SubmissionCard{
+			Card:          r.Card,
+			RawUSD:        r.RawUSD,
+			PSA10Price:    r.Grades.PSA10,
+			PSA9Price:     r.Grades.Grade9,
+			ExpectedGrade: grade,
+			ExpectedValue: expectedValue(r.Grades.PSA10, r.Grades.Grade9, grade),
+			Grade8Price:   r.Grades.Grade8,
+			PriceSpread:   r.PriceSpread,
+
+			PSA10Forecast30d: r.PSA10Forecast30d,
+		}
+		if r.Population != nil {
+			card.GradeOdds = analysis.EstimateGradeDistribution(r.Population)
+		}
+		candidates = append(candidates, card)
+	}
+
+	schedule := o.feeBook.PSA(o.feeDate())
+	optimizer := monitoring.NewBulkOptimizerWithSchedule(o.feePct, o.shipping, schedule)
+	optimizer.SetCostOfCapital(o.costOfCapital)
+	optimizer.SetGradingCost(o.gradingCost)
+	plan := optimizer.Plan(candidates, monitoring.SubmissionLimits{BudgetUSD: o.budget, MaxCards: o.maxCards})
+
+	fmt.Fprintf(c.stdout, "BULK SUBMISSION PLAN (%d candidate cards)\n\n", len(candidates))
+	if len(plan.Batches) == 0 {
+		fmt.Fprintln(c.stdout, "No service level could be filled to its minimum.")
+		if o.trials > 0 && len(candidates) > 0 {
+			// Still show the downside of submitting every candidate individually
+			sims := make([]monitoring.SimulationCard, len(candidates))
+			for i, card := range candidates {
+				fee := o.gradingCost
+				if fee <= 0 {
+					fee = schedule.Fee(card.PSA10Price)
+				}
+				sims[i] = monitoring.SimulationCardFromSubmission(card, fee)
+			}
+			sim := monitoring.NewRiskSimulator(o.trials, o.feePct, o.shipping, 1)
+			fmt.Fprintf(c.stdout, "\n%s\n", monitoring.FormatRiskReport(sim.Simulate(sims)))
+		}
+	}
+	for _, b := range plan.Batches {
+		fmt.Fprintln(c.stdout, optimizer.GenerateSubmissionForm(b))
+		if o.trials > 0 {
+			sim := monitoring.NewRiskSimulator(o.trials, o.feePct, o.shipping, 1)
+			fmt.Fprintln(c.stdout, monitoring.FormatRiskReport(sim.Simulate(monitoring.SimulationCardsFromBatch(b))))
+		}
+	}
+	if len(plan.Batches) > 0 {
+		fmt.Fprintf(c.stdout, "Plan Total Cost: %s\n\n", o.money(plan.TotalCost()))
+	}
+	if len(plan.Excluded) > 0 {
+		fmt.Fprintf(c.stdout, "LEFT OUT (%d cards):\n", len(plan.Excluded))
+		for _, e := range plan.Excluded {
+			fmt.Fprintf(c.stdout, "- %s #%s: %s\n", e.Card.Card.Name, e.Card.Card.Number, e.Reason)
+		}
+		fmt.Fprintln(c.stdout)
+	}
+	fmt.Fprintf(c.stdout, "Submission timing: %s\n", optimizer.RecommendSubmissionTiming())
+	fmt.Fprintf(c.stdout, "Bulk pricing: %s\n", optimizer.SuggestBulkDiscounts(len(candidates)))
+	return nil
+}
+
+// expectedValue interpolates between the PSA 9 and PSA 10 prices using the
+// expected grade. Without a PSA 9 price, PSA 9 is assumed to be half of PSA 10.
+func expectedValue(psa10, psa9, grade float64) float64 {
+	weight := grade - 9
+	if weight < 0 {
+		weight = 0
+	}
+	if weight > 1 {
+		weight = 1
+	}
+	if psa9 <= 0 {
+		psa9 = psa10 * 0.5
+	}
+	return psa9 + weight*(psa10-psa9)
+}
+
+// marketTiming analyzes every saved snapshot for the set in --snapshot-dir
+func (c *cli) marketTiming(o *options) error {
+	if o.set == "" {
+		return usageErrorf("--set is required for market-timing")
+	}
+
+	files, err := filepath.Glob(filepath.Join(o.snapshotDir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("list snapshots: %w", err)
+	}
+
+	var snapshots []*monitoring.Snapshot
+	for _, f := range files {
+		snap, err := monitoring.LoadSnapshot(f)
+		if err != nil {
+			c.debugf(o, "skipping %s: %v", f, err)
+			continue
+		}
+		if strings.EqualFold(snap.SetName, o.set) {
+			snapshots = append(snapshots, snap)
+		}
+	}
+	if len(snapshots) < 2 {
+		return fmt.Errorf("market-timing needs at least two snapshots of %q in %s (found %d); save more with the snapshot command", o.set, o.snapshotDir, len(snapshots))
+	}
+
+	analyzer := monitoring.NewMarketAnalyzer(snapshots, o.feeBook)
+	recs := analyzer.AnalyzeMarket(o.gradingCost, o.shipping, o.feePct)
+	fmt.Fprint(c.stdout, monitoring.FormatTimingReport(recs, o.set, analyzer.SeasonalAnalysis()))
+	return nil
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/analysis"
+	"github.com/guarzo/pkmgradegap/internal/currency"
+	"github.com/guarzo/pkmgradegap/internal/fees"
+	"github.com/guarzo/pkmgradegap/internal/report"
+)
+
+// options holds every flag the CLI understands. Subcommands register only the
+// groups they use; the legacy single-command mode registers all of them.
+type options struct {
+	// Selection
+	set      string
+	analysis string
+	listSets bool
+	web      bool
+
+	// Filters
+	maxAgeYears      int
+	minDeltaUSD      float64
+	minRawUSD        float64
+	top              int
+	allowThinPremium bool
+
+	// Costs
+	gradingCost float64
+	shipping    float64
+	feePct      float64
+	feeSchedule string
+	feesAsOf    string
+	feeBook     *fees.Book // loaded from --fee-schedule by useFees; nil uses the built-in schedules
+
+	costOfCapital float64
+
+	// Scoring modifiers
+	japaneseWeight float64
+	why            bool
+	scoring        string
+
+	// Data sources
+	withEbay       bool
+	ebayMax        int
+	withGamestop   bool
+	withPop        bool
+	withPopAPI     bool
+	popSources     string
+	popCSV         string
+	popTargeting   bool
+	popMinPriority float64
+	census         string
+	censusCSV      string
+	withSales      bool
+	withVolatility bool
+	fusionMode     bool
+	projectPrices  bool
+
+	// Data management
+	cachePath      string
+	cacheTTL       time.Duration
+	hotCache       bool
+	maxStale       string
+	snapshotIn     string
+	snapshotOut    string
+	snapshotDir    string
+	historyPath    string
+	volatilityPath string
+	popHistoryPath string
+	portfolioPath  string
+
+	// Monitoring & alerts
+	compareSnapshots  string
+	alertThresholdPct float64
+	alertThresholdUSD float64
+	alertCSV          string
+	minSeverity       string
+	popJumpPct        float64
+	gemDropPts        float64
+
+	// Server & web cache
+	port            int
+	autoOpen        bool
+	webCacheDir     string
+	refreshSchedule string
+	maxSets         int
+	force           bool
+
+	// Output
+	format   string
+	currency string
+	fxRates  string
+	rates    currency.Provider // loaded from --fx-rates by useCurrency; nil uses the fixed rates
+	trials   int
+	simulate bool
+
+	// Bulk submission
+	budget   float64
+	maxCards int
+
+	// Utility
+	verbose bool
+	debug   bool
+}
+
+func defaultOptions() *options {
+	return &options{
+		analysis:          "rank",
+		maxAgeYears:       10,
+		minDeltaUSD:       25,
+		minRawUSD:         5,
+		top:               25,
+		gradingCost:       0,
+		shipping:          20,
+		feePct:            0.13,
+		costOfCapital:     0.08,
+		japaneseWeight:    1.0,
+		scoring:           analysis.ScoringHeuristic,
+		ebayMax:           3,
+		cachePath:         "data/cache.kv",
+		cacheTTL:          24 * time.Hour,
+		snapshotDir:       "data/snapshots",
+		historyPath:       "data/targets.csv",
+		volatilityPath:    "data/volatility.json",
+		popHistoryPath:    "data/pop_history.jsonl",
+		popSources:        "api,scraper,csv,cache",
+		popMinPriority:    0.3,
+		portfolioPath:     "data/portfolio.json",
+		alertThresholdPct: 10.0,
+		alertThresholdUSD: 5.0,
+		minSeverity:       "LOW",
+		popJumpPct:        10.0,
+		gemDropPts:        5.0,
+		port:              8080,
+		refreshSchedule:   "0 4 * * *",
+		maxSets:           100,
+		format:            report.FormatCSV,
+		currency:          currency.USD,
+		trials:            10000,
+	}
+}
+
+// newFlagSet creates a flag set that reports errors instead of exiting so
+// run() can map them onto exit codes.
+func newFlagSet(name, summary string, output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		if name == "pkmgradegap" {
+			fmt.Fprint(output, usageText)
+		} else {
+			fmt.Fprintf(output, "Usage: pkmgradegap %s [flags]\n\n%s\n\nFlags:\n", name, summary)
+		}
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func addSetFlags(fs *flag.FlagSet, o *options) {
+	fs.StringVar(&o.set, "set", o.set, "Set name or ID to analyze")
+}
+
+func addAnalysisFlags(fs *flag.FlagSet, o *options) {
+	fs.StringVar(&o.analysis, "analysis", o.analysis, "Mode: rank|raw-vs-psa10|psa9-cgc95-bgs95-vs-psa10|crossgrade|graders|bulk-optimize|market-timing")
+}
+
+func addFilterFlags(fs *flag.FlagSet, o *options) {
+	fs.IntVar(&o.maxAgeYears, "max-age-years", o.maxAgeYears, "Only sets released within N years (0=disable)")
+	fs.Float64Var(&o.minDeltaUSD, "min-delta-usd", o.minDeltaUSD, "Minimum PSA10-Raw gap required")
+	fs.Float64Var(&o.minRawUSD, "min-raw-usd", o.minRawUSD, "Minimum raw card price")
+	fs.IntVar(&o.top, "top", o.top, "Show top N results")
+	fs.BoolVar(&o.allowThinPremium, "allow-thin-premium", o.allowThinPremium, "Allow cards with PSA9/PSA10 > 0.75")
+}
+
+func addCostFlags(fs *flag.FlagSet, o *options) {
+	fs.Float64Var(&o.gradingCost, "grading-cost", o.gradingCost, "PSA grading fee per card (0=use the fee schedule)")
+	fs.StringVar(&o.feeSchedule, "fee-schedule", o.feeSchedule, "JSON file of grading fee schedules (default: built-in)")
+	fs.StringVar(&o.feesAsOf, "fees-as-of", o.feesAsOf, "Price grading with the fee schedule in effect on YYYY-MM-DD (default: today, or the snapshot date)")
+	fs.Float64Var(&o.shipping, "shipping", o.shipping, "Round-trip shipping cost")
+	fs.Float64Var(&o.feePct, "fee-pct", o.feePct, "Selling fee percentage")
+	fs.Float64Var(&o.costOfCapital, "cost-of-capital", o.costOfCapital, "Annual rate to discount proceeds at over the grading turnaround (0=disable)")
+}
+
+func addScoringFlags(fs *flag.FlagSet, o *options) {
+	fs.Float64Var(&o.japaneseWeight, "japanese-weight", o.japaneseWeight, "Multiplier for Japanese cards")
+	fs.BoolVar(&o.why, "why", o.why, "Show scoring factor breakdown")
+	fs.StringVar(&o.scoring, "scoring", o.scoring, "Scoring model: heuristic|ev|risk")
+}
+
+func addSourceFlags(fs *flag.FlagSet, o *options) {
+	fs.BoolVar(&o.withEbay, "with-ebay", o.withEbay, "Fetch current eBay listings (requires EBAY_APP_ID)")
+	fs.IntVar(&o.ebayMax, "ebay-max", o.ebayMax, "Max eBay listings per card")
+	fs.BoolVar(&o.withGamestop, "with-gamestop", o.withGamestop, "Include GameStop graded listings (web scraping)")
+	addPopulationFlags(fs, o)
+	fs.BoolVar(&o.withSales, "with-sales", o.withSales, "Include sales transaction data")
+	fs.BoolVar(&o.withVolatility, "with-volatility", o.withVolatility, "Include 30-day price volatility data")
+	fs.BoolVar(&o.fusionMode, "fusion-mode", o.fusionMode, "Enable multi-source data fusion")
+	fs.BoolVar(&o.projectPrices, "project-prices", o.projectPrices, "Project PSA 10 prices to the grading return date from PriceCharting price history")
+}
+
+func addPopulationFlags(fs *flag.FlagSet, o *options) {
+	fs.BoolVar(&o.withPop, "with-pop", o.withPop, "Include PSA population data")
+	fs.BoolVar(&o.withPopAPI, "with-pop-api", o.withPopAPI, "Include PSA population data via the PSA API (implies --with-pop)")
+	fs.StringVar(&o.popSources, "pop-sources", o.popSources, "PSA population sources to try in order: api (needs PSA_POPULATION_API_KEY), scraper, csv (needs --pop-csv) and cache (the last counts found, up to 30 days old)")
+	fs.StringVar(&o.popCSV, "pop-csv", o.popCSV, "PSA population CSV, or a directory of them, for the csv population source")
+	fs.BoolVar(&o.popTargeting, "pop-targeting", o.popTargeting, "Look up population only for cards with a high enough fetch priority, learned from the past top picks in --history")
+	fs.Float64Var(&o.popMinPriority, "pop-min-priority", o.popMinPriority, "Fetch priority (0-1) a card needs for a population lookup with --pop-targeting")
+	fs.StringVar(&o.popHistoryPath, "pop-history", o.popHistoryPath, "Record population lookups here and read growth trends from them (empty=disable)")
+	fs.StringVar(&o.census, "census", o.census, "Also look up these companies' census, e.g. cgc,bgs, so scarcity counts every company's 10s (implies --with-pop)")
+	fs.StringVar(&o.censusCSV, "census-csv", o.censusCSV, "Import companies' census from CSV instead of scraping, e.g. cgc=data/cgc.csv,bgs=data/bgs.csv (implies --with-pop)")
+}
+
+func addCacheFlags(fs *flag.FlagSet, o *options) {
+	fs.StringVar(&o.cachePath, "cache", o.cachePath, "Cache store directory; a JSON cache file from older versions beside it is migrated")
+	fs.DurationVar(&o.cacheTTL, "cache-ttl", o.cacheTTL, "Maximum cache time-to-live (0=provider defaults)")
+	fs.BoolVar(&o.hotCache, "hot-cache", o.hotCache, "Keep busy PriceCharting lookups in a memory and compressed-disk layer in front of the cache")
+	fs.StringVar(&o.maxStale, "max-stale", o.maxStale, "Serve cached PriceCharting matches up to this long past their TTL while refreshing them in the background: a duration such as 72h, or namespace=duration pairs such as pricecharting=72h")
+}
+
+func addDataFlags(fs *flag.FlagSet, o *options) {
+	addCacheFlags(fs, o)
+	fs.StringVar(&o.snapshotIn, "snapshot-in", o.snapshotIn, "Load price data from snapshot instead of the APIs")
+	fs.StringVar(&o.snapshotOut, "snapshot-out", o.snapshotOut, "Save price data for reproducibility")
+	fs.StringVar(&o.snapshotDir, "snapshot-dir", o.snapshotDir, "Directory of saved snapshots (market-timing)")
+	fs.StringVar(&o.historyPath, "history", o.historyPath, "Append top picks here (empty=disable)")
+	fs.StringVar(&o.volatilityPath, "volatility-file", o.volatilityPath, "Price history file for --with-volatility")
+}
+
+func addAlertFlags(fs *flag.FlagSet, o *options) {
+	fs.StringVar(&o.compareSnapshots, "compare-snapshots", o.compareSnapshots, "Compare two snapshots for price alerts (PATH1,PATH2)")
+	fs.Float64Var(&o.alertThresholdPct, "alert-threshold-pct", o.alertThresholdPct, "Alert threshold for percentage change")
+	fs.Float64Var(&o.alertThresholdUSD, "alert-threshold-usd", o.alertThresholdUSD, "Alert threshold for dollar change")
+	fs.StringVar(&o.alertCSV, "alert-csv", o.alertCSV, "Export alerts to CSV file")
+	fs.StringVar(&o.minSeverity, "min-severity", o.minSeverity, "Only report alerts at or above this severity (LOW|MEDIUM|HIGH)")
+	fs.Float64Var(&o.popJumpPct, "pop-jump-pct", o.popJumpPct, "Alert when the PSA 10 population grows by this % between snapshots (0=disable)")
+	fs.Float64Var(&o.gemDropPts, "gem-drop-pts", o.gemDropPts, "Alert when the gem rate falls by this many percentage points between snapshots (0=disable)")
+}
+
+func addServerFlags(fs *flag.FlagSet, o *options) {
+	fs.IntVar(&o.port, "port", o.port, "Web server port")
+	fs.BoolVar(&o.autoOpen, "auto-open", o.autoOpen, "Auto-open browser on server start")
+	fs.StringVar(&o.refreshSchedule, "refresh-schedule", o.refreshSchedule, "Cron schedule for web cache refresh (empty=disable)")
+}
+
+func addWebCacheFlags(fs *flag.FlagSet, o *options) {
+	fs.StringVar(&o.webCacheDir, "web-cache-dir", o.webCacheDir, "Directory for pre-computed web results")
+	fs.IntVar(&o.maxSets, "max-sets", o.maxSets, "Maximum number of sets to process during refresh")
+}
+
+func addOutputFlags(fs *flag.FlagSet, o *options) {
+	fs.StringVar(&o.format, "format", o.format, "Output format: "+strings.Join(report.Formats, "|"))
+	fs.StringVar(&o.currency, "currency", o.currency, "Currency for reported prices, e.g. USD or EUR")
+	fs.StringVar(&o.fxRates, "fx-rates", o.fxRates, "JSON exchange rates file (default: built-in approximate rates)")
+}
+
+func addSimulationFlags(fs *flag.FlagSet, o *options) {
+	fs.IntVar(&o.trials, "trials", o.trials, "Monte Carlo trials per batch for submission risk (0=disable)")
+	fs.BoolVar(&o.simulate, "simulate", o.simulate, "Simulate submitting rank's top picks together and report the profit range on stderr")
+}
+
+func addSubmissionFlags(fs *flag.FlagSet, o *options) {
+	fs.Float64Var(&o.budget, "budget", o.budget, "Cap on raw purchases, grading fees and shipping for bulk-optimize (0=unlimited)")
+	fs.IntVar(&o.maxCards, "max-cards", o.maxCards, "Maximum cards in a bulk-optimize plan (0=unlimited)")
+}
+
+func addLogFlags(fs *flag.FlagSet, o *options) {
+	fs.BoolVar(&o.verbose, "verbose", o.verbose, "Enable verbose logging")
+	fs.BoolVar(&o.debug, "debug", o.debug, "Enable debug mode")
+}
+
+// envBool reports whether an environment variable is set to a true value
+func envBool(name string) bool {
+	enabled, _ := strconv.ParseBool(os.Getenv(name))
+	return enabled
+}
+
+// useFees loads --fee-schedule as the run's fee book and checks --fees-as-of
+func useFees(o *options) error {
+	if o.feesAsOf != "" {
+		if _, err := time.Parse("2006-01-02", o.feesAsOf); err != nil {
+			return usageErrorf("--fees-as-of: want YYYY-MM-DD, got %q", o.feesAsOf)
+		}
+	}
+	if o.feeSchedule == "" {
+		return nil
+	}
+	book, err := fees.Load(o.feeSchedule)
+	if err != nil {
+		return err
+	}
+	o.feeBook = book
+	return nil
+}
+
+// useCurrency loads --fx-rates as the run's exchange rates and checks
+// --currency has a rate
+func useCurrency(o *options) error {
+	if o.fxRates != "" {
+		rates, err := currency.Load(o.fxRates)
+		if err != nil {
+			return err
+		}
+		o.rates = rates
+	}
+	o.currency = strings.ToUpper(o.currency)
+	if _, err := o.fx().Rate(currency.USD, o.currency); err != nil {
+		return usageErrorf("--currency: %v", err)
+	}
+	return nil
+}
+
+// fx returns the --fx-rates exchange rates, or the fixed rates without a file
+func (o *options) fx() currency.Provider {
+	if o.rates != nil {
+		return o.rates
+	}
+	return currency.Fixed()
+}
+
+// money formats a USD amount in the --currency, e.g. "€12.34"
+func (o *options) money(usd float64) string {
+	rate, err := o.fx().Rate(currency.USD, o.currency)
+	if err != nil {
+		return currency.Format(usd, currency.USD)
+	}
+	return currency.Format(rate.Convert(usd), rate.To)
+}
+
+// feeDate returns the --fees-as-of date, defaulting to now
+func (o *options) feeDate() time.Time {
+	if t, err := time.Parse("2006-01-02", o.feesAsOf); err == nil {
+		return t
+	}
+	return time.Now()
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/fees"
+	"github.com/guarzo/pkmgradegap/internal/portfolio"
+	"github.com/guarzo/pkmgradegap/internal/report"
+)
+
+const portfolioUsage = `Usage: pkmgradegap portfolio <add|submit|return|sell|list|mark> [flags]
+
+Track the cards we own through purchase, grading and sale.
+
+  add      Record a purchase
+  submit   Record sending a card to a grader
+  return   Record the grade a card came back with
+  sell     Record a sale
+  list     List holdings as recorded
+  mark     Value holdings at current PriceCharting prices (needs PRICECHARTING_TOKEN)
+
+Run "pkmgradegap portfolio <subcommand> --help" for flags.
+`
+
+func (c *cli) runPortfolio(args []string) error {
+	subcommands := map[string]func(*options, []string) error{
+		"add":    c.portfolioAdd,
+		"submit": c.portfolioSubmit,
+		"return": c.portfolioReturn,
+		"sell":   c.portfolioSell,
+		"list":   c.portfolioList,
+		"mark":   c.portfolioMark,
+	}
+	if len(args) == 0 || args[0] == "--help" || args[0] == "-h" {
+		fmt.Fprint(c.stderr, portfolioUsage)
+		if len(args) == 0 {
+			return usageErrorf("portfolio needs a subcommand")
+		}
+		return flag.ErrHelp
+	}
+	sub, ok := subcommands[args[0]]
+	if !ok {
+		fmt.Fprint(c.stderr, portfolioUsage)
+		return usageErrorf("unknown portfolio subcommand %q", args[0])
+	}
+	return sub(defaultOptions(), args[1:])
+}
+
+func addPortfolioFlags(fs *flag.FlagSet, o *options) {
+	fs.StringVar(&o.portfolioPath, "portfolio", o.portfolioPath, "Portfolio file")
+}
+
+// portfolioFlagSet creates the flag set shared by every portfolio subcommand
+func (c *cli) portfolioFlagSet(name, summary string, o *options) *flag.FlagSet {
+	fs := newFlagSet("portfolio "+name, summary, c.stderr)
+	addPortfolioFlags(fs, o)
+	return fs
+}
+
+// parseDay reads a YYYY-MM-DD flag value, defaulting to today
+func parseDay(flagName, value string) (time.Time, error) {
+	if value == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, usageErrorf("--%s: want YYYY-MM-DD, got %q", flagName, value)
+	}
+	return t, nil
+}
+
+// updatePortfolio opens the store, applies change and saves it
+func updatePortfolio(o *options, change func(*portfolio.Store) error) error {
+	store, err := portfolio.Open(o.portfolioPath)
+	if err != nil {
+		return err
+	}
+	if err := change(store); err != nil {
+		return err
+	}
+	return store.Save()
+}
+
+func (c *cli) portfolioAdd(o *options, args []string) error {
+	fs := c.portfolioFlagSet("add", "Record a purchase.", o)
+	addSetFlags(fs, o)
+	card := fs.String("card", "", "Card name")
+	number := fs.String("number", "", "Card number in the set")
+	cost := fs.Float64("cost", 0, "Purchase price in USD, including buyer fees and shipping")
+	source := fs.String("source", "", "Where the card was bought, e.g. eBay")
+	date := fs.String("date", "", "Purchase date YYYY-MM-DD (default today)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if o.set == "" || *card == "" {
+		return usageErrorf("--set and --card are required")
+	}
+	bought, err := parseDay("date", *date)
+	if err != nil {
+		return err
+	}
+
+	return updatePortfolio(o, func(s *portfolio.Store) error {
+		h, err := s.Add(portfolio.Holding{
+			SetName:  o.set,
+			CardName: *card,
+			Number:   *number,
+			Purchase: portfolio.Purchase{Date: bought, CostUSD: *cost, Source: *source},
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Added holding %d: %s #%s (%s) for $%.2f\n", h.ID, h.CardName, h.Number, h.SetName, *cost)
+		return nil
+	})
+}
+
+func (c *cli) portfolioSubmit(o *options, args []string) error {
+	fs := c.portfolioFlagSet("submit", "Record sending a card to a grader.\nWith --fee 0 the fee comes from the grader's fee schedule for --tier.", o)
+	id := fs.Int("id", 0, "Holding ID")
+	grader := fs.String("grader", "PSA", "Grading company")
+	tier := fs.String("tier", "", "Service level, e.g. Value")
+	subID := fs.String("submission-id", "", "Grader's submission number")
+	fee := fs.Float64("fee", 0, "Grading fee for this card (0=from the fee schedule)")
+	shipping := fs.Float64("shipping", 0, "This card's share of shipping and insurance")
+	date := fs.String("date", "", "Submission date YYYY-MM-DD (default today)")
+	fs.StringVar(&o.feeSchedule, "fee-schedule", o.feeSchedule, "JSON file of grading fee schedules (default: built-in)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *id <= 0 {
+		return usageErrorf("--id is required")
+	}
+	submitted, err := parseDay("date", *date)
+	if err != nil {
+		return err
+	}
+	if err := useFees(o); err != nil {
+		return err
+	}
+	if *fee <= 0 {
+		if *tier == "" {
+			return usageErrorf("give --fee or a --tier to look up in the fee schedule")
+		}
+		level, err := scheduleLevel(o.feeBook, *grader, *tier, submitted)
+		if err != nil {
+			return err
+		}
+		*tier = level.Name
+		*fee = level.CostPerCard
+	}
+
+	return updatePortfolio(o, func(s *portfolio.Store) error {
+		err := s.Submit(*id, portfolio.Submission{
+			Grader:       strings.ToUpper(*grader),
+			Tier:         *tier,
+			SubmissionID: *subID,
+			FeeUSD:       *fee,
+			ShippingUSD:  *shipping,
+			Submitted:    submitted,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Submitted holding %d to %s %s at $%.2f\n", *id, strings.ToUpper(*grader), *tier, *fee)
+		return nil
+	})
+}
+
+// scheduleLevel finds a service level by name in the grader's fee schedule
+// in effect on the given date
+func scheduleLevel(book *fees.Book, grader, tier string, at time.Time) (fees.ServiceLevel, error) {
+	schedule, err := book.At(grader, at)
+	if err != nil {
+		return fees.ServiceLevel{}, err
+	}
+	names := make([]string, len(schedule.Levels))
+	for i, l := range schedule.Levels {
+		if strings.EqualFold(l.Name, tier) {
+			return l, nil
+		}
+		names[i] = l.Name
+	}
+	return fees.ServiceLevel{}, usageErrorf("--tier: %s has no %q level (available: %s)", grader, tier, strings.Join(names, ", "))
+}
+
+func (c *cli) portfolioReturn(o *options, args []string) error {
+	fs := c.portfolioFlagSet("return", "Record the grade a card came back with.", o)
+	id := fs.Int("id", 0, "Holding ID")
+	grade := fs.String("grade", "", "Grade received, e.g. \"PSA 10\" or \"BGS 9.5\"")
+	date := fs.String("date", "", "Return date YYYY-MM-DD (default today)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *id <= 0 || *grade == "" {
+		return usageErrorf("--id and --grade are required")
+	}
+	returned, err := parseDay("date", *date)
+	if err != nil {
+		return err
+	}
+
+	return updatePortfolio(o, func(s *portfolio.Store) error {
+		if err := s.Return(*id, *grade, returned); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "Holding %d returned %s\n", *id, *grade)
+		return nil
+	})
+}
+
+func (c *cli) portfolioSell(o *options, args []string) error {
+	fs := c.portfolioFlagSet("sell", "Record a sale.", o)
+	id := fs.Int("id", 0, "Holding ID")
+	price := fs.Float64("price", 0, "Sale price in USD")
+	feesUSD := fs.Float64("fees", 0, "Marketplace and payment fees in USD")
+	venue := fs.String("venue", "", "Where the card sold, e.g. eBay")
+	date := fs.String("date", "", "Sale date YYYY-MM-DD (default today)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *id <= 0 || *price <= 0 {
+		return usageErrorf("--id and --price are required")
+	}
+	sold, err := parseDay("date", *date)
+	if err != nil {
+		return err
+	}
+
+	return updatePortfolio(o, func(s *portfolio.Store) error {
+		if err := s.Sell(*id, portfolio.Sale{Date: sold, PriceUSD: *price, FeesUSD: *feesUSD, Venue: *venue}); err != nil {
+			return err
+		}
+		h, _ := s.Get(*id)
+		fmt.Fprintf(c.stdout, "Sold holding %d for $%.2f (realised $%.2f)\n", *id, *price, *price-*feesUSD-h.CostBasis())
+		return nil
+	})
+}
+
+func (c *cli) portfolioList(o *options, args []string) error {
+	fs := c.portfolioFlagSet("list", "List holdings as recorded.", o)
+	addOutputFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(o.format); err != nil {
+		return err
+	}
+	if err := useCurrency(o); err != nil {
+		return err
+	}
+	store, err := portfolio.Open(o.portfolioPath)
+	if err != nil {
+		return err
+	}
+	return c.writeReport(o, portfolio.ListTable(store.Holdings))
+}
+
+func (c *cli) portfolioMark(o *options, args []string) error {
+	fs := c.portfolioFlagSet("mark", "Value holdings at current PriceCharting prices.", o)
+	addCacheFlags(fs, o)
+	addOutputFlags(fs, o)
+	addLogFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if err := checkFormat(o.format); err != nil {
+		return err
+	}
+	if err := useCurrency(o); err != nil {
+		return err
+	}
+	store, err := portfolio.Open(o.portfolioPath)
+	if err != nil {
+		return err
+	}
+
+	p, err := newProviders(o, c.stderr)
+	if err != nil {
+		return err
+	}
+	defer p.close()
+	if !p.prices.Available() && hasHeldCards(store.Holdings) {
+		return fmt.Errorf("PRICECHARTING_TOKEN is not set; it is needed to mark holdings to market")
+	}
+
+	positions := portfolio.Mark(store.Holdings, p.prices)
+	if err := c.writeReport(o, portfolio.MarkTable(positions)); err != nil {
+		return err
+	}
+
+	// Keep machine-readable formats clean; the totals go to stderr there
+	summary := c.stderr
+	if o.format == report.FormatTable {
+		summary = c.stdout
+	}
+	s := portfolio.Summarize(positions)
+	fmt.Fprintf(summary, "\nHeld cost basis %s, market value %s, unrealised P&L %s, realised P&L %s\n",
+		o.money(s.CostBasisUSD), o.money(s.MarketUSD), o.money(s.UnrealizedUSD), o.money(s.RealizedUSD))
+	if s.Unpriced > 0 {
+		fmt.Fprintf(summary, "%d held cards have no market price and are excluded from the market value\n", s.Unpriced)
+	}
+	return nil
+}
+
+// hasHeldCards reports whether any holding still needs a market price
+func hasHeldCards(holdings []portfolio.Holding) bool {
+	for _, h := range holdings {
+		if h.Sale == nil {
+			return true
+		}
+	}
+	return false
+}
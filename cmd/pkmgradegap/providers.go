@@ -0,0 +1,354 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/cards"
+	"github.com/guarzo/pkmgradegap/internal/ebay"
+	"github.com/guarzo/pkmgradegap/internal/gamestop"
+	"github.com/guarzo/pkmgradegap/internal/monitoring"
+	"github.com/guarzo/pkmgradegap/internal/population"
+	"github.com/guarzo/pkmgradegap/internal/prices"
+	"github.com/guarzo/pkmgradegap/internal/sales"
+	"github.com/guarzo/pkmgradegap/internal/volatility"
+)
+
+// providers bundles the data sources a command needs. Optional sources are
+// nil unless the matching --with-* flag was given.
+type providers struct {
+	cache    *cache.Cache
+	cards    *cards.PokeTCGIO
+	prices   *prices.PriceCharting
+	pop      population.Provider
+	target   *population.TargetingEngine // nil unless --pop-targeting
+	ebay     *ebay.Client
+	gamestop gamestop.Provider
+	sales    sales.Provider
+	vol      *volatility.Tracker
+}
+
+// newProviders builds the providers selected by the options. API tokens come
+// from the environment; the *_MOCK variables swap in deterministic mocks.
+func newProviders(o *options, warn io.Writer) (*providers, error) {
+	policy, err := cache.ParseStalePolicy(o.maxStale)
+	if err != nil {
+		return nil, usageErrorf("--max-stale: %v", err)
+	}
+	popSources, err := parsePopSources(o)
+	if err != nil {
+		return nil, err
+	}
+	if o.popMinPriority < 0 || o.popMinPriority > 1 {
+		return nil, usageErrorf("--pop-min-priority must be between 0 and 1, got %g", o.popMinPriority)
+	}
+	census, err := parseCensus(o)
+	if err != nil {
+		return nil, err
+	}
+	c, err := cache.Open(o.cachePath, policy)
+	if err != nil {
+		return nil, fmt.Errorf("open cache: %w", err)
+	}
+	c.SetMaxTTL(o.cacheTTL)
+
+	p := &providers{
+		cache:  c,
+		cards:  cards.NewPokeTCGIO(os.Getenv("POKEMONTCGIO_API_KEY"), c),
+		prices: prices.NewPriceCharting(os.Getenv("PRICECHARTING_TOKEN"), c),
+	}
+
+	if o.projectPrices {
+		p.prices.EnableHistoricalEnrichment()
+	}
+
+	if o.hotCache {
+		hot := hotCacheConfig(o)
+		hot.Backing = c
+		if err := p.prices.EnableMultiLayerCache(hot); err != nil {
+			fmt.Fprintf(warn, "warning: --hot-cache: %v; using the cache alone\n", err)
+		}
+	}
+
+	if o.withPop || o.withPopAPI || len(census) > 0 {
+		if p.pop, err = newPopulationProvider(c, popSources, o.popCSV, census); err != nil {
+			c.Close()
+			return nil, err
+		}
+		if o.popHistoryPath != "" {
+			if h, err := population.OpenHistory(o.popHistoryPath); err != nil {
+				fmt.Fprintf(warn, "warning: --pop-history: %v; population trends disabled\n", err)
+			} else {
+				p.pop = population.NewHistoryProvider(p.pop, h)
+			}
+		}
+		if o.popTargeting {
+			target, err := newTargeting(o)
+			if err != nil {
+				fmt.Fprintf(warn, "warning: --history: %v; population targeting uses heuristics alone\n", err)
+			}
+			p.target = target
+		}
+	}
+
+	if o.withEbay {
+		p.ebay = ebay.NewClient(os.Getenv("EBAY_APP_ID"))
+		if !p.ebay.Available() {
+			fmt.Fprintln(warn, "warning: --with-ebay requires EBAY_APP_ID; eBay listings disabled")
+		}
+	}
+
+	if o.withGamestop {
+		if envBool("GAMESTOP_MOCK") {
+			fmt.Fprintln(warn, "warning: GAMESTOP_MOCK is set; skipping GameStop scraping")
+		} else {
+			config := gamestop.DefaultConfig()
+			config.Cache = c
+			p.gamestop = gamestop.NewProvider(config)
+		}
+	}
+
+	if o.withSales {
+		switch {
+		case envBool("SALES_MOCK"):
+			p.sales = sales.NewMockProvider()
+		case os.Getenv("POKEMON_PRICE_TRACKER_API_KEY") == "" && os.Getenv("EBAY_APP_ID") != "":
+			// Fall back to eBay sold listings
+			p.sales = ebay.NewSalesProvider(ebay.NewClient(os.Getenv("EBAY_APP_ID")), 50)
+		case os.Getenv("POKEMON_PRICE_TRACKER_API_KEY") == "":
+			fmt.Fprintln(warn, "warning: --with-sales requires POKEMON_PRICE_TRACKER_API_KEY or EBAY_APP_ID; --with-sales ignored (set SALES_MOCK=true for mock data)")
+		default:
+			p.sales = sales.NewProvider(sales.Config{
+				PokemonPriceTrackerAPIKey: os.Getenv("POKEMON_PRICE_TRACKER_API_KEY"),
+				PokemonPriceTrackerURL:    os.Getenv("POKEMON_PRICE_TRACKER_URL"),
+				CacheEnabled:              true,
+				Cache:                     c,
+				CacheTTLMinutes:           60,
+				RequestTimeout:            30 * time.Second,
+				MaxRetries:                3,
+				RateLimitPerMin:           60,
+				Debug:                     o.verbose || o.debug,
+			})
+		}
+	}
+
+	if o.withVolatility {
+		p.vol = volatility.NewTracker(o.volatilityPath)
+	}
+
+	return p, nil
+}
+
+// revalidationGrace bounds how long closing waits on the refreshes of stale
+// prices served this run
+const revalidationGrace = 30 * time.Second
+
+// close finishes refreshing stale entries, saves what the cache saw this
+// run, for "pkmgradegap cache stats", and closes it
+func (p *providers) close() error {
+	p.prices.FinishRevalidation(revalidationGrace)
+	err := p.cache.SaveUsage()
+	if cerr := p.cache.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// hotCacheConfig places PriceCharting's hot layer inside the cache store
+func hotCacheConfig(o *options) cache.CacheConfig {
+	return cache.CacheConfig{
+		L1MaxSize:     2000,              // Hot cache for 2000 items
+		L1TTL:         30 * time.Minute,  // Short TTL for volatile prices
+		L2MaxSize:     100 * 1024 * 1024, // 100MB disk cache
+		L2TTL:         24 * time.Hour,    // Longer TTL for stable data
+		L2Path:        hotCachePath(o),
+		EnablePredict: true,
+		CompressL2:    true,
+	}
+}
+
+func hotCachePath(o *options) string {
+	return filepath.Join(cache.StorePath(o.cachePath), "hot")
+}
+
+// knownPopulationMaxAge is how long the cache population source serves the
+// last counts found for a card
+const knownPopulationMaxAge = 30 * 24 * time.Hour
+
+// popSourceNames are the --pop-sources a population chain can try
+var popSourceNames = []string{"api", "scraper", "csv", "cache"}
+
+// newPopulationProvider picks the mock when POPULATION_MOCK is set, otherwise
+// a chain of the PSA population sources in the order given, combined with
+// the census of any other companies asked for. The csv source is skipped
+// without a CSV path.
+func newPopulationProvider(c cache.Store, sources []string, csvPath string, census []censusSource) (population.Provider, error) {
+	if envBool("POPULATION_MOCK") {
+		return population.NewMockProvider(), nil
+	}
+	var links []population.Provider
+	for _, src := range sources {
+		switch src {
+		case "api":
+			limiter := rate.NewLimiter(rate.Every(time.Second), 1)
+			links = append(links, population.NewPSAAPIProvider(os.Getenv("PSA_POPULATION_API_KEY"), limiter, c))
+		case "scraper":
+			links = append(links, population.NewPSAScraper(c))
+		case "csv":
+			if csvPath == "" {
+				continue
+			}
+			if _, err := os.Stat(csvPath); err != nil {
+				return nil, fmt.Errorf("--pop-csv: %w", err)
+			}
+			links = append(links, population.NewCSVProvider(population.CSVConfig{DataPath: csvPath}))
+		case "cache":
+			links = append(links, population.NewCacheProvider(c, knownPopulationMaxAge))
+		}
+	}
+	psa := population.NewChainProvider(links...)
+	if len(census) == 0 {
+		return psa, nil
+	}
+	var others []population.Provider
+	for _, src := range census {
+		if src.path != "" {
+			p, err := population.NewCensusCSVProvider(src.grader, src.path)
+			if err != nil {
+				return nil, fmt.Errorf("--census-csv: %w", err)
+			}
+			others = append(others, p)
+			continue
+		}
+		p, err := population.NewCensusScraper(src.grader, c)
+		if err != nil {
+			return nil, err
+		}
+		others = append(others, p)
+	}
+	return population.NewCombinedProvider(psa, others...), nil
+}
+
+// parsePopSources reads --pop-sources
+func parsePopSources(o *options) ([]string, error) {
+	var sources []string
+	for _, name := range strings.Split(o.popSources, ",") {
+		src := strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(popSourceNames, src) {
+			return nil, usageErrorf("--pop-sources: unknown population source %q (want %s)", name,
+				strings.Join(popSourceNames, ", "))
+		}
+		if !slices.Contains(sources, src) {
+			sources = append(sources, src)
+		}
+	}
+	return sources, nil
+}
+
+// newTargeting builds the population targeting engine and teaches it the
+// past top picks in the --history file. The engine comes back, using its
+// heuristics alone, even when the history can't be read.
+func newTargeting(o *options) (*population.TargetingEngine, error) {
+	engine := population.NewTargetingEngine(population.TargetingConfig{
+		EnableHeuristics: true,
+		MinFetchPriority: &o.popMinPriority,
+	})
+	if o.historyPath == "" {
+		return engine, nil
+	}
+	ha := monitoring.NewHistoryAnalyzer()
+	if err := ha.LoadHistory(o.historyPath); err != nil {
+		return engine, err
+	}
+	engine.Learn(pastPicks(ha.Entries()))
+	return engine, nil
+}
+
+// pastPicks converts the picks history into what targeting learns from
+func pastPicks(entries []monitoring.HistoryEntry) []population.PastPick {
+	picks := make([]population.PastPick, 0, len(entries))
+	for _, e := range entries {
+		pick := population.PastPick{Set: e.Set, Name: e.Card, Number: e.Number, At: e.Timestamp}
+		switch {
+		case e.NetROI != nil:
+			pick.ROI = *e.NetROI
+		case e.RawUSD > 0:
+			// Rows written before the net ROI was recorded only have the
+			// gross gap over the raw price
+			pick.ROI = e.DeltaUSD / e.RawUSD
+		}
+		picks = append(picks, pick)
+	}
+	return picks
+}
+
+// censusSource is where a company's census comes from: a CSV import, or its
+// site when path is empty
+type censusSource struct {
+	grader string
+	path   string
+}
+
+// parseCensus reads --census and --census-csv; a company in both is
+// imported rather than scraped
+func parseCensus(o *options) ([]censusSource, error) {
+	var sources []censusSource
+	index := make(map[string]int)
+	add := func(flagName, name, path string) error {
+		grader := strings.ToUpper(strings.TrimSpace(name))
+		if !slices.Contains(population.CensusGraders, grader) {
+			return usageErrorf("%s: unknown grading company %q (want %s)", flagName, name,
+				strings.ToLower(strings.Join(population.CensusGraders, ", ")))
+		}
+		if i, ok := index[grader]; ok {
+			if path != "" {
+				sources[i].path = path
+			}
+			return nil
+		}
+		index[grader] = len(sources)
+		sources = append(sources, censusSource{grader: grader, path: path})
+		return nil
+	}
+	if o.census != "" {
+		for _, name := range strings.Split(o.census, ",") {
+			if err := add("--census", name, ""); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if o.censusCSV != "" {
+		for _, part := range strings.Split(o.censusCSV, ",") {
+			name, path, ok := strings.Cut(part, "=")
+			if !ok || strings.TrimSpace(path) == "" {
+				return nil, usageErrorf("--census-csv: want company=path, got %q", part)
+			}
+			if err := add("--census-csv", name, strings.TrimSpace(path)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sources, nil
+}
+
+// cacheSummary describes the hit rates of every provider sharing the cache,
+// in total and by namespace
+func cacheSummary(s cache.Stats) string {
+	parts := []string{fmt.Sprintf("%d hits, %d misses, %d writes (%.0f%% hit rate)",
+		s.Hits, s.Misses, s.Writes, s.HitRate()*100)}
+	if s.Stale > 0 {
+		parts[0] += fmt.Sprintf(", %d served stale", s.Stale)
+	}
+	for _, ns := range s.NamespaceNames() {
+		parts = append(parts, fmt.Sprintf("%s %.0f%%", ns, s.Namespaces[ns].HitRate()*100))
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/analysis"
+	"github.com/guarzo/pkmgradegap/internal/currency"
+	"github.com/guarzo/pkmgradegap/internal/monitoring"
+	"github.com/guarzo/pkmgradegap/internal/population"
+	"github.com/guarzo/pkmgradegap/internal/report"
+)
+
+func (c *cli) runRank(args []string) error {
+	o := defaultOptions()
+	fs := newFlagSet("rank", "Rank a set's cards by grading opportunity.", c.stderr)
+	addSetFlags(fs, o)
+	addAnalysisFlags(fs, o)
+	addFilterFlags(fs, o)
+	addCostFlags(fs, o)
+	addScoringFlags(fs, o)
+	addSourceFlags(fs, o)
+	addDataFlags(fs, o)
+	addOutputFlags(fs, o)
+	addSimulationFlags(fs, o)
+	addSubmissionFlags(fs, o)
+	addLogFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return c.rank(o)
+}
+
+// rank runs one of the set-based analysis modes and writes the report to stdout
+func (c *cli) rank(o *options) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := useFees(o); err != nil {
+		return err
+	}
+	if err := useCurrency(o); err != nil {
+		return err
+	}
+
+	// Market timing works from saved snapshots, not live prices
+	if o.analysis == "market-timing" {
+		return c.marketTiming(o)
+	}
+
+	switch o.analysis {
+	case "rank", "raw-vs-psa10", "psa9-cgc95-bgs95-vs-psa10", "crossgrade", "graders", "bulk-optimize":
+	default:
+		return usageErrorf("unknown --analysis mode %q", o.analysis)
+	}
+	if _, err := analysis.GetScorer(o.scoring); err != nil {
+		return usageErrorf("--scoring: %v", err)
+	}
+	if err := checkFormat(o.format); err != nil {
+		return err
+	}
+
+	set, rows, err := c.loadRows(ctx, o)
+	if err != nil {
+		return err
+	}
+
+	if o.snapshotOut != "" {
+		if err := saveSnapshot(o.snapshotOut, set.Name, rows); err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stderr, "Saved snapshot to %s\n", o.snapshotOut)
+	}
+
+	if o.analysis == "bulk-optimize" {
+		return c.printOptimization(o, rows)
+	}
+
+	var ranked *analysis.RankResult
+	var t *report.Table
+	switch o.analysis {
+	case "rank":
+		ranked = analysis.Rank(rows, set, analysisConfig(o))
+		t = ranked.Table()
+	case "raw-vs-psa10":
+		t = analysis.RawVsPSA10(rows)
+	case "psa9-cgc95-bgs95-vs-psa10":
+		t = analysis.MultiVsPSA10(rows)
+	case "crossgrade":
+		t = analysis.Crossgrade(rows)
+	case "graders":
+		t = analysis.CompareGraders(rows, analysisConfig(o))
+	}
+	if err := c.writeReport(o, t); err != nil {
+		return err
+	}
+	if ranked != nil {
+		if savings, ok := targetingSavings(rows, ranked.Rows); ok {
+			fmt.Fprintln(c.stderr, savings)
+		}
+		if o.simulate && o.trials > 0 && len(ranked.Rows) > 0 {
+			sim := monitoring.NewRiskSimulator(o.trials, o.feePct, o.shipping, 1)
+			risk := sim.Simulate(simulationCards(analysisConfig(o), ranked.Rows))
+			fmt.Fprintf(c.stderr, "\n%s", monitoring.FormatRiskReport(risk))
+		}
+	}
+
+	if ranked != nil && o.historyPath != "" {
+		entries := historyEntries(set.Name, ranked.Rows, time.Now())
+		if len(entries) > 0 {
+			if err := os.MkdirAll(filepath.Dir(o.historyPath), 0755); err != nil {
+				return fmt.Errorf("create history dir: %w", err)
+			}
+			if err := monitoring.NewHistoryAnalyzer().AppendHistory(o.historyPath, entries); err != nil {
+				return fmt.Errorf("append history: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// analysisConfig maps CLI options onto the analysis package configuration
+func analysisConfig(o *options) analysis.Config {
+	return analysis.Config{
+		MaxAgeYears:      o.maxAgeYears,
+		MinDeltaUSD:      o.minDeltaUSD,
+		MinRawUSD:        o.minRawUSD,
+		TopN:             o.top,
+		GradingCost:      o.gradingCost,
+		Fees:             o.feeBook,
+		FeesAsOf:         o.feeDate(),
+		CostOfCapital:    o.costOfCapital,
+		ShippingCost:     o.shipping,
+		FeePct:           o.feePct,
+		JapaneseWeight:   o.japaneseWeight,
+		ShowWhy:          o.why,
+		WithEbay:         o.withEbay,
+		EbayMax:          o.ebayMax,
+		WithVolatility:   o.withVolatility,
+		AllowThinPremium: o.allowThinPremium,
+		WithMarketplace:  o.withGamestop,
+		Scoring:          o.scoring,
+	}
+}
+
+// targetingSavings tallies the lookups population targeting skipped and the
+// top picks among them, and false when the rows weren't targeted. A card's
+// printings share one lookup, so it counts once.
+func targetingSavings(rows []analysis.Row, top []analysis.ScoredRow) (population.TargetingSavings, bool) {
+	var s population.TargetingSavings
+	seen := make(map[string]bool)
+	for _, r := range rows {
+		key := r.Card.Number + "|" + r.Card.Name
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if r.PopPriority > 0 || r.PopSkipped {
+			s.Cards++
+		}
+		if r.PopSkipped {
+			s.Skipped++
+		}
+	}
+	s.TopPicks = len(top)
+	for _, sr := range top {
+		if sr.PopSkipped {
+			s.SkippedTopPicks = append(s.SkippedTopPicks, fmt.Sprintf("%s #%s", sr.Label(), sr.Card.Number))
+		}
+	}
+	return s, s.Cards > 0
+}
+
+// simulationCards prices each ranked row's grading the way ranking did:
+// --grading-cost, or the PSA fee for its declared value
+func simulationCards(config analysis.Config, rows []analysis.ScoredRow) []monitoring.SimulationCard {
+	cards := make([]monitoring.SimulationCard, len(rows))
+	for i, sr := range rows {
+		cards[i] = monitoring.SimulationCardFromScoredRow(sr, config.GradingFee(sr.Row))
+	}
+	return cards
+}
+
+// historyEntries converts ranked rows into picks history entries
+func historyEntries(setName string, rows []analysis.ScoredRow, now time.Time) []monitoring.HistoryEntry {
+	entries := make([]monitoring.HistoryEntry, 0, len(rows))
+	for _, sr := range rows {
+		notes := sr.RawNote
+		if sr.IsJapanese {
+			notes = strings.TrimSpace(notes + " [JPN]")
+		}
+		if sr.Stale {
+			notes = strings.TrimSpace(notes + " [STALE]")
+		}
+		if sr.Population != nil && sr.Population.Estimated {
+			notes = strings.TrimSpace(notes + " [EST POP]")
+		}
+		var netROI *float64
+		if sr.TotalCostUSD > 0 {
+			roi := sr.NetProfitUSD / sr.TotalCostUSD
+			netROI = &roi
+		}
+		entries = append(entries, monitoring.HistoryEntry{
+			Timestamp: now,
+			Card:      sr.Label(),
+			Number:    sr.Card.Number,
+			Set:       setName,
+			RawUSD:    sr.RawUSD,
+			PSA10USD:  sr.Grades.PSA10,
+			DeltaUSD:  sr.Grades.PSA10 - sr.RawUSD,
+			Score:     sr.Score,
+			Notes:     notes,
+			NetROI:    netROI,
+		})
+	}
+	return entries
+}
+
+// writeReport writes a USD table to stdout in the --format and --currency
+func (c *cli) writeReport(o *options, t *report.Table) error {
+	if o.currency != "" && o.currency != currency.USD {
+		rate, err := o.fx().Rate(currency.USD, o.currency)
+		if err != nil {
+			return err
+		}
+		t = t.InCurrency(o.currency, rate.Value)
+	}
+	return report.Write(c.stdout, o.format, t)
+}
+
+// checkFormat rejects output formats the report package can't render
+func checkFormat(format string) error {
+	for _, f := range report.Formats {
+		if format == f {
+			return nil
+		}
+	}
+	return usageErrorf("unknown --format %q (want %s)", format, strings.Join(report.Formats, "|"))
+}
+
+func saveSnapshot(path, setName string, rows []analysis.Row) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("create snapshot dir: %w", err)
+		}
+	}
+	return monitoring.SaveSnapshot(path, monitoring.CreateSnapshotFromRows(setName, rows))
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/guarzo/pkmgradegap/internal/webcache"
+)
+
+func (c *cli) runRefresh(args []string) error {
+	o := defaultOptions()
+	defaults := webcache.DefaultRefreshOptions()
+	o.top = defaults.TopN
+	o.minRawUSD = defaults.MinRawUSD
+	o.minDeltaUSD = defaults.MinDeltaUSD
+	o.maxAgeYears = defaults.MaxAgeYears
+	o.maxSets = defaults.MaxSetsToProcess
+
+	fs := newFlagSet("refresh", "Rebuild the pre-computed web cache used by the server.", c.stderr)
+	addFilterFlags(fs, o)
+	addCostFlags(fs, o)
+	addPopulationFlags(fs, o)
+	addCacheFlags(fs, o)
+	addWebCacheFlags(fs, o)
+	fs.BoolVar(&o.force, "force", o.force, "Refresh even if the web cache is still fresh")
+	addLogFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	p, err := newProviders(o, c.stderr)
+	if err != nil {
+		return err
+	}
+	defer p.close()
+
+	rs, wc, err := c.newRefreshService(o, p)
+	if err != nil {
+		return err
+	}
+	if !o.force && !wc.NeedsRefresh() {
+		fmt.Fprintln(c.stdout, "Web cache is fresh; use --force to refresh anyway")
+		return nil
+	}
+	return rs.PerformRefresh(ctx, "manual", refreshOptions(o))
+}
+
+// newRefreshService wires the providers into a web cache refresh service
+func (c *cli) newRefreshService(o *options, p *providers) (*webcache.RefreshService, *webcache.WebCache, error) {
+	if err := useFees(o); err != nil {
+		return nil, nil, err
+	}
+	if !p.prices.Available() {
+		return nil, nil, fmt.Errorf("PRICECHARTING_TOKEN is not set; graded prices are required for a refresh")
+	}
+	wc := webcache.NewWebCache(o.webCacheDir)
+	return webcache.NewRefreshService(wc, p.cards, p.prices, p.pop, p.vol, o.feeBook), wc, nil
+}
+
+func refreshOptions(o *options) webcache.RefreshOptions {
+	opts := webcache.DefaultRefreshOptions()
+	opts.TopN = o.top
+	opts.MinRawUSD = o.minRawUSD
+	opts.MinDeltaUSD = o.minDeltaUSD
+	opts.MaxAgeYears = o.maxAgeYears
+	opts.GradingCost = o.gradingCost
+	opts.ShippingCost = o.shipping
+	opts.FeePct = o.feePct
+	opts.WithPopulation = o.withPop || o.withPopAPI
+	opts.MaxSetsToProcess = o.maxSets
+	return opts
+}
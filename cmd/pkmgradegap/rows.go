@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/analysis"
+	"github.com/guarzo/pkmgradegap/internal/ebay"
+	"github.com/guarzo/pkmgradegap/internal/fusion"
+	"github.com/guarzo/pkmgradegap/internal/gamestop"
+	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/monitoring"
+	"github.com/guarzo/pkmgradegap/internal/prices"
+	"github.com/guarzo/pkmgradegap/internal/progress"
+	"github.com/guarzo/pkmgradegap/internal/sales"
+)
+
+// resolveSet finds a set by ID or by case-insensitive name
+func resolveSet(p *providers, name string) (*model.Set, error) {
+	sets, err := p.cards.ListSets()
+	if err != nil {
+		return nil, fmt.Errorf("list sets: %w", err)
+	}
+	for _, s := range sets {
+		if s.ID == name || strings.EqualFold(s.Name, name) {
+			set := s
+			return &set, nil
+		}
+	}
+	return nil, fmt.Errorf("set %q not found (use list-sets to see available sets)", name)
+}
+
+// loadRows returns the analysis rows for the selected set, either from a
+// saved snapshot (--snapshot-in) or by querying the providers.
+func (c *cli) loadRows(ctx context.Context, o *options) (*model.Set, []analysis.Row, error) {
+	if o.snapshotIn != "" {
+		snap, err := monitoring.LoadSnapshot(o.snapshotIn)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Re-score old snapshots with the fees that applied at the time
+		if o.feesAsOf == "" && !snap.Timestamp.IsZero() {
+			o.feesAsOf = snap.Timestamp.Format("2006-01-02")
+		}
+		return &model.Set{Name: snap.SetName}, rowsFromSnapshot(snap), nil
+	}
+
+	if o.set == "" {
+		return nil, nil, usageErrorf("--set is required")
+	}
+
+	p, err := newProviders(o, c.stderr)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer p.close()
+	if !p.prices.Available() {
+		return nil, nil, fmt.Errorf("PRICECHARTING_TOKEN is not set; graded prices are required (or use --snapshot-in)")
+	}
+
+	set, err := resolveSet(p, o.set)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	setCards, err := p.cards.CardsBySetID(set.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch cards for %s: %w", set.Name, err)
+	}
+
+	rows := c.buildRows(ctx, o, p, set, setCards)
+	return set, rows, nil
+}
+
+// buildRows looks up graded prices and the optional data sources for every card
+func (c *cli) buildRows(ctx context.Context, o *options, p *providers, set *model.Set, setCards []model.Card) []analysis.Row {
+	ind := progress.WithTotal(fmt.Sprintf("Looking up prices for %d cards in %s", len(setCards), set.Name), len(setCards), false)
+	ind.Start()
+
+	rows := make([]analysis.Row, 0, len(setCards))
+	for i, card := range setCards {
+		if ctx.Err() != nil {
+			break
+		}
+		// Cards printed several ways get a row per printing, each priced
+		// and ranked on its own; the card's other data is shared
+		looked := c.lookupCard(ctx, o, p, set.Name, card)
+		printings := analysis.Printings(card)
+		if len(printings) < 2 {
+			rows = append(rows, c.buildRow(o, p, set.Name, card, analysis.Printing{}, looked))
+		} else {
+			for _, pr := range printings {
+				rows = append(rows, c.buildRow(o, p, set.Name, card, pr, looked))
+			}
+		}
+		ind.Update(i + 1)
+	}
+	ind.Finish()
+	c.debugf(o, "cache: %s", cacheSummary(p.cache.Stats()))
+
+	return analysis.SanitizeRows(rows, analysis.DefaultSanitizeConfig())
+}
+
+// cardLookups is what the optional sources know about a card. It is looked
+// up once per card and shared by the rows of its printings.
+type cardLookups struct {
+	population  *model.PSAPopulation
+	popPriority float64
+	popSkipped  bool
+	listings    *gamestop.ListingData
+	ebay        []ebay.Listing
+	sales       *sales.SalesData
+}
+
+// lookupCard fetches a card's population, GameStop and eBay listings and sales
+func (c *cli) lookupCard(ctx context.Context, o *options, p *providers, setName string, card model.Card) cardLookups {
+	var looked cardLookups
+
+	if p.pop != nil && p.pop.Available() && p.target != nil {
+		target := card
+		if target.SetName == "" {
+			target.SetName = setName
+		}
+		fp := p.target.FetchPriority(target)
+		looked.popPriority, looked.popSkipped = fp.Priority, !fp.Fetch
+		c.debugf(o, "population priority for %s #%s: %.2f (%s)", card.Name, card.Number, fp.Priority, fp.Reason)
+	}
+	if p.pop != nil && p.pop.Available() && !looked.popSkipped {
+		if pd, err := p.pop.LookupPopulation(ctx, card); err != nil {
+			c.debugf(o, "population lookup failed for %s #%s: %v", card.Name, card.Number, err)
+		} else if pd != nil {
+			looked.population = &model.PSAPopulation{
+				TotalGraded: pd.TotalGraded,
+				PSA10:       pd.PSA10Population,
+				PSA9:        pd.PSA9Population,
+				PSA8:        pd.PSA8Population,
+				Grades:      pd.GradePopulation,
+				LastUpdated: pd.LastUpdated,
+				Source:      pd.Source,
+				FetchedAt:   pd.FetchedAt,
+				Estimated:   pd.Estimated,
+			}
+			if pd.TrendObservations > 0 {
+				looked.population.Trend = pd.PopulationTrend
+				looked.population.PSA10PerMonth = pd.PSA10PerMonth
+				looked.population.GemRateDrift = pd.GemRateDrift
+				looked.population.MonthsToSaturation = pd.MonthsToSaturation
+			}
+			if len(pd.Graders) > 0 {
+				looked.population.CombinedGraded = pd.CombinedGraded()
+				looked.population.CombinedGem = pd.CombinedGem()
+			}
+		}
+	}
+
+	if p.gamestop != nil && p.gamestop.Available() {
+		if ld, err := p.gamestop.GetListings(setName, card.Name, card.Number); err != nil {
+			c.debugf(o, "GameStop lookup failed for %s #%s: %v", card.Name, card.Number, err)
+		} else if ld != nil && ld.ListingCount > 0 {
+			looked.listings = ld
+		}
+	}
+
+	if p.ebay != nil && p.ebay.Available() {
+		if ls, err := p.ebay.SearchRawListings(setName, card.Name, card.Number, o.ebayMax); err != nil {
+			c.debugf(o, "eBay lookup failed for %s #%s: %v", card.Name, card.Number, err)
+		} else {
+			looked.ebay = ls
+		}
+	}
+
+	if p.sales != nil && p.sales.Available() {
+		if sd, err := p.sales.GetSalesData(setName, card.Name, card.Number); err != nil {
+			c.debugf(o, "sales lookup failed for %s #%s: %v", card.Name, card.Number, err)
+		} else if sd != nil {
+			looked.sales = sd
+		}
+	}
+
+	return looked
+}
+
+// buildRow prices one printing of a card; a zero Printing prices the card
+// as a whole. Only the printing's TCGplayer and PriceCharting prices are
+// looked up here; the rest comes from the card's lookups.
+func (c *cli) buildRow(o *options, p *providers, setName string, card model.Card, pr analysis.Printing, looked cardLookups) analysis.Row {
+	row := analysis.Row{
+		Card:        card,
+		Printing:    pr.Name,
+		Population:  looked.population,
+		PopPriority: looked.popPriority,
+		PopSkipped:  looked.popSkipped,
+	}
+	// Every source's prices by grade, fused into a consensus
+	var observed []fusion.PriceData
+	if pr.Key == "" {
+		row.RawUSD, row.RawSrc, row.RawNote = analysis.ExtractUngraded(card, o.fx())
+		observed = fusion.FromTCGPlayer(card)
+	} else {
+		row.RawUSD, row.RawSrc, row.RawNote = analysis.PrintingUSD(card, pr)
+		observed = fusion.FromTCGPlayerPrinting(card, pr.Key)
+	}
+	rawUSD := row.RawUSD
+
+	// PSA 10 sale prices, used to estimate how widely a graded copy's sale varies
+	var psa10Sales []float64
+
+	match, err := p.prices.LookupCardVariant(setName, card, pr.Variant)
+	if err != nil {
+		c.debugf(o, "price lookup failed for %s #%s: %v", row.Label(), card.Number, err)
+	} else if match != nil {
+		applyPriceMatch(&row, match)
+		observed = append(observed, fusion.FromPriceCharting(match, time.Now())...)
+		for _, s := range match.RecentSales {
+			if isPSA10(s.Grade) && s.PriceCents > 0 {
+				psa10Sales = append(psa10Sales, float64(s.PriceCents)/100.0)
+			}
+		}
+	}
+
+	// Cardmarket and eBay's raw listings don't tell printings apart, so
+	// they only price the card's likeliest printing
+	if ps := analysis.Printings(card); pr.Key == "" || ps[0].Key == pr.Key {
+		observed = append(observed, fusion.FromCardmarket(card)...)
+		observed = append(observed, fusion.FromEbayListings(looked.ebay)...)
+	}
+
+	if ld := looked.listings; ld != nil {
+		row.ActiveListings = ld.ListingCount
+		row.LowestListing = ld.LowestPrice
+		observed = append(observed, gamestop.ConvertToPriceData(ld)...)
+	}
+
+	if sd := looked.sales; sd != nil {
+		for _, s := range sd.RecentSales {
+			if isPSA10(s.Grade) && s.Price > 0 {
+				psa10Sales = append(psa10Sales, s.Price)
+			}
+		}
+		observed = append(observed, fusion.FromSales(sd)...)
+	}
+
+	row.PriceSpread = analysis.SalePriceSpread(psa10Sales)
+
+	engine := fusion.NewFusionEngine()
+	engine.Rates = o.fx()
+	fused := engine.FuseByGrade(observed)
+	if o.fusionMode {
+		applyFused(&row, fused)
+	} else if row.Grades.PSA10 == 0 {
+		// Other sources fill in when PriceCharting has no PSA 10 price
+		row.Grades.PSA10 = fused[fusion.GradePSA10].Value
+	}
+
+	if p.vol != nil {
+		name := row.Label()
+		if rawUSD > 0 {
+			p.vol.AddPrice(setName, name, card.Number, "raw", rawUSD)
+		}
+		if row.Grades.PSA10 > 0 {
+			p.vol.AddPrice(setName, name, card.Number, "psa10", row.Grades.PSA10)
+		}
+		row.Volatility = p.vol.Calculate30DayVolatility(setName, name, card.Number, "psa10")
+	}
+
+	return row
+}
+
+// applyPriceMatch copies PriceCharting prices (in cents) and match metadata onto a row
+func applyPriceMatch(row *analysis.Row, match *prices.PCMatch) {
+	row.Grades = analysis.Grades{
+		PSA10:   float64(match.PSA10Cents) / 100.0,
+		Grade9:  float64(match.Grade9Cents) / 100.0,
+		Grade95: float64(match.Grade95Cents) / 100.0,
+		Grade8:  float64(match.NewPriceCents) / 100.0,
+		BGS10:   float64(match.BGS10Cents) / 100.0,
+		CGC10:   float64(match.CGC10Cents) / 100.0,
+		SGC10:   float64(match.SGC10Cents) / 100.0,
+	}
+	row.PSA10Forecast30d = float64(match.PredictedPrice30d) / 100.0
+	row.UPC = match.UPC
+	row.MatchConfidence = match.MatchConfidence
+	row.MatchMethod = string(match.MatchMethod)
+	row.Variant = match.Variant
+	row.Language = match.Language
+	row.Stale = match.Stale
+}
+
+// applyFused replaces single-source prices with the fused consensus for
+// every grade more than one source priced
+func applyFused(row *analysis.Row, fused map[string]fusion.FusedPrice) {
+	grades := map[string]*float64{
+		fusion.GradePSA10: &row.Grades.PSA10,
+		fusion.GradePSA9:  &row.Grades.Grade9,
+		fusion.Grade95:    &row.Grades.Grade95,
+		fusion.GradeBGS10: &row.Grades.BGS10,
+	}
+	for grade, price := range grades {
+		if f, ok := fused[grade]; ok && len(f.Sources) > 1 {
+			*price = f.Value
+		}
+	}
+	if f, ok := fused[fusion.GradeRaw]; ok && len(f.Sources) > 1 {
+		row.RawUSD = f.Value
+		row.RawSrc = "fused:" + strings.Join(f.Sources, "+")
+		row.RawNote = "USD"
+	}
+}
+
+// rowsFromSnapshot rebuilds analysis rows from saved snapshot prices
+func rowsFromSnapshot(snap *monitoring.Snapshot) []analysis.Row {
+	keys := make([]string, 0, len(snap.Cards))
+	for k := range snap.Cards {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rows := make([]analysis.Row, 0, len(keys))
+	for _, k := range keys {
+		cd := snap.Cards[k]
+		rows = append(rows, analysis.Row{
+			Card:     cd.Card,
+			Printing: cd.Printing,
+			RawUSD:   cd.RawUSD,
+			RawSrc:   "snapshot",
+			RawNote:  "USD",
+			Grades: analysis.Grades{
+				PSA10:   cd.PSA10Price,
+				Grade9:  cd.PSA9Price,
+				Grade95: cd.Grade95Price,
+				Grade8:  cd.Grade8Price,
+				BGS10:   cd.BGS10Price,
+				CGC10:   cd.CGC10Price,
+				SGC10:   cd.SGC10Price,
+			},
+			Population: cd.Population,
+		})
+	}
+	return rows
+}
+
+// isPSA10 matches grade labels such as "PSA 10" or "psa10"
+func isPSA10(grade string) bool {
+	return strings.EqualFold(strings.ReplaceAll(grade, " ", ""), "PSA10")
+}
+
+func (c *cli) debugf(o *options, format string, args ...interface{}) {
+	if o.verbose || o.debug {
+		fmt.Fprintf(c.stderr, format+"\n", args...)
+	}
+}
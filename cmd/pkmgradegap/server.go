@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/guarzo/pkmgradegap/internal/analysis"
+	"github.com/guarzo/pkmgradegap/internal/webcache"
+)
+
+func (c *cli) runServer(args []string) error {
+	o := defaultOptions()
+	fs := newFlagSet("server", "Start the web interface.", c.stderr)
+	addServerFlags(fs, o)
+	addPopulationFlags(fs, o)
+	addCacheFlags(fs, o)
+	addWebCacheFlags(fs, o)
+	fs.StringVar(&o.snapshotDir, "snapshot-dir", o.snapshotDir, "Directory of saved snapshots")
+	addLogFlags(fs, o)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	return c.serve(o)
+}
+
+// server exposes the analysis and pre-computed web cache over HTTP
+type server struct {
+	cli      *cli
+	opts     *options
+	prov     *providers
+	webCache *webcache.WebCache
+	refresh  *webcache.RefreshService
+
+	// analyzeMu serialises live analyses so concurrent requests
+	// don't multiply upstream API traffic
+	analyzeMu sync.Mutex
+
+	refreshMu  sync.Mutex
+	refreshing bool
+}
+
+func (c *cli) serve(o *options) error {
+	if err := useFees(o); err != nil {
+		return err
+	}
+	p, err := newProviders(o, c.stderr)
+	if err != nil {
+		return err
+	}
+	defer p.close()
+
+	s := &server{
+		cli:      c,
+		opts:     o,
+		prov:     p,
+		webCache: webcache.NewWebCache(o.webCacheDir),
+	}
+	if p.prices.Available() {
+		s.refresh = webcache.NewRefreshService(s.webCache, p.cards, p.prices, p.pop, p.vol, o.feeBook)
+	} else {
+		fmt.Fprintln(c.stderr, "warning: PRICECHARTING_TOKEN is not set; analysis and cache refresh are disabled")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if s.refresh != nil {
+		go func() {
+			if err := s.refresh.RefreshIfNeeded(ctx, "startup"); err != nil {
+				log.Printf("Startup refresh failed: %v", err)
+			}
+		}()
+
+		if o.refreshSchedule != "" {
+			scheduler := cron.New()
+			if _, err := scheduler.AddFunc(o.refreshSchedule, func() { s.startRefresh(ctx, "scheduled") }); err != nil {
+				return usageErrorf("invalid --refresh-schedule %q: %v", o.refreshSchedule, err)
+			}
+			scheduler.Start()
+			defer scheduler.Stop()
+		}
+	}
+
+	addr := fmt.Sprintf(":%d", o.port)
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	url := fmt.Sprintf("http://localhost:%d", o.port)
+	fmt.Fprintf(c.stdout, "Serving on %s (Ctrl+C to stop)\n", url)
+	if o.autoOpen {
+		if err := openBrowser(url); err != nil {
+			fmt.Fprintf(c.stderr, "warning: could not open browser: %v\n", err)
+		}
+	}
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return fmt.Errorf("http server: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return httpServer.Shutdown(shutdownCtx)
+	}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/health", s.handleHealth)
+	mux.HandleFunc("GET /api/sets", s.handleSets)
+	mux.HandleFunc("GET /api/sets/summary", s.handleSetsSummary)
+	mux.HandleFunc("GET /api/opportunities", s.handleOpportunities)
+	mux.HandleFunc("GET /api/opportunities/all", s.handleAllCards)
+	mux.HandleFunc("POST /api/analyze", s.handleAnalyze)
+	mux.HandleFunc("POST /api/refresh", s.handleRefresh)
+	mux.HandleFunc("GET /api/snapshots", s.handleSnapshots)
+	return mux
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	health := map[string]any{
+		"status": "ok",
+		"providers": map[string]bool{
+			"pricecharting": s.prov.prices.Available(),
+			"population":    s.prov.pop != nil && s.prov.pop.Available(),
+		},
+		"webCacheStale": s.webCache.IsStale(),
+		"refreshing":    s.isRefreshing(),
+	}
+	if meta, err := s.webCache.LoadMetadata(); err == nil {
+		health["lastRefresh"] = meta.LastRefresh
+	}
+	writeJSON(w, http.StatusOK, health)
+}
+
+func (s *server) handleSets(w http.ResponseWriter, r *http.Request) {
+	sets, err := s.prov.cards.ListSets()
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sets)
+}
+
+func (s *server) handleSetsSummary(w http.ResponseWriter, r *http.Request) {
+	summaries, err := s.webCache.LoadSetsSummary()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+func (s *server) handleOpportunities(w http.ResponseWriter, r *http.Request) {
+	result, err := s.webCache.LoadTopOpportunities()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *server) handleAllCards(w http.ResponseWriter, r *http.Request) {
+	result, err := s.webCache.LoadAllCards()
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// analyzeRequest mirrors the rank flags; zero values keep the CLI defaults
+type analyzeRequest struct {
+	Set            string  `json:"set"`
+	Top            int     `json:"top"`
+	MinDeltaUSD    float64 `json:"minDeltaUSD"`
+	MinRawUSD      float64 `json:"minRawUSD"`
+	MaxAgeYears    int     `json:"maxAgeYears"`
+	GradingCost    float64 `json:"gradingCost"`
+	ShippingCost   float64 `json:"shippingCost"`
+	FeePct         float64 `json:"feePct"`
+	JapaneseWeight float64 `json:"japaneseWeight"`
+	ShowWhy        bool    `json:"why"`
+	Scoring        string  `json:"scoring"`
+}
+
+func (s *server) handleAnalyze(w http.ResponseWriter, r *http.Request) {
+	if !s.prov.prices.Available() {
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("PRICECHARTING_TOKEN is not set"))
+		return
+	}
+
+	var req analyzeRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		return
+	}
+	if req.Set == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("set is required"))
+		return
+	}
+
+	o := *s.opts
+	o.set = req.Set
+	if req.Top > 0 {
+		o.top = req.Top
+	}
+	if req.MinDeltaUSD > 0 {
+		o.minDeltaUSD = req.MinDeltaUSD
+	}
+	if req.MinRawUSD > 0 {
+		o.minRawUSD = req.MinRawUSD
+	}
+	if req.MaxAgeYears > 0 {
+		o.maxAgeYears = req.MaxAgeYears
+	}
+	if req.GradingCost > 0 {
+		o.gradingCost = req.GradingCost
+	}
+	if req.ShippingCost > 0 {
+		o.shipping = req.ShippingCost
+	}
+	if req.FeePct > 0 {
+		o.feePct = req.FeePct
+	}
+	if req.JapaneseWeight > 0 {
+		o.japaneseWeight = req.JapaneseWeight
+	}
+	o.why = req.ShowWhy
+	if req.Scoring != "" {
+		if _, err := analysis.GetScorer(req.Scoring); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		o.scoring = req.Scoring
+	}
+
+	s.analyzeMu.Lock()
+	defer s.analyzeMu.Unlock()
+
+	set, err := resolveSet(s.prov, o.set)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	setCards, err := s.prov.cards.CardsBySetID(set.ID)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+
+	rows := s.cli.buildRows(r.Context(), &o, s.prov, set, setCards)
+	ranked := analysis.Rank(rows, set, analysisConfig(&o))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"set":    set,
+		"report": ranked.Table(),
+	})
+}
+
+func (s *server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	if s.refresh == nil {
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("refresh is disabled without PRICECHARTING_TOKEN"))
+		return
+	}
+	// Refreshes outlive the request, so they run on a background context
+	if !s.startRefresh(context.Background(), "manual") {
+		writeError(w, http.StatusConflict, fmt.Errorf("a refresh is already running"))
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "refresh started"})
+}
+
+func (s *server) handleSnapshots(w http.ResponseWriter, r *http.Request) {
+	files, err := filepath.Glob(filepath.Join(s.opts.snapshotDir, "*.json"))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	type snapshotInfo struct {
+		Name     string    `json:"name"`
+		Size     int64     `json:"size"`
+		Modified time.Time `json:"modified"`
+	}
+	infos := make([]snapshotInfo, 0, len(files))
+	for _, f := range files {
+		st, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		infos = append(infos, snapshotInfo{Name: filepath.Base(f), Size: st.Size(), Modified: st.ModTime()})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Modified.After(infos[j].Modified)
+	})
+	writeJSON(w, http.StatusOK, infos)
+}
+
+// startRefresh runs a full web cache refresh in the background unless one is
+// already in progress, and reports whether a new refresh was started.
+func (s *server) startRefresh(ctx context.Context, source string) bool {
+	s.refreshMu.Lock()
+	if s.refreshing {
+		s.refreshMu.Unlock()
+		return false
+	}
+	s.refreshing = true
+	s.refreshMu.Unlock()
+
+	go func() {
+		defer func() {
+			s.refreshMu.Lock()
+			s.refreshing = false
+			s.refreshMu.Unlock()
+		}()
+		if err := s.refresh.PerformRefresh(ctx, source, refreshOptions(s.opts)); err != nil {
+			log.Printf("Web cache refresh (%s) failed: %v", source, err)
+		}
+	}()
+	return true
+}
+
+func (s *server) isRefreshing() bool {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+	return s.refreshing
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("write response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// openBrowser opens url in the platform's default browser
+func openBrowser(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
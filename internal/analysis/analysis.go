@@ -4,28 +4,38 @@ import (
 	"fmt"
 	"math"
 	"sort"
-	"strconv"
 	"strings"
 	"time"
 
+	"github.com/guarzo/pkmgradegap/internal/currency"
+	"github.com/guarzo/pkmgradegap/internal/fees"
 	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/report"
 )
 
 type Grades struct {
 	PSA10   float64
 	Grade9  float64
 	Grade95 float64 // maps to 9.5 (PC "box-only-price")
+	Grade8  float64 // maps to 8 (PC "new-price" for trading cards)
 	BGS10   float64
+	CGC10   float64 // PC "condition-17-price"
+	SGC10   float64 // PC "condition-18-price"
 }
 
 type Row struct {
-	Card       model.Card
-	RawUSD     float64
-	RawSrc     string
-	RawNote    string
-	Grades     Grades
-	Population *model.PSAPopulation // Optional population data
-	Volatility float64              // 30-day price variance (0-1 scale)
+	Card        model.Card
+	RawUSD      float64
+	RawSrc      string
+	RawNote     string
+	Grades      Grades
+	Population  *model.PSAPopulation // Optional population data
+	PopPriority float64              // Population fetch priority from targeting, 0-1 (0 = no targeting)
+	PopSkipped  bool                 // Targeting ranked the card too low for a population lookup
+	Volatility  float64              // 30-day price variance (0-1 scale)
+	PriceSpread float64              // Relative spread of recent PSA 10 sales (0 = unknown)
+
+	PSA10Forecast30d float64 // PriceCharting's predicted PSA 10 price in 30 days (0 = no forecast)
 
 	// Sprint 3: Marketplace fields
 	ActiveListings      int     // Current marketplace listings
@@ -42,13 +52,24 @@ type Row struct {
 	MatchMethod     string  // How the match was found ("upc", "id", "search", "fuzzy")
 	Variant         string  // Card variant (1st Edition, Shadowless, etc.)
 	Language        string  // Card language
+	Printing        string  // TCGplayer printing the row prices, when the card has several
+	Stale           bool    // PriceCharting prices came from a cache entry past its TTL
 
 	// Sprint 1: Auction fields
 	AuctionOpportunities int     // Number of ending auctions found
-	BestAuctionBid      float64 // Current bid of most profitable auction
-	BestAuctionProfit   float64 // Estimated profit of best auction
-	BestAuctionURL      string  // URL to best auction opportunity
-	BestAuctionRisk     string  // Risk level of best auction (LOW/MEDIUM/HIGH)
+	BestAuctionBid       float64 // Current bid of most profitable auction
+	BestAuctionProfit    float64 // Estimated profit of best auction
+	BestAuctionURL       string  // URL to best auction opportunity
+	BestAuctionRisk      string  // Risk level of best auction (LOW/MEDIUM/HIGH)
+}
+
+// Label names the row's card, with its printing when the card has several,
+// e.g. "Pikachu (Reverse Holo)"
+func (r Row) Label() string {
+	if r.Printing == "" {
+		return r.Card.Name
+	}
+	return r.Card.Name + " (" + r.Printing + ")"
 }
 
 type Config struct {
@@ -56,108 +77,174 @@ type Config struct {
 	MinDeltaUSD      float64
 	MinRawUSD        float64
 	TopN             int
-	GradingCost      float64
+	GradingCost      float64    // per-card fee; 0 uses the PSA fee schedule for the card's PSA 10 price
+	FeesAsOf         time.Time  // date of the fee schedule to use; zero means now
+	Fees             *fees.Book // grading fee schedules; nil uses the built-in ones
 	ShippingCost     float64
 	FeePct           float64
+	CostOfCapital    float64 // annual rate proceeds are discounted at over the grading turnaround
 	JapaneseWeight   float64
 	ShowWhy          bool
 	WithEbay         bool
 	EbayMax          int
-	WithAuctions     bool // Sprint 1: Include auction opportunities
-	WithVolatility   bool // Include volatility data
-	AllowThinPremium bool // Allow PSA9/PSA10 > 0.75
-	WithMarketplace  bool // Sprint 3: Include marketplace data
+	WithAuctions     bool   // Sprint 1: Include auction opportunities
+	WithVolatility   bool   // Include volatility data
+	AllowThinPremium bool   // Allow PSA9/PSA10 > 0.75
+	WithMarketplace  bool   // Sprint 3: Include marketplace data
+	Scoring          string // Registered Scorer name; empty means ScoringHeuristic
+}
+
+// GradingFee returns the per-card grading fee for r: GradingCost when set,
+// otherwise the PSA fee for its PSA 10 price from the schedule in effect at
+// FeesAsOf
+func (c Config) GradingFee(r Row) float64 {
+	if c.GradingCost > 0 {
+		return c.GradingCost
+	}
+	return c.Fees.PSA(c.feeDate()).Fee(r.Grades.PSA10)
+}
+
+func (c Config) feeDate() time.Time {
+	if c.FeesAsOf.IsZero() {
+		return time.Now()
+	}
+	return c.FeesAsOf
 }
 
 type ScoredRow struct {
 	Row
-	Score          float64
-	BreakEvenUSD   float64
-	NetProfitUSD   float64
-	TotalCostUSD   float64
-	IsJapanese     bool
-	SetAgeYears    int
-	ScoreBreakdown string
-	PSA10Rate      float64 // Calculated from population
-	PSA9Rate       float64 // Calculated from population
-}
-
-// Extract USD prices from TCGplayer only (no EUR fallback)
+	Score        float64
+	BreakEvenUSD float64
+	NetProfitUSD float64
+	TotalCostUSD float64
+	IsJapanese   bool
+	SetAgeYears  int
+	Factors      []Factor // How the scorer arrived at Score
+	PSA10Rate    float64  // Calculated from population
+	PSA9Rate     float64  // Calculated from population
+
+	// Time value over the PSA service level's turnaround
+	TurnaroundDays    int
+	ProjectedPSA10USD float64 // PSA 10 price projected to the return date
+	AnnualROI         float64 // undiscounted ROI compounded to a year
+
+	// Set by scorers that model the grade distribution
+	Distribution      GradeDistribution
+	ExpectedResaleUSD float64
+}
+
+// ExtractUngradedUSD returns a card's raw price in USD from TCGplayer,
+// falling back to Cardmarket converted at the fixed exchange rates. The
+// note records the currency, or the rate used for a conversion.
 func ExtractUngradedUSD(c model.Card) (value float64, source string, note string) {
-	// Try TCGplayer markets in order of likeliest printing buckets.
-	if c.TCGPlayer != nil && c.TCGPlayer.Prices != nil {
-		typeNameOrder := []string{"normal", "holofoil", "reverseHolofoil", "1stEditionHolofoil", "1stEditionNormal"}
-		for _, t := range typeNameOrder {
-			if p, ok := c.TCGPlayer.Prices[t]; ok && p.Market != nil && *p.Market > 0 {
-				return round2(*p.Market), "tcgplayer.market", "USD"
+	return ExtractUngraded(c, currency.Fixed())
+}
+
+// ExtractUngraded returns a card's raw price in USD: the TCGplayer market
+// price of its likeliest printing (see Printings) or, without one,
+// Cardmarket's trend price or 30-day average converted from EUR with fx. A
+// nil fx disables the fallback.
+func ExtractUngraded(c model.Card, fx currency.Provider) (value float64, source string, note string) {
+	if ps := Printings(c); len(ps) > 0 {
+		return PrintingUSD(c, ps[0])
+	}
+	if fx != nil {
+		if eur, src := cardmarketEUR(c); eur > 0 {
+			if rate, err := fx.Rate(currency.EUR, currency.USD); err == nil {
+				return round2(rate.Convert(eur)), src, rate.String()
 			}
 		}
 	}
-	// Return 0 if no USD price available (skip EUR prices)
 	return 0, "", ""
 }
 
-func ReportRawVsPSA10(rows []Row) [][]string {
-	out := [][]string{
-		{"Card", "Number", "RawUSD", "RawSource", "PSA10_USD", "Delta_USD", "Notes"},
+// cardmarketEUR returns Cardmarket's trend price, or its 30-day average
+// when there is no trend, in EUR
+func cardmarketEUR(c model.Card) (float64, string) {
+	if c.Cardmarket == nil {
+		return 0, ""
 	}
+	if p := c.Cardmarket.Prices.TrendPrice; p != nil && *p > 0 {
+		return *p, "cardmarket.trend"
+	}
+	if p := c.Cardmarket.Prices.Avg30; p != nil && *p > 0 {
+		return *p, "cardmarket.avg30"
+	}
+	return 0, ""
+}
+
+// RawVsPSA10 lists every card priced both raw and in PSA 10
+func RawVsPSA10(rows []Row) *report.Table {
+	t := &report.Table{Columns: []report.Column{
+		{Name: "Card"},
+		{Name: "Number"},
+		{Name: "RawUSD", Kind: report.Money},
+		{Name: "RawSource"},
+		{Name: "PSA10_USD", Kind: report.Money},
+		{Name: "Delta_USD", Kind: report.Money},
+		{Name: "Notes"},
+	}}
 	for _, r := range rows {
 		if r.RawUSD <= 0 || r.Grades.PSA10 <= 0 {
 			continue
 		}
-		delta := r.Grades.PSA10 - r.RawUSD
 		notes := r.RawNote
-		out = append(out, []string{
-			fmt.Sprintf("%s", r.Card.Name),
-			r.Card.Number,
-			money(r.RawUSD),
-			r.RawSrc,
-			money(r.Grades.PSA10),
-			money(delta),
-			notes,
-		})
+		if r.Stale {
+			notes += " [STALE]"
+		}
+		t.AddRow(r.Label(), r.Card.Number, r.RawUSD, r.RawSrc, r.Grades.PSA10, r.Grades.PSA10-r.RawUSD, notes)
 	}
-	return out
+	return t
 }
 
-func ReportMultiVsPSA10(rows []Row) [][]string {
-	out := [][]string{
-		{"Card", "Number", "PSA9_USD", "CGC/BGS_9.5_USD", "BGS10_USD", "PSA10_USD", "PSA9/10_%", "9.5/10_%", "BGS10/PSA10_%"},
-	}
+// ReportRawVsPSA10 renders RawVsPSA10 as CSV records
+func ReportRawVsPSA10(rows []Row) [][]string {
+	return RawVsPSA10(rows).Records()
+}
+
+// MultiVsPSA10 compares PSA 9, 9.5 and BGS 10 prices against PSA 10
+func MultiVsPSA10(rows []Row) *report.Table {
+	t := &report.Table{Columns: []report.Column{
+		{Name: "Card"},
+		{Name: "Number"},
+		{Name: "PSA9_USD", Kind: report.Money},
+		{Name: "CGC/BGS_9.5_USD", Kind: report.Money},
+		{Name: "BGS10_USD", Kind: report.Money},
+		{Name: "PSA10_USD", Kind: report.Money},
+		{Name: "PSA9/10_%", Kind: report.Percent, Precision: 1},
+		{Name: "9.5/10_%", Kind: report.Percent, Precision: 1},
+		{Name: "BGS10/PSA10_%", Kind: report.Percent, Precision: 1},
+	}}
 	for _, r := range rows {
 		if r.Grades.PSA10 <= 0 {
 			continue
 		}
-		psa9pct := pct(r.Grades.Grade9, r.Grades.PSA10)
-		g95pct := pct(r.Grades.Grade95, r.Grades.PSA10)
-		bgs10pct := pct(r.Grades.BGS10, r.Grades.PSA10)
-		out = append(out, []string{
-			r.Card.Name,
+		t.AddRow(
+			r.Label(),
 			r.Card.Number,
-			money(r.Grades.Grade9),
-			money(r.Grades.Grade95),
-			money(r.Grades.BGS10),
-			money(r.Grades.PSA10),
-			psa9pct,
-			g95pct,
-			bgs10pct,
-		})
+			r.Grades.Grade9,
+			r.Grades.Grade95,
+			r.Grades.BGS10,
+			r.Grades.PSA10,
+			pct(r.Grades.Grade9, r.Grades.PSA10),
+			pct(r.Grades.Grade95, r.Grades.PSA10),
+			pct(r.Grades.BGS10, r.Grades.PSA10),
+		)
 	}
-	return out
+	return t
 }
 
-func pct(a, b float64) string {
-	if a <= 0 || b <= 0 {
-		return ""
-	}
-	return fmt.Sprintf("%.1f%%", (a/b)*100.0)
+// ReportMultiVsPSA10 renders MultiVsPSA10 as CSV records
+func ReportMultiVsPSA10(rows []Row) [][]string {
+	return MultiVsPSA10(rows).Records()
 }
 
-func money(v float64) string {
-	if v <= 0 {
-		return ""
+// pct returns a as a percentage of b, or nil when either price is missing
+func pct(a, b float64) any {
+	if a <= 0 || b <= 0 {
+		return nil
 	}
-	return "$" + strconv.FormatFloat(round2(v), 'f', 2, 64)
+	return (a / b) * 100.0
 }
 
 func round2(f float64) float64 {
@@ -226,23 +313,27 @@ type EbayListing struct {
 	URL   string
 }
 
-// ReportRank generates the ranked opportunities report
-func ReportRank(rows []Row, set *model.Set, config Config) [][]string {
-	return ReportRankWithEbay(rows, set, config, nil)
+// RankResult is the scored, filtered and sorted output of the rank analysis
+type RankResult struct {
+	Rows    []ScoredRow
+	Scoring string // name of the Scorer that produced the scores
+	Notice  string // set when the whole set was skipped
+	config  Config
 }
 
-// ReportRankWithEbay generates the ranked opportunities report with eBay integration
-func ReportRankWithEbay(rows []Row, set *model.Set, config Config, ebayClient EbayProvider) [][]string {
+// Rank scores rows with the configured Scorer, drops cards that fail the
+// filters and returns the best TopN first.
+func Rank(rows []Row, set *model.Set, config Config) *RankResult {
+	scorer := scorerFor(config)
+	result := &RankResult{Scoring: scorer.Name(), config: config}
+
 	// Calculate set age if filtering is enabled
 	setAge := 0
 	if config.MaxAgeYears > 0 && set != nil && set.ReleaseDate != "" {
 		setAge = calculateSetAge(set.ReleaseDate)
 		if setAge > config.MaxAgeYears {
-			// Return empty result with header for old sets
-			return [][]string{
-				{"Card", "No", "RawUSD", "PSA10USD", "DeltaUSD", "CostUSD", "BreakEvenUSD", "Score", "Notes", "EBayLinks"},
-				{fmt.Sprintf("Set %s is %d years old, exceeds --max-age-years %d", set.Name, setAge, config.MaxAgeYears), "", "", "", "", "", "", "", "", ""},
-			}
+			result.Notice = fmt.Sprintf("Set %s is %d years old, exceeds --max-age-years %d", set.Name, setAge, config.MaxAgeYears)
+			return result
 		}
 	}
 
@@ -277,96 +368,42 @@ func ReportRankWithEbay(rows []Row, set *model.Set, config Config, ebayClient Eb
 		}
 
 		// Calculate costs and score
-		totalCost := r.RawUSD + config.GradingCost + config.ShippingCost
-		sellingFees := r.Grades.PSA10 * config.FeePct
-		netProfit := r.Grades.PSA10 - totalCost - sellingFees
-		breakEven := totalCost / (1 - config.FeePct)
-
-		// Base score is net profit
-		score := netProfit
-
-		// Add premium lift bonus (rewards steep PSA10 premium)
-		if r.Grades.PSA10 > 0 && r.Grades.Grade9 > 0 {
-			premiumLift := (1 - r.Grades.Grade9/r.Grades.PSA10) * 10
-			score += premiumLift
+		cost := totalCost(r, config)
+		days := config.ServiceLevel(r).TurnaroundDays
+		breakEven := cost / config.proceedsFactor(r, days)
+		scored := scorer.Score(r, config)
+
+		// Annualise what the sale returns on the day it happens, before discounting
+		resale := scored.ExpectedResaleUSD
+		if resale <= 0 {
+			resale = r.Grades.PSA10
 		}
-
-		// Check if Japanese (simple heuristic: card name contains Japanese characters or set is Japanese)
-		isJapanese := containsJapanese(r.Card.Name)
-		if isJapanese {
-			score *= config.JapaneseWeight
-		}
-
-		// Population-based scoring factors
-		psa10Rate := float64(0)
-		psa9Rate := float64(0)
-
-		// Apply population scarcity bonus if population data is available
-		if r.Population != nil {
-			// Calculate PSA 10 rate (success rate)
-			if r.Population.TotalGraded > 0 {
-				psa10Rate = float64(r.Population.PSA10) / float64(r.Population.TotalGraded)
-				psa9Rate = float64(r.Population.PSA9) / float64(r.Population.TotalGraded)
-			}
-
-			// Apply scarcity bonus based on PSA 10 population
-			scarcityBonus := calculateScarcityBonus(r.Population.PSA10)
-			score += scarcityBonus
-
-			// Apply population quality bonus (high PSA 10 rate indicates good card quality)
-			if r.Population.TotalGraded >= 100 { // Need sufficient sample size
-				qualityBonus := psa10Rate * 5 // Bonus for cards that grade well
-				score += qualityBonus
-			}
-		}
-
-		// Apply volatility penalty if available
-		if config.WithVolatility && r.Volatility > 0 {
-			if r.Volatility > 0.2 {
-				score *= 0.9
-			}
+		proceeds := resale * priceDrift(r, days) * (1 - config.FeePct)
+
+		// Population success rates; models with a grade distribution report theirs
+		psa10Rate := scored.Distribution.PSA10
+		psa9Rate := scored.Distribution.PSA9
+		if scored.Distribution == (GradeDistribution{}) && r.Population != nil && r.Population.TotalGraded > 0 {
+			psa10Rate = float64(r.Population.PSA10) / float64(r.Population.TotalGraded)
+			psa9Rate = float64(r.Population.PSA9) / float64(r.Population.TotalGraded)
 		}
 
 		scoredRow := ScoredRow{
-			Row:          r,
-			Score:        score,
-			BreakEvenUSD: breakEven,
-			NetProfitUSD: netProfit,
-			TotalCostUSD: totalCost,
-			IsJapanese:   isJapanese,
-			SetAgeYears:  setAge,
-			PSA10Rate:    psa10Rate,
-			PSA9Rate:     psa9Rate,
-		}
-
-		if config.ShowWhy {
-			breakdown := fmt.Sprintf("Profit:%.2f", netProfit)
-
-			// Add premium lift breakdown
-			if r.Grades.PSA10 > 0 && r.Grades.Grade9 > 0 {
-				premiumLift := (1 - r.Grades.Grade9/r.Grades.PSA10) * 10
-				breakdown += fmt.Sprintf(" Premium:%.2f", premiumLift)
-			}
-
-			// Add population factors if available
-			if r.Population != nil {
-				scarcityBonus := calculateScarcityBonus(r.Population.PSA10)
-				breakdown += fmt.Sprintf(" Scarcity:%.2f", scarcityBonus)
-
-				if r.Population.TotalGraded >= 100 {
-					qualityBonus := psa10Rate * 5
-					breakdown += fmt.Sprintf(" Quality:%.2f", qualityBonus)
-				}
-			}
-
-			// Add other factors
-			breakdown += fmt.Sprintf(" JPN:%v", isJapanese)
-
-			if config.WithVolatility && r.Volatility > 0.2 {
-				breakdown += " Vol:0.9x"
-			}
-
-			scoredRow.ScoreBreakdown = breakdown
+			Row:               r,
+			Score:             scored.Score,
+			BreakEvenUSD:      breakEven,
+			NetProfitUSD:      scored.NetProfitUSD,
+			TotalCostUSD:      cost,
+			IsJapanese:        containsJapanese(r.Card.Name),
+			SetAgeYears:       setAge,
+			Factors:           scored.Factors,
+			PSA10Rate:         psa10Rate,
+			PSA9Rate:          psa9Rate,
+			Distribution:      scored.Distribution,
+			ExpectedResaleUSD: scored.ExpectedResaleUSD,
+			TurnaroundDays:    days,
+			ProjectedPSA10USD: ProjectPrice(r.Grades.PSA10, r.PSA10Forecast30d, days),
+			AnnualROI:         AnnualizedROI(proceeds/cost-1, days),
 		}
 
 		scoredRows = append(scoredRows, scoredRow)
@@ -382,92 +419,225 @@ func ReportRankWithEbay(rows []Row, set *model.Set, config Config, ebayClient Eb
 		scoredRows = scoredRows[:config.TopN]
 	}
 
-	// Build output
-	header := []string{"Card", "No", "RawUSD", "PSA10USD", "DeltaUSD", "CostUSD", "BreakEvenUSD", "Score", "Notes"}
+	result.Rows = scoredRows
+	return result
+}
+
+// Table lays the ranked rows out in the rank report's columns
+func (r *RankResult) Table() *report.Table {
+	config := r.config
+	t := &report.Table{Notice: r.Notice}
+	t.Columns = []report.Column{
+		{Name: "Card"},
+		{Name: "No"},
+		{Name: "RawUSD", Kind: report.Money},
+		{Name: "PSA10USD", Kind: report.Money},
+		{Name: "DeltaUSD", Kind: report.Money},
+		{Name: "CostUSD", Kind: report.Money},
+		{Name: "BreakEvenUSD", Kind: report.Money},
+		{Name: "Score", Kind: report.Number, Precision: 1},
+		{Name: "Notes"},
+		{Name: "TurnaroundDays", Kind: report.Integer},
+		{Name: "AnnualROI%", Kind: report.Percent, Precision: 1},
+	}
+	// Only rows priced with --project-prices carry a forecast
+	withProjection := false
+	for _, sr := range r.Rows {
+		if sr.PSA10Forecast30d > 0 {
+			withProjection = true
+			break
+		}
+	}
+	if withProjection {
+		t.Columns = append(t.Columns, report.Column{Name: "PSA10AtReturnUSD", Kind: report.Money})
+	}
+	// Only rows with a population history carry a trend
+	withPopTrend := false
+	for _, sr := range r.Rows {
+		if sr.Population != nil && sr.Population.Trend != "" {
+			withPopTrend = true
+			break
+		}
+	}
+	if withPopTrend {
+		t.Columns = append(t.Columns,
+			report.Column{Name: "PopTrend"},
+			report.Column{Name: "PSA10PerMonth", Kind: report.Number, Precision: 1},
+			report.Column{Name: "MonthsToSaturation", Kind: report.Number, Precision: 1},
+		)
+	}
+	// Only rows looked up in other companies' census carry a combined supply
+	withCombined := false
+	for _, sr := range r.Rows {
+		if sr.Population != nil && sr.Population.CombinedGraded > 0 {
+			withCombined = true
+			break
+		}
+	}
+	if withCombined {
+		t.Columns = append(t.Columns,
+			report.Column{Name: "AllGraded", Kind: report.Integer},
+			report.Column{Name: "AllGem10", Kind: report.Integer},
+		)
+	}
+	// Only rows from a run with population targeting carry a fetch priority
+	withPriority := false
+	for _, sr := range r.Rows {
+		if sr.PopPriority > 0 || sr.PopSkipped {
+			withPriority = true
+			break
+		}
+	}
+	if withPriority {
+		t.Columns = append(t.Columns, report.Column{Name: "PopPriority", Kind: report.Number, Precision: 2})
+	}
+	// The heuristic assumes a PSA 10, so it has no expected resale to show
+	withExpected := r.Scoring != ScoringHeuristic
+	if withExpected {
+		t.Columns = append(t.Columns,
+			report.Column{Name: "ExpectedUSD", Kind: report.Money},
+			report.Column{Name: "PSA10Prob", Kind: report.Percent},
+		)
+	}
 	if config.WithEbay {
-		header = append(header, "EBayLinks")
+		t.Columns = append(t.Columns, report.Column{Name: "EBayLinks"})
 	}
 	if config.WithAuctions {
-		header = append(header, "AuctionCount", "BestBid", "AuctionProfit%", "AuctionRisk", "AuctionURL")
+		t.Columns = append(t.Columns,
+			report.Column{Name: "AuctionCount", Kind: report.Integer},
+			report.Column{Name: "BestBid", Kind: report.Money},
+			report.Column{Name: "AuctionProfit%", Kind: report.Percent, Precision: 1},
+			report.Column{Name: "AuctionRisk"},
+			report.Column{Name: "AuctionURL"},
+		)
 	}
 	if config.WithVolatility {
-		header = append(header, "Volatility30D")
+		t.Columns = append(t.Columns, report.Column{Name: "Volatility30D", Kind: report.Percent, Precision: 1})
 	}
 	if config.WithMarketplace {
-		header = append(header, "ActiveListings", "LowestListing", "OptimalPrice", "CompetitionLevel", "MarketTrend", "ListingVelocity")
+		t.Columns = append(t.Columns,
+			report.Column{Name: "ActiveListings", Kind: report.Integer},
+			report.Column{Name: "LowestListing", Kind: report.Money},
+			report.Column{Name: "OptimalPrice", Kind: report.Money},
+			report.Column{Name: "CompetitionLevel"},
+			report.Column{Name: "MarketTrend"},
+			report.Column{Name: "ListingVelocity", Kind: report.Number, Precision: 1},
+		)
 	}
 	if config.ShowWhy {
-		header = append(header, "Why")
+		t.Columns = append(t.Columns, report.Column{Name: "Why"})
 	}
 
-	out := [][]string{header}
-
-	for _, sr := range scoredRows {
+	for _, sr := range r.Rows {
 		notes := sr.RawNote
 		if sr.IsJapanese {
 			notes += " [JPN]"
 		}
+		if sr.Stale {
+			notes += " [STALE]"
+		}
+		if sr.Population != nil && sr.Population.Estimated {
+			notes += " [EST POP]"
+		}
+		if sr.PopSkipped {
+			notes += " [POP SKIPPED]"
+		}
 
-		row := []string{
-			sr.Card.Name,
+		row := []any{
+			sr.Label(),
 			sr.Card.Number,
-			money(sr.RawUSD),
-			money(sr.Grades.PSA10),
-			money(sr.Grades.PSA10 - sr.RawUSD),
-			money(sr.TotalCostUSD),
-			money(sr.BreakEvenUSD),
-			fmt.Sprintf("%.1f", sr.Score),
+			sr.RawUSD,
+			sr.Grades.PSA10,
+			sr.Grades.PSA10 - sr.RawUSD,
+			sr.TotalCostUSD,
+			sr.BreakEvenUSD,
+			sr.Score,
 			notes,
+			sr.TurnaroundDays,
+			sr.AnnualROI * 100,
+		}
+
+		if withProjection {
+			row = append(row, sr.ProjectedPSA10USD)
+		}
+
+		if withPopTrend {
+			if p := sr.Population; p != nil && p.Trend != "" {
+				var saturation any = p.MonthsToSaturation
+				if p.MonthsToSaturation < 0 {
+					saturation = nil
+				}
+				row = append(row, p.Trend, p.PSA10PerMonth, saturation)
+			} else {
+				row = append(row, "", nil, nil)
+			}
+		}
+
+		if withCombined {
+			if p := sr.Population; p != nil && p.CombinedGraded > 0 {
+				row = append(row, p.CombinedGraded, p.CombinedGem)
+			} else {
+				row = append(row, nil, nil)
+			}
+		}
+
+		if withPriority {
+			row = append(row, sr.PopPriority)
+		}
+
+		if withExpected {
+			row = append(row, sr.ExpectedResaleUSD, sr.Distribution.PSA10*100)
 		}
 
 		if config.WithEbay {
-			// Add empty eBay links column for now
-			// This would be populated if ebayClient was provided
+			// eBay links are not fetched during ranking yet
 			row = append(row, "")
 		}
 
 		if config.WithAuctions {
-			// Add auction data columns
-			auctionCount := fmt.Sprintf("%d", sr.AuctionOpportunities)
-			bestBid := ""
-			auctionProfit := ""
-			auctionRisk := ""
-			auctionURL := ""
-
+			var auctionProfit any
+			var auctionRisk, auctionURL string
 			if sr.BestAuctionBid > 0 {
-				bestBid = money(sr.BestAuctionBid)
-				auctionProfit = fmt.Sprintf("%.1f%%", sr.BestAuctionProfit)
+				auctionProfit = sr.BestAuctionProfit
 				auctionRisk = sr.BestAuctionRisk
 				auctionURL = sr.BestAuctionURL
 			}
-
-			row = append(row, auctionCount, bestBid, auctionProfit, auctionRisk, auctionURL)
+			row = append(row, sr.AuctionOpportunities, sr.BestAuctionBid, auctionProfit, auctionRisk, auctionURL)
 		}
 
 		if config.WithVolatility {
-			row = append(row, fmt.Sprintf("%.1f%%", sr.Volatility*100))
+			row = append(row, sr.Volatility*100)
 		}
 
 		if config.WithMarketplace {
-			// Add marketplace columns
 			row = append(row,
-				fmt.Sprintf("%d", sr.ActiveListings),
-				money(sr.LowestListing),
-				money(sr.OptimalListingPrice),
+				sr.ActiveListings,
+				sr.LowestListing,
+				sr.OptimalListingPrice,
 				sr.CompetitionLevel,
 				sr.MarketTrend,
-				fmt.Sprintf("%.1f", sr.ListingVelocity),
+				sr.ListingVelocity,
 			)
 		}
 
 		if config.ShowWhy {
-			row = append(row, sr.ScoreBreakdown)
+			row = append(row, FormatFactors(sr.Factors))
 		}
 
-		out = append(out, row)
+		t.AddRow(row...)
 	}
+	return t
+}
 
-	return out
+// ReportRank renders Rank as CSV records
+func ReportRank(rows []Row, set *model.Set, config Config) [][]string {
+	return Rank(rows, set, config).Table().Records()
+}
+
+// ReportRankWithEbay renders Rank as CSV records. The eBay client is not
+// consulted yet; the EBayLinks column is left empty.
+func ReportRankWithEbay(rows []Row, set *model.Set, config Config, ebayClient EbayProvider) [][]string {
+	return ReportRank(rows, set, config)
 }
 
 func calculateSetAge(releaseDate string) int {
@@ -504,11 +674,16 @@ func containsJapanese(s string) bool {
 	return false
 }
 
-// ReportCrossgrade identifies CGC/BGS 9.5 cards worth regrading to PSA
-func ReportCrossgrade(rows []Row) [][]string {
-	out := [][]string{
-		{"Card", "No", "CGC95USD", "PSA10USD", "CrossgradeROI%", "Notes"},
-	}
+// Crossgrade identifies CGC/BGS 9.5 cards worth regrading to PSA
+func Crossgrade(rows []Row) *report.Table {
+	t := &report.Table{Columns: []report.Column{
+		{Name: "Card"},
+		{Name: "No"},
+		{Name: "CGC95USD", Kind: report.Money},
+		{Name: "PSA10USD", Kind: report.Money},
+		{Name: "CrossgradeROI%", Kind: report.Percent, Precision: 1},
+		{Name: "Notes"},
+	}}
 
 	// PSA crossgrade submission costs
 	crossgradeCost := 30.0 // PSA crossgrade service
@@ -531,18 +706,15 @@ func ReportCrossgrade(rows []Row) [][]string {
 		}
 
 		notes := fmt.Sprintf("Investment: $%.2f, Net: $%.2f", totalInvestment, netRevenue)
-
-		out = append(out, []string{
-			r.Card.Name,
-			r.Card.Number,
-			money(r.Grades.Grade95),
-			money(r.Grades.PSA10),
-			fmt.Sprintf("%.1f%%", roi),
-			notes,
-		})
+		t.AddRow(r.Label(), r.Card.Number, r.Grades.Grade95, r.Grades.PSA10, roi, notes)
 	}
 
-	return out
+	return t
+}
+
+// ReportCrossgrade renders Crossgrade as CSV records
+func ReportCrossgrade(rows []Row) [][]string {
+	return Crossgrade(rows).Records()
 }
 
 // calculateScarcityBonus returns a bonus score based on PSA 10 population scarcity
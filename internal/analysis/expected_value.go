@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"math"
+	"strings"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+// GradeDistribution is the probability of each outcome of a PSA submission.
+// The fields sum to 1.
+type GradeDistribution struct {
+	PSA10 float64
+	PSA9  float64
+	PSA8  float64
+	Lower float64 // PSA 7 and below, resold at the raw floor
+}
+
+// DefaultGradeDistribution is the prior used for modern pack-fresh cards when
+// no population data is available.
+var DefaultGradeDistribution = GradeDistribution{
+	PSA10: 0.35,
+	PSA9:  0.40,
+	PSA8:  0.15,
+	Lower: 0.10,
+}
+
+// priorWeight is how many graded copies the default distribution counts as.
+// Thin populations are pulled toward the prior instead of trusted outright.
+const priorWeight = 20.0
+
+// EstimateGradeDistribution blends the card's PSA population with the default
+// prior. Per-grade counts in pop.Grades are preferred over the summary fields.
+func EstimateGradeDistribution(pop *model.PSAPopulation) GradeDistribution {
+	prior := DefaultGradeDistribution
+	if pop == nil {
+		return prior
+	}
+
+	psa10, psa9, psa8, total := pop.PSA10, pop.PSA9, pop.PSA8, pop.TotalGraded
+	if len(pop.Grades) > 0 {
+		psa10, psa9, psa8 = 0, 0, 0
+		sum := 0
+		for grade, count := range pop.Grades {
+			switch normalizePSAGrade(grade) {
+			case "10":
+				psa10 += count
+			case "9":
+				psa9 += count
+			case "8":
+				psa8 += count
+			}
+			sum += count
+		}
+		if sum > total {
+			total = sum
+		}
+	}
+	if known := psa10 + psa9 + psa8; known > total {
+		total = known
+	}
+	if total <= 0 {
+		return prior
+	}
+
+	n := float64(total)
+	denom := n + priorWeight
+	d := GradeDistribution{
+		PSA10: (float64(psa10) + priorWeight*prior.PSA10) / denom,
+		PSA9:  (float64(psa9) + priorWeight*prior.PSA9) / denom,
+		PSA8:  (float64(psa8) + priorWeight*prior.PSA8) / denom,
+	}
+	d.Lower = 1 - d.PSA10 - d.PSA9 - d.PSA8
+	if d.Lower < 0 {
+		d.Lower = 0
+	}
+	return d
+}
+
+// normalizePSAGrade maps population keys such as "PSA 10" or "9" to the bare grade
+func normalizePSAGrade(grade string) string {
+	g := strings.TrimSpace(strings.ToUpper(grade))
+	g = strings.TrimSpace(strings.TrimPrefix(g, "PSA"))
+	return g
+}
+
+// ExpectedResale returns the probability-weighted sale price of a graded
+// copy. Grades without a known price, and anything below PSA 8, fall back to
+// selling the card raw again.
+func ExpectedResale(r Row, d GradeDistribution) float64 {
+	floor := r.RawUSD
+	payout := func(price float64) float64 {
+		if price < floor {
+			return floor
+		}
+		return price
+	}
+	return d.PSA10*payout(r.Grades.PSA10) +
+		d.PSA9*payout(r.Grades.Grade9) +
+		d.PSA8*payout(r.Grades.Grade8) +
+		d.Lower*floor
+}
+
+// SalePriceSpread returns the coefficient of variation (std dev / mean) of a
+// set of sale prices, or 0 when there are too few sales to say.
+func SalePriceSpread(prices []float64) float64 {
+	if len(prices) < 3 {
+		return 0
+	}
+	mean := 0.0
+	for _, p := range prices {
+		mean += p
+	}
+	mean /= float64(len(prices))
+	if mean <= 0 {
+		return 0
+	}
+	variance := 0.0
+	for _, p := range prices {
+		variance += (p - mean) * (p - mean)
+	}
+	variance /= float64(len(prices) - 1)
+	return math.Sqrt(variance) / mean
+}
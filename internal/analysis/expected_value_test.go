@@ -0,0 +1,137 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+func TestEstimateGradeDistribution(t *testing.T) {
+	t.Run("no population uses prior", func(t *testing.T) {
+		if got := EstimateGradeDistribution(nil); got != DefaultGradeDistribution {
+			t.Errorf("expected default distribution, got %+v", got)
+		}
+	})
+
+	t.Run("large population dominates prior", func(t *testing.T) {
+		d := EstimateGradeDistribution(&model.PSAPopulation{
+			TotalGraded: 10000,
+			PSA10:       1000,
+			PSA9:        6000,
+			PSA8:        2000,
+		})
+		if abs(d.PSA10-0.10) > 0.01 || abs(d.PSA9-0.60) > 0.01 || abs(d.PSA8-0.20) > 0.01 {
+			t.Errorf("expected roughly 10/60/20, got %+v", d)
+		}
+		if abs(d.PSA10+d.PSA9+d.PSA8+d.Lower-1) > 1e-9 {
+			t.Errorf("distribution does not sum to 1: %+v", d)
+		}
+	})
+
+	t.Run("thin population shrinks toward prior", func(t *testing.T) {
+		d := EstimateGradeDistribution(&model.PSAPopulation{TotalGraded: 2, PSA10: 2})
+		if d.PSA10 >= 0.5 {
+			t.Errorf("two gems should not imply a >50%% gem rate, got %.2f", d.PSA10)
+		}
+		if d.PSA10 <= DefaultGradeDistribution.PSA10 {
+			t.Errorf("expected gem rate above the prior, got %.2f", d.PSA10)
+		}
+	})
+
+	t.Run("per-grade counts preferred", func(t *testing.T) {
+		d := EstimateGradeDistribution(&model.PSAPopulation{
+			TotalGraded: 1000,
+			PSA10:       900, // stale summary field
+			Grades: map[string]int{
+				"PSA 10": 100,
+				"PSA 9":  500,
+				"PSA 8":  200,
+				"PSA 7":  200,
+			},
+		})
+		if d.PSA10 > 0.15 {
+			t.Errorf("expected grade map to override PSA10 summary, got %.2f", d.PSA10)
+		}
+		if d.Lower < 0.15 {
+			t.Errorf("expected PSA 7 copies in the lower bucket, got %.2f", d.Lower)
+		}
+	})
+}
+
+func TestExpectedResale(t *testing.T) {
+	row := Row{
+		RawUSD: 50,
+		Grades: Grades{PSA10: 300, Grade9: 100, Grade8: 40},
+	}
+	d := GradeDistribution{PSA10: 0.25, PSA9: 0.25, PSA8: 0.25, Lower: 0.25}
+
+	// PSA 8 sells below raw, so it and the lower grades resell at the raw floor
+	want := 0.25*300 + 0.25*100 + 0.25*50 + 0.25*50
+	if got := ExpectedResale(row, d); abs(got-want) > 0.001 {
+		t.Errorf("ExpectedResale = %.2f, want %.2f", got, want)
+	}
+
+	// Missing grade prices also fall back to the floor
+	row.Grades.Grade9 = 0
+	want = 0.25*300 + 0.75*50
+	if got := ExpectedResale(row, d); abs(got-want) > 0.001 {
+		t.Errorf("ExpectedResale without PSA 9 price = %.2f, want %.2f", got, want)
+	}
+}
+
+func TestReportRank_ExpectedValuePenalisesLowGemRate(t *testing.T) {
+	rows := []Row{
+		{
+			// Huge PSA 10 price but almost never gems
+			Card:   model.Card{Name: "Rarely Gems", Number: "1"},
+			RawUSD: 50,
+			Grades: Grades{PSA10: 400, Grade9: 80, Grade8: 60},
+			Population: &model.PSAPopulation{
+				TotalGraded: 5000, PSA10: 100, PSA9: 2500, PSA8: 1500,
+			},
+		},
+		{
+			// Smaller PSA 10 price but gems reliably
+			Card:   model.Card{Name: "Gems Easily", Number: "2"},
+			RawUSD: 50,
+			Grades: Grades{PSA10: 250, Grade9: 120, Grade8: 80},
+			Population: &model.PSAPopulation{
+				TotalGraded: 5000, PSA10: 4000, PSA9: 900, PSA8: 100,
+			},
+		},
+	}
+	config := Config{
+		GradingCost:  25,
+		ShippingCost: 20,
+		FeePct:       0.13,
+		MinDeltaUSD:  25,
+		TopN:         10,
+	}
+
+	out := ReportRank(rows, nil, config)
+	if len(out) != 3 || out[1][0] != "Rarely Gems" {
+		t.Fatalf("expected PSA 10 scoring to rank Rarely Gems first, got %v", out)
+	}
+
+	config.Scoring = ScoringExpectedValue
+	out = ReportRank(rows, nil, config)
+	if len(out) != 3 || out[1][0] != "Gems Easily" {
+		t.Fatalf("expected EV scoring to rank Gems Easily first, got %v", out)
+	}
+	if out[0][11] != "ExpectedUSD" || out[0][12] != "PSA10Prob" {
+		t.Errorf("expected EV columns in header, got %v", out[0])
+	}
+}
+
+func TestSalePriceSpread(t *testing.T) {
+	if got := SalePriceSpread([]float64{100, 120}); got != 0 {
+		t.Errorf("expected 0 with too few sales, got %v", got)
+	}
+	if got := SalePriceSpread([]float64{100, 100, 100}); got != 0 {
+		t.Errorf("expected 0 for identical sales, got %v", got)
+	}
+	// mean 100, sample std dev 10
+	if got := SalePriceSpread([]float64{90, 100, 110}); abs(got-0.1) > 1e-9 {
+		t.Errorf("expected spread 0.1, got %v", got)
+	}
+}
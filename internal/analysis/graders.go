@@ -0,0 +1,348 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/guarzo/pkmgradegap/internal/fees"
+	"github.com/guarzo/pkmgradegap/internal/report"
+)
+
+// PriceField names a PriceCharting price column that a graded slab sells at
+type PriceField string
+
+const (
+	FieldPSA10   PriceField = "manual-only-price"  // PSA 10
+	FieldGrade9  PriceField = "graded-price"       // 9 from any grader
+	FieldGrade95 PriceField = "box-only-price"     // 9.5 from any grader
+	FieldGrade8  PriceField = "new-price"          // 8 from any grader
+	FieldBGS10   PriceField = "bgs-10-price"       // BGS 10
+	FieldCGC10   PriceField = "condition-17-price" // CGC 10
+	FieldSGC10   PriceField = "condition-18-price" // SGC 10
+)
+
+// Price returns the price for a PriceCharting field, or 0 if unknown
+func (g Grades) Price(f PriceField) float64 {
+	switch f {
+	case FieldPSA10:
+		return g.PSA10
+	case FieldGrade9:
+		return g.Grade9
+	case FieldGrade95:
+		return g.Grade95
+	case FieldGrade8:
+		return g.Grade8
+	case FieldBGS10:
+		return g.BGS10
+	case FieldCGC10:
+		return g.CGC10
+	case FieldSGC10:
+		return g.SGC10
+	default:
+		return 0
+	}
+}
+
+// Condition is the true state of a card, independent of who grades it
+type Condition int
+
+const (
+	Gem      Condition = iota // PSA 10 quality
+	NearGem                   // top of the PSA 9 range; a 9.5 where the grader has one
+	Mint                      // the rest of PSA 9
+	NearMint                  // PSA 8
+	Played                    // below 8, resold raw
+	numConditions
+)
+
+// nearGemShare is the fraction of PSA 9s that other graders would call 9.5
+const nearGemShare = 0.3
+
+// ConditionOdds splits a PSA grade distribution into grader-neutral conditions
+func ConditionOdds(d GradeDistribution) [numConditions]float64 {
+	return [numConditions]float64{
+		Gem:      d.PSA10,
+		NearGem:  d.PSA9 * nearGemShare,
+		Mint:     d.PSA9 * (1 - nearGemShare),
+		NearMint: d.PSA8,
+		Played:   d.Lower,
+	}
+}
+
+// Payout is one slab a condition can come back as. Fields are tried in order
+// so a thinly traded label can fall back to a close substitute.
+type Payout struct {
+	Label  string
+	Share  float64 // share of the condition that receives this label
+	Fields []PriceField
+}
+
+// Grader describes how each card condition translates into a sellable slab
+// at a grading company. Its fees come from the fee schedule under Name.
+type Grader struct {
+	Name    string
+	Payouts [numConditions][]Payout
+}
+
+// price returns the first known price among fields, floored at raw
+func (p Payout) price(r Row) float64 {
+	for _, f := range p.Fields {
+		if v := r.Grades.Price(f); v > 0 {
+			return math.Max(v, r.RawUSD)
+		}
+	}
+	return r.RawUSD
+}
+
+// ExpectedResale returns the probability-weighted resale price of a card
+// graded by g. Played copies resell raw.
+func (g Grader) ExpectedResale(r Row, odds [numConditions]float64) float64 {
+	total := 0.0
+	for c, p := range odds {
+		payouts := g.Payouts[c]
+		if len(payouts) == 0 {
+			total += p * r.RawUSD
+			continue
+		}
+		for _, po := range payouts {
+			total += p * po.Share * po.price(r)
+		}
+	}
+	return total
+}
+
+// DeclaredValue is the best-case slab price, which grading companies use to
+// pick the service level.
+func (g Grader) DeclaredValue(r Row) float64 {
+	best := 0.0
+	for _, payouts := range g.Payouts {
+		for _, po := range payouts {
+			best = math.Max(best, po.price(r))
+		}
+	}
+	return best
+}
+
+func slab(label string, fields ...PriceField) []Payout {
+	return []Payout{{Label: label, Share: 1, Fields: fields}}
+}
+
+// Built-in graders
+var (
+	PSA = Grader{
+		Name: "PSA",
+		Payouts: [numConditions][]Payout{
+			Gem:      slab("PSA 10", FieldPSA10),
+			NearGem:  slab("PSA 9", FieldGrade9),
+			Mint:     slab("PSA 9", FieldGrade9),
+			NearMint: slab("PSA 8", FieldGrade8),
+		},
+	}
+
+	BGS = Grader{
+		Name: "BGS",
+		Payouts: [numConditions][]Payout{
+			// Most gem copies miss Pristine on a subgrade and come back 9.5
+			Gem: {
+				{Label: "BGS 10", Share: 0.15, Fields: []PriceField{FieldBGS10, FieldGrade95}},
+				{Label: "BGS 9.5", Share: 0.85, Fields: []PriceField{FieldGrade95}},
+			},
+			NearGem:  slab("BGS 9.5", FieldGrade95, FieldGrade9),
+			Mint:     slab("BGS 9", FieldGrade9),
+			NearMint: slab("BGS 8", FieldGrade8),
+		},
+	}
+
+	CGC = Grader{
+		Name: "CGC",
+		Payouts: [numConditions][]Payout{
+			Gem:      slab("CGC 10", FieldCGC10, FieldGrade95),
+			NearGem:  slab("CGC 9.5", FieldGrade95, FieldGrade9),
+			Mint:     slab("CGC 9", FieldGrade9),
+			NearMint: slab("CGC 8", FieldGrade8),
+		},
+	}
+
+	SGC = Grader{
+		Name: "SGC",
+		Payouts: [numConditions][]Payout{
+			Gem:      slab("SGC 10", FieldSGC10, FieldGrade95),
+			NearGem:  slab("SGC 9.5", FieldGrade95, FieldGrade9),
+			Mint:     slab("SGC 9", FieldGrade9),
+			NearMint: slab("SGC 8", FieldGrade8),
+		},
+	}
+)
+
+var (
+	gradersMu sync.RWMutex
+	graders   []Grader
+)
+
+func init() {
+	RegisterGrader(PSA)
+	RegisterGrader(BGS)
+	RegisterGrader(CGC)
+	RegisterGrader(SGC)
+}
+
+// RegisterGrader adds a grader to the comparison, replacing any grader
+// already registered under the same name.
+func RegisterGrader(g Grader) {
+	gradersMu.Lock()
+	defer gradersMu.Unlock()
+	for i := range graders {
+		if graders[i].Name == g.Name {
+			graders[i] = g
+			return
+		}
+	}
+	graders = append(graders, g)
+}
+
+// GetGrader returns the grader registered under name (case-insensitive)
+func GetGrader(name string) (Grader, error) {
+	gradersMu.RLock()
+	defer gradersMu.RUnlock()
+	for _, g := range graders {
+		if strings.EqualFold(g.Name, name) {
+			return g, nil
+		}
+	}
+	names := make([]string, len(graders))
+	for i, g := range graders {
+		names[i] = g.Name
+	}
+	return Grader{}, fmt.Errorf("unknown grader %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// Graders returns the registered graders in registration order
+func Graders() []Grader {
+	gradersMu.RLock()
+	defer gradersMu.RUnlock()
+	return append([]Grader(nil), graders...)
+}
+
+// GraderOption is the economics of sending one card to one grader
+type GraderOption struct {
+	Grader            string
+	Tier              fees.ServiceLevel
+	ExpectedResaleUSD float64
+	NetProfitUSD      float64
+}
+
+// GraderRecommendation ranks the graders for one card, best first
+type GraderRecommendation struct {
+	Row
+	Options []GraderOption
+}
+
+// Best returns the grader with the highest expected net profit
+func (r GraderRecommendation) Best() GraderOption {
+	if len(r.Options) == 0 {
+		return GraderOption{}
+	}
+	return r.Options[0]
+}
+
+// Option returns the option for the named grader
+func (r GraderRecommendation) Option(name string) (GraderOption, bool) {
+	for _, o := range r.Options {
+		if o.Grader == name {
+			return o, true
+		}
+	}
+	return GraderOption{}, false
+}
+
+// Crossover reports whether the card's near-gem copies are worth more as a
+// 9.5 than as a PSA 9, which is where non-PSA graders can win.
+func (r GraderRecommendation) Crossover() bool {
+	return r.Grades.Grade95 > 0 && r.Grades.Grade9 > 0 && r.Grades.Grade95 > r.Grades.Grade9
+}
+
+// RecommendGrader prices a card at every grader with a fee schedule in
+// effect at config.FeesAsOf. Each grader's own schedule replaces
+// config.GradingCost; shipping and selling fees apply to all, and proceeds
+// are discounted over each grader's own turnaround.
+func RecommendGrader(r Row, config Config, gs []Grader) GraderRecommendation {
+	odds := ConditionOdds(EstimateGradeDistribution(r.Population))
+	rec := GraderRecommendation{Row: r}
+	for _, g := range gs {
+		schedule, err := config.Fees.At(g.Name, config.feeDate())
+		if err != nil {
+			continue
+		}
+		tier, ok := schedule.Level(g.DeclaredValue(r))
+		if !ok {
+			tier = schedule.Levels[len(schedule.Levels)-1]
+		}
+		resale := g.ExpectedResale(r, odds)
+		rec.Options = append(rec.Options, GraderOption{
+			Grader:            g.Name,
+			Tier:              tier,
+			ExpectedResaleUSD: resale,
+			NetProfitUSD:      resale*config.proceedsFactor(r, tier.TurnaroundDays) - r.RawUSD - tier.CostPerCard - config.ShippingCost,
+		})
+	}
+	sort.SliceStable(rec.Options, func(i, j int) bool {
+		return rec.Options[i].NetProfitUSD > rec.Options[j].NetProfitUSD
+	})
+	return rec
+}
+
+// CompareGraders recommends a grading company for each priced card, most
+// profitable first.
+func CompareGraders(rows []Row, config Config) *report.Table {
+	gs := Graders()
+	cols := []report.Column{
+		{Name: "Card"},
+		{Name: "No"},
+		{Name: "RawUSD", Kind: report.Money},
+		{Name: "Best"},
+		{Name: "Tier"},
+		{Name: "TurnaroundDays", Kind: report.Integer},
+		{Name: "BestNetUSD", Kind: report.Number, Precision: 2},
+	}
+	for _, g := range gs {
+		cols = append(cols, report.Column{Name: g.Name + "NetUSD", Kind: report.Number, Precision: 2})
+	}
+	cols = append(cols, report.Column{Name: "Notes"})
+	t := &report.Table{Columns: cols}
+
+	var recs []GraderRecommendation
+	for _, r := range rows {
+		if r.RawUSD <= 0 || (r.Grades.PSA10 <= 0 && r.Grades.Grade95 <= 0) {
+			continue
+		}
+		recs = append(recs, RecommendGrader(r, config, gs))
+	}
+	sort.SliceStable(recs, func(i, j int) bool {
+		return recs[i].Best().NetProfitUSD > recs[j].Best().NetProfitUSD
+	})
+	if config.TopN > 0 && len(recs) > config.TopN {
+		recs = recs[:config.TopN]
+	}
+
+	for _, rec := range recs {
+		best := rec.Best()
+		values := []any{rec.Label(), rec.Card.Number, rec.RawUSD, best.Grader, best.Tier.Name, best.Tier.TurnaroundDays, best.NetProfitUSD}
+		for _, g := range gs {
+			if o, ok := rec.Option(g.Name); ok {
+				values = append(values, o.NetProfitUSD)
+			} else {
+				values = append(values, nil)
+			}
+		}
+		note := ""
+		if rec.Crossover() {
+			note = fmt.Sprintf("9.5 $%.2f beats PSA 9 $%.2f", rec.Grades.Grade95, rec.Grades.Grade9)
+		}
+		values = append(values, note)
+		t.AddRow(values...)
+	}
+	return t
+}
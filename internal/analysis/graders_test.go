@@ -0,0 +1,110 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+func TestGrader_PayoutFallback(t *testing.T) {
+	row := Row{RawUSD: 10, Grades: Grades{Grade95: 90}}
+	odds := ConditionOdds(GradeDistribution{PSA10: 1})
+
+	// No CGC 10 sales yet, so a gem CGC slab is priced as a 9.5
+	if got := CGC.ExpectedResale(row, odds); abs(got-90) > 1e-9 {
+		t.Errorf("expected CGC 10 to fall back to the 9.5 price, got %.2f", got)
+	}
+	row.Grades.CGC10 = 150
+	if got := CGC.ExpectedResale(row, odds); abs(got-150) > 1e-9 {
+		t.Errorf("expected CGC 10 price, got %.2f", got)
+	}
+	// Without a PSA 10 price, the gem resells at the raw floor
+	if got := PSA.ExpectedResale(row, odds); abs(got-10) > 1e-9 {
+		t.Errorf("expected raw floor for missing PSA 10, got %.2f", got)
+	}
+}
+
+func TestRecommendGrader(t *testing.T) {
+	config := Config{FeePct: 0.13, ShippingCost: 20}
+
+	t.Run("PSA 10 premium favours PSA", func(t *testing.T) {
+		row := Row{
+			Card:   model.Card{Name: "Charizard", Number: "4"},
+			RawUSD: 60,
+			Grades: Grades{PSA10: 450, Grade9: 110, Grade95: 140, Grade8: 70},
+		}
+		rec := RecommendGrader(row, config, Graders())
+		if rec.Best().Grader != "PSA" {
+			t.Errorf("expected PSA, got %+v", rec.Options)
+		}
+		if len(rec.Options) != 4 {
+			t.Errorf("expected an option per grader, got %d", len(rec.Options))
+		}
+	})
+
+	t.Run("BGS 9.5 beating PSA 9 favours a 9.5 grader", func(t *testing.T) {
+		row := Row{
+			Card:   model.Card{Name: "Umbreon", Number: "95"},
+			RawUSD: 40,
+			Grades: Grades{PSA10: 150, Grade9: 60, Grade95: 140, Grade8: 45},
+			// Rarely gems, so most value sits in the 9 range
+			Population: &model.PSAPopulation{TotalGraded: 4000, PSA10: 200, PSA9: 3000, PSA8: 600},
+		}
+		rec := RecommendGrader(row, config, Graders())
+		if rec.Best().Grader == "PSA" {
+			t.Errorf("expected a non-PSA grader to win, got %+v", rec.Options)
+		}
+		if !rec.Crossover() {
+			t.Error("expected crossover when the 9.5 outsells the PSA 9")
+		}
+		psa, _ := rec.Option("PSA")
+		bgs, _ := rec.Option("BGS")
+		if bgs.ExpectedResaleUSD <= psa.ExpectedResaleUSD {
+			t.Errorf("expected BGS resale above PSA, got BGS %.2f PSA %.2f", bgs.ExpectedResaleUSD, psa.ExpectedResaleUSD)
+		}
+	})
+}
+
+func TestCompareGraders(t *testing.T) {
+	rows := []Row{
+		{Card: model.Card{Name: "Unpriced", Number: "1"}, RawUSD: 5},
+		{Card: model.Card{Name: "Pikachu", Number: "25"}, RawUSD: 20, Grades: Grades{PSA10: 200, Grade9: 70, Grade95: 90}},
+		{Card: model.Card{Name: "Eevee", Number: "133"}, RawUSD: 10, Grades: Grades{PSA10: 60, Grade9: 25}},
+	}
+	tbl := CompareGraders(rows, Config{FeePct: 0.13, ShippingCost: 20})
+
+	header := tbl.Header()
+	want := []string{"Card", "No", "RawUSD", "Best", "Tier", "TurnaroundDays", "BestNetUSD", "PSANetUSD", "BGSNetUSD", "CGCNetUSD", "SGCNetUSD", "Notes"}
+	if len(header) != len(want) {
+		t.Fatalf("header = %v, want %v", header, want)
+	}
+	for i := range want {
+		if header[i] != want[i] {
+			t.Errorf("column %d = %s, want %s", i, header[i], want[i])
+		}
+	}
+
+	if len(tbl.Rows) != 2 {
+		t.Fatalf("expected unpriced card to be skipped, got %d rows", len(tbl.Rows))
+	}
+	if tbl.Rows[0][0] != "Pikachu" {
+		t.Errorf("expected most profitable card first, got %v", tbl.Rows[0][0])
+	}
+	if note := tbl.Rows[0][len(want)-1]; note != "9.5 $90.00 beats PSA 9 $70.00" {
+		t.Errorf("unexpected crossover note %q", note)
+	}
+
+	tbl = CompareGraders(rows, Config{TopN: 1})
+	if len(tbl.Rows) != 1 {
+		t.Errorf("expected TopN to limit rows, got %d", len(tbl.Rows))
+	}
+}
+
+func TestGetGrader(t *testing.T) {
+	if g, err := GetGrader("bgs"); err != nil || g.Name != "BGS" {
+		t.Errorf("expected case-insensitive lookup, got %v %v", g.Name, err)
+	}
+	if _, err := GetGrader("ACE"); err == nil {
+		t.Error("expected error for unknown grader")
+	}
+}
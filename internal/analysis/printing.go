@@ -0,0 +1,59 @@
+package analysis
+
+import "github.com/guarzo/pkmgradegap/internal/model"
+
+// Printing is one print run of a card that TCGplayer prices separately
+type Printing struct {
+	Key     string // TCGplayer price bucket, e.g. "reverseHolofoil"
+	Name    string // e.g. "Reverse Holo"
+	Variant string // PriceCharting variant to search for; empty for the card's base product
+}
+
+// printings lists TCGplayer's price buckets in order of likeliest printing
+var printings = []Printing{
+	{Key: "normal", Name: "Normal"},
+	{Key: "holofoil", Name: "Holo", Variant: "holo"},
+	{Key: "reverseHolofoil", Name: "Reverse Holo", Variant: "reverse holo"},
+	{Key: "1stEditionHolofoil", Name: "1st Edition Holo", Variant: "1st edition"},
+	{Key: "1stEditionNormal", Name: "1st Edition", Variant: "1st edition"},
+	{Key: "unlimitedHolofoil", Name: "Unlimited Holo"},
+	{Key: "unlimited", Name: "Unlimited"},
+}
+
+// Printings returns the printings of a card TCGplayer has a market price
+// for, in order of likeliest printing
+func Printings(c model.Card) []Printing {
+	// A holo is the card's base product unless it also comes non-holo
+	holoIsBase := tcgMarket(c, "normal") <= 0
+
+	var out []Printing
+	for _, p := range printings {
+		if tcgMarket(c, p.Key) <= 0 {
+			continue
+		}
+		if p.Key == "holofoil" && holoIsBase {
+			p.Variant = ""
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// PrintingUSD returns the TCGplayer market price of one printing of a card
+func PrintingUSD(c model.Card, p Printing) (value float64, source string, note string) {
+	if v := tcgMarket(c, p.Key); v > 0 {
+		return round2(v), "tcgplayer.market", "USD"
+	}
+	return 0, "", ""
+}
+
+// tcgMarket returns TCGplayer's market price for a price bucket, or 0
+func tcgMarket(c model.Card, key string) float64 {
+	if c.TCGPlayer == nil {
+		return 0
+	}
+	if p, ok := c.TCGPlayer.Prices[key]; ok && p.Market != nil {
+		return *p.Market
+	}
+	return 0
+}
@@ -0,0 +1,100 @@
+package analysis
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+// tcgCard returns a card with TCGplayer market prices by price bucket
+func tcgCard(t *testing.T, prices string) model.Card {
+	t.Helper()
+	var block model.TCGPlayerBlock
+	if err := json.Unmarshal([]byte(`{"Prices": `+prices+`}`), &block); err != nil {
+		t.Fatal(err)
+	}
+	return model.Card{Name: "Pikachu", Number: "25", TCGPlayer: &block}
+}
+
+func TestPrintings(t *testing.T) {
+	tests := []struct {
+		name     string
+		prices   string
+		want     []string // Name:Variant
+		firstUSD float64
+	}{
+		{
+			name:     "common with a reverse holo",
+			prices:   `{"normal": {"market": 0.25}, "reverseHolofoil": {"market": 1.5}}`,
+			want:     []string{"Normal:", "Reverse Holo:reverse holo"},
+			firstUSD: 0.25,
+		},
+		{
+			name:     "holo rare with a reverse holo",
+			prices:   `{"reverseHolofoil": {"market": 9}, "holofoil": {"market": 4}}`,
+			want:     []string{"Holo:", "Reverse Holo:reverse holo"},
+			firstUSD: 4,
+		},
+		{
+			name:     "holo alongside a non-holo",
+			prices:   `{"normal": {"market": 2}, "holofoil": {"market": 6}}`,
+			want:     []string{"Normal:", "Holo:holo"},
+			firstUSD: 2,
+		},
+		{
+			name:     "vintage holo",
+			prices:   `{"1stEditionHolofoil": {"market": 900}, "unlimitedHolofoil": {"market": 120}}`,
+			want:     []string{"1st Edition Holo:1st edition", "Unlimited Holo:"},
+			firstUSD: 900,
+		},
+		{
+			name:   "unpriced buckets are skipped",
+			prices: `{"normal": {"low": 1}, "holofoil": {"market": 0}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			card := tcgCard(t, tt.prices)
+			ps := Printings(card)
+			var got []string
+			for _, p := range ps {
+				got = append(got, p.Name+":"+p.Variant)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Printings = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Printings = %v, want %v", got, tt.want)
+				}
+			}
+			if value, _, _ := ExtractUngraded(card, nil); value != tt.firstUSD {
+				t.Errorf("ExtractUngraded = %.2f, want the first printing's %.2f", value, tt.firstUSD)
+			}
+		})
+	}
+}
+
+func TestPrintingUSD(t *testing.T) {
+	card := tcgCard(t, `{"holofoil": {"market": 4.004}, "reverseHolofoil": {"market": 9}}`)
+	ps := Printings(card)
+
+	if value, source, _ := PrintingUSD(card, ps[1]); value != 9 || source != "tcgplayer.market" {
+		t.Errorf("expected the reverse holo's own price, got %.2f %s", value, source)
+	}
+	if value, _, _ := PrintingUSD(card, ps[0]); value != 4 {
+		t.Errorf("expected the holo price, got %.2f", value)
+	}
+	if value, _, _ := PrintingUSD(card, Printing{Key: "1stEditionNormal"}); value != 0 {
+		t.Errorf("expected no price for a printing the card lacks, got %.2f", value)
+	}
+
+	row := Row{Card: card, Printing: ps[1].Name}
+	if row.Label() != "Pikachu (Reverse Holo)" {
+		t.Errorf("Label = %q", row.Label())
+	}
+	if (Row{Card: card}).Label() != "Pikachu" {
+		t.Errorf("expected a plain name without a printing")
+	}
+}
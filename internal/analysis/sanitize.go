@@ -5,6 +5,7 @@ import (
 	"math"
 	"strings"
 
+	"github.com/guarzo/pkmgradegap/internal/currency"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
@@ -26,6 +27,7 @@ type SanitizeConfig struct {
 	EnablePennyCards bool               // Allow cards under minimum price
 	OutlierThreshold float64            // Statistical outlier threshold (default 10.0 std devs)
 	CustomCaps       map[string]float64 // Override default price caps
+	Rates            currency.Provider  // Converts Cardmarket EUR prices; nil uses the fixed rates
 }
 
 // DefaultSanitizeConfig returns default sanitization settings
@@ -78,7 +80,10 @@ func SanitizeRow(row Row, config *SanitizeConfig) Row {
 	row.Grades.PSA10 = SanitizePrice(row.Grades.PSA10, rarity, config)
 	row.Grades.Grade9 = SanitizePrice(row.Grades.Grade9, rarity, config)
 	row.Grades.Grade95 = SanitizePrice(row.Grades.Grade95, rarity, config)
+	row.Grades.Grade8 = SanitizePrice(row.Grades.Grade8, rarity, config)
 	row.Grades.BGS10 = SanitizePrice(row.Grades.BGS10, rarity, config)
+	row.Grades.CGC10 = SanitizePrice(row.Grades.CGC10, rarity, config)
+	row.Grades.SGC10 = SanitizePrice(row.Grades.SGC10, rarity, config)
 
 	return row
 }
@@ -129,13 +134,17 @@ func ExtractUngradedUSDWithSanitization(c model.Card, config *SanitizeConfig) (v
 	}
 
 	// Fallback to Cardmarket EUR (converted) if explicitly enabled
-	if config.EnablePennyCards && c.Cardmarket != nil {
-		if trend := c.Cardmarket.Prices.TrendPrice; trend != nil && *trend > 0 {
-			// Convert EUR to USD (approximate rate)
-			usdPrice := *trend * 1.1
-			price := SanitizePrice(usdPrice, c.Rarity, config)
-			if price > 0 {
-				return round2(price), "cardmarket.trend", "EUR→USD"
+	if config.EnablePennyCards {
+		fx := config.Rates
+		if fx == nil {
+			fx = currency.Fixed()
+		}
+		if eur, src := cardmarketEUR(c); eur > 0 {
+			if rate, err := fx.Rate(currency.EUR, currency.USD); err == nil {
+				price := SanitizePrice(rate.Convert(eur), c.Rarity, config)
+				if price > 0 {
+					return round2(price), src, rate.String()
+				}
 			}
 		}
 	}
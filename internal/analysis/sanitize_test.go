@@ -4,6 +4,7 @@ import (
 	"math"
 	"testing"
 
+	"github.com/guarzo/pkmgradegap/internal/currency"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
@@ -319,3 +320,39 @@ func BenchmarkSanitizeRows(b *testing.B) {
 		_ = SanitizeRows(rows, config)
 	}
 }
+
+func TestExtractUngraded_CardmarketFallback(t *testing.T) {
+	trend, avg30, market := 20.0, 18.0, 25.0
+	card := model.Card{Name: "EU only", Cardmarket: &model.CardmarketBlock{}}
+	card.Cardmarket.Prices.TrendPrice = &trend
+	card.Cardmarket.Prices.Avg30 = &avg30
+	fx := &currency.Table{Base: currency.USD, Rates: map[string]float64{currency.EUR: 0.8}, Source: "test"}
+
+	value, source, note := ExtractUngraded(card, fx)
+	if value != 25 || source != "cardmarket.trend" || note != "EUR→USD @1.2500 (test)" {
+		t.Errorf("expected the converted trend price, got %.2f %s %q", value, source, note)
+	}
+
+	card.Cardmarket.Prices.TrendPrice = nil
+	if value, source, _ := ExtractUngraded(card, fx); value != 22.5 || source != "cardmarket.avg30" {
+		t.Errorf("expected the converted 30-day average, got %.2f %s", value, source)
+	}
+
+	if value, _, _ := ExtractUngraded(card, nil); value != 0 {
+		t.Errorf("expected no fallback without rates, got %.2f", value)
+	}
+	if value, _, _ := ExtractUngraded(card, &currency.Table{Base: currency.USD, Source: "empty"}); value != 0 {
+		t.Errorf("expected no fallback without a EUR rate, got %.2f", value)
+	}
+
+	card.TCGPlayer = &model.TCGPlayerBlock{Prices: map[string]struct {
+		Low       *float64 `json:"low,omitempty"`
+		Mid       *float64 `json:"mid,omitempty"`
+		High      *float64 `json:"high,omitempty"`
+		Market    *float64 `json:"market,omitempty"`
+		DirectLow *float64 `json:"directLow,omitempty"`
+	}{"normal": {Market: &market}}}
+	if value, source, note := ExtractUngraded(card, fx); value != 25 || source != "tcgplayer.market" || note != "USD" {
+		t.Errorf("expected TCGplayer to win, got %.2f %s %s", value, source, note)
+	}
+}
@@ -0,0 +1,285 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Names of the built-in scoring models, selected with Config.Scoring
+const (
+	ScoringHeuristic     = "heuristic" // assume a PSA 10, plus bonus factors (default)
+	ScoringExpectedValue = "ev"        // probability-weighted resale across grades
+	ScoringRiskAdjusted  = "risk"      // expected value less a penalty for outcome spread
+)
+
+// Factor is one named contribution to a card's score. Additive factors are
+// summed; multipliers are applied to the running total in order.
+type Factor struct {
+	Name       string  `json:"name"`
+	Value      float64 `json:"value"`
+	Multiplier bool    `json:"multiplier,omitempty"`
+}
+
+func (f Factor) String() string {
+	if f.Multiplier {
+		return fmt.Sprintf("%s:%.2fx", f.Name, f.Value)
+	}
+	return fmt.Sprintf("%s:%.2f", f.Name, f.Value)
+}
+
+// FormatFactors renders a breakdown the way the Why column shows it
+func FormatFactors(factors []Factor) string {
+	parts := make([]string, len(factors))
+	for i, f := range factors {
+		parts[i] = f.String()
+	}
+	return strings.Join(parts, " ")
+}
+
+// ScoreResult is a scorer's verdict on one card
+type ScoreResult struct {
+	Score             float64
+	NetProfitUSD      float64
+	ExpectedResaleUSD float64           // zero for models that assume a PSA 10
+	Distribution      GradeDistribution // zero for models that assume a PSA 10
+	Factors           []Factor
+}
+
+// applyFactors folds factors into a score in order
+func applyFactors(factors []Factor) float64 {
+	score := 0.0
+	for _, f := range factors {
+		if f.Multiplier {
+			score *= f.Value
+		} else {
+			score += f.Value
+		}
+	}
+	return score
+}
+
+// Scorer turns a priced card into a ranking score. Filtering and cost
+// accounting stay in the report; a scorer only decides how good a card is.
+type Scorer interface {
+	Name() string
+	Score(r Row, config Config) ScoreResult
+}
+
+var (
+	scorersMu sync.RWMutex
+	scorers   = map[string]Scorer{}
+)
+
+func init() {
+	RegisterScorer(NewHeuristicScorer())
+	RegisterScorer(NewExpectedValueScorer())
+	RegisterScorer(NewRiskAdjustedScorer())
+}
+
+// RegisterScorer makes a scorer selectable by name, replacing any scorer
+// already registered under that name.
+func RegisterScorer(s Scorer) {
+	scorersMu.Lock()
+	defer scorersMu.Unlock()
+	scorers[s.Name()] = s
+}
+
+// GetScorer returns the scorer registered under name; an empty name selects
+// the heuristic scorer.
+func GetScorer(name string) (Scorer, error) {
+	if name == "" {
+		name = ScoringHeuristic
+	}
+	scorersMu.RLock()
+	defer scorersMu.RUnlock()
+	s, ok := scorers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scoring model %q (available: %s)", name, strings.Join(scorerNamesLocked(), ", "))
+	}
+	return s, nil
+}
+
+// ScorerNames lists the registered scoring models in sorted order
+func ScorerNames() []string {
+	scorersMu.RLock()
+	defer scorersMu.RUnlock()
+	return scorerNamesLocked()
+}
+
+func scorerNamesLocked() []string {
+	names := make([]string, 0, len(scorers))
+	for name := range scorers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// scorerFor resolves config.Scoring, falling back to the heuristic for
+// unknown names so report generation never fails on a typo.
+func scorerFor(config Config) Scorer {
+	s, err := GetScorer(config.Scoring)
+	if err != nil {
+		s, _ = GetScorer(ScoringHeuristic)
+	}
+	return s
+}
+
+// totalCost is the all-in cost of buying a card raw and getting it graded
+func totalCost(r Row, config Config) float64 {
+	return r.RawUSD + config.GradingFee(r) + config.ShippingCost
+}
+
+// appendAdjustments adds the factors every built-in model shares after its
+// price-driven base: the Japanese multiplier, then population scarcity.
+// Scarcity is judged by the 10s across grading companies expected when the
+// graded card comes back, so fast-growing populations earn less of a bonus.
+func appendAdjustments(factors []Factor, r Row, config Config) []Factor {
+	if containsJapanese(r.Card.Name) {
+		factors = append(factors, Factor{Name: "JPN", Value: config.JapaneseWeight, Multiplier: true})
+	}
+	if r.Population != nil {
+		gems := r.Population.GemSupplyAfter(config.ServiceLevel(r).TurnaroundDays)
+		if bonus := calculateScarcityBonus(gems); bonus > 0 {
+			factors = append(factors, Factor{Name: "Scarcity", Value: bonus})
+		}
+	}
+	return factors
+}
+
+// HeuristicScorer is the original ranking model: PSA 10 net profit plus
+// bonuses for a steep PSA 9→10 premium and a high population gem rate.
+type HeuristicScorer struct {
+	PremiumLiftWeight   float64 // points per unit of (1 - PSA9/PSA10)
+	QualityWeight       float64 // points per unit of PSA 10 gem rate
+	QualityMinGraded    int     // population needed before the gem rate counts
+	VolatilityThreshold float64
+	VolatilityPenalty   float64
+}
+
+// NewHeuristicScorer returns the heuristic scorer with its historical weights
+func NewHeuristicScorer() *HeuristicScorer {
+	return &HeuristicScorer{
+		PremiumLiftWeight:   10,
+		QualityWeight:       5,
+		QualityMinGraded:    100,
+		VolatilityThreshold: 0.2,
+		VolatilityPenalty:   0.9,
+	}
+}
+
+func (s *HeuristicScorer) Name() string { return ScoringHeuristic }
+
+func (s *HeuristicScorer) Score(r Row, config Config) ScoreResult {
+	netProfit := presentValue(r, config, r.Grades.PSA10) - totalCost(r, config)
+	factors := []Factor{{Name: "Profit", Value: netProfit}}
+	if r.Grades.PSA10 > 0 && r.Grades.Grade9 > 0 {
+		lift := (1 - r.Grades.Grade9/r.Grades.PSA10) * s.PremiumLiftWeight
+		factors = append(factors, Factor{Name: "Premium", Value: lift})
+	}
+	factors = appendAdjustments(factors, r, config)
+	if r.Population != nil && r.Population.TotalGraded > 0 && r.Population.TotalGraded >= s.QualityMinGraded {
+		rate := float64(r.Population.PSA10) / float64(r.Population.TotalGraded)
+		factors = append(factors, Factor{Name: "Quality", Value: rate * s.QualityWeight})
+	}
+	if config.WithVolatility && r.Volatility > s.VolatilityThreshold {
+		factors = append(factors, Factor{Name: "Vol", Value: s.VolatilityPenalty, Multiplier: true})
+	}
+
+	return ScoreResult{
+		Score:        applyFactors(factors),
+		NetProfitUSD: netProfit,
+		Factors:      factors,
+	}
+}
+
+// ExpectedValueScorer weighs each grade's resale price by how often the card
+// actually lands that grade, so rarely-gemming cards stop looking like sure
+// things.
+type ExpectedValueScorer struct {
+	VolatilityThreshold float64
+	VolatilityPenalty   float64
+}
+
+// NewExpectedValueScorer returns the expected-value scorer with default weights
+func NewExpectedValueScorer() *ExpectedValueScorer {
+	return &ExpectedValueScorer{VolatilityThreshold: 0.2, VolatilityPenalty: 0.9}
+}
+
+func (s *ExpectedValueScorer) Name() string { return ScoringExpectedValue }
+
+func (s *ExpectedValueScorer) Score(r Row, config Config) ScoreResult {
+	dist := EstimateGradeDistribution(r.Population)
+	resale := ExpectedResale(r, dist)
+	netProfit := presentValue(r, config, resale) - totalCost(r, config)
+
+	factors := appendAdjustments([]Factor{{Name: "EVProfit", Value: netProfit}}, r, config)
+	if config.WithVolatility && r.Volatility > s.VolatilityThreshold {
+		factors = append(factors, Factor{Name: "Vol", Value: s.VolatilityPenalty, Multiplier: true})
+	}
+
+	return ScoreResult{
+		Score:             applyFactors(factors),
+		NetProfitUSD:      netProfit,
+		ExpectedResaleUSD: resale,
+		Distribution:      dist,
+		Factors:           factors,
+	}
+}
+
+// RiskAdjustedScorer starts from expected value and subtracts a multiple of
+// the standard deviation of the resale outcome, then scales by price
+// volatility, favouring cards whose result is predictable.
+type RiskAdjustedScorer struct {
+	RiskAversion float64 // dollars of score given up per dollar of outcome spread
+}
+
+// NewRiskAdjustedScorer returns the risk-adjusted scorer with default weights
+func NewRiskAdjustedScorer() *RiskAdjustedScorer {
+	return &RiskAdjustedScorer{RiskAversion: 0.5}
+}
+
+func (s *RiskAdjustedScorer) Name() string { return ScoringRiskAdjusted }
+
+func (s *RiskAdjustedScorer) Score(r Row, config Config) ScoreResult {
+	dist := EstimateGradeDistribution(r.Population)
+	resale := ExpectedResale(r, dist)
+	netProfit := presentValue(r, config, resale) - totalCost(r, config)
+	spread := resaleStdDev(r, dist) * (1 - config.FeePct)
+
+	factors := appendAdjustments([]Factor{
+		{Name: "EVProfit", Value: netProfit},
+		{Name: "Risk", Value: -s.RiskAversion * spread},
+	}, r, config)
+	// Any volatility counts against the score here, not just past a threshold
+	if config.WithVolatility && r.Volatility > 0 {
+		factors = append(factors, Factor{Name: "Vol", Value: math.Max(0, 1-r.Volatility), Multiplier: true})
+	}
+
+	return ScoreResult{
+		Score:             applyFactors(factors),
+		NetProfitUSD:      netProfit,
+		ExpectedResaleUSD: resale,
+		Distribution:      dist,
+		Factors:           factors,
+	}
+}
+
+// resaleStdDev is the standard deviation of the graded resale price
+func resaleStdDev(r Row, d GradeDistribution) float64 {
+	mean := ExpectedResale(r, d)
+	floor := r.RawUSD
+	variance := 0.0
+	for _, o := range []struct{ p, price float64 }{
+		{d.PSA10, math.Max(r.Grades.PSA10, floor)},
+		{d.PSA9, math.Max(r.Grades.Grade9, floor)},
+		{d.PSA8, math.Max(r.Grades.Grade8, floor)},
+		{d.Lower, floor},
+	} {
+		variance += o.p * (o.price - mean) * (o.price - mean)
+	}
+	return math.Sqrt(variance)
+}
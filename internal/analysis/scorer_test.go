@@ -0,0 +1,154 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+func TestGetScorer(t *testing.T) {
+	for _, name := range []string{"", ScoringHeuristic, ScoringExpectedValue, ScoringRiskAdjusted} {
+		s, err := GetScorer(name)
+		if err != nil {
+			t.Fatalf("GetScorer(%q): %v", name, err)
+		}
+		if name == "" && s.Name() != ScoringHeuristic {
+			t.Errorf("expected empty name to select heuristic, got %s", s.Name())
+		}
+	}
+
+	if _, err := GetScorer("nope"); err == nil || !strings.Contains(err.Error(), "heuristic") {
+		t.Errorf("expected unknown scorer error listing available models, got %v", err)
+	}
+}
+
+func TestScorers_FactorsExplainScore(t *testing.T) {
+	row := Row{
+		Card:       model.Card{Name: "リザードン", Number: "6"},
+		RawUSD:     40,
+		Grades:     Grades{PSA10: 300, Grade9: 120, Grade8: 70},
+		Population: &model.PSAPopulation{TotalGraded: 400, PSA10: 150, PSA9: 200, PSA8: 40},
+		Volatility: 0.3,
+	}
+	config := Config{
+		GradingCost:    25,
+		ShippingCost:   20,
+		FeePct:         0.13,
+		JapaneseWeight: 1.1,
+		WithVolatility: true,
+	}
+
+	for _, name := range ScorerNames() {
+		t.Run(name, func(t *testing.T) {
+			s, _ := GetScorer(name)
+			result := s.Score(row, config)
+			if len(result.Factors) == 0 {
+				t.Fatal("expected a factor breakdown")
+			}
+			if got := applyFactors(result.Factors); abs(got-result.Score) > 1e-9 {
+				t.Errorf("factors fold to %.4f but score is %.4f", got, result.Score)
+			}
+			if !hasFactor(result.Factors, "JPN") {
+				t.Errorf("expected JPN factor for a Japanese card, got %s", FormatFactors(result.Factors))
+			}
+			if !hasFactor(result.Factors, "Vol") {
+				t.Errorf("expected Vol factor at 30%% volatility, got %s", FormatFactors(result.Factors))
+			}
+		})
+	}
+}
+
+func TestRiskAdjustedScorer_PrefersPredictableOutcome(t *testing.T) {
+	config := Config{GradingCost: 25, ShippingCost: 20, FeePct: 0.13}
+	pop := &model.PSAPopulation{TotalGraded: 1000, PSA10: 500, PSA9: 400, PSA8: 100}
+
+	// Similar expected resale, very different spread
+	steady := Row{RawUSD: 50, Grades: Grades{PSA10: 200, Grade9: 200, Grade8: 200}, Population: pop}
+	swingy := Row{RawUSD: 50, Grades: Grades{PSA10: 380, Grade9: 50, Grade8: 50}, Population: pop}
+
+	ev := NewExpectedValueScorer()
+	risk := NewRiskAdjustedScorer()
+
+	evSteady, evSwingy := ev.Score(steady, config), ev.Score(swingy, config)
+	if evSwingy.Score < evSteady.Score {
+		t.Fatalf("test setup: expected swingy card to have the higher EV, got %.2f vs %.2f", evSwingy.Score, evSteady.Score)
+	}
+
+	if rs, rw := risk.Score(steady, config), risk.Score(swingy, config); rs.Score <= rw.Score {
+		t.Errorf("expected risk-adjusted model to prefer the steady card, got steady %.2f swingy %.2f", rs.Score, rw.Score)
+	}
+}
+
+func TestRegisterScorer_Custom(t *testing.T) {
+	RegisterScorer(constScorer{})
+	t.Cleanup(func() {
+		scorersMu.Lock()
+		delete(scorers, constScorer{}.Name())
+		scorersMu.Unlock()
+	})
+
+	rows := []Row{
+		{Card: model.Card{Name: "A", Number: "1"}, RawUSD: 10, Grades: Grades{PSA10: 100}},
+		{Card: model.Card{Name: "B", Number: "2"}, RawUSD: 10, Grades: Grades{PSA10: 200}},
+	}
+	out := ReportRank(rows, nil, Config{Scoring: "const", ShowWhy: true})
+	if len(out) != 3 {
+		t.Fatalf("expected 2 ranked rows, got %v", out)
+	}
+	why := out[1][len(out[1])-1]
+	if why != "Const:1.00" {
+		t.Errorf("expected Why column from custom factors, got %q", why)
+	}
+}
+
+type constScorer struct{}
+
+func (constScorer) Name() string { return "const" }
+
+func (constScorer) Score(r Row, config Config) ScoreResult {
+	return ScoreResult{Score: 1, Factors: []Factor{{Name: "Const", Value: 1}}}
+}
+
+func hasFactor(factors []Factor, name string) bool {
+	for _, f := range factors {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestScarcity_UsesPopulationAtReturn(t *testing.T) {
+	row := Row{
+		Card:       model.Card{Name: "Pikachu", Number: "25"},
+		RawUSD:     40,
+		Grades:     Grades{PSA10: 300},
+		Population: &model.PSAPopulation{TotalGraded: 400, PSA10: 150},
+	}
+	config := Config{GradingCost: 25, ShippingCost: 20, FeePct: 0.13}
+	scarcity := func(r Row) float64 {
+		for _, f := range appendAdjustments(nil, r, config) {
+			if f.Name == "Scarcity" {
+				return f.Value
+			}
+		}
+		return 0
+	}
+
+	if got := scarcity(row); got != 5 {
+		t.Fatalf("expected the rare-tier bonus without a trend, got %.1f", got)
+	}
+	// Enough new PSA 10s by the time the card is back to leave the tier
+	days := config.ServiceLevel(row).TurnaroundDays
+	row.Population.PSA10PerMonth = 100 * 30 / float64(max(days, 1))
+	if got := scarcity(row); got != 2 {
+		t.Errorf("expected the uncommon-tier bonus at PSA 10 pop %d, got %.1f", row.Population.PSA10After(days), got)
+	}
+	// Other companies' 10s count toward the supply too
+	row.Population.PSA10PerMonth = 0
+	row.Population.CombinedGem = 150 + 400
+	if got := scarcity(row); got != 1 {
+		t.Errorf("expected the somewhat-common bonus at %d 10s across companies, got %.1f", row.Population.GemSupplyAfter(days), got)
+	}
+}
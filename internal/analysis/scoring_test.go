@@ -1,8 +1,10 @@
 package analysis
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/guarzo/pkmgradegap/internal/fees"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
@@ -285,32 +287,16 @@ func scoreAndFilter(rows []Row, config Config) []ScoredRow {
 
 		// Calculate costs and score
 		totalCost := r.RawUSD + config.GradingCost + config.ShippingCost
-		sellingFees := r.Grades.PSA10 * config.FeePct
-		netProfit := r.Grades.PSA10 - totalCost - sellingFees
-		breakEven := totalCost / (1 - config.FeePct)
-
-		// Base score is net profit
-		score := netProfit
-
-		// Add premium lift bonus (rewards steep PSA10 premium)
-		if r.Grades.PSA10 > 0 && r.Grades.Grade9 > 0 {
-			premiumLift := (1 - r.Grades.Grade9/r.Grades.PSA10) * 10
-			score += premiumLift
-		}
-
-		// Check if Japanese
-		isJapanese := containsJapanese(r.Card.Name)
-		if isJapanese {
-			score *= config.JapaneseWeight
-		}
+		result := NewHeuristicScorer().Score(r, config)
 
 		scoredRow := ScoredRow{
 			Row:          r,
-			Score:        score,
-			BreakEvenUSD: breakEven,
-			NetProfitUSD: netProfit,
+			Score:        result.Score,
+			BreakEvenUSD: totalCost / (1 - config.FeePct),
+			NetProfitUSD: result.NetProfitUSD,
 			TotalCostUSD: totalCost,
-			IsJapanese:   isJapanese,
+			IsJapanese:   containsJapanese(r.Card.Name),
+			Factors:      result.Factors,
 		}
 
 		scoredRows = append(scoredRows, scoredRow)
@@ -326,3 +312,59 @@ func abs(x float64) float64 {
 	}
 	return x
 }
+
+func TestRank_TypedResult(t *testing.T) {
+	rows := []Row{
+		{Card: model.Card{Name: "Low", Number: "1"}, RawUSD: 20, Grades: Grades{PSA10: 100}},
+		{Card: model.Card{Name: "High", Number: "2"}, RawUSD: 20, Grades: Grades{PSA10: 300}},
+		{Card: model.Card{Name: "Unpriced", Number: "3"}, RawUSD: 20},
+	}
+	config := Config{GradingCost: 25, ShippingCost: 20, FeePct: 0.13, TopN: 10}
+
+	result := Rank(rows, nil, config)
+	if len(result.Rows) != 2 || result.Rows[0].Card.Name != "High" {
+		t.Fatalf("expected High then Low, got %+v", result.Rows)
+	}
+	if result.Scoring != ScoringHeuristic {
+		t.Errorf("expected default scorer, got %q", result.Scoring)
+	}
+
+	records := result.Table().Records()
+	if records[1][2] != "$20.00" || records[1][3] != "$300.00" {
+		t.Errorf("expected CSV layout to format money, got %v", records[1])
+	}
+}
+
+func TestRank_OldSetNotice(t *testing.T) {
+	rows := []Row{{Card: model.Card{Name: "A", Number: "1"}, RawUSD: 20, Grades: Grades{PSA10: 300}}}
+	set := &model.Set{Name: "Base Set", ReleaseDate: "1999/01/09"}
+
+	result := Rank(rows, set, Config{MaxAgeYears: 10})
+	if len(result.Rows) != 0 || !strings.Contains(result.Notice, "Base Set") {
+		t.Errorf("expected old set to be skipped with a notice, got %+v", result)
+	}
+}
+
+func TestConfig_GradingFee(t *testing.T) {
+	row := Row{RawUSD: 20, Grades: Grades{PSA10: 300}}
+
+	if got := (Config{GradingCost: 30}).GradingFee(row); got != 30 {
+		t.Errorf("expected explicit grading cost, got %.2f", got)
+	}
+	// $300 declared falls in the built-in Value Plus level
+	if got := (Config{}).GradingFee(row); got != 25 {
+		t.Errorf("expected fee schedule price, got %.2f", got)
+	}
+	row.Grades.PSA10 = 150
+	if got := (Config{}).GradingFee(row); got != 19 {
+		t.Errorf("expected Value level for $150, got %.2f", got)
+	}
+
+	book, err := fees.Parse([]byte(`{"version": 1, "schedules": [{"grader": "PSA", "effective": "2020-01-01", "levels": [{"name": "Any", "costPerCard": 40}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := (Config{Fees: book}).GradingFee(row); got != 40 {
+		t.Errorf("expected the config's fee book, got %.2f", got)
+	}
+}
@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"math"
+
+	"github.com/guarzo/pkmgradegap/internal/fees"
+)
+
+// DiscountFactor returns what a dollar received after days is worth today at
+// an annual cost of capital
+func DiscountFactor(annualRate float64, days int) float64 {
+	if annualRate <= 0 || days <= 0 {
+		return 1
+	}
+	return math.Pow(1+annualRate, -float64(days)/365)
+}
+
+// ProjectPrice extrapolates a 30-day price forecast to days ahead by
+// compounding the forecast's monthly change. Without a forecast the price is
+// assumed to hold.
+func ProjectPrice(price, forecast30d float64, days int) float64 {
+	if price <= 0 || forecast30d <= 0 || days <= 0 {
+		return price
+	}
+	return price * math.Pow(forecast30d/price, float64(days)/30)
+}
+
+// AnnualizedROI compounds a return earned over days into a yearly rate, so a
+// slow, cheap service level can be compared fairly with a fast, dear one
+func AnnualizedROI(roi float64, days int) float64 {
+	if days <= 0 {
+		return roi
+	}
+	if roi <= -1 {
+		return -1
+	}
+	return math.Pow(1+roi, 365/float64(days)) - 1
+}
+
+// ServiceLevel returns the PSA service level r is submitted at: the cheapest
+// level in the FeesAsOf schedule covering its PSA 10 price, or the top level
+// past every cap. Its TurnaroundDays applies even when GradingCost overrides
+// the fee.
+func (c Config) ServiceLevel(r Row) fees.ServiceLevel {
+	s := c.Fees.PSA(c.feeDate())
+	if l, ok := s.Level(r.Grades.PSA10); ok {
+		return l
+	}
+	if len(s.Levels) == 0 {
+		return fees.ServiceLevel{}
+	}
+	return s.Levels[len(s.Levels)-1]
+}
+
+// priceDrift is the factor r's PSA 10 price is projected to move by over days
+func priceDrift(r Row, days int) float64 {
+	if r.Grades.PSA10 <= 0 {
+		return 1
+	}
+	return ProjectPrice(r.Grades.PSA10, r.PSA10Forecast30d, days) / r.Grades.PSA10
+}
+
+// proceedsFactor converts a resale price quoted today into what selling the
+// slab after days is worth today: moved along the PSA 10 forecast, net of
+// selling fees and discounted at CostOfCapital
+func (c Config) proceedsFactor(r Row, days int) float64 {
+	return priceDrift(r, days) * (1 - c.FeePct) * DiscountFactor(c.CostOfCapital, days)
+}
+
+// presentValue is the value today of reselling r at resale once it is back
+// from grading
+func presentValue(r Row, config Config, resale float64) float64 {
+	return resale * config.proceedsFactor(r, config.ServiceLevel(r).TurnaroundDays)
+}
@@ -0,0 +1,99 @@
+package analysis
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+func TestTimeValueHelpers(t *testing.T) {
+	if got := DiscountFactor(0.08, 365); abs(got-1/1.08) > 1e-9 {
+		t.Errorf("expected a year at 8%% to discount by 1/1.08, got %v", got)
+	}
+	if got := DiscountFactor(0, 65); got != 1 {
+		t.Errorf("expected no discount at a zero rate, got %v", got)
+	}
+
+	// +10% a month compounds to +21% over 60 days
+	if got := ProjectPrice(100, 110, 60); abs(got-121) > 1e-9 {
+		t.Errorf("expected 121, got %v", got)
+	}
+	if got := ProjectPrice(100, 0, 60); got != 100 {
+		t.Errorf("expected the price to hold without a forecast, got %v", got)
+	}
+
+	if got := AnnualizedROI(0.1, 73); abs(got-(math.Pow(1.1, 5)-1)) > 1e-9 {
+		t.Errorf("expected 10%% over 73 days to compound five times, got %v", got)
+	}
+	if got := AnnualizedROI(-1.5, 30); got != -1 {
+		t.Errorf("expected a total loss to floor at -100%%, got %v", got)
+	}
+}
+
+func TestConfig_ServiceLevel(t *testing.T) {
+	// A flat fee override still waits out the schedule's turnaround
+	config := Config{GradingCost: 25}
+	if l := config.ServiceLevel(Row{Grades: Grades{PSA10: 150}}); l.Name != "Value" || l.TurnaroundDays != 65 {
+		t.Errorf("expected Value at 65 days, got %+v", l)
+	}
+	if l := config.ServiceLevel(Row{Grades: Grades{PSA10: 1500}}); l.Name != "Express" || l.TurnaroundDays != 15 {
+		t.Errorf("expected Express at 15 days, got %+v", l)
+	}
+}
+
+func TestRank_TimeValue(t *testing.T) {
+	rows := []Row{
+		// Value tier: $19 fee, 65 days
+		{Card: model.Card{Name: "Slow", Number: "1"}, RawUSD: 40, Grades: Grades{PSA10: 180}},
+		// Express tier: $75 fee, 15 days
+		{Card: model.Card{Name: "Fast", Number: "2"}, RawUSD: 400, Grades: Grades{PSA10: 1200}},
+	}
+	config := Config{FeePct: 0.13, ShippingCost: 20}
+
+	plain := Rank(rows, nil, config).Rows
+	config.CostOfCapital = 0.08
+	discounted := Rank(rows, nil, config).Rows
+
+	byName := func(rs []ScoredRow) map[string]ScoredRow {
+		m := map[string]ScoredRow{}
+		for _, r := range rs {
+			m[r.Card.Name] = r
+		}
+		return m
+	}
+	p, d := byName(plain), byName(discounted)
+
+	if d["Slow"].TurnaroundDays != 65 || d["Fast"].TurnaroundDays != 15 {
+		t.Fatalf("unexpected turnarounds: slow %d, fast %d", d["Slow"].TurnaroundDays, d["Fast"].TurnaroundDays)
+	}
+	for _, name := range []string{"Slow", "Fast"} {
+		if d[name].NetProfitUSD >= p[name].NetProfitUSD {
+			t.Errorf("%s: expected discounting to cut net profit", name)
+		}
+		if d[name].AnnualROI != p[name].AnnualROI {
+			t.Errorf("%s: expected annual ROI to ignore the cost of capital", name)
+		}
+	}
+	// The slow card loses a larger share of its proceeds to the wait
+	slowCut := 1 - DiscountFactor(0.08, 65)
+	if got := (p["Slow"].NetProfitUSD - d["Slow"].NetProfitUSD) / (180 * 0.87); abs(got-slowCut) > 1e-9 {
+		t.Errorf("expected proceeds discounted by %.4f, got %.4f", slowCut, got)
+	}
+
+	// A rising forecast lifts both the projection and the return
+	rows[0].PSA10Forecast30d = 198
+	rising := byName(Rank(rows, nil, config).Rows)
+	if rising["Slow"].ProjectedPSA10USD <= 180 || rising["Slow"].AnnualROI <= d["Slow"].AnnualROI {
+		t.Errorf("expected a rising forecast to raise the projection and ROI, got %+v", rising["Slow"])
+	}
+
+	// The time-value columns follow the fixed ones so existing CSV column
+	// positions don't move
+	header := Rank(rows, nil, config).Table().Header()
+	want := []string{"Card", "No", "RawUSD", "PSA10USD", "DeltaUSD", "CostUSD", "BreakEvenUSD", "Score", "Notes", "TurnaroundDays", "AnnualROI%", "PSA10AtReturnUSD"}
+	if len(header) < len(want) || !reflect.DeepEqual(header[:len(want)], want) {
+		t.Errorf("expected time-value columns after Notes, got %v", header)
+	}
+}
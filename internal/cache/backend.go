@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"strings"
+	"time"
+)
+
+// Namespaces group cache entries by what they hold. Each is stored apart,
+// so clearing or sweeping one doesn't touch the others.
+const (
+	NamespaceSets          = "sets"
+	NamespaceCards         = "cards"
+	NamespacePriceCharting = "pricecharting"
+	NamespacePopulation    = "population"
+	NamespaceSales         = "sales"
+	NamespaceMeta          = "meta" // the cache's own bookkeeping, such as Usage
+	NamespaceOther         = "other"
+)
+
+// Namespaces lists every namespace
+var Namespaces = []string{
+	NamespaceSets,
+	NamespaceCards,
+	NamespacePriceCharting,
+	NamespacePopulation,
+	NamespaceSales,
+	NamespaceMeta,
+	NamespaceOther,
+}
+
+// NamespaceOf returns the namespace a key belongs to, from its prefix
+func NamespaceOf(key string) string {
+	prefix, _, _ := strings.Cut(key, "|")
+	switch {
+	case strings.HasPrefix(key, "sets:"):
+		return NamespaceSets
+	case prefix == "cards":
+		return NamespaceCards
+	case prefix == "pc":
+		return NamespacePriceCharting
+	case prefix == "pop":
+		return NamespacePopulation
+	case prefix == "sales":
+		return NamespaceSales
+	case prefix == "meta":
+		return NamespaceMeta
+	}
+	return NamespaceOther
+}
+
+// Expired reports whether the entry's TTL has run out at now
+func (e Entry) Expired(now time.Time) bool {
+	return e.TTL > 0 && now.Sub(e.Timestamp) > e.TTL
+}
+
+// Backend stores cache entries by namespace and key
+type Backend interface {
+	Get(ns, key string) (Entry, bool, error)
+	Put(ns, key string, e Entry) error
+	Delete(ns, key string) error
+	// Clear removes every entry in a namespace, or in all of them when ns
+	// is empty
+	Clear(ns string) error
+	// Keys lists a namespace's keys, expired or not, in sorted order
+	Keys(ns string) ([]string, error)
+	// Sweep removes entries expired at now and returns how many it removed
+	Sweep(now time.Time) (int, error)
+	Close() error
+}
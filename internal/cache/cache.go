@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,125 +15,287 @@ type Entry struct {
 	TTL       time.Duration   `json:"ttl"`
 }
 
+// Cache stores JSON values with a TTL in a Backend, filed under the
+// namespace each key's prefix names (see NamespaceOf). It implements Store;
+// a nil *Cache stores nothing.
 type Cache struct {
-	path    string
-	entries map[string]Entry
+	backend Backend
+	maxTTL  time.Duration
+	stale   StalePolicy
 	mu      sync.RWMutex
+	stats   statsTracker
 }
 
+// New opens the cache at path, a KVStore directory named like path with a
+// ".kv" extension (so "data/cache.json" and "data/cache" both open
+// "data/cache.kv"). A JSON cache file written by earlier versions alongside
+// it is migrated into the store. Expired entries are swept on open.
 func New(path string) (*Cache, error) {
-	c := &Cache{
-		path:    path,
-		entries: make(map[string]Entry),
+	return Open(path, nil)
+}
+
+// Open opens the cache at path like New, serving entries stale as policy
+// allows (see GetStale). The sweep on open keeps entries the policy may
+// still serve.
+func Open(path string, policy StalePolicy) (*Cache, error) {
+	base := strings.TrimSuffix(StorePath(path), ".kv")
+	store, err := OpenKVStore(base + ".kv")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := MigrateJSON(store, base+".json"); err != nil {
+		store.Close()
+		return nil, err
 	}
+	if _, err := store.Sweep(time.Now().Add(-policy.Max())); err != nil {
+		store.Close()
+		return nil, err
+	}
+	c := NewWithBackend(store)
+	c.stale = policy
+	return c, nil
+}
 
-	// Load existing cache if file exists
-	if _, err := os.Stat(path); err == nil {
-		data, err := os.ReadFile(path)
-		if err != nil {
-			return nil, fmt.Errorf("read cache: %w", err)
+// StorePath returns the KVStore directory New opens for path
+func StorePath(path string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(path, ".json"), ".kv") + ".kv"
+}
+
+// NewWithBackend returns a cache that keeps its entries in b
+func NewWithBackend(b Backend) *Cache {
+	return &Cache{backend: b}
+}
+
+// MigrateJSON moves the entries of a JSON cache file written by earlier
+// versions into b, then renames the file with a ".migrated" suffix. Expired
+// entries are dropped, and a missing or unreadable file migrates nothing.
+func MigrateJSON(b Backend, path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read cache: %w", err)
+	}
+	var entries map[string]Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		// Ignore corrupt cache, start fresh
+		return 0, nil
+	}
+
+	now := time.Now()
+	migrated := 0
+	for key, e := range entries {
+		if e.Expired(now) {
+			continue
 		}
-		if len(data) > 0 {
-			if err := json.Unmarshal(data, &c.entries); err != nil {
-				// Ignore corrupt cache, start fresh
-				c.entries = make(map[string]Entry)
-			}
+		if err := b.Put(NamespaceOf(key), key, e); err != nil {
+			return migrated, fmt.Errorf("migrate cache: %w", err)
 		}
+		migrated++
 	}
-
-	return c, nil
+	if err := os.Rename(path, path+".migrated"); err != nil {
+		return migrated, fmt.Errorf("migrate cache: %w", err)
+	}
+	return migrated, nil
 }
 
+// Get decodes the live entry under key into target
 func (c *Cache) Get(key string, target interface{}) (bool, error) {
-	c.mu.RLock()
-	entry, ok := c.entries[key]
-	if !ok {
-		c.mu.RUnlock()
+	if c == nil {
 		return false, nil
 	}
+	ns := NamespaceOf(key)
+	entry, ok, err := c.backend.Get(ns, key)
+	if err != nil || !ok {
+		c.stats.lookup(ns, key, false)
+		return false, err
+	}
 
-	// Check TTL
-	expired := entry.TTL > 0 && time.Since(entry.Timestamp) > entry.TTL
-	if !expired {
-		// Entry is valid, unmarshal and return
-		err := json.Unmarshal(entry.Data, target)
-		c.mu.RUnlock()
-		if err != nil {
-			return false, fmt.Errorf("unmarshal cache entry: %w", err)
+	// Check TTL; entries GetStale may still serve are kept
+	if now := time.Now(); entry.Expired(now) {
+		if ok, _ := c.stalePolicy().servable(ns, entry, now); !ok {
+			_ = c.backend.Delete(ns, key)
 		}
-		return true, nil
+		c.stats.lookup(ns, key, false)
+		return false, nil
 	}
-	c.mu.RUnlock()
 
-	// Entry expired, need write lock to delete
-	c.mu.Lock()
-	// Double-check the entry still exists and is expired
-	if e, exists := c.entries[key]; exists && e.TTL > 0 && time.Since(e.Timestamp) > e.TTL {
-		delete(c.entries, key)
+	if err := json.Unmarshal(entry.Data, target); err != nil {
+		c.stats.lookup(ns, key, false)
+		return false, fmt.Errorf("unmarshal cache entry: %w", err)
+	}
+	c.stats.lookup(ns, key, true)
+	return true, nil
+}
+
+// GetStale decodes the entry under key into target like Get, but also
+// serves an expired entry while it is within the stale policy's limit for
+// its namespace, reporting it as stale so the caller can refresh it
+func (c *Cache) GetStale(key string, target interface{}) (found, stale bool, err error) {
+	if c == nil {
+		return false, false, nil
+	}
+	ns := NamespaceOf(key)
+	entry, ok, err := c.backend.Get(ns, key)
+	if err != nil || !ok {
+		c.stats.lookup(ns, key, false)
+		return false, false, err
+	}
+	ok, stale = c.stalePolicy().servable(ns, entry, time.Now())
+	if !ok {
+		_ = c.backend.Delete(ns, key)
+		c.stats.lookup(ns, key, false)
+		return false, false, nil
+	}
+	if err := json.Unmarshal(entry.Data, target); err != nil {
+		c.stats.lookup(ns, key, false)
+		return false, false, fmt.Errorf("unmarshal cache entry: %w", err)
+	}
+	c.stats.lookup(ns, key, true)
+	if stale {
+		c.stats.served(ns)
+	}
+	return true, stale, nil
+}
+
+// SetStalePolicy changes how long past their TTL GetStale serves entries
+func (c *Cache) SetStalePolicy(policy StalePolicy) {
+	if c == nil {
+		return
 	}
+	c.mu.Lock()
+	c.stale = policy
 	c.mu.Unlock()
+}
 
-	return false, nil
+func (c *Cache) stalePolicy() StalePolicy {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.stale
 }
 
+// Put stores value under key for ttl, capped by SetMaxTTL
 func (c *Cache) Put(key string, value interface{}, ttl time.Duration) error {
+	if c == nil {
+		return nil
+	}
 	data, err := json.Marshal(value)
 	if err != nil {
 		return fmt.Errorf("marshal value: %w", err)
 	}
 
-	c.mu.Lock()
-	c.entries[key] = Entry{
+	c.mu.RLock()
+	if c.maxTTL > 0 && (ttl <= 0 || ttl > c.maxTTL) {
+		ttl = c.maxTTL
+	}
+	c.mu.RUnlock()
+
+	ns := NamespaceOf(key)
+	c.stats.write(ns)
+	return c.backend.Put(ns, key, Entry{
 		Data:      data,
 		Timestamp: time.Now(),
 		TTL:       ttl,
+	})
+}
+
+// SetMaxTTL caps the TTL of entries written after the call.
+// Providers pick their own TTLs; this lets callers shorten all of them at once.
+// A zero duration removes the cap.
+func (c *Cache) SetMaxTTL(ttl time.Duration) {
+	if c == nil {
+		return
 	}
+	c.mu.Lock()
+	c.maxTTL = ttl
 	c.mu.Unlock()
+}
 
-	return c.saveLocked()
+// Clear removes all cache entries
+func (c *Cache) Clear() error {
+	if c == nil {
+		return nil
+	}
+	return c.backend.Clear("")
 }
 
-// saveLocked saves the cache to disk without additional locking
-// Call this when you already have a lock or after releasing it
-func (c *Cache) saveLocked() error {
-	// Create parent directory if needed
-	if dir := filepath.Dir(c.path); dir != "" && dir != "." {
-		if err := os.MkdirAll(dir, 0755); err != nil {
-			return fmt.Errorf("create cache dir: %w", err)
-		}
+// Remove deletes a specific cache entry
+func (c *Cache) Remove(key string) error {
+	if c == nil {
+		return nil
 	}
+	return c.backend.Delete(NamespaceOf(key), key)
+}
 
-	// Take a read lock to safely marshal entries
-	c.mu.RLock()
-	data, err := json.MarshalIndent(c.entries, "", "  ")
-	c.mu.RUnlock()
+// InvalidatePrefix removes every entry whose key starts with prefix, in any
+// namespace, and returns how many it removed
+func (c *Cache) InvalidatePrefix(prefix string) (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+	// A prefix that runs past the first separator names its namespace;
+	// a shorter one may match keys in any of them
+	namespaces := Namespaces
+	if strings.ContainsAny(prefix, "|:") {
+		namespaces = []string{NamespaceOf(prefix)}
+	}
+	removed := 0
+	for _, ns := range namespaces {
+		keys, err := c.backend.Keys(ns)
+		if err != nil {
+			return removed, err
+		}
+		for _, key := range keys {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			if err := c.backend.Delete(ns, key); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+	}
+	return removed, nil
+}
 
-	if err != nil {
-		return fmt.Errorf("marshal cache: %w", err)
+// Stats returns the cache's hits, misses and writes since it was opened
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{Namespaces: map[string]Counts{}}
 	}
+	return c.stats.snapshot()
+}
 
-	return os.WriteFile(c.path, data, 0644)
+// Info returns the entry stored under key, expired or not, without
+// counting a lookup
+func (c *Cache) Info(key string) (Entry, bool, error) {
+	if c == nil {
+		return Entry{}, false, nil
+	}
+	return c.backend.Get(NamespaceOf(key), key)
 }
 
-// save is deprecated, use saveLocked instead
-func (c *Cache) save() error {
-	return c.saveLocked()
+// Sweep removes expired entries, but for those the stale policy may still
+// serve, and returns how many it removed
+func (c *Cache) Sweep() (int, error) {
+	if c == nil {
+		return 0, nil
+	}
+	return c.backend.Sweep(time.Now().Add(-c.stalePolicy().Max()))
 }
 
-// Clear removes all cache entries
-func (c *Cache) Clear() error {
-	c.mu.Lock()
-	c.entries = make(map[string]Entry)
-	c.mu.Unlock()
-	return c.saveLocked()
+// Backend returns the store the cache keeps its entries in
+func (c *Cache) Backend() Backend {
+	return c.backend
 }
 
-// Remove deletes a specific cache entry
-func (c *Cache) Remove(key string) error {
-	c.mu.Lock()
-	delete(c.entries, key)
-	c.mu.Unlock()
-	return c.saveLocked()
+// Close closes the cache's backend
+func (c *Cache) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.backend.Close()
 }
 
 // BuildKey creates semantic cache keys
@@ -148,6 +310,53 @@ func BuildKey(parts ...string) string {
 	return key
 }
 
+// cardFamilies are the key prefixes providers file a card's entries under,
+// as BuildKey(family, set, card name, number, ...)
+var cardFamilies = []string{"pc", "sales", "gamestop", BuildKey("pop", "card"), BuildKey("pop", "known")}
+
+// CardKeys returns the keys under which providers cache a set's entries, or
+// one card's when cardName is given, or one numbered card's. Each names an
+// entry itself and, followed by "|", the entries beneath it.
+func CardKeys(setName, cardName, number string) []string {
+	parts := []string{setName}
+	if cardName != "" {
+		parts = append(parts, cardName)
+		if number != "" {
+			parts = append(parts, number)
+		}
+	}
+	keys := make([]string, 0, len(cardFamilies)+1)
+	for _, family := range cardFamilies {
+		keys = append(keys, BuildKey(append([]string{family}, parts...)...))
+	}
+	if cardName == "" {
+		keys = append(keys, BuildKey("pop", "set", setName))
+	}
+	return keys
+}
+
+// InvalidateCard removes every provider's entries for a set, a card or a
+// numbered card (see CardKeys) and returns how many it removed
+func (c *Cache) InvalidateCard(setName, cardName, number string) (int, error) {
+	removed := 0
+	for _, key := range CardKeys(setName, cardName, number) {
+		if _, ok, err := c.Info(key); err != nil {
+			return removed, err
+		} else if ok {
+			if err := c.Remove(key); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+		n, err := c.InvalidatePrefix(key + "|")
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
 // Common cache keys
 func SetsKey() string {
 	return "sets:v2"
@@ -126,6 +126,50 @@ func TestCache_TTLExpiration(t *testing.T) {
 	}
 }
 
+func TestCache_MaxTTL(t *testing.T) {
+	tempDir := t.TempDir()
+	cachePath := filepath.Join(tempDir, "test_max_ttl_cache.json")
+
+	cache, err := New(cachePath)
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+
+	// Cap every entry at 50ms regardless of what the caller asks for
+	cache.SetMaxTTL(50 * time.Millisecond)
+
+	if err := cache.Put("long_ttl", "capped", 24*time.Hour); err != nil {
+		t.Fatalf("Failed to put long TTL value: %v", err)
+	}
+	if err := cache.Put("no_ttl", "capped", 0); err != nil {
+		t.Fatalf("Failed to put permanent value: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	var result string
+	for _, key := range []string{"long_ttl", "no_ttl"} {
+		found, err := cache.Get(key, &result)
+		if err != nil {
+			t.Errorf("Failed to get %s: %v", key, err)
+		}
+		if found {
+			t.Errorf("Expected %s to be expired by the max TTL", key)
+		}
+	}
+
+	// Removing the cap restores caller TTLs
+	cache.SetMaxTTL(0)
+	if err := cache.Put("uncapped", "kept", time.Hour); err != nil {
+		t.Fatalf("Failed to put uncapped value: %v", err)
+	}
+	time.Sleep(60 * time.Millisecond)
+	found, err := cache.Get("uncapped", &result)
+	if err != nil || !found {
+		t.Errorf("Expected uncapped entry to survive, found=%v err=%v", found, err)
+	}
+}
+
 func TestCache_Persistence(t *testing.T) {
 	tempDir := t.TempDir()
 	cachePath := filepath.Join(tempDir, "test_persistence_cache.json")
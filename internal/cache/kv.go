@@ -0,0 +1,379 @@
+package cache
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KVStore is an embedded key-value Backend. Each namespace is an
+// append-only log of JSON records in the store's directory, so a write
+// appends one line instead of rewriting the cache. An in-memory index
+// points every key at its latest record, and a log is compacted once
+// superseded records outnumber live ones.
+type KVStore struct {
+	dir        string
+	logs       map[string]*kvLog
+	compactMin int // superseded records a log may hold before it is compacted
+	rename     func(oldpath, newpath string) error
+	remove     func(name string) error
+	mu         sync.RWMutex
+}
+
+type kvLog struct {
+	path  string
+	file  *os.File
+	index map[string]kvLoc
+	dead  int // superseded, deleted and unreadable records
+}
+
+// kvLoc is where a key's latest record sits in its log
+type kvLoc struct {
+	offset  int64
+	length  int
+	expires time.Time // zero when the entry never expires
+}
+
+// kvRecord is one line of a log; a nil Entry deletes the key
+type kvRecord struct {
+	Key string `json:"key"`
+	*Entry
+}
+
+const kvExt = ".log"
+
+// OpenKVStore opens the store in dir, which is created on the first write
+func OpenKVStore(dir string) (*KVStore, error) {
+	s := &KVStore{dir: dir, logs: make(map[string]*kvLog), compactMin: 1000, rename: os.Rename, remove: os.Remove}
+	files, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("open cache store: %w", err)
+	}
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != kvExt {
+			continue
+		}
+		ns := strings.TrimSuffix(f.Name(), kvExt)
+		l, err := s.openLog(ns)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.logs[ns] = l
+	}
+	return s, nil
+}
+
+// openLog opens a namespace's log and replays it into an index
+func (s *KVStore) openLog(ns string) (*kvLog, error) {
+	l := &kvLog{path: filepath.Join(s.dir, ns+kvExt), index: make(map[string]kvLoc)}
+	f, err := os.OpenFile(l.path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("open cache log: %w", err)
+	}
+	l.file = f
+
+	r := bufio.NewReader(f)
+	var offset int64
+	terminated := true
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(line) > 0 {
+			l.replay(line, offset)
+			offset += int64(len(line))
+			terminated = line[len(line)-1] == '\n'
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("read cache log: %w", err)
+		}
+	}
+	// A write cut short leaves a partial line; start the next record on a
+	// line of its own
+	if !terminated {
+		if _, err := f.Write([]byte("\n")); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("repair cache log: %w", err)
+		}
+	}
+	return l, nil
+}
+
+func (l *kvLog) replay(line []byte, offset int64) {
+	var rec kvRecord
+	if err := json.Unmarshal(line, &rec); err != nil || rec.Key == "" {
+		l.dead++
+		return
+	}
+	if _, ok := l.index[rec.Key]; ok {
+		l.dead++
+	}
+	if rec.Entry == nil {
+		delete(l.index, rec.Key)
+		l.dead++
+		return
+	}
+	l.index[rec.Key] = kvLoc{offset: offset, length: len(line), expires: expiresAt(*rec.Entry)}
+}
+
+// append writes a record to the end of the log and returns its offset
+func (l *kvLog) append(rec kvRecord) (int64, int, error) {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return 0, 0, fmt.Errorf("marshal cache record: %w", err)
+	}
+	line = append(line, '\n')
+	if _, err := l.file.Write(line); err != nil {
+		return 0, 0, fmt.Errorf("write cache log: %w", err)
+	}
+	// Ask for the end rather than tracking it, in case another process
+	// appended too
+	end, err := l.file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, 0, fmt.Errorf("write cache log: %w", err)
+	}
+	return end - int64(len(line)), len(line), nil
+}
+
+func (l *kvLog) read(loc kvLoc) ([]byte, error) {
+	buf := make([]byte, loc.length)
+	if _, err := l.file.ReadAt(buf, loc.offset); err != nil {
+		return nil, fmt.Errorf("read cache log: %w", err)
+	}
+	return buf, nil
+}
+
+// log returns a namespace's log, creating it if needed
+func (s *KVStore) log(ns string) (*kvLog, error) {
+	if l, ok := s.logs[ns]; ok {
+		return l, nil
+	}
+	if ns == "" || strings.ContainsAny(ns, `/\.`) {
+		return nil, fmt.Errorf("invalid cache namespace %q", ns)
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, fmt.Errorf("create cache dir: %w", err)
+	}
+	l, err := s.openLog(ns)
+	if err != nil {
+		return nil, err
+	}
+	s.logs[ns] = l
+	return l, nil
+}
+
+// Get returns a key's entry, expired or not
+func (s *KVStore) Get(ns, key string) (Entry, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.logs[ns]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	loc, ok := l.index[key]
+	if !ok {
+		return Entry{}, false, nil
+	}
+	line, err := l.read(loc)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	var rec kvRecord
+	if err := json.Unmarshal(line, &rec); err != nil || rec.Key != key || rec.Entry == nil {
+		// Another process compacted the log under the index
+		return Entry{}, false, nil
+	}
+	return *rec.Entry, true, nil
+}
+
+// Put appends an entry for a key, replacing any earlier one
+func (s *KVStore) Put(ns, key string, e Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, err := s.log(ns)
+	if err != nil {
+		return err
+	}
+	offset, length, err := l.append(kvRecord{Key: key, Entry: &e})
+	if err != nil {
+		return err
+	}
+	if _, ok := l.index[key]; ok {
+		l.dead++
+	}
+	l.index[key] = kvLoc{offset: offset, length: length, expires: expiresAt(e)}
+	return s.maybeCompact(l)
+}
+
+// Delete removes a key; deleting a missing key does nothing
+func (s *KVStore) Delete(ns, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	l, ok := s.logs[ns]
+	if !ok {
+		return nil
+	}
+	if _, ok := l.index[key]; !ok {
+		return nil
+	}
+	if _, _, err := l.append(kvRecord{Key: key}); err != nil {
+		return err
+	}
+	delete(l.index, key)
+	l.dead += 2 // the entry and its tombstone
+	return s.maybeCompact(l)
+}
+
+// Clear removes every entry in a namespace, or in all of them when ns is
+// empty
+func (s *KVStore) Clear(ns string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, l := range s.logs {
+		if ns != "" && name != ns {
+			continue
+		}
+		l.file.Close()
+		if err := s.remove(l.path); err != nil && !os.IsNotExist(err) {
+			// The log is still on disk; reopen it so the namespace keeps
+			// working, or forget it so nothing writes to the closed file
+			if reopened, rerr := s.openLog(name); rerr == nil {
+				s.logs[name] = reopened
+			} else {
+				delete(s.logs, name)
+			}
+			return fmt.Errorf("clear cache: %w", err)
+		}
+		delete(s.logs, name)
+	}
+	return nil
+}
+
+// Keys lists a namespace's keys, expired or not, in sorted order
+func (s *KVStore) Keys(ns string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	l, ok := s.logs[ns]
+	if !ok {
+		return nil, nil
+	}
+	keys := make([]string, 0, len(l.index))
+	for k := range l.index {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// Sweep removes entries expired at now, compacting the logs it removed
+// them from
+func (s *KVStore) Sweep(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for _, l := range s.logs {
+		expired := 0
+		for key, loc := range l.index {
+			if !loc.expires.IsZero() && now.After(loc.expires) {
+				delete(l.index, key)
+				expired++
+			}
+		}
+		if expired == 0 {
+			continue
+		}
+		l.dead += expired
+		removed += expired
+		if err := s.compact(l); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// Close closes the store's logs
+func (s *KVStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var firstErr error
+	for _, l := range s.logs {
+		if err := l.file.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func (s *KVStore) maybeCompact(l *kvLog) error {
+	if l.dead < s.compactMin || l.dead <= len(l.index) {
+		return nil
+	}
+	return s.compact(l)
+}
+
+// compact rewrites a log with only its live records. The log keeps its
+// open file until the rewrite has replaced it, so a failed compaction
+// leaves the log as it was.
+func (s *KVStore) compact(l *kvLog) error {
+	tmpPath := l.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_APPEND|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("compact cache log: %w", err)
+	}
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("compact cache log: %w", err)
+	}
+	w := bufio.NewWriter(tmp)
+	index := make(map[string]kvLoc, len(l.index))
+	var offset int64
+	for key, loc := range l.index {
+		line, err := l.read(loc)
+		if err == nil {
+			_, err = w.Write(line)
+		}
+		if err != nil {
+			return fail(err)
+		}
+		index[key] = kvLoc{offset: offset, length: loc.length, expires: loc.expires}
+		offset += int64(loc.length)
+	}
+	if err := w.Flush(); err != nil {
+		return fail(err)
+	}
+	if err := s.rename(tmpPath, l.path); err != nil {
+		return fail(err)
+	}
+
+	// The rewritten file's handle follows it through the rename
+	l.file.Close()
+	l.file = tmp
+	l.index = index
+	l.dead = 0
+	return nil
+}
+
+func expiresAt(e Entry) time.Time {
+	if e.TTL <= 0 {
+		return time.Time{}
+	}
+	return e.Timestamp.Add(e.TTL)
+}
@@ -0,0 +1,283 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testEntry(t *testing.T, v any, ttl time.Duration) Entry {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Entry{Data: data, Timestamp: time.Now(), TTL: ttl}
+}
+
+func TestKVStore_PerKeyWrites(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "cache.kv")
+	s, err := OpenKVStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(NamespacePriceCharting, "pc|a", testEntry(t, 1, 0)); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(dir, NamespacePriceCharting+kvExt)
+	before, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put(NamespacePriceCharting, "pc|b", testEntry(t, 2, 0)); err != nil {
+		t.Fatal(err)
+	}
+	after, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(after, before) || bytes.Count(after, []byte("\n")) != 2 {
+		t.Errorf("expected the second write appended as one line, got:\n%s", after)
+	}
+
+	if err := s.Put(NamespacePriceCharting, "pc|a", testEntry(t, 3, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(NamespacePriceCharting, "pc|b"); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	// Reopening replays the log: the latest write wins and deletes stick
+	s, err = OpenKVStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	e, ok, err := s.Get(NamespacePriceCharting, "pc|a")
+	if err != nil || !ok || string(e.Data) != "3" {
+		t.Errorf("expected the replaced value, got %s %v %v", e.Data, ok, err)
+	}
+	if _, ok, _ := s.Get(NamespacePriceCharting, "pc|b"); ok {
+		t.Error("expected the deleted key to stay deleted")
+	}
+}
+
+func TestKVStore_Namespaces(t *testing.T) {
+	s, err := OpenKVStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	_ = s.Put(NamespaceSets, "sets:v2", testEntry(t, "sets", 0))
+	_ = s.Put(NamespaceCards, "cards|set|sv1", testEntry(t, "cards", 0))
+	_ = s.Put(NamespaceCards, "cards|set|sv2", testEntry(t, "cards", 0))
+
+	if keys, _ := s.Keys(NamespaceCards); !reflect.DeepEqual(keys, []string{"cards|set|sv1", "cards|set|sv2"}) {
+		t.Errorf("Keys = %v", keys)
+	}
+	if _, ok, _ := s.Get(NamespaceSets, "cards|set|sv1"); ok {
+		t.Error("expected keys to belong to one namespace")
+	}
+
+	if err := s.Clear(NamespaceCards); err != nil {
+		t.Fatal(err)
+	}
+	if keys, _ := s.Keys(NamespaceCards); len(keys) != 0 {
+		t.Errorf("expected cards cleared, got %v", keys)
+	}
+	if _, ok, _ := s.Get(NamespaceSets, "sets:v2"); !ok {
+		t.Error("expected clearing cards to leave sets alone")
+	}
+
+	if err := s.Put("../escape", "k", testEntry(t, 1, 0)); err == nil {
+		t.Error("expected a namespace that isn't a plain name to be rejected")
+	}
+}
+
+func TestKVStore_SweepAndCompact(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenKVStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.compactMin = 4
+
+	expired := testEntry(t, "old", time.Minute)
+	expired.Timestamp = time.Now().Add(-time.Hour)
+	_ = s.Put(NamespaceOther, "stale", expired)
+	_ = s.Put(NamespaceOther, "fresh", testEntry(t, "new", time.Hour))
+	_ = s.Put(NamespaceOther, "forever", testEntry(t, "kept", 0))
+
+	n, err := s.Sweep(time.Now())
+	if err != nil || n != 1 {
+		t.Fatalf("Sweep = %d, %v; want 1 removed", n, err)
+	}
+	if keys, _ := s.Keys(NamespaceOther); !reflect.DeepEqual(keys, []string{"forever", "fresh"}) {
+		t.Errorf("Keys after sweep = %v", keys)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, NamespaceOther+kvExt))
+	if bytes.Contains(data, []byte("stale")) {
+		t.Error("expected the sweep to compact the expired entry out of the log")
+	}
+
+	// Rewriting one key piles up superseded records until the log compacts
+	for i := 0; i < 10; i++ {
+		if err := s.Put(NamespaceOther, "fresh", testEntry(t, i, time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, _ = os.ReadFile(filepath.Join(dir, NamespaceOther+kvExt))
+	if lines := bytes.Count(data, []byte("\n")); lines > 2+s.compactMin+1 {
+		t.Errorf("expected the log compacted, got %d lines", lines)
+	}
+	if e, ok, _ := s.Get(NamespaceOther, "fresh"); !ok || string(e.Data) != "9" {
+		t.Errorf("expected the latest value after compaction, got %s", e.Data)
+	}
+}
+
+func TestKVStore_CompactFailure(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenKVStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.rename = func(string, string) error { return errors.New("disk full") }
+
+	expired := testEntry(t, "old", time.Minute)
+	expired.Timestamp = time.Now().Add(-time.Hour)
+	_ = s.Put(NamespaceOther, "stale", expired)
+	_ = s.Put(NamespaceOther, "kept", testEntry(t, "kept", 0))
+
+	if _, err := s.Sweep(time.Now()); err == nil {
+		t.Fatal("expected the failed compaction reported")
+	}
+	if _, err := os.Stat(filepath.Join(dir, NamespaceOther+kvExt+".tmp")); !os.IsNotExist(err) {
+		t.Error("expected the rewrite removed after a failed compaction")
+	}
+
+	// The log keeps working on its original file
+	if e, ok, err := s.Get(NamespaceOther, "kept"); err != nil || !ok || string(e.Data) != `"kept"` {
+		t.Errorf("expected the entry readable after a failed compaction, got %s %v %v", e.Data, ok, err)
+	}
+	if err := s.Put(NamespaceOther, "added", testEntry(t, 1, 0)); err != nil {
+		t.Fatalf("expected the log writable after a failed compaction: %v", err)
+	}
+	if e, ok, err := s.Get(NamespaceOther, "added"); err != nil || !ok || string(e.Data) != "1" {
+		t.Errorf("expected the new entry readable, got %s %v %v", e.Data, ok, err)
+	}
+}
+
+func TestKVStore_ClearFailure(t *testing.T) {
+	s, err := OpenKVStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	s.remove = func(string) error { return errors.New("permission denied") }
+
+	_ = s.Put(NamespaceOther, "kept", testEntry(t, "kept", 0))
+	if err := s.Clear(NamespaceOther); err == nil {
+		t.Fatal("expected the failed clear reported")
+	}
+
+	// The namespace stays usable instead of holding a closed file
+	if e, ok, err := s.Get(NamespaceOther, "kept"); err != nil || !ok || string(e.Data) != `"kept"` {
+		t.Errorf("expected the entry readable after a failed clear, got %s %v %v", e.Data, ok, err)
+	}
+	if err := s.Put(NamespaceOther, "added", testEntry(t, 1, 0)); err != nil {
+		t.Fatalf("expected the log writable after a failed clear: %v", err)
+	}
+}
+
+func TestKVStore_PartialWrite(t *testing.T) {
+	dir := t.TempDir()
+	good, _ := json.Marshal(kvRecord{Key: "a", Entry: &Entry{Data: json.RawMessage(`1`), Timestamp: time.Now()}})
+	log := append(append(good, '\n'), []byte(`{"key":"b","da`)...)
+	if err := os.WriteFile(filepath.Join(dir, NamespaceOther+kvExt), log, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := OpenKVStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := s.Get(NamespaceOther, "a"); !ok {
+		t.Error("expected the complete record to survive")
+	}
+	if err := s.Put(NamespaceOther, "c", testEntry(t, 3, 0)); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = OpenKVStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if keys, _ := s.Keys(NamespaceOther); !reflect.DeepEqual(keys, []string{"a", "c"}) {
+		t.Errorf("expected the write after a torn record to be readable, got %v", keys)
+	}
+}
+
+func TestNew_MigratesJSON(t *testing.T) {
+	dir := t.TempDir()
+	legacy := map[string]Entry{
+		SetsKey():                           testEntry(t, []string{"sv1"}, time.Hour),
+		PriceChartingKey("151", "Mew", "1"): testEntry(t, 4200, 0),
+		"pop|card|x":                        {Data: json.RawMessage(`1`), Timestamp: time.Now().Add(-2 * time.Hour), TTL: time.Hour},
+	}
+	data, _ := json.Marshal(legacy)
+	jsonPath := filepath.Join(dir, "cache.json")
+	if err := os.WriteFile(jsonPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	var cents int
+	if found, err := c.Get(PriceChartingKey("151", "Mew", "1"), &cents); !found || err != nil || cents != 4200 {
+		t.Errorf("expected the migrated price, got %d %v %v", cents, found, err)
+	}
+	if keys, _ := c.Backend().Keys(NamespaceSets); len(keys) != 1 {
+		t.Errorf("expected sets filed in their namespace, got %v", keys)
+	}
+	if keys, _ := c.Backend().Keys(NamespacePopulation); len(keys) != 0 {
+		t.Errorf("expected expired entries left behind, got %v", keys)
+	}
+	if _, err := os.Stat(jsonPath + ".migrated"); err != nil {
+		t.Errorf("expected the JSON file renamed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "cache.kv", NamespacePriceCharting+kvExt)); err != nil {
+		t.Errorf("expected a store beside the old file: %v", err)
+	}
+}
+
+func TestNamespaceOf(t *testing.T) {
+	tests := map[string]string{
+		SetsKey():                            NamespaceSets,
+		CardsKey("sv1"):                      NamespaceCards,
+		PriceChartingKey("151", "Mew", "1"):  NamespacePriceCharting,
+		BuildKey("pop", "card", "x"):         NamespacePopulation,
+		BuildKey("sales", "151", "Mew", "1"): NamespaceSales,
+		"gamestop-Mew-1":                     NamespaceOther,
+		"pcx|not-pricecharting":              NamespaceOther,
+	}
+	for key, want := range tests {
+		if got := NamespaceOf(key); got != want {
+			t.Errorf("NamespaceOf(%q) = %s, want %s", key, got, want)
+		}
+	}
+}
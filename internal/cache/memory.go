@@ -2,6 +2,7 @@ package cache
 
 import (
 	"container/list"
+	"strings"
 	"sync"
 	"time"
 )
@@ -106,6 +107,18 @@ func (m *MemoryCache) Delete(key string) error {
 	return nil
 }
 
+// deletePrefix removes the items whose key starts with prefix
+func (m *MemoryCache) deletePrefix(prefix string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, element := range m.items {
+		if strings.HasPrefix(key, prefix) {
+			m.removeElement(element)
+		}
+	}
+}
+
 // Clear removes all items from the memory cache
 func (m *MemoryCache) Clear() error {
 	m.mu.Lock()
@@ -216,199 +229,3 @@ type MemoryCacheStats struct {
 	ExpiredItems   int     `json:"expired_items"`
 	UtilizationPct float64 `json:"utilization_pct"`
 }
-
-// TTLCache is a simple TTL-only cache (no LRU eviction)
-type TTLCache struct {
-	items map[string]*TTLCacheItem
-	mu    sync.RWMutex
-}
-
-// TTLCacheItem represents an item in the TTL cache
-type TTLCacheItem struct {
-	Value     interface{}
-	ExpiresAt time.Time
-}
-
-// NewTTLCache creates a new TTL-only cache
-func NewTTLCache() *TTLCache {
-	return &TTLCache{
-		items: make(map[string]*TTLCacheItem),
-	}
-}
-
-// Get retrieves an item from the TTL cache
-func (t *TTLCache) Get(key string) (interface{}, bool) {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-
-	item, exists := t.items[key]
-	if !exists {
-		return nil, false
-	}
-
-	// Check if expired
-	if time.Now().After(item.ExpiresAt) {
-		// Don't remove here to avoid upgrading the lock
-		return nil, false
-	}
-
-	return item.Value, true
-}
-
-// Set stores an item in the TTL cache
-func (t *TTLCache) Set(key string, value interface{}, ttl time.Duration) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	t.items[key] = &TTLCacheItem{
-		Value:     value,
-		ExpiresAt: time.Now().Add(ttl),
-	}
-
-	return nil
-}
-
-// Delete removes an item from the TTL cache
-func (t *TTLCache) Delete(key string) error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	delete(t.items, key)
-	return nil
-}
-
-// Clean removes expired items from the TTL cache
-func (t *TTLCache) Clean() error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	now := time.Now()
-	for key, item := range t.items {
-		if now.After(item.ExpiresAt) {
-			delete(t.items, key)
-		}
-	}
-
-	return nil
-}
-
-// Size returns the current number of items in cache
-func (t *TTLCache) Size() int {
-	t.mu.RLock()
-	defer t.mu.RUnlock()
-	return len(t.items)
-}
-
-// ThreadSafeMap is a generic thread-safe map with optional TTL
-type ThreadSafeMap struct {
-	data map[string]interface{}
-	ttl  map[string]time.Time
-	mu   sync.RWMutex
-}
-
-// NewThreadSafeMap creates a new thread-safe map
-func NewThreadSafeMap() *ThreadSafeMap {
-	return &ThreadSafeMap{
-		data: make(map[string]interface{}),
-		ttl:  make(map[string]time.Time),
-	}
-}
-
-// Get retrieves a value from the map
-func (m *ThreadSafeMap) Get(key string) (interface{}, bool) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	// Check TTL if set
-	if expiry, hasTTL := m.ttl[key]; hasTTL {
-		if time.Now().After(expiry) {
-			return nil, false
-		}
-	}
-
-	value, exists := m.data[key]
-	return value, exists
-}
-
-// Set stores a value in the map
-func (m *ThreadSafeMap) Set(key string, value interface{}) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	m.data[key] = value
-	delete(m.ttl, key) // Remove any existing TTL
-}
-
-// SetWithTTL stores a value in the map with TTL
-func (m *ThreadSafeMap) SetWithTTL(key string, value interface{}, ttl time.Duration) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	m.data[key] = value
-	m.ttl[key] = time.Now().Add(ttl)
-}
-
-// Delete removes a value from the map
-func (m *ThreadSafeMap) Delete(key string) {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	delete(m.data, key)
-	delete(m.ttl, key)
-}
-
-// Keys returns all keys in the map
-func (m *ThreadSafeMap) Keys() []string {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	keys := make([]string, 0, len(m.data))
-	now := time.Now()
-
-	for key := range m.data {
-		// Check TTL
-		if expiry, hasTTL := m.ttl[key]; hasTTL {
-			if now.After(expiry) {
-				continue // Skip expired items
-			}
-		}
-		keys = append(keys, key)
-	}
-
-	return keys
-}
-
-// Size returns the number of items in the map
-func (m *ThreadSafeMap) Size() int {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	count := 0
-	now := time.Now()
-
-	for key := range m.data {
-		// Check TTL
-		if expiry, hasTTL := m.ttl[key]; hasTTL {
-			if now.After(expiry) {
-				continue // Skip expired items
-			}
-		}
-		count++
-	}
-
-	return count
-}
-
-// Clean removes expired items
-func (m *ThreadSafeMap) Clean() {
-	m.mu.Lock()
-	defer m.mu.Unlock()
-
-	now := time.Now()
-	for key, expiry := range m.ttl {
-		if now.After(expiry) {
-			delete(m.data, key)
-			delete(m.ttl, key)
-		}
-	}
-}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -17,10 +18,11 @@ type MultiLayerCache struct {
 	l1 *MemoryCache // Hot data - fast access
 	l2 *DiskCache   // Warm data - persistent
 
-	config    CacheConfig
-	stats     *CacheStats
-	predictor *CachePredictor
-	mu        sync.RWMutex
+	config      CacheConfig
+	stats       *statsTracker
+	countMisses bool // false when a miss falls through to the backing cache, which counts it
+	predictor   *CachePredictor
+	mu          sync.RWMutex
 }
 
 // CacheConfig holds configuration for the multi-layer cache
@@ -32,21 +34,11 @@ type CacheConfig struct {
 	L2Path        string        // Path for disk cache
 	EnablePredict bool          // Enable predictive caching
 	CompressL2    bool          // Compress L2 cache entries
-}
 
-// CacheStats tracks cache performance metrics
-type CacheStats struct {
-	L1Hits         int64     `json:"l1_hits"`
-	L1Misses       int64     `json:"l1_misses"`
-	L1HitRate      float64   `json:"l1_hit_rate"`
-	L2Hits         int64     `json:"l2_hits"`
-	L2Misses       int64     `json:"l2_misses"`
-	L2HitRate      float64   `json:"l2_hit_rate"`
-	OverallHitRate float64   `json:"overall_hit_rate"`
-	Evictions      int64     `json:"evictions"`
-	Prefetches     int64     `json:"prefetches"`
-	StartTime      time.Time `json:"start_time"`
-	mu             sync.RWMutex
+	// Backing is the cache the layer sits in front of. Its Stats count the
+	// layer's hits, and its own lookups count the misses that fall through;
+	// without one the layer keeps its own stats.
+	Backing *Cache
 }
 
 // CacheEntry represents a cached item
@@ -77,11 +69,12 @@ func NewMultiLayerCache(config CacheConfig) (*MultiLayerCache, error) {
 		config.L2Path = "./cache"
 	}
 
-	cache := &MultiLayerCache{
-		config: config,
-		stats: &CacheStats{
-			StartTime: time.Now(),
-		},
+	cache := &MultiLayerCache{config: config}
+	if config.Backing != nil {
+		cache.stats = &config.Backing.stats
+	} else {
+		cache.stats = &statsTracker{}
+		cache.countMisses = true
 	}
 
 	// Initialize L1 cache (memory)
@@ -116,17 +109,16 @@ func (m *MultiLayerCache) GetEntry(key string) *CacheEntry {
 
 	// Try L1 first
 	if data, found := m.l1.Get(key); found {
-		m.stats.recordL1Hit()
+		m.recordLookup(key, true)
 		return &CacheEntry{
 			Key:  key,
 			Data: data,
 		}
 	}
-	m.stats.recordL1Miss()
 
 	// Try L2
 	if data, found := m.l2.Get(key); found {
-		m.stats.recordL2Hit()
+		m.recordLookup(key, true)
 		// Promote to L1
 		m.l1.Set(key, data, m.config.L1TTL)
 		return &CacheEntry{
@@ -134,7 +126,7 @@ func (m *MultiLayerCache) GetEntry(key string) *CacheEntry {
 			Data: data,
 		}
 	}
-	m.stats.recordL2Miss()
+	m.recordLookup(key, false)
 
 	return nil
 }
@@ -167,53 +159,6 @@ func (m *MultiLayerCache) Put(key string, data interface{}, priority CachePriori
 	return nil
 }
 
-// Stats recording methods
-func (s *CacheStats) recordL1Hit() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.L1Hits++
-	s.updateRates()
-}
-
-func (s *CacheStats) recordL1Miss() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.L1Misses++
-	s.updateRates()
-}
-
-func (s *CacheStats) recordL2Hit() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.L2Hits++
-	s.updateRates()
-}
-
-func (s *CacheStats) recordL2Miss() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.L2Misses++
-	s.updateRates()
-}
-
-func (s *CacheStats) updateRates() {
-	totalL1 := s.L1Hits + s.L1Misses
-	if totalL1 > 0 {
-		s.L1HitRate = float64(s.L1Hits) / float64(totalL1)
-	}
-
-	totalL2 := s.L2Hits + s.L2Misses
-	if totalL2 > 0 {
-		s.L2HitRate = float64(s.L2Hits) / float64(totalL2)
-	}
-
-	totalHits := s.L1Hits + s.L2Hits
-	totalRequests := totalL1 // Only count initial requests
-	if totalRequests > 0 {
-		s.OverallHitRate = float64(totalHits) / float64(totalRequests)
-	}
-}
-
 // Get retrieves an item from the cache, checking L1 -> L2 -> L3
 func (c *MultiLayerCache) Get(key string) (interface{}, bool) {
 	c.mu.RLock()
@@ -226,19 +171,18 @@ func (c *MultiLayerCache) Get(key string) (interface{}, bool) {
 
 	// Try L1 first (memory cache)
 	if data, found := c.l1.Get(key); found {
-		c.recordHit(1)
+		c.recordLookup(key, true)
 		return data, true
 	}
-	c.recordMiss(1)
 
 	// Try L2 (disk cache)
 	if data, found := c.l2.Get(key); found {
-		c.recordHit(2)
+		c.recordLookup(key, true)
 		// Promote to L1
 		c.l1.Set(key, data, c.config.L1TTL)
 		return data, true
 	}
-	c.recordMiss(2)
+	c.recordLookup(key, false)
 
 	return nil, false
 }
@@ -304,9 +248,6 @@ func (c *MultiLayerCache) Clear() error {
 		errors = append(errors, fmt.Errorf("L2 clear failed: %w", err))
 	}
 
-	// Reset stats
-	c.stats = &CacheStats{StartTime: time.Now()}
-
 	if len(errors) > 0 {
 		return fmt.Errorf("cache clear errors: %v", errors)
 	}
@@ -314,6 +255,16 @@ func (c *MultiLayerCache) Clear() error {
 	return nil
 }
 
+// InvalidatePrefix removes the items whose key starts with prefix from
+// both layers and returns how many it removed from disk
+func (c *MultiLayerCache) InvalidatePrefix(prefix string) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.l1.deletePrefix(prefix)
+	return c.l2.deletePrefix(prefix)
+}
+
 // Prefetch loads data into cache proactively
 func (c *MultiLayerCache) Prefetch(ctx context.Context, targets []PrefetchTarget) error {
 	for _, target := range targets {
@@ -341,11 +292,10 @@ func (c *MultiLayerCache) GetPredictedTargets() []PrefetchTarget {
 	return c.predictor.GetPredictions()
 }
 
-// GetStats returns cache performance statistics
-func (c *MultiLayerCache) GetStats() *CacheStats {
-	c.stats.mu.RLock()
-	defer c.stats.mu.RUnlock()
-	return c.stats
+// GetStats returns the stats the layer counts its lookups in: its backing
+// cache's, or its own without one
+func (c *MultiLayerCache) GetStats() Stats {
+	return c.stats.snapshot()
 }
 
 // Optimize performs cache optimization operations
@@ -372,27 +322,10 @@ func (c *MultiLayerCache) Optimize() error {
 
 // Helper methods
 
-func (c *MultiLayerCache) recordHit(layer int) {
-	c.stats.mu.Lock()
-	defer c.stats.mu.Unlock()
-
-	switch layer {
-	case 1:
-		c.stats.L1Hits++
-	case 2:
-		c.stats.L2Hits++
-	}
-}
-
-func (c *MultiLayerCache) recordMiss(layer int) {
-	c.stats.mu.Lock()
-	defer c.stats.mu.Unlock()
-
-	switch layer {
-	case 1:
-		c.stats.L1Misses++
-	case 2:
-		c.stats.L2Misses++
+// recordLookup counts a lookup under its key's namespace
+func (c *MultiLayerCache) recordLookup(key string, hit bool) {
+	if hit || c.countMisses {
+		c.stats.lookup(NamespaceOf(key), key, hit)
 	}
 }
 
@@ -508,6 +441,33 @@ func (d *DiskCache) Clear() error {
 	return os.RemoveAll(d.basePath)
 }
 
+// deletePrefix removes the entries whose key starts with prefix. File names
+// are the hex of the key, so a key prefix is a file name prefix.
+func (d *DiskCache) deletePrefix(prefix string) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	files, err := os.ReadDir(d.basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	hexPrefix := fmt.Sprintf("%x", []byte(prefix))
+	removed := 0
+	for _, f := range files {
+		if f.IsDir() || !strings.HasPrefix(f.Name(), hexPrefix) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.basePath, f.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
 // Clean removes expired entries
 func (d *DiskCache) Clean() error {
 	d.mu.Lock()
@@ -117,6 +117,23 @@ func (p *CachePredictor) RecordAccess(key string) {
 	p.updateCorrelations(record)
 }
 
+// Replay learns from lookups recorded earlier, in the order they happened
+func (p *CachePredictor) Replay(records []AccessRecord) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, record := range records {
+		if record.Context == "" {
+			record.Context = p.extractContext(record.Key)
+		}
+		p.addAccessRecord(record)
+		p.updatePatterns(record)
+		p.updateSequences(record)
+		p.updateTimePatterns(record)
+		p.updateCorrelations(record)
+	}
+}
+
 // RecordSet records a cache set operation
 func (p *CachePredictor) RecordSet(key string, data interface{}) {
 	p.mu.Lock()
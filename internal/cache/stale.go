@@ -0,0 +1,76 @@
+package cache
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// StalePolicy is how long past its TTL an entry in each namespace may still
+// be served by GetStale while a fresh copy is fetched. Namespaces it leaves
+// out are never served stale.
+type StalePolicy map[string]time.Duration
+
+// ParseStalePolicy reads a policy from "72h", which applies to every
+// provider namespace, or from "pricecharting=72h,sales=12h". An empty
+// string is an empty policy.
+func ParseStalePolicy(s string) (StalePolicy, error) {
+	p := StalePolicy{}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return p, nil
+	}
+	if !strings.Contains(s, "=") {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid max staleness %q", s)
+		}
+		for _, ns := range staleNamespaces() {
+			p[ns] = d
+		}
+		return p, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		ns, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || !slices.Contains(staleNamespaces(), ns) {
+			return nil, fmt.Errorf("invalid max staleness %q: want namespace=duration with a namespace of %s",
+				part, strings.Join(staleNamespaces(), ", "))
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid max staleness %q", part)
+		}
+		p[ns] = d
+	}
+	return p, nil
+}
+
+// staleNamespaces are the namespaces providers cache in, which a policy
+// may cover
+func staleNamespaces() []string {
+	return slices.DeleteFunc(slices.Clone(Namespaces), func(ns string) bool { return ns == NamespaceMeta })
+}
+
+// For returns how long past its TTL an entry in ns may be served
+func (p StalePolicy) For(ns string) time.Duration {
+	return p[ns]
+}
+
+// Max returns the longest staleness any namespace allows
+func (p StalePolicy) Max() time.Duration {
+	var longest time.Duration
+	for _, d := range p {
+		longest = max(longest, d)
+	}
+	return longest
+}
+
+// servable reports whether an entry of namespace ns may be served at now,
+// and whether it would be served stale
+func (p StalePolicy) servable(ns string, e Entry, now time.Time) (ok, stale bool) {
+	if !e.Expired(now) {
+		return true, false
+	}
+	return now.Sub(e.Timestamp.Add(e.TTL)) <= p.For(ns), true
+}
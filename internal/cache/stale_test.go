@@ -0,0 +1,109 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseStalePolicy(t *testing.T) {
+	p, err := ParseStalePolicy("72h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.For(NamespacePriceCharting) != 72*time.Hour || p.For(NamespaceMeta) != 0 {
+		t.Errorf("expected 72h for every provider namespace, got %v", p)
+	}
+
+	p, err = ParseStalePolicy("pricecharting=72h, sales=12h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.For(NamespacePriceCharting) != 72*time.Hour || p.For(NamespaceSales) != 12*time.Hour || p.For(NamespaceCards) != 0 {
+		t.Errorf("unexpected policy %v", p)
+	}
+	if p.Max() != 72*time.Hour {
+		t.Errorf("Max = %v", p.Max())
+	}
+
+	for _, bad := range []string{"soon", "pricecharting=soon", "meta=1h", "prices=1h", "-1h"} {
+		if _, err := ParseStalePolicy(bad); err == nil {
+			t.Errorf("ParseStalePolicy(%q): expected an error", bad)
+		}
+	}
+	if p, err := ParseStalePolicy(""); err != nil || len(p) != 0 {
+		t.Errorf("expected an empty policy, got %v, %v", p, err)
+	}
+}
+
+func TestCache_GetStale(t *testing.T) {
+	c := NewMemory()
+	c.SetStalePolicy(StalePolicy{NamespacePriceCharting: time.Hour})
+	stored := time.Now().Add(-2 * time.Hour)
+	put := func(key string, ttl time.Duration) {
+		_ = c.Backend().Put(NamespaceOf(key), key, Entry{Data: []byte("1"), Timestamp: stored, TTL: ttl})
+	}
+	fresh := PriceChartingKey("151", "Mew", "151")
+	stale := PriceChartingKey("151", "Mewtwo", "150")
+	tooOld := PriceChartingKey("151", "Pikachu", "25")
+	other := BuildKey("sales", "151", "Mew", "151")
+	put(fresh, 3*time.Hour)
+	put(stale, 90*time.Minute)
+	put(tooOld, 30*time.Minute)
+	put(other, 90*time.Minute)
+
+	var v int
+	if found, isStale, _ := c.GetStale(fresh, &v); !found || isStale {
+		t.Errorf("fresh entry: found %v, stale %v", found, isStale)
+	}
+	// Get misses the stale entry but keeps it for GetStale
+	if found, _ := c.Get(stale, &v); found {
+		t.Error("expected Get to miss an expired entry")
+	}
+	if found, isStale, _ := c.GetStale(stale, &v); !found || !isStale || v != 1 {
+		t.Errorf("stale entry: found %v, stale %v", found, isStale)
+	}
+	if found, _, _ := c.GetStale(tooOld, &v); found {
+		t.Error("expected an entry past the policy's limit to miss")
+	}
+	if found, _, _ := c.GetStale(other, &v); found {
+		t.Error("expected a namespace outside the policy never to serve stale")
+	}
+	if _, ok, _ := c.Info(tooOld); ok {
+		t.Error("expected the unservable entry to be removed")
+	}
+	if s := c.Stats(); s.Stale != 1 || s.Namespaces[NamespacePriceCharting].Stale != 1 {
+		t.Errorf("stale count = %d", s.Stale)
+	}
+}
+
+func TestOpen_KeepsStaleEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.kv")
+	c, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = c.Put(PriceChartingKey("151", "Mew", "151"), 1, time.Millisecond)
+	c.Close()
+	time.Sleep(5 * time.Millisecond)
+
+	c, err = Open(path, StalePolicy{NamespacePriceCharting: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	found, stale, _ := c.GetStale(PriceChartingKey("151", "Mew", "151"), &v)
+	c.Close()
+	if !found || !stale {
+		t.Errorf("expected the expired entry to survive the sweep on open, found %v stale %v", found, stale)
+	}
+
+	c, err = New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, ok, _ := c.Info(PriceChartingKey("151", "Mew", "151")); ok {
+		t.Error("expected opening without a policy to sweep the expired entry")
+	}
+}
@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store is the cache every provider accepts. *Cache implements it, so one
+// configured cache serves them all and counts their hits in one place.
+type Store interface {
+	// Get decodes a live entry into target and reports whether one was found
+	Get(key string, target interface{}) (bool, error)
+	// GetStale is Get that may also serve an expired entry, reporting it
+	// as stale (see StalePolicy)
+	GetStale(key string, target interface{}) (found, stale bool, err error)
+	// Put stores value under key; a ttl of zero never expires
+	Put(key string, value interface{}, ttl time.Duration) error
+	Remove(key string) error
+	// InvalidatePrefix removes every entry whose key starts with prefix and
+	// returns how many it removed
+	InvalidatePrefix(prefix string) (int, error)
+	Clear() error
+	Stats() Stats
+}
+
+// Counts tallies cache lookups and writes
+type Counts struct {
+	Hits   int64 `json:"hits"`
+	Misses int64 `json:"misses"`
+	Writes int64 `json:"writes"`
+	Stale  int64 `json:"stale"` // hits served past their TTL
+}
+
+// HitRate returns the share of lookups that hit, from 0 to 1
+func (c Counts) HitRate() float64 {
+	if c.Hits+c.Misses == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(c.Hits+c.Misses)
+}
+
+func (c *Counts) add(o Counts) {
+	c.Hits += o.Hits
+	c.Misses += o.Misses
+	c.Writes += o.Writes
+	c.Stale += o.Stale
+}
+
+// Stats are a cache's counts since it was opened, in total and by namespace
+type Stats struct {
+	Counts
+	Namespaces map[string]Counts `json:"namespaces"`
+}
+
+// NamespaceNames returns the namespaces with counts, in sorted order
+func (s Stats) NamespaceNames() []string {
+	names := make([]string, 0, len(s.Namespaces))
+	for ns := range s.Namespaces {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// statsTracker counts lookups and writes by namespace and remembers the
+// latest lookups for the predictor
+type statsTracker struct {
+	mu       sync.Mutex
+	counts   map[string]*Counts
+	accesses []AccessRecord
+}
+
+func (t *statsTracker) countsFor(ns string) *Counts {
+	if t.counts == nil {
+		t.counts = make(map[string]*Counts)
+	}
+	c, ok := t.counts[ns]
+	if !ok {
+		c = &Counts{}
+		t.counts[ns] = c
+	}
+	return c
+}
+
+func (t *statsTracker) lookup(ns, key string, hit bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if hit {
+		t.countsFor(ns).Hits++
+	} else {
+		t.countsFor(ns).Misses++
+	}
+	t.accesses = append(t.accesses, AccessRecord{Key: key, Timestamp: time.Now(), Hit: hit, Context: ns})
+	if len(t.accesses) > maxUsageAccesses {
+		t.accesses = t.accesses[len(t.accesses)-maxUsageAccesses:]
+	}
+}
+
+func (t *statsTracker) served(ns string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.countsFor(ns).Stale++
+}
+
+func (t *statsTracker) write(ns string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.countsFor(ns).Writes++
+}
+
+func (t *statsTracker) snapshot() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := Stats{Namespaces: make(map[string]Counts, len(t.counts))}
+	for ns, c := range t.counts {
+		s.Namespaces[ns] = *c
+		s.add(*c)
+	}
+	return s
+}
+
+func (t *statsTracker) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.counts = nil
+}
+
+// drain returns the lookups recorded since the last drain
+func (t *statsTracker) drain() []AccessRecord {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := t.accesses
+	t.accesses = nil
+	return out
+}
+
+// Typed is a view of a Store that reads and writes one type of value under
+// a key prefix. A Typed over a nil Store caches nothing.
+type Typed[T any] struct {
+	store  Store
+	prefix []string
+}
+
+// NewTyped returns a view of s whose keys are BuildKey(prefix..., key)
+func NewTyped[T any](s Store, prefix ...string) Typed[T] {
+	return Typed[T]{store: s, prefix: prefix}
+}
+
+func (t Typed[T]) key(key string) string {
+	return BuildKey(append(append([]string(nil), t.prefix...), key)...)
+}
+
+// Get returns the value cached under key. Unreadable entries count as
+// missing.
+func (t Typed[T]) Get(key string) (T, bool) {
+	var v T
+	if t.store == nil {
+		return v, false
+	}
+	found, err := t.store.Get(t.key(key), &v)
+	if err != nil || !found {
+		var zero T
+		return zero, false
+	}
+	return v, true
+}
+
+// Set caches v under key for ttl
+func (t Typed[T]) Set(key string, v T, ttl time.Duration) error {
+	if t.store == nil {
+		return nil
+	}
+	return t.store.Put(t.key(key), v, ttl)
+}
+
+// Remove drops the value cached under key
+func (t Typed[T]) Remove(key string) error {
+	if t.store == nil {
+		return nil
+	}
+	return t.store.Remove(t.key(key))
+}
+
+// Invalidate drops every value in the view and returns how many it dropped
+func (t Typed[T]) Invalidate() (int, error) {
+	if t.store == nil {
+		return 0, nil
+	}
+	return t.store.InvalidatePrefix(BuildKey(t.prefix...) + "|")
+}
+
+// MemoryBackend is a Backend that keeps entries in memory, for caches that
+// needn't outlive the process
+type MemoryBackend struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]Entry
+}
+
+// NewMemoryBackend returns an empty in-memory backend
+func NewMemoryBackend() *MemoryBackend {
+	return &MemoryBackend{entries: make(map[string]map[string]Entry)}
+}
+
+// NewMemory returns a cache kept in memory
+func NewMemory() *Cache {
+	return NewWithBackend(NewMemoryBackend())
+}
+
+func (m *MemoryBackend) Get(ns, key string) (Entry, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.entries[ns][key]
+	return e, ok, nil
+}
+
+func (m *MemoryBackend) Put(ns, key string, e Entry) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.entries[ns] == nil {
+		m.entries[ns] = make(map[string]Entry)
+	}
+	m.entries[ns][key] = e
+	return nil
+}
+
+func (m *MemoryBackend) Delete(ns, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries[ns], key)
+	return nil
+}
+
+func (m *MemoryBackend) Clear(ns string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if ns == "" {
+		m.entries = make(map[string]map[string]Entry)
+	} else {
+		delete(m.entries, ns)
+	}
+	return nil
+}
+
+func (m *MemoryBackend) Keys(ns string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	keys := make([]string, 0, len(m.entries[ns]))
+	for k := range m.entries[ns] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (m *MemoryBackend) Sweep(now time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	removed := 0
+	for _, entries := range m.entries {
+		for k, e := range entries {
+			if e.Expired(now) {
+				delete(entries, k)
+				removed++
+			}
+		}
+	}
+	return removed, nil
+}
+
+func (m *MemoryBackend) Close() error {
+	return nil
+}
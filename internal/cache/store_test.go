@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testListing struct {
+	Price float64 `json:"price"`
+}
+
+func TestTyped_RoundTrip(t *testing.T) {
+	c := NewMemory()
+	listings := NewTyped[*testListing](c, "gamestop", "Base Set")
+
+	if _, found := listings.Get("Charizard"); found {
+		t.Fatal("expected a miss on an empty cache")
+	}
+	if err := listings.Set("Charizard", &testListing{Price: 420}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	got, found := listings.Get("Charizard")
+	if !found || got.Price != 420 {
+		t.Errorf("expected the stored listing, got %+v %v", got, found)
+	}
+
+	var raw testListing
+	if found, _ := c.Get("gamestop|Base Set|Charizard", &raw); !found {
+		t.Error("expected the typed key built from the prefix")
+	}
+
+	var none Typed[string]
+	if err := none.Set("k", "v", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, found := none.Get("k"); found {
+		t.Error("expected a view without a store to cache nothing")
+	}
+}
+
+func TestCache_Stats(t *testing.T) {
+	c := NewMemory()
+	var v int
+	_ = c.Put(PriceChartingKey("151", "Mew", "151"), 1, time.Hour)
+	_, _ = c.Get(PriceChartingKey("151", "Mew", "151"), &v)
+	_, _ = c.Get(PriceChartingKey("151", "Mew", "150"), &v)
+	_, _ = c.Get(BuildKey("pop", "card", "x"), &v)
+
+	s := c.Stats()
+	if s.Hits != 1 || s.Misses != 2 || s.Writes != 1 {
+		t.Errorf("totals = %+v", s.Counts)
+	}
+	if pc := s.Namespaces[NamespacePriceCharting]; pc.HitRate() != 0.5 {
+		t.Errorf("pricecharting hit rate = %.2f, want 0.5", pc.HitRate())
+	}
+	if names := s.NamespaceNames(); len(names) != 2 || names[0] != NamespacePopulation {
+		t.Errorf("NamespaceNames = %v", names)
+	}
+}
+
+func TestCache_InvalidatePrefix(t *testing.T) {
+	c, err := New(filepath.Join(t.TempDir(), "cache.kv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	_ = c.Put(PriceChartingKey("151", "Mew", "151"), 1, 0)
+	_ = c.Put(PriceChartingKey("151", "Mewtwo", "150"), 2, 0)
+	_ = c.Put(PriceChartingKey("Base Set", "Mew", "8"), 3, 0)
+	_ = c.Put(BuildKey("sales", "151", "Mew", "151"), 4, 0)
+
+	n, err := c.InvalidatePrefix(BuildKey("pc", "151") + "|")
+	if err != nil || n != 2 {
+		t.Fatalf("InvalidatePrefix = %d, %v; want 2", n, err)
+	}
+	var v int
+	if found, _ := c.Get(PriceChartingKey("Base Set", "Mew", "8"), &v); !found {
+		t.Error("expected another set's entry to survive")
+	}
+	if found, _ := c.Get(BuildKey("sales", "151", "Mew", "151"), &v); !found {
+		t.Error("expected another namespace's entry to survive")
+	}
+
+	if n, _ := c.InvalidatePrefix(""); n != 2 {
+		t.Errorf("expected an empty prefix to remove everything left, removed %d", n)
+	}
+}
+
+func TestCache_Nil(t *testing.T) {
+	var c *Cache
+	var store Store = c
+	var v int
+	if err := store.Put("k", 1, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := store.Get("k", &v); found || err != nil {
+		t.Errorf("expected a nil cache to miss, got %v %v", found, err)
+	}
+	if n, _ := store.InvalidatePrefix(""); n != 0 {
+		t.Errorf("InvalidatePrefix = %d", n)
+	}
+}
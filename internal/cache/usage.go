@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// maxUsageAccesses bounds the lookups a Usage keeps for the predictor
+const maxUsageAccesses = 5000
+
+// Usage is what a cache has seen across the runs that saved it
+type Usage struct {
+	Stats    Stats          `json:"stats"`
+	Accesses []AccessRecord `json:"accesses"` // latest lookups, oldest first
+	Since    time.Time      `json:"since"`
+	Updated  time.Time      `json:"updated"`
+}
+
+// UsageKey is where a cache keeps its Usage
+func UsageKey() string {
+	return BuildKey("meta", "usage")
+}
+
+// Predictor returns a CachePredictor trained on the saved lookups
+func (u Usage) Predictor() *CachePredictor {
+	p := NewCachePredictor()
+	p.Replay(u.Accesses)
+	return p
+}
+
+// LoadUsage returns the usage saved by earlier runs; an empty Usage when
+// none was saved
+func (c *Cache) LoadUsage() (Usage, error) {
+	u := Usage{Stats: Stats{Namespaces: map[string]Counts{}}}
+	if c == nil {
+		return u, nil
+	}
+	e, ok, err := c.backend.Get(NamespaceMeta, UsageKey())
+	if err != nil || !ok {
+		return u, err
+	}
+	if err := json.Unmarshal(e.Data, &u); err != nil {
+		return u, fmt.Errorf("read cache usage: %w", err)
+	}
+	if u.Stats.Namespaces == nil {
+		u.Stats.Namespaces = map[string]Counts{}
+	}
+	return u, nil
+}
+
+// SaveUsage adds this run's counts and lookups, including those of a
+// MultiLayerCache backed by c, to the saved usage. A run that didn't use
+// the cache saves nothing.
+func (c *Cache) SaveUsage() error {
+	if c == nil {
+		return nil
+	}
+	run := c.stats.snapshot()
+	if run.Counts == (Counts{}) {
+		return nil
+	}
+	u, err := c.LoadUsage()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if u.Since.IsZero() {
+		u.Since = now
+	}
+	u.Updated = now
+
+	u.Stats.add(run.Counts)
+	for ns, counts := range run.Namespaces {
+		total := u.Stats.Namespaces[ns]
+		total.add(counts)
+		u.Stats.Namespaces[ns] = total
+	}
+	u.Accesses = append(u.Accesses, c.stats.drain()...)
+	if len(u.Accesses) > maxUsageAccesses {
+		u.Accesses = u.Accesses[len(u.Accesses)-maxUsageAccesses:]
+	}
+
+	data, err := json.Marshal(u)
+	if err != nil {
+		return fmt.Errorf("save cache usage: %w", err)
+	}
+	// Counts start over so saving twice doesn't add a run twice
+	c.stats.reset()
+	return c.backend.Put(NamespaceMeta, UsageKey(), Entry{Data: data, Timestamp: now})
+}
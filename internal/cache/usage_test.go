@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCache_SaveUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.kv")
+	for run := 0; run < 2; run++ {
+		c, err := New(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var v int
+		_ = c.Put(PriceChartingKey("151", "Mew", "151"), 1, time.Hour)
+		_, _ = c.Get(PriceChartingKey("151", "Mew", "151"), &v)
+		_, _ = c.Get(PriceChartingKey("151", "Mewtwo", "150"), &v)
+		if err := c.SaveUsage(); err != nil {
+			t.Fatal(err)
+		}
+		// A second save in the same run adds nothing
+		if err := c.SaveUsage(); err != nil {
+			t.Fatal(err)
+		}
+		c.Close()
+	}
+
+	c, err := New(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	u, err := c.LoadUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Stats.Hits != 2 || u.Stats.Misses != 2 || u.Stats.Writes != 2 {
+		t.Errorf("saved totals = %+v, want two runs' worth", u.Stats.Counts)
+	}
+	if pc := u.Stats.Namespaces[NamespacePriceCharting]; pc.HitRate() != 0.5 {
+		t.Errorf("pricecharting hit rate = %.2f, want 0.5", pc.HitRate())
+	}
+	if len(u.Accesses) != 4 {
+		t.Errorf("saved %d lookups, want 4", len(u.Accesses))
+	}
+	if u.Since.IsZero() || u.Updated.Before(u.Since) {
+		t.Errorf("since %v, updated %v", u.Since, u.Updated)
+	}
+}
+
+func TestCache_InvalidateCard(t *testing.T) {
+	c := NewMemory()
+	keys := []string{
+		PriceChartingKey("151", "Mew", "151"),
+		BuildKey("pc", "151", "Mew", "151", "reverse holo"),
+		BuildKey("sales", "151", "Mew", "151"),
+		BuildKey("gamestop", "151", "Mew", "151"),
+		BuildKey("pop", "card", "151", "Mew", "151"),
+		BuildKey("pop", "set", "151"),
+		PriceChartingKey("151", "Mewtwo", "150"),
+		PriceChartingKey("Base Set", "Mew", "8"),
+	}
+	for _, key := range keys {
+		_ = c.Put(key, 1, 0)
+	}
+
+	n, err := c.InvalidateCard("151", "Mew", "151")
+	if err != nil || n != 5 {
+		t.Fatalf("InvalidateCard(card) = %d, %v; want 5", n, err)
+	}
+	n, err = c.InvalidateCard("151", "", "")
+	if err != nil || n != 2 {
+		t.Fatalf("InvalidateCard(set) = %d, %v; want 2", n, err)
+	}
+	var v int
+	if found, _ := c.Get(PriceChartingKey("Base Set", "Mew", "8"), &v); !found {
+		t.Error("expected another set's entry to survive")
+	}
+}
+
+func TestMultiLayerCache_InvalidatePrefix(t *testing.T) {
+	mc, err := NewMultiLayerCache(CacheConfig{L2Path: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = mc.Set(PriceChartingKey("151", "Mew", "151"), 1, time.Hour)
+	_ = mc.Set(BuildKey("pc", "151", "Mew", "151", "reverse holo"), 2, time.Hour)
+	_ = mc.Set(PriceChartingKey("151", "Mewtwo", "150"), 3, time.Hour)
+
+	n, err := mc.InvalidatePrefix(PriceChartingKey("151", "Mew", "151"))
+	if err != nil || n == 0 {
+		t.Fatalf("InvalidatePrefix = %d, %v", n, err)
+	}
+	if _, found := mc.Get(BuildKey("pc", "151", "Mew", "151", "reverse holo")); found {
+		t.Error("expected the variant entry to be gone")
+	}
+	if _, found := mc.Get(PriceChartingKey("151", "Mewtwo", "150")); !found {
+		t.Error("expected another card's entry to survive")
+	}
+}
+
+func TestMultiLayerCache_BackingStats(t *testing.T) {
+	c := NewMemory()
+	mc, err := NewMultiLayerCache(CacheConfig{L2Path: t.TempDir(), Backing: c})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := PriceChartingKey("151", "Mew", "151")
+	_ = mc.Set(key, 1, time.Hour)
+	if _, found := mc.Get(key); !found {
+		t.Fatal("expected the hot layer to serve the entry")
+	}
+	// A hot miss falls through to the cache, which counts it once
+	var v int
+	other := PriceChartingKey("151", "Mewtwo", "150")
+	if _, found := mc.Get(other); found {
+		t.Fatal("expected a miss")
+	}
+	_, _ = c.Get(other, &v)
+
+	stats := c.Stats()
+	if got := stats.Namespaces[NamespacePriceCharting]; got.Hits != 1 || got.Misses != 1 {
+		t.Errorf("expected the hot hit and the fallen-through miss counted once each, got %+v", got)
+	}
+	if mc.GetStats().Counts != stats.Counts {
+		t.Errorf("expected the hot layer to report its backing cache's stats, got %+v", mc.GetStats().Counts)
+	}
+
+	standalone, err := NewMultiLayerCache(CacheConfig{L2Path: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	standalone.Get(key)
+	if got := standalone.GetStats().Counts; got.Misses != 1 {
+		t.Errorf("expected a layer without a backing cache to count its own misses, got %+v", got)
+	}
+}
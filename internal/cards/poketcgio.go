@@ -16,11 +16,11 @@ import (
 
 type PokeTCGIO struct {
 	apiKey string
-	cache  *cache.Cache
+	cache  cache.Store
 	client *http.Client
 }
 
-func NewPokeTCGIO(apiKey string, c *cache.Cache) *PokeTCGIO {
+func NewPokeTCGIO(apiKey string, c cache.Store) *PokeTCGIO {
 	return &PokeTCGIO{
 		apiKey: apiKey,
 		cache:  c,
@@ -162,7 +162,7 @@ func TestNewPokeTCGIO_Comprehensive(t *testing.T) {
 	tests := []struct {
 		name         string
 		apiKey       string
-		cache        *cache.Cache
+		cache        cache.Store
 		expectNilKey bool
 	}{
 		{
@@ -0,0 +1,160 @@
+// Package currency converts prices between currencies. Rates come from a
+// Provider: a rates file loaded with Load, or the fixed stand-in rates that
+// keep conversions working offline when no file is configured.
+package currency
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Currency codes
+const (
+	USD = "USD"
+	EUR = "EUR"
+	GBP = "GBP"
+	CAD = "CAD"
+	JPY = "JPY"
+)
+
+const dateLayout = "2006-01-02"
+
+// Rate is the price of one unit of From in To
+type Rate struct {
+	From   string
+	To     string
+	Value  float64
+	AsOf   time.Time // zero when the source doesn't date its rates
+	Source string    // "fixed" or the rates file the rate came from
+}
+
+// Convert converts an amount in From to To
+func (r Rate) Convert(amount float64) float64 {
+	return amount * r.Value
+}
+
+// String describes the rate for notes, e.g. "EUR→USD @1.0870 (fixed)"
+func (r Rate) String() string {
+	s := fmt.Sprintf("%s→%s @%.4f (%s", r.From, r.To, r.Value, r.Source)
+	if !r.AsOf.IsZero() {
+		s += " " + r.AsOf.Format(dateLayout)
+	}
+	return s + ")"
+}
+
+// Provider supplies exchange rates
+type Provider interface {
+	Rate(from, to string) (Rate, error)
+}
+
+// Table holds rates against one base currency: Rates["EUR"] is how many
+// euros one unit of Base buys
+type Table struct {
+	Base   string
+	AsOf   time.Time
+	Rates  map[string]float64
+	Source string
+}
+
+type tableJSON struct {
+	Base  string             `json:"base"`
+	AsOf  string             `json:"asOf,omitempty"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// Rate returns the rate between two currencies in the table
+func (t *Table) Rate(from, to string) (Rate, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	r := Rate{From: from, To: to, Value: 1, AsOf: t.AsOf, Source: t.Source}
+	if from == to {
+		return r, nil
+	}
+	f, err := t.perBase(from)
+	if err != nil {
+		return Rate{}, err
+	}
+	v, err := t.perBase(to)
+	if err != nil {
+		return Rate{}, err
+	}
+	r.Value = v / f
+	return r, nil
+}
+
+// perBase returns how many units of code one unit of the base buys
+func (t *Table) perBase(code string) (float64, error) {
+	if code == strings.ToUpper(t.Base) {
+		return 1, nil
+	}
+	if v, ok := t.Rates[code]; ok && v > 0 {
+		return v, nil
+	}
+	return 0, fmt.Errorf("no %s rate in %s rates", code, t.Source)
+}
+
+// Fixed returns stand-in rates for when no rates file is configured. They
+// are approximate and only meant to keep conversions working offline.
+func Fixed() *Table {
+	return &Table{
+		Base:   USD,
+		Rates:  map[string]float64{EUR: 0.92, GBP: 0.79, CAD: 1.36, JPY: 150},
+		Source: "fixed",
+	}
+}
+
+// Parse reads a rates file:
+//
+//	{"base": "USD", "asOf": "2024-05-01", "rates": {"EUR": 0.92, "GBP": 0.79}}
+func Parse(data []byte, source string) (*Table, error) {
+	var raw tableJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse rates: %w", err)
+	}
+	if raw.Base == "" || len(raw.Rates) == 0 {
+		return nil, fmt.Errorf("rates file %s needs a base currency and rates", source)
+	}
+	t := &Table{Base: strings.ToUpper(raw.Base), Rates: make(map[string]float64, len(raw.Rates)), Source: source}
+	for code, v := range raw.Rates {
+		if v <= 0 {
+			return nil, fmt.Errorf("rates file %s: %s rate must be positive", source, code)
+		}
+		t.Rates[strings.ToUpper(code)] = v
+	}
+	if raw.AsOf != "" {
+		asOf, err := time.Parse(dateLayout, raw.AsOf)
+		if err != nil {
+			return nil, fmt.Errorf("rates file %s: asOf: %w", source, err)
+		}
+		t.AsOf = asOf
+	}
+	return t, nil
+}
+
+// Load reads a rates file from disk
+func Load(path string) (*Table, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rates: %w", err)
+	}
+	return Parse(data, path)
+}
+
+var symbols = map[string]string{USD: "$", EUR: "€", GBP: "£", CAD: "CA$", JPY: "¥"}
+
+// Symbol returns the currency's symbol, or the code and a space when it
+// has none
+func Symbol(code string) string {
+	if s, ok := symbols[strings.ToUpper(code)]; ok {
+		return s
+	}
+	return strings.ToUpper(code) + " "
+}
+
+// Format formats an amount as money, e.g. "€12.34"
+func Format(amount float64, code string) string {
+	return Symbol(code) + strconv.FormatFloat(amount, 'f', 2, 64)
+}
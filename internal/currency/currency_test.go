@@ -0,0 +1,70 @@
+package currency
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTable_Rate(t *testing.T) {
+	tab := Fixed()
+
+	r, err := tab.Rate(EUR, USD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Value-1/0.92) > 1e-9 || r.Source != "fixed" {
+		t.Errorf("unexpected EUR→USD rate %+v", r)
+	}
+	if got := r.Convert(92); math.Abs(got-100) > 1e-9 {
+		t.Errorf("expected €92 to be $100, got %.4f", got)
+	}
+	if r.String() != "EUR→USD @1.0870 (fixed)" {
+		t.Errorf("unexpected rate note %q", r.String())
+	}
+
+	if r, _ := tab.Rate("usd", "USD"); r.Value != 1 {
+		t.Errorf("expected identity rate, got %v", r.Value)
+	}
+	if r, _ := tab.Rate(GBP, EUR); math.Abs(r.Value-0.92/0.79) > 1e-9 {
+		t.Errorf("expected a cross rate through the base, got %v", r.Value)
+	}
+	if _, err := tab.Rate("XYZ", USD); err == nil {
+		t.Error("expected an error for an unknown currency")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	if err := os.WriteFile(path, []byte(`{"base": "eur", "asOf": "2024-05-01", "rates": {"usd": 1.1}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tab, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := tab.Rate(EUR, USD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Value != 1.1 || !strings.Contains(r.String(), "rates.json 2024-05-01") {
+		t.Errorf("unexpected rate %s", r)
+	}
+
+	for _, bad := range []string{`{"rates": {"EUR": 1}}`, `{"base": "USD", "rates": {"EUR": 0}}`, `{"base": "USD", "asOf": "May", "rates": {"EUR": 1}}`, `nope`} {
+		if _, err := Parse([]byte(bad), "test"); err == nil {
+			t.Errorf("expected %s to be rejected", bad)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if got := Format(12.5, EUR); got != "€12.50" {
+		t.Errorf("Format = %q", got)
+	}
+	if got := Format(3, "chf"); got != "CHF 3.00" {
+		t.Errorf("Format = %q", got)
+	}
+}
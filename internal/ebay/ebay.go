@@ -6,12 +6,13 @@ import (
 	"io"
 	"net/http"
 	"net/url"
-	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/listing"
 )
 
 type Listing struct {
@@ -75,8 +76,12 @@ type Bid struct {
 	Timestamp time.Time
 }
 
+// findingEndpoint is the eBay Finding API
+const findingEndpoint = "https://svcs.ebay.com/services/search/FindingService/v1"
+
 type Client struct {
 	appID       string
+	findingURL  string
 	httpClient  *http.Client
 	rateLimiter *rateLimiter
 }
@@ -118,6 +123,7 @@ type findingResponse struct {
 func NewClient(appID string) *Client {
 	return &Client{
 		appID:      appID,
+		findingURL: findingEndpoint,
 		httpClient: &http.Client{Timeout: 15 * time.Second},
 		rateLimiter: &rateLimiter{
 			minDelay: 1 * time.Second, // eBay Finding API has 5000 calls/day limit = ~1 call per 17 seconds, but we'll be conservative
@@ -153,7 +159,7 @@ func (c *Client) SearchRawListings(setName, cardName, number string, max int) ([
 		setName, cardName, number)
 
 	// eBay Finding API endpoint
-	endpoint := "https://svcs.ebay.com/services/search/FindingService/v1"
+	endpoint := c.findingURL
 
 	params := url.Values{}
 	params.Set("OPERATION-NAME", "findItemsAdvanced")
@@ -247,8 +253,8 @@ func (c *Client) SearchRawListings(setName, cardName, number string, max int) ([
 				continue // Skip malformed items
 			}
 
-			// Filter out graded cards by title
-			if c.isGradedCard(listing.Title) {
+			// Keep single raw cards; slabs, lots and proxies would skew raw comps
+			if !isRawSingle(listing.Title) {
 				continue
 			}
 
@@ -335,10 +341,9 @@ func (c *Client) parseItem(item struct {
 	return listing, nil
 }
 
-var gradedPattern = regexp.MustCompile(`(?i)\b(psa|bgs|cgc|sgc|beckett|graded|slab|slabbed|authenticated|gem\s+mint|pristine|black\s+label|perfect\s+10|mint\s+9|nm-mt\s+8)\b`)
-
-func (c *Client) isGradedCard(title string) bool {
-	return gradedPattern.MatchString(title)
+// isRawSingle reports whether a title is one genuine, ungraded card
+func isRawSingle(title string) bool {
+	return listing.Parse(title).SingleRaw()
 }
 
 func (c *Client) sortByListingType(listings []Listing) {
@@ -365,7 +370,7 @@ func (c *Client) GetEndingAuctions(minutesRemaining int, category string) ([]Auc
 	c.rateLimiter.wait()
 
 	// eBay Finding API endpoint
-	endpoint := "https://svcs.ebay.com/services/search/FindingService/v1"
+	endpoint := c.findingURL
 
 	params := url.Values{}
 	params.Set("OPERATION-NAME", "findItemsAdvanced")
@@ -449,8 +454,8 @@ func (c *Client) GetEndingAuctions(minutesRemaining int, category string) ([]Auc
 				continue // Skip malformed items
 			}
 
-			// Filter out graded cards by title
-			if c.isGradedCard(auction.Title) {
+			// Only single raw cards are worth buying to grade
+			if !isRawSingle(auction.Title) {
 				continue
 			}
 
@@ -586,7 +591,7 @@ func (c *Client) GetAuctionDetails(itemID string) (*AuctionDetail, error) {
 	// For now, use the Finding API to get basic auction info
 	// In a production environment, you'd want to use the Shopping API or Trading API
 	// for more detailed information
-	endpoint := "https://svcs.ebay.com/services/search/FindingService/v1"
+	endpoint := c.findingURL
 
 	params := url.Values{}
 	params.Set("OPERATION-NAME", "findItemsAdvanced")
@@ -7,8 +7,6 @@ import (
 	"strings"
 	"testing"
 	"time"
-
-	"github.com/guarzo/pkmgradegap/internal/testutil"
 )
 
 // MockClient is a test-only implementation
@@ -199,30 +197,29 @@ func TestMockClient_ErrorHandling(t *testing.T) {
 	}
 }
 
-func TestClient_IsGradedCard(t *testing.T) {
-	client := NewClient(testutil.GetTestEbayAppID()) // Use test key from environment or default
-
+func TestIsRawSingle(t *testing.T) {
 	tests := []struct {
 		title    string
 		expected bool
 	}{
-		{"Pokemon Charizard PSA 10", true},
-		{"BGS 9.5 Pikachu Card", true},
-		{"CGC 9 Mint Card", true},
-		{"Pokemon Raw Charizard NM", false},
-		{"Ungraded Pikachu Card", false}, // "Ungraded" is raw, not graded
-		{"Pokemon Cards Lot", false},
-		{"PSA Ready Card", true},         // PSA mentioned
-		{"Authentic slab card", true},    // slab mentioned
-		{"Perfect 10 Gem Mint", true},    // perfect 10 mentioned
-		{"Near Mint Card", false},        // Just condition, not graded
-		{"Graded by professional", true}, // graded mentioned
+		{"Pokemon Charizard PSA 10", false},
+		{"BGS 9.5 Pikachu Card", false},
+		{"CGC 9 Mint Card", false},
+		{"Pokemon Raw Charizard NM", true},
+		{"Ungraded Pikachu Card", true},   // "Ungraded" is raw, not graded
+		{"Pokemon Cards Lot", false},      // several cards
+		{"PSA Ready Card", true},          // a grading candidate is still raw
+		{"Authentic slab card", false},    // slab mentioned
+		{"Perfect 10 Gem Mint", false},    // perfect 10 mentioned
+		{"Near Mint Card", true},          // Just condition, not graded
+		{"Graded by professional", false}, // graded mentioned
+		{"Charizard Proxy Holo", false},   // not a real card
 	}
 
 	for _, test := range tests {
-		result := client.isGradedCard(test.title)
+		result := isRawSingle(test.title)
 		if result != test.expected {
-			t.Errorf("isGradedCard(%q) = %v, want %v", test.title, result, test.expected)
+			t.Errorf("isRawSingle(%q) = %v, want %v", test.title, result, test.expected)
 		}
 	}
 }
@@ -1,5 +1,7 @@
 package ebay
 
+import "github.com/guarzo/pkmgradegap/internal/sales"
+
 // Provider defines the interface for eBay listing providers
 type Provider interface {
 	Available() bool
@@ -8,3 +10,14 @@ type Provider interface {
 
 // Ensure Client implements Provider
 var _ Provider = (*Client)(nil)
+
+// SoldProvider searches completed eBay sales
+type SoldProvider interface {
+	Available() bool
+	SearchSoldListings(setName, cardName, number string, max int) ([]sales.SaleRecord, error)
+}
+
+var (
+	_ SoldProvider   = (*Client)(nil)
+	_ sales.Provider = (*SalesProvider)(nil)
+)
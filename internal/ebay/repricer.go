@@ -9,6 +9,7 @@ import (
 
 	"github.com/guarzo/pkmgradegap/internal/model"
 	"github.com/guarzo/pkmgradegap/internal/prices"
+	"github.com/guarzo/pkmgradegap/internal/sales"
 )
 
 // PriceSuggestion represents pricing recommendation for a listing
@@ -410,6 +411,19 @@ func (r *Repricer) fetchMarketData(listing UserListing) (*MarketData, error) {
 		}
 	}
 
+	// Get what the card actually sold for in the listing's grade
+	if r.findingClient != nil && r.findingClient.Available() {
+		sold, err := r.findingClient.SearchSoldListings(
+			listing.SetName,
+			listing.CardName,
+			listing.CardNumber,
+			50,
+		)
+		if err == nil {
+			marketData.RecentSales = soldComps(sold, listingGrade(listing))
+		}
+	}
+
 	// Get prices from PriceCharting if available
 	if r.priceProvider != nil && r.priceProvider.Available() {
 		// Create a model.Card for lookup
@@ -456,3 +470,9 @@ func (r *Repricer) fetchMarketData(listing UserListing) (*MarketData, error) {
 
 	return marketData, nil
 }
+
+// listingGrade reads the grade of the card being sold from the listing
+// title; raw listings return GradeRaw
+func listingGrade(listing UserListing) string {
+	return sales.NormalizeGrade("", listing.Title)
+}
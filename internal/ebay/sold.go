@@ -0,0 +1,196 @@
+package ebay
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/sales"
+)
+
+// completedResponse is the Finding API's findCompletedItems response
+type completedResponse struct {
+	FindCompletedItemsResponse []struct {
+		SearchResult []struct {
+			Item []struct {
+				Title         []string `json:"title"`
+				SellingStatus []struct {
+					CurrentPrice []struct {
+						Value      []string `json:"__value__"`
+						CurrencyID []string `json:"@currencyId"`
+					} `json:"currentPrice"`
+					SellingState []string `json:"sellingState"`
+				} `json:"sellingStatus"`
+				ListingInfo []struct {
+					EndTime []string `json:"endTime"`
+				} `json:"listingInfo"`
+			} `json:"item"`
+		} `json:"searchResult"`
+	} `json:"findCompletedItemsResponse"`
+}
+
+// SearchSoldListings returns a card's recently sold eBay listings, newest
+// first, as sale records with the grade read from each title. Unsold and
+// non-USD listings are skipped.
+func (c *Client) SearchSoldListings(setName, cardName, number string, max int) ([]sales.SaleRecord, error) {
+	// Unlike the raw search, graded copies are kept; the grade comes from the title
+	return c.searchSold(fmt.Sprintf("pokemon \"%s\" \"%s\" #%s", setName, cardName, number), max)
+}
+
+// searchSold runs a sold-only completed-items search for query
+func (c *Client) searchSold(query string, max int) ([]sales.SaleRecord, error) {
+	if !c.Available() {
+		return nil, fmt.Errorf("eBay app ID not configured")
+	}
+
+	// Apply rate limiting
+	c.rateLimiter.wait()
+
+	params := url.Values{}
+	params.Set("OPERATION-NAME", "findCompletedItems")
+	params.Set("SERVICE-VERSION", "1.0.0")
+	params.Set("SECURITY-APPNAME", c.appID)
+	params.Set("RESPONSE-DATA-FORMAT", "JSON")
+	params.Set("keywords", query)
+	params.Set("categoryId", "183454") // Trading Card Games category
+
+	params.Set("itemFilter(0).name", "SoldItemsOnly")
+	params.Set("itemFilter(0).value(0)", "true")
+
+	params.Set("paginationInput.entriesPerPage", strconv.Itoa(max))
+	params.Set("sortOrder", "EndTimeSoonest")
+
+	req, err := http.NewRequest("GET", c.findingURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("X-EBAY-SOA-SERVICE-NAME", "FindingService")
+	req.Header.Set("X-EBAY-SOA-OPERATION-NAME", "findCompletedItems")
+	req.Header.Set("X-EBAY-SOA-SERVICE-VERSION", "1.0.0")
+	req.Header.Set("X-EBAY-SOA-SECURITY-APPNAME", c.appID)
+	req.Header.Set("X-EBAY-SOA-RESPONSE-DATA-FORMAT", "JSON")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("eBay API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("eBay API returned status %d", resp.StatusCode)
+	}
+
+	var ebayResp completedResponse
+	if err := json.Unmarshal(bodyBytes, &ebayResp); err != nil {
+		return nil, fmt.Errorf("parse eBay response: %w", err)
+	}
+
+	var records []sales.SaleRecord
+	if len(ebayResp.FindCompletedItemsResponse) > 0 &&
+		len(ebayResp.FindCompletedItemsResponse[0].SearchResult) > 0 {
+
+		for _, item := range ebayResp.FindCompletedItemsResponse[0].SearchResult[0].Item {
+			if len(item.Title) == 0 || len(item.SellingStatus) == 0 || len(item.SellingStatus[0].CurrentPrice) == 0 {
+				continue // Skip malformed items
+			}
+			status := item.SellingStatus[0]
+			if len(status.SellingState) > 0 && status.SellingState[0] != "EndedWithSales" {
+				continue
+			}
+			price := status.CurrentPrice[0]
+			if len(price.CurrencyID) > 0 && price.CurrencyID[0] != "USD" {
+				continue
+			}
+			if len(price.Value) == 0 {
+				continue
+			}
+			value, err := strconv.ParseFloat(price.Value[0], 64)
+			if err != nil || value <= 0 {
+				continue
+			}
+
+			record := sales.SaleRecord{
+				Price:    value,
+				Grade:    sales.NormalizeGrade("", item.Title[0]),
+				Platform: "eBay",
+				Title:    item.Title[0],
+			}
+			if len(item.ListingInfo) > 0 && len(item.ListingInfo[0].EndTime) > 0 {
+				if endTime, err := time.Parse(time.RFC3339, item.ListingInfo[0].EndTime[0]); err == nil {
+					record.Date = endTime
+				}
+			}
+			records = append(records, record)
+		}
+	}
+
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Date.After(records[j].Date) })
+	if len(records) > max {
+		records = records[:max]
+	}
+	return records, nil
+}
+
+// SalesProvider serves eBay sold listings through the sales.Provider
+// interface
+type SalesProvider struct {
+	client SoldProvider
+	max    int
+}
+
+// NewSalesProvider creates a sales provider that reads up to max sold
+// listings per card
+func NewSalesProvider(client SoldProvider, max int) *SalesProvider {
+	if max <= 0 {
+		max = 50
+	}
+	return &SalesProvider{client: client, max: max}
+}
+
+// Available returns true when the eBay client is configured
+func (p *SalesProvider) Available() bool {
+	return p.client != nil && p.client.Available()
+}
+
+// GetProviderName returns the provider name
+func (p *SalesProvider) GetProviderName() string {
+	return "eBay"
+}
+
+// IsMockMode returns false; the data comes from eBay
+func (p *SalesProvider) IsMockMode() bool {
+	return false
+}
+
+// GetSalesData returns a card's sold eBay listings, or nil when none sold
+func (p *SalesProvider) GetSalesData(setName, cardName, number string) (*sales.SalesData, error) {
+	records, err := p.client.SearchSoldListings(setName, cardName, number, p.max)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	return sales.NewSalesData(setName, cardName, number, p.GetProviderName(), records), nil
+}
+
+// soldComps returns the prices of sold listings in the given grade
+func soldComps(records []sales.SaleRecord, grade string) []float64 {
+	var comps []float64
+	for _, r := range records {
+		if strings.EqualFold(r.Grade, grade) {
+			comps = append(comps, r.Price)
+		}
+	}
+	return comps
+}
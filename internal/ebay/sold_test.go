@@ -0,0 +1,136 @@
+package ebay
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+const completedJSON = `{"findCompletedItemsResponse": [{"searchResult": [{"item": [
+	{"title": ["Pokemon Evolving Skies Umbreon VMAX 215/203 PSA 10 GEM MINT"],
+	 "sellingStatus": [{"currentPrice": [{"__value__": ["410.00"], "@currencyId": ["USD"]}], "sellingState": ["EndedWithSales"]}],
+	 "listingInfo": [{"endTime": ["2024-05-02T18:00:00.000Z"]}]},
+	{"title": ["Umbreon VMAX 215/203 Evolving Skies NM"],
+	 "sellingStatus": [{"currentPrice": [{"__value__": ["95.00"], "@currencyId": ["USD"]}], "sellingState": ["EndedWithSales"]}],
+	 "listingInfo": [{"endTime": ["2024-05-04T18:00:00.000Z"]}]},
+	{"title": ["Umbreon VMAX 215 Evolving Skies"],
+	 "sellingStatus": [{"currentPrice": [{"__value__": ["105.00"], "@currencyId": ["USD"]}], "sellingState": ["EndedWithSales"]}],
+	 "listingInfo": [{"endTime": ["2024-05-01T18:00:00.000Z"]}]},
+	{"title": ["Umbreon VMAX 215 unsold"],
+	 "sellingStatus": [{"currentPrice": [{"__value__": ["60.00"], "@currencyId": ["USD"]}], "sellingState": ["EndedWithoutSales"]}]},
+	{"title": ["Umbreon VMAX 215 UK"],
+	 "sellingStatus": [{"currentPrice": [{"__value__": ["80.00"], "@currencyId": ["GBP"]}], "sellingState": ["EndedWithSales"]}]}
+]}]}]}`
+
+// newFindingServer stubs the Finding API: sold searches get completedJSON,
+// active searches get no items
+func newFindingServer(t *testing.T) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("OPERATION-NAME") == "findCompletedItems" {
+			if r.URL.Query().Get("itemFilter(0).name") != "SoldItemsOnly" {
+				t.Errorf("expected a sold-only search, got %s", r.URL.RawQuery)
+			}
+			w.Write([]byte(completedJSON))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient("app")
+	c.findingURL = srv.URL
+	c.rateLimiter.minDelay = 0
+	return c
+}
+
+func TestClient_SearchSoldListings(t *testing.T) {
+	c := newFindingServer(t)
+	records, err := c.SearchSoldListings("Evolving Skies", "Umbreon VMAX", "215", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected unsold and non-USD items skipped, got %d records", len(records))
+	}
+	want := []struct {
+		price float64
+		grade string
+	}{{95, "Raw"}, {410, "PSA 10"}, {105, "Raw"}}
+	for i, w := range want {
+		r := records[i]
+		if r.Price != w.price || r.Grade != w.grade || r.Platform != "eBay" || r.Date.IsZero() {
+			t.Errorf("record %d: got %+v, want %v %s newest first", i, r, w.price, w.grade)
+		}
+	}
+
+	if _, err := NewClient("").SearchSoldListings("s", "c", "1", 10); err == nil {
+		t.Error("expected error without an app ID")
+	}
+}
+
+func TestSalesProvider(t *testing.T) {
+	p := NewSalesProvider(newFindingServer(t), 0)
+	sd, err := p.GetSalesData("Evolving Skies", "Umbreon VMAX", "215")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sd.SaleCount != 3 || sd.MedianPrice != 100 || sd.DataSource != "eBay" {
+		t.Errorf("unexpected sales data %+v", sd)
+	}
+}
+
+func TestTradingClient_GetCompetitorPrices(t *testing.T) {
+	c := &TradingClient{finding: newFindingServer(t)}
+
+	// The grade comes from the condition, or the title when it names none
+	for _, tc := range []struct {
+		title, condition string
+		want             []float64
+	}{
+		{"Umbreon VMAX 215 Evolving Skies", "PSA 10", []float64{410}},
+		{"Umbreon VMAX 215 Evolving Skies PSA 10", "Graded", []float64{410}},
+		{"Umbreon VMAX 215 Evolving Skies", "Near Mint", []float64{95, 105}},
+	} {
+		got, err := c.GetCompetitorPrices(tc.title, tc.condition)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q in %q: got %v, want %v", tc.title, tc.condition, got, tc.want)
+		}
+	}
+
+	if _, err := (&TradingClient{finding: NewClient("")}).GetCompetitorPrices("x", "PSA 10"); err == nil {
+		t.Error("expected error without an app ID")
+	}
+}
+
+func TestRepricer_UsesSoldComps(t *testing.T) {
+	r := NewRepricer(nil, newFindingServer(t))
+
+	raw, err := r.fetchMarketData(UserListing{Title: "Umbreon VMAX 215/203 Evolving Skies", SetName: "Evolving Skies", CardName: "Umbreon VMAX", CardNumber: "215"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw.RecentSales) != 2 {
+		t.Errorf("expected the two raw sales as comps for a raw listing, got %v", raw.RecentSales)
+	}
+
+	slab, err := r.fetchMarketData(UserListing{Title: "Umbreon VMAX 215 PSA 10", SetName: "Evolving Skies", CardName: "Umbreon VMAX", CardNumber: "215"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slab.RecentSales) != 1 || slab.RecentSales[0] != 410 {
+		t.Errorf("expected only the PSA 10 sale as a comp, got %v", slab.RecentSales)
+	}
+
+	suggestion, err := r.AnalyzeListing(UserListing{CurrentPrice: 600}, *slab)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suggestion.RecentSalesAvg != 410 || suggestion.Action != "DECREASE" {
+		t.Errorf("expected a cut toward the sold price, got %s at avg %.2f", suggestion.Action, suggestion.RecentSalesAvg)
+	}
+}
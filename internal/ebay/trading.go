@@ -2,11 +2,14 @@ package ebay
 
 import (
 	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/sales"
 )
 
 // TradingClient handles eBay Trading API and Inventory API operations
 type TradingClient struct {
 	tradingAPI   *TradingAPIClient
+	finding      *Client // Finding API, for sold comparables
 	oauthManager *OAuthManager
 	sandbox      bool
 }
@@ -53,6 +56,7 @@ type ListingSummary struct {
 func NewTradingClient(oauthManager *OAuthManager, appID string, sandbox bool) *TradingClient {
 	return &TradingClient{
 		tradingAPI:   NewTradingAPIClient(oauthManager, appID, sandbox),
+		finding:      NewClient(appID),
 		oauthManager: oauthManager,
 		sandbox:      sandbox,
 	}
@@ -98,11 +102,17 @@ func (c *TradingClient) GetListingSummary(userID string) (*ListingSummary, error
 
 // This method is now implemented in TradingAPIClient
 
-// GetCompetitorPrices fetches competitor prices for similar items
+// GetCompetitorPrices returns what items matching title sold for on eBay in
+// the grade the title names, or else the one condition names; an ungraded
+// condition such as "Near Mint" compares with raw sales
 func (c *TradingClient) GetCompetitorPrices(title string, condition string) ([]float64, error) {
-	// This would use the Finding API (already implemented in ebay.go)
-	// Reuse existing Client for Finding API calls
-
-	// For now, return empty slice (would integrate with existing Finding API)
-	return []float64{}, nil
+	records, err := c.finding.searchSold(title, 50)
+	if err != nil {
+		return nil, err
+	}
+	grade := sales.NormalizeGrade("", title)
+	if grade == sales.GradeRaw {
+		grade = sales.NormalizeGrade(condition, "")
+	}
+	return soldComps(records, grade), nil
 }
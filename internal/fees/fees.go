@@ -0,0 +1,198 @@
+// Package fees holds grading fee schedules. Schedules carry an effective
+// date so a snapshot can be priced with the fees that applied when it was
+// taken, and the built-in book can be replaced with an edited JSON file.
+package fees
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FormatVersion is the schedule file version this package reads
+const FormatVersion = 1
+
+const dateLayout = "2006-01-02"
+
+//go:embed schedules.json
+var builtin []byte
+
+// ServiceLevel is one tier of a grader's service
+type ServiceLevel struct {
+	Name             string  `json:"name"`
+	CostPerCard      float64 `json:"costPerCard"`
+	TurnaroundDays   int     `json:"turnaroundDays"`
+	MaxDeclaredValue float64 `json:"maxDeclaredValue,omitempty"` // 0 means no cap
+	MinCards         int     `json:"minCards,omitempty"`
+}
+
+// Schedule is a grader's service levels from an effective date, cheapest first
+type Schedule struct {
+	Grader    string
+	Effective time.Time
+	Levels    []ServiceLevel
+}
+
+type scheduleJSON struct {
+	Grader    string         `json:"grader"`
+	Effective string         `json:"effective"`
+	Levels    []ServiceLevel `json:"levels"`
+}
+
+// MarshalJSON writes the effective date as YYYY-MM-DD
+func (s Schedule) MarshalJSON() ([]byte, error) {
+	return json.Marshal(scheduleJSON{s.Grader, s.Effective.Format(dateLayout), s.Levels})
+}
+
+// UnmarshalJSON reads the effective date as YYYY-MM-DD
+func (s *Schedule) UnmarshalJSON(data []byte) error {
+	var raw scheduleJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	effective, err := time.Parse(dateLayout, raw.Effective)
+	if err != nil {
+		return fmt.Errorf("schedule %s: effective date: %w", raw.Grader, err)
+	}
+	*s = Schedule{Grader: raw.Grader, Effective: effective, Levels: raw.Levels}
+	return nil
+}
+
+// Level returns the cheapest level accepting the declared value. ok is false
+// when the value exceeds every level's cap.
+func (s Schedule) Level(declaredValue float64) (ServiceLevel, bool) {
+	for _, l := range s.Levels {
+		if l.MaxDeclaredValue <= 0 || declaredValue <= l.MaxDeclaredValue {
+			return l, true
+		}
+	}
+	return ServiceLevel{}, false
+}
+
+// Fee returns the per-card fee for the declared value, charging the top
+// level when the value exceeds every cap
+func (s Schedule) Fee(declaredValue float64) float64 {
+	if l, ok := s.Level(declaredValue); ok {
+		return l.CostPerCard
+	}
+	if len(s.Levels) == 0 {
+		return 0
+	}
+	return s.Levels[len(s.Levels)-1].CostPerCard
+}
+
+// Book is every schedule for every grader, with history
+type Book struct {
+	Version   int        `json:"version"`
+	Schedules []Schedule `json:"schedules"`
+}
+
+// Parse reads and validates a schedule file
+func Parse(data []byte) (*Book, error) {
+	var b Book
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parse fee schedules: %w", err)
+	}
+	if b.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported fee schedule version %d (want %d)", b.Version, FormatVersion)
+	}
+	for _, s := range b.Schedules {
+		if err := s.validate(); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(b.Schedules, func(i, j int) bool {
+		return b.Schedules[i].Effective.Before(b.Schedules[j].Effective)
+	})
+	return &b, nil
+}
+
+func (s Schedule) validate() error {
+	if s.Grader == "" {
+		return fmt.Errorf("fee schedule missing grader")
+	}
+	if len(s.Levels) == 0 {
+		return fmt.Errorf("fee schedule %s %s has no levels", s.Grader, s.Effective.Format(dateLayout))
+	}
+	for i, l := range s.Levels {
+		if l.Name == "" || l.CostPerCard < 0 {
+			return fmt.Errorf("fee schedule %s: level %d needs a name and a non-negative cost", s.Grader, i+1)
+		}
+		if i > 0 {
+			prev := s.Levels[i-1].MaxDeclaredValue
+			if prev <= 0 || (l.MaxDeclaredValue > 0 && l.MaxDeclaredValue <= prev) {
+				return fmt.Errorf("fee schedule %s: levels must be ordered by declared value cap", s.Grader)
+			}
+		}
+	}
+	return nil
+}
+
+// Load reads a schedule file from disk
+func Load(path string) (*Book, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fee schedules: %w", err)
+	}
+	return Parse(data)
+}
+
+// At returns the grader's schedule in effect at t. Times before the first
+// schedule get the earliest one, which is the best record available. A nil
+// book is the built-in one.
+func (b *Book) At(grader string, t time.Time) (Schedule, error) {
+	if b == nil {
+		b = builtinBook
+	}
+	var found *Schedule
+	for i := range b.Schedules {
+		s := &b.Schedules[i]
+		if !strings.EqualFold(s.Grader, grader) {
+			continue
+		}
+		if found == nil || !s.Effective.After(t) {
+			found = s
+		}
+	}
+	if found == nil {
+		return Schedule{}, fmt.Errorf("no fee schedule for grader %q", grader)
+	}
+	return *found, nil
+}
+
+// Current returns the grader's schedule in effect now
+func (b *Book) Current(grader string) (Schedule, error) {
+	return b.At(grader, time.Now())
+}
+
+// builtinBook is the embedded schedules, parsed once
+var builtinBook *Book
+
+func init() {
+	b, err := Parse(builtin)
+	if err != nil {
+		panic(fmt.Sprintf("fees: built-in schedules: %v", err))
+	}
+	builtinBook = b
+}
+
+// Builtin returns the built-in schedules. The book is shared, so callers
+// must not modify it.
+func Builtin() *Book {
+	return builtinBook
+}
+
+// PSA returns the book's PSA schedule in effect at t, falling back to the
+// built-in schedule when the book has none. The built-in book always has
+// one, so callers needn't handle a missing schedule.
+func (b *Book) PSA(t time.Time) Schedule {
+	s, err := b.At("PSA", t)
+	if err != nil {
+		s, _ = builtinBook.At("PSA", t)
+	}
+	return s
+}
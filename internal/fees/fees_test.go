@@ -0,0 +1,125 @@
+package fees
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const historyJSON = `{
+  "version": 1,
+  "schedules": [
+    {"grader": "PSA", "effective": "2025-06-01", "levels": [
+      {"name": "Value", "costPerCard": 25, "turnaroundDays": 60, "maxDeclaredValue": 499, "minCards": 20},
+      {"name": "Regular", "costPerCard": 75, "turnaroundDays": 20}
+    ]},
+    {"grader": "PSA", "effective": "2023-01-01", "levels": [
+      {"name": "Value", "costPerCard": 19, "turnaroundDays": 65, "maxDeclaredValue": 199, "minCards": 20},
+      {"name": "Regular", "costPerCard": 39, "turnaroundDays": 30}
+    ]}
+  ]
+}`
+
+func date(s string) time.Time {
+	t, _ := time.Parse(dateLayout, s)
+	return t
+}
+
+func TestBuiltinSchedules(t *testing.T) {
+	b := Builtin()
+	for _, grader := range []string{"PSA", "BGS", "CGC", "SGC"} {
+		if _, err := b.Current(grader); err != nil {
+			t.Errorf("built-in book missing %s: %v", grader, err)
+		}
+	}
+	psa := Builtin().PSA(time.Now())
+	if l, ok := psa.Level(300); !ok || l.Name != "Value Plus" || l.CostPerCard != 25 {
+		t.Errorf("expected Value Plus at $25 for $300, got %+v", l)
+	}
+}
+
+func TestBook_AtUsesScheduleInEffect(t *testing.T) {
+	b, err := Parse([]byte(historyJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		when string
+		fee  float64
+	}{
+		{"2022-05-01", 19}, // before any schedule: earliest
+		{"2024-12-31", 19},
+		{"2025-06-01", 25},
+		{"2026-01-01", 25},
+	}
+	for _, tt := range tests {
+		s, err := b.At("psa", date(tt.when))
+		if err != nil {
+			t.Fatalf("At(%s): %v", tt.when, err)
+		}
+		if got := s.Fee(150); got != tt.fee {
+			t.Errorf("fee at %s = %.2f, want %.2f", tt.when, got, tt.fee)
+		}
+	}
+
+	if _, err := b.At("BGS", time.Now()); err == nil {
+		t.Error("expected error for grader without a schedule")
+	}
+}
+
+func TestSchedule_Level(t *testing.T) {
+	s := Builtin().PSA(date("2024-01-01"))
+	if l, _ := s.Level(800); l.Name != "Regular" {
+		t.Errorf("expected Regular for $800, got %s", l.Name)
+	}
+	if _, ok := s.Level(20000); ok {
+		t.Error("expected no level above every declared value cap")
+	}
+	if got := s.Fee(20000); got != 300 {
+		t.Errorf("expected top-level fee past the caps, got %.2f", got)
+	}
+}
+
+func TestParse_Rejects(t *testing.T) {
+	tests := map[string]string{
+		"version":     `{"version": 2, "schedules": []}`,
+		"date":        `{"version": 1, "schedules": [{"grader": "PSA", "effective": "June", "levels": [{"name": "A", "costPerCard": 1}]}]}`,
+		"no levels":   `{"version": 1, "schedules": [{"grader": "PSA", "effective": "2024-01-01", "levels": []}]}`,
+		"level order": `{"version": 1, "schedules": [{"grader": "PSA", "effective": "2024-01-01", "levels": [{"name": "A", "costPerCard": 1, "maxDeclaredValue": 500}, {"name": "B", "costPerCard": 2, "maxDeclaredValue": 100}]}]}`,
+	}
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fees.json")
+	if err := os.WriteFile(path, []byte(historyJSON), 0644); err != nil {
+		t.Fatal(err)
+	}
+	b, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := b.PSA(date("2025-07-01")).Fee(150); got != 25 {
+		t.Errorf("expected the loaded schedule, got fee %.2f", got)
+	}
+	var none *Book
+	if got := none.PSA(date("2025-07-01")).Fee(150); got != 19 {
+		t.Errorf("expected a nil book to use the built-in schedule, got fee %.2f", got)
+	}
+	noPSA := &Book{Version: FormatVersion, Schedules: []Schedule{{Grader: "CGC", Effective: date("2024-01-01"), Levels: []ServiceLevel{{Name: "Bulk", CostPerCard: 15}}}}}
+	if got := noPSA.PSA(date("2025-07-01")).Fee(150); got != 19 {
+		t.Errorf("expected a book without PSA to fall back to the built-in schedule, got fee %.2f", got)
+	}
+
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil || !strings.Contains(err.Error(), "read fee schedules") {
+		t.Errorf("expected read error, got %v", err)
+	}
+}
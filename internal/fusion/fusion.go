@@ -0,0 +1,330 @@
+// Package fusion combines prices for the same card and grade from several
+// sources into one consensus price. Observations are weighted by the kind
+// of source, how much the source trusts its own number, how fresh it is and
+// how many sales or listings stand behind it; prices far from the rest are
+// discarded before averaging.
+package fusion
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/currency"
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+// SourceType is the kind of evidence a price is
+type SourceType string
+
+const (
+	SourceTypeSale    SourceType = "sale"    // completed transactions
+	SourceTypeGuide   SourceType = "guide"   // aggregated price guides such as PriceCharting or TCGPlayer market
+	SourceTypeListing SourceType = "listing" // asking prices
+)
+
+// Grade keys shared with the GameStop provider and the analysis rows
+const (
+	GradeRaw   = "raw"
+	GradePSA9  = "psa9"
+	Grade95    = "grade95" // any company's 9.5; PriceCharting prices them together
+	GradePSA10 = "psa10"
+	GradeBGS10 = "bgs10"
+)
+
+// DataSource describes where a price came from
+type DataSource struct {
+	Name       string
+	Type       SourceType
+	Freshness  time.Duration // age of the observation
+	Volume     int           // sales or listings behind the price
+	Confidence float64       // 0-1, how much the source trusts this price; 0 means unknown
+	Timestamp  time.Time
+}
+
+// PriceData is one source's price for a card in one grade
+type PriceData struct {
+	Value    float64
+	Currency string // "" is treated as USD
+	Grade    string // one of the Grade keys
+	Source   DataSource
+}
+
+// FusedPrice is the consensus price for one grade
+type FusedPrice struct {
+	Value      float64
+	Confidence float64  // 0-1
+	Sources    []string // names of the sources that contributed, sorted
+	Used       int      // observations averaged
+	Outliers   int      // observations discarded as outliers
+	Warnings   []string
+}
+
+// FusedData holds a card's consensus prices by grade key
+type FusedData struct {
+	Card   model.Card
+	Prices map[string]FusedPrice
+}
+
+// Price returns the consensus value for a grade, or 0
+func (d *FusedData) Price(grade string) float64 {
+	if d == nil {
+		return 0
+	}
+	return d.Prices[grade].Value
+}
+
+// FusionEngine holds the weighting and outlier settings
+type FusionEngine struct {
+	TypeWeights      map[SourceType]float64
+	HalfLife         time.Duration // an observation this old counts half
+	StaleAfter       time.Duration // warn when the newest observation is older
+	OutlierThreshold float64       // discard prices more than this many robust deviations from the median
+	MinSpread        float64       // floor on the deviation, as a fraction of the median
+
+	Rates currency.Provider // converts non-USD prices to USD; nil skips them
+}
+
+// NewFusionEngine returns an engine with the default weights: sales count
+// most, then price guides, then asking prices
+func NewFusionEngine() *FusionEngine {
+	return &FusionEngine{
+		TypeWeights: map[SourceType]float64{
+			SourceTypeSale:    1.0,
+			SourceTypeGuide:   0.8,
+			SourceTypeListing: 0.5,
+		},
+		HalfLife:         14 * 24 * time.Hour,
+		StaleAfter:       30 * 24 * time.Hour,
+		OutlierThreshold: 3,
+		MinSpread:        0.1,
+	}
+}
+
+// FusePrice fuses prices with the default engine
+func FusePrice(prices []PriceData) FusedPrice {
+	return NewFusionEngine().FusePrice(prices)
+}
+
+type weighted struct {
+	PriceData
+	weight float64
+}
+
+// FusePrice produces a consensus from observations of a single grade.
+// Non-USD prices are converted with Rates, or skipped when it has no rate.
+// With three or more observations, prices far from the weighted median are
+// discarded; the rest are averaged by weight.
+func (e *FusionEngine) FusePrice(prices []PriceData) FusedPrice {
+	var result FusedPrice
+	var obs []weighted
+	skipped := 0
+	for _, p := range prices {
+		if p.Value <= 0 || math.IsNaN(p.Value) || math.IsInf(p.Value, 0) {
+			continue
+		}
+		if p.Currency != "" && !strings.EqualFold(p.Currency, currency.USD) {
+			usd, ok := e.toUSD(p)
+			if !ok {
+				skipped++
+				continue
+			}
+			p = usd
+		}
+		obs = append(obs, weighted{PriceData: p, weight: e.weight(p)})
+	}
+	if skipped > 0 {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("skipped %d non-USD prices", skipped))
+	}
+	if len(obs) == 0 {
+		return result
+	}
+
+	kept := obs
+	if len(obs) >= 3 {
+		kept = kept[:0:0]
+		var dropped []string
+		center := weightedMedian(obs)
+		limit := e.OutlierThreshold * e.spread(obs, center)
+		for _, o := range obs {
+			if math.Abs(o.Value-center) > limit {
+				dropped = append(dropped, fmt.Sprintf("%s $%.2f", o.Source.Name, o.Value))
+				continue
+			}
+			kept = append(kept, o)
+		}
+		result.Outliers = len(dropped)
+		if len(dropped) > 0 {
+			result.Warnings = append(result.Warnings, "discarded outliers: "+strings.Join(dropped, ", "))
+		}
+	}
+
+	var sum, total float64
+	for _, o := range kept {
+		sum += o.Value * o.weight
+		total += o.weight
+	}
+	result.Value = round2(sum / total)
+	result.Used = len(kept)
+	result.Sources = sourceNames(kept)
+	result.Confidence = e.confidence(kept, result.Value, len(result.Sources))
+
+	if len(result.Sources) == 1 {
+		result.Warnings = append(result.Warnings, "single source: "+result.Sources[0])
+	}
+	if len(kept) == 2 {
+		lo, hi := math.Min(kept[0].Value, kept[1].Value), math.Max(kept[0].Value, kept[1].Value)
+		if hi > 2*lo {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("sources disagree: $%.2f vs $%.2f", lo, hi))
+		}
+	}
+	if newest := newestAge(kept); e.StaleAfter > 0 && newest > e.StaleAfter {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("newest price is %d days old", int(newest.Hours()/24)))
+	}
+	return result
+}
+
+// FuseByGrade groups observations by grade key and fuses each group
+func (e *FusionEngine) FuseByGrade(prices []PriceData) map[string]FusedPrice {
+	groups := make(map[string][]PriceData)
+	for _, p := range prices {
+		if p.Grade == "" {
+			continue
+		}
+		groups[p.Grade] = append(groups[p.Grade], p)
+	}
+	result := make(map[string]FusedPrice, len(groups))
+	for grade, group := range groups {
+		if fused := e.FusePrice(group); fused.Value > 0 {
+			result[grade] = fused
+		}
+	}
+	return result
+}
+
+// Fuse builds the fused prices for a card
+func (e *FusionEngine) Fuse(card model.Card, prices []PriceData) *FusedData {
+	return &FusedData{Card: card, Prices: e.FuseByGrade(prices)}
+}
+
+// toUSD converts a price to USD at the engine's rates
+func (e *FusionEngine) toUSD(p PriceData) (PriceData, bool) {
+	if e.Rates == nil {
+		return p, false
+	}
+	rate, err := e.Rates.Rate(strings.ToUpper(p.Currency), currency.USD)
+	if err != nil {
+		return p, false
+	}
+	p.Value, p.Currency = rate.Convert(p.Value), currency.USD
+	return p, true
+}
+
+// weight is how much an observation counts toward the consensus
+func (e *FusionEngine) weight(p PriceData) float64 {
+	w, ok := e.TypeWeights[p.Source.Type]
+	if !ok {
+		w = 0.5
+	}
+	return w * sourceConfidence(p) * e.decay(p.Source.Freshness) * (1 + math.Log10(1+float64(max(p.Source.Volume, 0))))
+}
+
+// decay halves an observation's weight every half-life
+func (e *FusionEngine) decay(age time.Duration) float64 {
+	if e.HalfLife <= 0 || age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(e.HalfLife))
+}
+
+// spread is a robust estimate of how far prices normally sit from the
+// center: the scaled median absolute deviation, floored at MinSpread of the
+// center so near-identical prices don't make every difference an outlier
+func (e *FusionEngine) spread(obs []weighted, center float64) float64 {
+	devs := make([]float64, len(obs))
+	for i, o := range obs {
+		devs[i] = math.Abs(o.Value - center)
+	}
+	sort.Float64s(devs)
+	mad := devs[len(devs)/2]
+	if len(devs)%2 == 0 {
+		mad = (devs[len(devs)/2-1] + devs[len(devs)/2]) / 2
+	}
+	return math.Max(1.4826*mad, e.MinSpread*center)
+}
+
+// confidence combines the sources' own confidence and freshness with how
+// well they agree and how many independent sources there are
+func (e *FusionEngine) confidence(obs []weighted, value float64, sources int) float64 {
+	var base, variance, total float64
+	for _, o := range obs {
+		base += o.weight * sourceConfidence(o.PriceData) * e.decay(o.Source.Freshness)
+		variance += o.weight * (o.Value - value) * (o.Value - value)
+		total += o.weight
+	}
+	base /= total
+	agreement := 1.0
+	if value > 0 {
+		agreement = math.Max(0.2, 1-math.Sqrt(variance/total)/value)
+	}
+	coverage := 1.0
+	switch sources {
+	case 1:
+		coverage = 0.6
+	case 2:
+		coverage = 0.85
+	}
+	return round2(math.Min(1, base*agreement*coverage))
+}
+
+func sourceConfidence(p PriceData) float64 {
+	if p.Source.Confidence <= 0 {
+		return 0.5
+	}
+	return math.Min(p.Source.Confidence, 1)
+}
+
+// weightedMedian is the value at which half the weight lies on either side
+func weightedMedian(obs []weighted) float64 {
+	sorted := append([]weighted(nil), obs...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Value < sorted[j].Value })
+	var total float64
+	for _, o := range sorted {
+		total += o.weight
+	}
+	var acc float64
+	for _, o := range sorted {
+		acc += o.weight
+		if acc >= total/2 {
+			return o.Value
+		}
+	}
+	return sorted[len(sorted)-1].Value
+}
+
+func sourceNames(obs []weighted) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, o := range obs {
+		if !seen[o.Source.Name] {
+			seen[o.Source.Name] = true
+			names = append(names, o.Source.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func newestAge(obs []weighted) time.Duration {
+	newest := obs[0].Source.Freshness
+	for _, o := range obs[1:] {
+		newest = min(newest, o.Source.Freshness)
+	}
+	return newest
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
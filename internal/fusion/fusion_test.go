@@ -0,0 +1,166 @@
+package fusion
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/currency"
+	"github.com/guarzo/pkmgradegap/internal/listing"
+	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/prices"
+	"github.com/guarzo/pkmgradegap/internal/sales"
+)
+
+func obs(name string, kind SourceType, value float64) PriceData {
+	return PriceData{Value: value, Currency: "USD", Grade: GradePSA10, Source: DataSource{Name: name, Type: kind, Confidence: 0.8}}
+}
+
+func TestFusePrice_DiscardsOutliers(t *testing.T) {
+	fused := FusePrice([]PriceData{
+		obs("PriceCharting", SourceTypeGuide, 200),
+		obs("Sales", SourceTypeSale, 210),
+		obs("eBay", SourceTypeListing, 205),
+		obs("GameStop", SourceTypeListing, 900),
+	})
+	if fused.Outliers != 1 || fused.Used != 3 {
+		t.Fatalf("expected the $900 listing discarded, got used %d outliers %d", fused.Used, fused.Outliers)
+	}
+	if fused.Value < 200 || fused.Value > 210 {
+		t.Errorf("expected a consensus between 200 and 210, got %.2f", fused.Value)
+	}
+	if !strings.Contains(strings.Join(fused.Warnings, ";"), "GameStop $900.00") {
+		t.Errorf("expected a warning naming the outlier, got %v", fused.Warnings)
+	}
+	if got := strings.Join(fused.Sources, ","); got != "PriceCharting,Sales,eBay" {
+		t.Errorf("unexpected sources %s", got)
+	}
+}
+
+func TestFusePrice_Weighting(t *testing.T) {
+	// Two sources never discard each other; sales outweigh asking prices
+	fused := FusePrice([]PriceData{
+		obs("Sales", SourceTypeSale, 100),
+		obs("GameStop", SourceTypeListing, 130),
+	})
+	if fused.Value <= 100 || fused.Value >= 115 {
+		t.Errorf("expected the sale to pull the price below the midpoint, got %.2f", fused.Value)
+	}
+
+	// A stale guide price counts for less than a fresh one
+	stale := obs("Old", SourceTypeGuide, 100)
+	stale.Source.Freshness = 60 * 24 * time.Hour
+	fused = FusePrice([]PriceData{stale, obs("New", SourceTypeGuide, 120)})
+	if fused.Value <= 115 {
+		t.Errorf("expected the fresh price to dominate, got %.2f", fused.Value)
+	}
+}
+
+func TestFusePrice_Confidence(t *testing.T) {
+	single := FusePrice([]PriceData{obs("PriceCharting", SourceTypeGuide, 100)})
+	if single.Value != 100 || len(single.Warnings) != 1 || !strings.HasPrefix(single.Warnings[0], "single source") {
+		t.Errorf("unexpected single-source result %+v", single)
+	}
+	agreeing := FusePrice([]PriceData{
+		obs("PriceCharting", SourceTypeGuide, 100),
+		obs("Sales", SourceTypeSale, 101),
+		obs("eBay", SourceTypeListing, 99),
+	})
+	if agreeing.Confidence <= single.Confidence {
+		t.Errorf("expected agreeing sources to raise confidence: %.2f vs %.2f", agreeing.Confidence, single.Confidence)
+	}
+
+	eur := obs("Cardmarket", SourceTypeGuide, 80)
+	eur.Currency = "EUR"
+	if fused := FusePrice([]PriceData{eur}); fused.Value != 0 || len(fused.Warnings) != 1 {
+		t.Errorf("expected non-USD prices skipped with a warning, got %+v", fused)
+	}
+	engine := NewFusionEngine()
+	engine.Rates = currency.Fixed()
+	rate, _ := currency.Fixed().Rate(currency.EUR, currency.USD)
+	if fused := engine.FusePrice([]PriceData{eur}); fused.Used != 1 || fused.Value != round2(rate.Convert(80)) {
+		t.Errorf("expected the EUR price converted at the engine's rates, got %+v", fused)
+	}
+	if fused := FusePrice(nil); fused.Value != 0 || fused.Confidence != 0 {
+		t.Errorf("expected an empty result, got %+v", fused)
+	}
+}
+
+func TestFuse_ByGrade(t *testing.T) {
+	market := 12.5
+	card := model.Card{Name: "Pikachu", TCGPlayer: &model.TCGPlayerBlock{Prices: map[string]struct {
+		Low       *float64 `json:"low,omitempty"`
+		Mid       *float64 `json:"mid,omitempty"`
+		High      *float64 `json:"high,omitempty"`
+		Market    *float64 `json:"market,omitempty"`
+		DirectLow *float64 `json:"directLow,omitempty"`
+	}{"holofoil": {Market: &market}}}}
+
+	var all []PriceData
+	all = append(all, FromTCGPlayer(card)...)
+	all = append(all, FromPriceCharting(&prices.PCMatch{LooseCents: 1000, PSA10Cents: 15000, Grade9Cents: 5000}, time.Now())...)
+	all = append(all, FromSales(&sales.SalesData{DataSource: "PokemonPriceTracker", RecentSales: []sales.SaleRecord{
+		{Grade: "PSA 10", Price: 140, Date: time.Now()},
+		{Grade: "PSA 10", Price: 160, Date: time.Now()},
+		{Grade: "BGS 9.5", Price: 90, Date: time.Now()},
+		{Grade: "CGC 8", Price: 30, Date: time.Now()},
+	}})...)
+
+	fused := NewFusionEngine().Fuse(card, all)
+	if raw := fused.Prices[GradeRaw]; len(raw.Sources) != 2 || raw.Value < 10 || raw.Value > 12.5 {
+		t.Errorf("expected raw fused from TCGPlayer and PriceCharting, got %+v", raw)
+	}
+	if psa10 := fused.Prices[GradePSA10]; len(psa10.Sources) != 2 || psa10.Value != 150 {
+		t.Errorf("expected PSA 10 of 150 from two sources, got %+v", psa10)
+	}
+	if fused.Price(Grade95) != 90 || fused.Price(GradePSA9) != 50 {
+		t.Errorf("unexpected single-source grades %+v", fused.Prices)
+	}
+	if len(fused.Prices) != 4 {
+		t.Errorf("expected grades the analysis doesn't price to be dropped, got %v", fused.Prices)
+	}
+}
+
+func TestListingGradeKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		listing listing.Listing
+		want    string
+	}{
+		{"raw", listing.Listing{}, GradeRaw},
+		{"PSA 10", listing.Listing{Graded: true, Grader: listing.PSA, Grade: 10}, GradePSA10},
+		{"BGS 10", listing.Listing{Graded: true, Grader: listing.BGS, Grade: 10}, GradeBGS10},
+		{"BGS 9.5", listing.Listing{Graded: true, Grader: listing.BGS, Grade: 9.5}, Grade95},
+		{"CGC 9.5", listing.Listing{Graded: true, Grader: listing.CGC, Grade: 9.5}, Grade95},
+		{"SGC 9.5", listing.Listing{Graded: true, Grader: listing.SGC, Grade: 9.5}, Grade95},
+		{"9.5 without a grader", listing.Listing{Graded: true, Grade: 9.5}, ""},
+		{"PSA 9", listing.Listing{Graded: true, Grader: listing.PSA, Grade: 9}, GradePSA9},
+		{"PSA 9 OC", listing.Listing{Graded: true, Grader: listing.PSA, Grade: 9, Qualifier: "OC"}, ""},
+		{"CGC 10", listing.Listing{Graded: true, Grader: listing.CGC, Grade: 10}, ""},
+	}
+	for _, tt := range tests {
+		if got := ListingGradeKey(tt.listing); got != tt.want {
+			t.Errorf("%s: ListingGradeKey = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGradeKey(t *testing.T) {
+	tests := map[string]string{
+		"PSA 10":  GradePSA10,
+		"psa10":   GradePSA10,
+		"BGS 10":  GradeBGS10,
+		"BGS 9.5": Grade95,
+		"CGC 9.5": Grade95,
+		"PSA 9":   GradePSA9,
+		"Raw":     GradeRaw,
+		"":        GradeRaw,
+		"CGC 10":  "",
+		"PSA 8":   "",
+	}
+	for in, want := range tests {
+		if got := GradeKey(in); got != want {
+			t.Errorf("GradeKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
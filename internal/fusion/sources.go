@@ -0,0 +1,229 @@
+package fusion
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/ebay"
+	"github.com/guarzo/pkmgradegap/internal/listing"
+	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/prices"
+	"github.com/guarzo/pkmgradegap/internal/sales"
+)
+
+// GradeKey maps a grade label such as "PSA 10", "BGS 9.5" or "Raw" onto a
+// grade key. Grades the analysis doesn't price return "".
+func GradeKey(label string) string {
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "", "raw", "ungraded":
+		return GradeRaw
+	}
+	l := listing.Parse(label)
+	if !l.Graded {
+		return ""
+	}
+	return ListingGradeKey(l)
+}
+
+// ListingGradeKey maps a parsed listing onto a grade key: raw listings are
+// GradeRaw, any named company's 9.5 is Grade95, and slabs the analysis
+// doesn't price, including qualified grades such as PSA 9 OC, return ""
+func ListingGradeKey(l listing.Listing) string {
+	if !l.Graded {
+		return GradeRaw
+	}
+	if l.Qualifier != "" {
+		return ""
+	}
+	switch {
+	case l.Grader != "" && l.Grade == 9.5:
+		return Grade95
+	case l.Grader == listing.PSA && l.Grade == 10:
+		return GradePSA10
+	case l.Grader == listing.BGS && l.Grade == 10:
+		return GradeBGS10
+	case l.Grader == listing.PSA && l.Grade == 9:
+		return GradePSA9
+	}
+	return ""
+}
+
+// FromPriceCharting turns a PriceCharting match into guide prices for each
+// grade it has. The match was looked up at the given time.
+func FromPriceCharting(m *prices.PCMatch, at time.Time) []PriceData {
+	if m == nil {
+		return nil
+	}
+	confidence := m.MatchConfidence
+	if confidence <= 0 {
+		confidence = 0.7
+	}
+	source := DataSource{
+		Name:       "PriceCharting",
+		Type:       SourceTypeGuide,
+		Freshness:  time.Since(at),
+		Volume:     m.SalesVolume,
+		Confidence: confidence,
+		Timestamp:  at,
+	}
+	var out []PriceData
+	for _, g := range []struct {
+		grade string
+		cents int
+	}{
+		{GradeRaw, m.LooseCents},
+		{GradePSA9, m.Grade9Cents},
+		{Grade95, m.Grade95Cents},
+		{GradePSA10, m.PSA10Cents},
+		{GradeBGS10, m.BGS10Cents},
+	} {
+		if g.cents > 0 {
+			out = append(out, PriceData{Value: float64(g.cents) / 100, Currency: "USD", Grade: g.grade, Source: source})
+		}
+	}
+	return out
+}
+
+// FromTCGPlayer returns the TCGPlayer market price for a raw copy, from the
+// first printing that has one
+func FromTCGPlayer(c model.Card) []PriceData {
+	if c.TCGPlayer == nil {
+		return nil
+	}
+	for _, printing := range []string{"normal", "holofoil", "reverseHolofoil", "1stEditionHolofoil", "1stEditionNormal"} {
+		if data := FromTCGPlayerPrinting(c, printing); data != nil {
+			return data
+		}
+	}
+	return nil
+}
+
+// FromTCGPlayerPrinting returns the TCGPlayer market price for a raw copy
+// of one printing, named by its price bucket (e.g. "reverseHolofoil")
+func FromTCGPlayerPrinting(c model.Card, printing string) []PriceData {
+	if c.TCGPlayer == nil {
+		return nil
+	}
+	p, ok := c.TCGPlayer.Prices[printing]
+	if !ok || p.Market == nil || *p.Market <= 0 {
+		return nil
+	}
+	source := DataSource{Name: "TCGPlayer", Type: SourceTypeGuide, Confidence: 0.8}
+	source.Timestamp, source.Freshness = updatedAt(c.TCGPlayer.Updated)
+	return []PriceData{{Value: *p.Market, Currency: "USD", Grade: GradeRaw, Source: source}}
+}
+
+// FromCardmarket returns the Cardmarket trend price for a raw copy. It is in
+// EUR, so the engine skips it unless its Rates convert it.
+func FromCardmarket(c model.Card) []PriceData {
+	if c.Cardmarket == nil {
+		return nil
+	}
+	price := c.Cardmarket.Prices.TrendPrice
+	if price == nil || *price <= 0 {
+		price = c.Cardmarket.Prices.Avg30
+	}
+	if price == nil || *price <= 0 {
+		return nil
+	}
+	source := DataSource{Name: "Cardmarket", Type: SourceTypeGuide, Confidence: 0.7}
+	source.Timestamp, source.Freshness = updatedAt(c.Cardmarket.Updated)
+	return []PriceData{{Value: *price, Currency: "EUR", Grade: GradeRaw, Source: source}}
+}
+
+// FromSales summarises completed sales as one observation per grade: the
+// median price, backed by the number of sales and as fresh as the latest
+func FromSales(sd *sales.SalesData) []PriceData {
+	if sd == nil {
+		return nil
+	}
+	name := sd.DataSource
+	if name == "" {
+		name = "Sales"
+	}
+	byGrade := make(map[string][]sales.SaleRecord)
+	for _, s := range sd.RecentSales {
+		if key := GradeKey(s.Grade); key != "" && s.Price > 0 {
+			byGrade[key] = append(byGrade[key], s)
+		}
+	}
+	return summarize(byGrade, name, SourceTypeSale, 0.8, func(s sales.SaleRecord) (float64, time.Time) {
+		return s.Price, s.Date
+	})
+}
+
+// FromEbayListings summarises eBay listings as one asking-price observation
+// per grade. Listings without a grade in the title count as raw; lots and
+// proxies are skipped.
+func FromEbayListings(listings []ebay.Listing) []PriceData {
+	byGrade := make(map[string][]ebay.Listing)
+	for _, l := range listings {
+		parsed := listing.Parse(l.Title)
+		if parsed.Lot || parsed.Proxy {
+			continue
+		}
+		if key := ListingGradeKey(parsed); key != "" && l.Price > 0 {
+			byGrade[key] = append(byGrade[key], l)
+		}
+	}
+	return summarize(byGrade, "eBay", SourceTypeListing, 0.6, func(l ebay.Listing) (float64, time.Time) {
+		return l.Price, time.Now()
+	})
+}
+
+// summarize reduces grouped observations to their median per grade
+func summarize[T any](byGrade map[string][]T, name string, kind SourceType, confidence float64, read func(T) (float64, time.Time)) []PriceData {
+	grades := make([]string, 0, len(byGrade))
+	for g := range byGrade {
+		grades = append(grades, g)
+	}
+	sort.Strings(grades)
+
+	var out []PriceData
+	for _, g := range grades {
+		values := make([]float64, 0, len(byGrade[g]))
+		var latest time.Time
+		for _, item := range byGrade[g] {
+			v, at := read(item)
+			values = append(values, v)
+			if at.After(latest) {
+				latest = at
+			}
+		}
+		out = append(out, PriceData{
+			Value:    median(values),
+			Currency: "USD",
+			Grade:    g,
+			Source: DataSource{
+				Name:       name,
+				Type:       kind,
+				Freshness:  time.Since(latest),
+				Volume:     len(values),
+				Confidence: confidence,
+				Timestamp:  latest,
+			},
+		})
+	}
+	return out
+}
+
+// updatedAt parses the pokemontcg.io "updatedAt" date; unknown dates are
+// treated as fresh
+func updatedAt(s string) (time.Time, time.Duration) {
+	t, err := time.Parse("2006/01/02", s)
+	if err != nil {
+		return time.Time{}, 0
+	}
+	return t, time.Since(t)
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
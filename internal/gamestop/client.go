@@ -16,6 +16,7 @@ import (
 
 	"github.com/andybalholm/brotli"
 	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/listing"
 	"github.com/guarzo/pkmgradegap/internal/model"
 	"github.com/guarzo/pkmgradegap/internal/ratelimit"
 )
@@ -26,7 +27,7 @@ import (
 type GameStopClient struct {
 	config  Config
 	client  *http.Client
-	cache   *cache.Cache
+	cache   cache.Store
 	limiter *ratelimit.Limiter
 }
 
@@ -36,13 +37,11 @@ func NewGameStopClient(config Config) *GameStopClient {
 		Timeout: config.RequestTimeout,
 	}
 
-	var c *cache.Cache
+	var c cache.Store
 	if config.CacheEnabled {
-		var err error
-		c, err = cache.New("/tmp/gamestop_cache.json")
-		if err != nil {
-			// Continue without cache
-			c = nil
+		c = config.Cache
+		if c == nil {
+			c = cache.NewMemory()
 		}
 	}
 
@@ -72,7 +71,7 @@ func (g *GameStopClient) GetListings(setName, cardName, number string) (*Listing
 	// Try cache first
 	if g.cache != nil {
 		var data ListingData
-		key := fmt.Sprintf("gamestop:%s:%s:%s", setName, cardName, number)
+		key := cache.BuildKey("gamestop", setName, cardName, number)
 		if found, _ := g.cache.Get(key, &data); found {
 			return &data, nil
 		}
@@ -111,7 +110,7 @@ func (g *GameStopClient) GetListings(setName, cardName, number string) (*Listing
 
 	// Cache the result
 	if g.cache != nil {
-		key := fmt.Sprintf("gamestop:%s:%s:%s", setName, cardName, number)
+		key := cache.BuildKey("gamestop", setName, cardName, number)
 		_ = g.cache.Put(key, data, time.Duration(g.config.CacheTTLMinutes)*time.Minute)
 	}
 
@@ -464,21 +463,9 @@ func (g *GameStopClient) extractPrice(product map[string]interface{}) float64 {
 }
 
 func (g *GameStopClient) extractGradeFromTitle(title string) string {
-	// Common grading patterns
-	patterns := []string{
-		`PSA\s+(\d+(?:\.\d+)?)`,
-		`BGS\s+(\d+(?:\.\d+)?)`,
-		`CGC\s+(\d+(?:\.\d+)?)`,
-		`SGC\s+(\d+(?:\.\d+)?)`,
-	}
-
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(`(?i)` + pattern)
-		if matches := re.FindStringSubmatch(title); len(matches) > 1 {
-			return strings.ToUpper(re.FindString(title))
-		}
+	if l := listing.Parse(title); l.Graded && l.Grade > 0 {
+		return l.Label()
 	}
-
 	return "Unknown"
 }
 
@@ -1,111 +1,56 @@
 package gamestop
 
 import (
-	"regexp"
 	"strings"
 	"time"
-	// "github.com/guarzo/pkmgradegap/internal/fusion" // TODO: Update when fusion package is refactored
+
+	"github.com/guarzo/pkmgradegap/internal/fusion"
+	"github.com/guarzo/pkmgradegap/internal/listing"
 )
 
-// ConvertToPriceData converts GameStop listings to fusion.PriceData for the fusion engine
-// TODO: Update when fusion package is refactored
-func ConvertToPriceData(listingData *ListingData) []interface{} { // []fusion.PriceData {
+// ConvertToPriceData converts in-stock GameStop listings to fusion prices
+func ConvertToPriceData(listingData *ListingData) []fusion.PriceData {
 	if listingData == nil || len(listingData.ActiveList) == 0 {
-		return []interface{}{} // []fusion.PriceData{}
+		return []fusion.PriceData{}
 	}
 
-	// priceData := make([]fusion.PriceData, 0, len(listingData.ActiveList))
-	priceData := make([]interface{}, 0, len(listingData.ActiveList))
-
+	priceData := make([]fusion.PriceData, 0, len(listingData.ActiveList))
 	for _, listing := range listingData.ActiveList {
 		if !listing.InStock || listing.Price <= 0 {
 			continue // Skip out-of-stock or invalid listings
 		}
-
-		// Determine if this is raw or graded
-		isRaw := isRawCard(listing.Grade, listing.Title)
-		grade := normalizeGrade(listing.Grade)
-
-		// Calculate confidence based on listing quality
-		confidence := calculateListingConfidence(listing)
-
-		// data := fusion.PriceData{
-		// 	Value:    listing.Price,
-		// 	Currency: "USD", // GameStop is USD
-		// 	Source: fusion.DataSource{
-		// 		Name:       "GameStop",
-		// 		Type:       fusion.SourceTypeListing,
-		// 		Freshness:  calculateFreshness(listingData.LastUpdated),
-		// 		Volume:     listingData.ListingCount,
-		// 		Confidence: confidence,
-		// 		Timestamp:  listingData.LastUpdated,
-		// 	},
-		// 	Raw:   isRaw,
-		// 	Grade: grade,
-		// }
-		data := map[string]interface{}{
-			"value":      listing.Price,
-			"currency":   "USD",
-			"source":     "GameStop",
-			"freshness":  calculateFreshness(listingData.LastUpdated),
-			"confidence": confidence,
-			"raw":        isRaw,
-			"grade":      grade,
-		}
-
-		priceData = append(priceData, data)
+		priceData = append(priceData, listingPrice(listingData, listing))
 	}
 
 	return priceData
 }
 
-// ConvertToPriceDataByGrade converts GameStop listings grouped by grade
-// TODO: Update when fusion package is refactored
-func ConvertToPriceDataByGrade(listingData *ListingData) map[string][]interface{} { // map[string][]fusion.PriceData {
-	// result := make(map[string][]fusion.PriceData)
-	result := make(map[string][]interface{})
+// ConvertToPriceDataByGrade converts GameStop listings grouped by grade key
+func ConvertToPriceDataByGrade(listingData *ListingData) map[string][]fusion.PriceData {
+	result := make(map[string][]fusion.PriceData)
 
-	if listingData == nil || len(listingData.ActiveList) == 0 {
-		return result
+	for _, data := range ConvertToPriceData(listingData) {
+		result[data.Grade] = append(result[data.Grade], data)
 	}
 
-	for _, listing := range listingData.ActiveList {
-		if !listing.InStock || listing.Price <= 0 {
-			continue
-		}
-
-		grade := normalizeGrade(listing.Grade)
-		key := getGradeKey(grade, listing.Title)
-
-		confidence := calculateListingConfidence(listing)
-
-		// data := fusion.PriceData{
-		// 	Value:    listing.Price,
-		// 	Currency: "USD",
-		// 	Source: fusion.DataSource{
-		// 		Name:       "GameStop",
-		// 		Type:       fusion.SourceTypeListing,
-		// 		Freshness:  calculateFreshness(listingData.LastUpdated),
-		// 		Volume:     listingData.ListingCount,
-		// 		Confidence: confidence,
-		// 		Timestamp:  listingData.LastUpdated,
-		// 	},
-		// 	Raw:   isRawCard(grade, listing.Title),
-		// 	Grade: grade,
-		// }
-		data := map[string]interface{}{
-			"value":      listing.Price,
-			"currency":   "USD",
-			"source":     "GameStop",
-			"confidence": confidence,
-			"raw":        isRawCard(grade, listing.Title),
-			"grade":      grade,
-		}
+	return result
+}
 
-		result[key] = append(result[key], data)
+// listingPrice describes one listing as an asking-price observation
+func listingPrice(listingData *ListingData, listing Listing) fusion.PriceData {
+	return fusion.PriceData{
+		Value:    listing.Price,
+		Currency: "USD", // GameStop is USD
+		Grade:    getGradeKey(normalizeGrade(listing.Grade), listing.Title),
+		Source: fusion.DataSource{
+			Name:       "GameStop",
+			Type:       fusion.SourceTypeListing,
+			Freshness:  calculateFreshness(listingData.LastUpdated),
+			Volume:     listingData.ListingCount,
+			Confidence: calculateListingConfidence(listing),
+			Timestamp:  listingData.LastUpdated,
+		},
 	}
-
-	return result
 }
 
 // GetLowestPriceByGrade returns the lowest price for each grade
@@ -132,125 +77,31 @@ func GetLowestPriceByGrade(listingData *ListingData) map[string]float64 {
 	return result
 }
 
+// isRawCard reports whether a listing's grade and title describe an
+// ungraded card
 func isRawCard(grade, title string) bool {
-	gradeLower := strings.ToLower(grade)
-	titleLower := strings.ToLower(title)
-
-	// Check for explicit raw card indicators first
-	rawIndicators := []string{
-		"raw", "ungraded", "nm", "near mint",
-		"lp", "light play", "mp", "moderate play",
-		"excellent", "very fine", "fine",
-		"mint", // plain "mint" without "gem mint" often indicates raw
-	}
-
-	for _, indicator := range rawIndicators {
-		if strings.Contains(gradeLower, indicator) || strings.Contains(titleLower, indicator) {
-			return true
-		}
-	}
-
-	// Check for graded card indicators
-	gradedIndicators := []string{
-		"psa", "bgs", "cgc", "sgc",
-		"graded", "gem mint",
-		"authenticated", "certified",
-	}
-
-	for _, indicator := range gradedIndicators {
-		if strings.Contains(gradeLower, indicator) || strings.Contains(titleLower, indicator) {
-			return false
-		}
-	}
-
-	// If grade is "Unknown" or empty, check title for grading info
-	if gradeLower == "unknown" || gradeLower == "" {
-		// Look for PSA/BGS numbers in title
-		gradePattern := `(PSA|BGS|CGC|SGC)\s*(\d+(?:\.\d+)?)`
-		re := regexp.MustCompile(`(?i)` + gradePattern)
-		if re.MatchString(titleLower) {
-			return false
-		}
-		// If no grading info found, assume raw
-		return true
-	}
-
-	// Default to graded if we have a specific grade
-	return false
+	return !listing.Parse(grade + " " + title).Graded
 }
 
+// normalizeGrade formats a listing grade as "PSA 10", "BGS 9.5" and so on;
+// grades that can't be read are upper-cased and an empty grade is "Unknown"
 func normalizeGrade(grade string) string {
+	grade = strings.TrimSpace(grade)
 	if grade == "" || strings.ToLower(grade) == "unknown" {
 		return "Unknown"
 	}
-
-	// Normalize common grading formats
-	grade = strings.TrimSpace(grade)
-
-	// Handle PSA grades
-	psaPattern := `(?i)PSA\s*(\d+(?:\.\d+)?)`
-	re := regexp.MustCompile(psaPattern)
-	if matches := re.FindStringSubmatch(grade); len(matches) > 1 {
-		return "PSA " + matches[1]
-	}
-
-	// Handle BGS grades
-	bgsPattern := `(?i)BGS\s*(\d+(?:\.\d+)?)`
-	re = regexp.MustCompile(bgsPattern)
-	if matches := re.FindStringSubmatch(grade); len(matches) > 1 {
-		return "BGS " + matches[1]
-	}
-
-	// Handle CGC grades
-	cgcPattern := `(?i)CGC\s*(\d+(?:\.\d+)?)`
-	re = regexp.MustCompile(cgcPattern)
-	if matches := re.FindStringSubmatch(grade); len(matches) > 1 {
-		return "CGC " + matches[1]
-	}
-
-	// Handle SGC grades
-	sgcPattern := `(?i)SGC\s*(\d+(?:\.\d+)?)`
-	re = regexp.MustCompile(sgcPattern)
-	if matches := re.FindStringSubmatch(grade); len(matches) > 1 {
-		return "SGC " + matches[1]
+	if l := listing.Parse(grade); l.Graded && l.Grade > 0 {
+		return l.Label()
 	}
-
 	return strings.ToUpper(grade)
 }
 
+// getGradeKey maps a listing onto the grade keys used by the analysis;
+// slabs the analysis doesn't price are "other"
 func getGradeKey(grade, title string) string {
-	// Map grades to standard keys used by the analysis system
-	gradeLower := strings.ToLower(grade)
-	titleLower := strings.ToLower(title)
-
-	// Check specific grades first (most specific to least specific)
-	if strings.Contains(gradeLower, "psa 10") || strings.Contains(titleLower, "psa 10") {
-		return "psa10"
-	}
-	if strings.Contains(gradeLower, "bgs 10") || strings.Contains(titleLower, "bgs 10") {
-		return "bgs10"
-	}
-
-	// Check for 9.5 grades (BGS 9.5, CGC 9.5) before checking for 9 grades
-	if strings.Contains(gradeLower, "9.5") || strings.Contains(titleLower, "9.5") ||
-		strings.Contains(gradeLower, "cgc") || strings.Contains(titleLower, "cgc") {
-		return "cgc95"
-	}
-
-	// Check for 9 grades (PSA 9, BGS 9)
-	if (strings.Contains(gradeLower, "psa 9") && !strings.Contains(gradeLower, "9.5")) ||
-		(strings.Contains(gradeLower, "bgs 9") && !strings.Contains(gradeLower, "9.5")) ||
-		(strings.Contains(titleLower, "psa 9") && !strings.Contains(titleLower, "9.5")) ||
-		(strings.Contains(titleLower, "bgs 9") && !strings.Contains(titleLower, "9.5")) {
-		return "psa9"
+	if key := fusion.ListingGradeKey(listing.Parse(grade + " " + title)); key != "" {
+		return key
 	}
-
-	// Check if raw
-	if isRawCard(grade, title) {
-		return "raw"
-	}
-
-	// Default grouping for other grades
 	return "other"
 }
 
@@ -300,38 +151,26 @@ func calculateFreshness(lastUpdated time.Time) time.Duration {
 	return time.Since(lastUpdated)
 }
 
-// Helper to merge GameStop data with other sources in the fusion engine
-// TODO: Update when fusion package is refactored
-func MergeWithFusionEngine(engine interface{}, gameStopData *ListingData,
-	otherPrices map[string][]interface{}) map[string]interface{} { // map[string]fusion.FusedPrice {
+// MergeWithFusionEngine fuses GameStop listings with other sources' prices,
+// keyed by grade, for each grade the analysis prices
+func MergeWithFusionEngine(engine *fusion.FusionEngine, gameStopData *ListingData,
+	otherPrices map[string][]fusion.PriceData) map[string]fusion.FusedPrice {
 
-	// result := make(map[string]fusion.FusedPrice)
-	result := make(map[string]interface{})
+	result := make(map[string]fusion.FusedPrice)
 
 	// Convert GameStop data by grade
 	gameStopPrices := ConvertToPriceDataByGrade(gameStopData)
 
 	// Merge with other sources for each grade
-	allGrades := []string{"raw", "psa9", "cgc95", "psa10", "bgs10"}
+	allGrades := []string{fusion.GradeRaw, fusion.GradePSA9, fusion.Grade95, fusion.GradePSA10, fusion.GradeBGS10}
 
 	for _, grade := range allGrades {
-		// var combinedPrices []fusion.PriceData
-		var combinedPrices []interface{}
-
-		// Add GameStop prices for this grade
-		if gsPrice, exists := gameStopPrices[grade]; exists {
-			combinedPrices = append(combinedPrices, gsPrice...)
-		}
-
-		// Add other source prices for this grade
-		if otherPrice, exists := otherPrices[grade]; exists {
-			combinedPrices = append(combinedPrices, otherPrice...)
-		}
+		var combinedPrices []fusion.PriceData
+		combinedPrices = append(combinedPrices, gameStopPrices[grade]...)
+		combinedPrices = append(combinedPrices, otherPrices[grade]...)
 
-		// Fuse the prices
-		if len(combinedPrices) > 0 {
-			// result[grade] = engine.FusePrice(combinedPrices)
-			result[grade] = combinedPrices // TODO: Implement fusion logic
+		if fused := engine.FusePrice(combinedPrices); fused.Value > 0 {
+			result[grade] = fused
 		}
 	}
 
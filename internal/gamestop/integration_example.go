@@ -5,7 +5,7 @@ import (
 	"log"
 	"time"
 
-	// "github.com/guarzo/pkmgradegap/internal/fusion" // TODO: Update when fusion package is refactored
+	"github.com/guarzo/pkmgradegap/internal/fusion"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
@@ -21,8 +21,7 @@ func ExampleIntegration() {
 	provider := NewGameStopClient(config)
 
 	// Initialize fusion engine
-	// fusionEngine := fusion.NewFusionEngine() // TODO: Update when fusion package is refactored
-	var fusionEngine interface{} // placeholder
+	fusionEngine := fusion.NewFusionEngine()
 
 	// Example card to analyze
 	card := model.Card{
@@ -43,22 +42,19 @@ func ExampleIntegration() {
 
 	// Example: Combine with other price sources (PriceCharting, sales data, etc.)
 	// This would normally come from your existing price providers
-	// otherPrices := make(map[string][]fusion.PriceData) // TODO: Update when fusion package is refactored
-	otherPrices := make(map[string][]interface{})
+	otherPrices := make(map[string][]fusion.PriceData)
 
 	// Merge and fuse prices
 	fusedPrices := MergeWithFusionEngine(fusionEngine, listingData, otherPrices)
 
 	// Display results
-	// TODO: Update when fusion package is refactored
 	for grade, fusedPrice := range fusedPrices {
-		// fmt.Printf("%s: $%.2f (confidence: %.2f)\n",
-		// 	grade, fusedPrice.Value, fusedPrice.Confidence)
-		//
-		// if len(fusedPrice.Warnings) > 0 {
-		// 	fmt.Printf("  Warnings: %v\n", fusedPrice.Warnings)
-		// }
-		fmt.Printf("%s: %v\n", grade, fusedPrice)
+		fmt.Printf("%s: $%.2f (confidence: %.2f)\n",
+			grade, fusedPrice.Value, fusedPrice.Confidence)
+
+		if len(fusedPrice.Warnings) > 0 {
+			fmt.Printf("  Warnings: %v\n", fusedPrice.Warnings)
+		}
 	}
 
 	// Get lowest prices by grade for quick comparison
@@ -70,12 +66,11 @@ func ExampleIntegration() {
 }
 
 // IntegrateWithExistingAnalysis shows how to add GameStop data to your existing analysis
-// TODO: Update when fusion package is refactored
 func IntegrateWithExistingAnalysis(
 	card model.Card,
-	existingPrices map[string][]interface{}, // map[string][]fusion.PriceData,
-	fusionEngine interface{}, // *fusion.FusionEngine,
-) map[string]interface{} { // map[string]fusion.FusedPrice {
+	existingPrices map[string][]fusion.PriceData,
+	fusionEngine *fusion.FusionEngine,
+) map[string]fusion.FusedPrice {
 
 	// Initialize GameStop web scraper
 	config := DefaultConfig()
@@ -99,18 +94,15 @@ func IntegrateWithExistingAnalysis(
 	return MergeWithFusionEngine(fusionEngine, listingData, existingPrices)
 }
 
-// TODO: Update when fusion package is refactored
 func fusePricesWithoutGameStop(
-	prices map[string][]interface{}, // map[string][]fusion.PriceData,
-	engine interface{}, // *fusion.FusionEngine,
-) map[string]interface{} { // map[string]fusion.FusedPrice {
-	// result := make(map[string]fusion.FusedPrice)
-	result := make(map[string]interface{})
+	prices map[string][]fusion.PriceData,
+	engine *fusion.FusionEngine,
+) map[string]fusion.FusedPrice {
+	result := make(map[string]fusion.FusedPrice)
 
 	for grade, priceData := range prices {
-		if len(priceData) > 0 {
-			// result[grade] = engine.FusePrice(priceData)
-			result[grade] = priceData // TODO: Implement fusion logic
+		if fused := engine.FusePrice(priceData); fused.Value > 0 {
+			result[grade] = fused
 		}
 	}
 
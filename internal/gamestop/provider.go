@@ -3,6 +3,7 @@ package gamestop
 import (
 	"time"
 
+	"github.com/guarzo/pkmgradegap/internal/cache"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
@@ -63,6 +64,7 @@ type Config struct {
 	// Cache settings
 	CacheEnabled    bool
 	CacheTTLMinutes int
+	Cache           cache.Store // where listings are cached; in memory when nil
 
 	// Search settings
 	MaxSearchResults int
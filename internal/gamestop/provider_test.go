@@ -11,7 +11,7 @@ import (
 	"time"
 
 	"github.com/andybalholm/brotli"
-	// "github.com/guarzo/pkmgradegap/internal/fusion" // TODO: Update when fusion package is refactored
+	"github.com/guarzo/pkmgradegap/internal/fusion"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
@@ -75,25 +75,19 @@ func TestConvertToPriceData(t *testing.T) {
 		t.Errorf("Expected 2 price data items, got %d", len(priceData))
 	}
 
-	// TODO: Update when fusion package is refactored
-	for _, dataInterface := range priceData {
-		if data, ok := dataInterface.(map[string]interface{}); ok {
-			if value, ok := data["value"].(float64); !ok || value <= 0 {
-				t.Error("Price data value should be positive")
-			}
-			if currency, ok := data["currency"].(string); !ok || currency != "USD" {
-				t.Errorf("Expected currency 'USD', got '%v'", data["currency"])
-			}
-			if source, ok := data["source"].(string); !ok || source != "GameStop" {
-				t.Errorf("Expected source 'GameStop', got '%v'", data["source"])
-			}
+	for _, data := range priceData {
+		if data.Value <= 0 {
+			t.Error("Price data value should be positive")
+		}
+		if data.Currency != "USD" {
+			t.Errorf("Expected currency 'USD', got '%s'", data.Currency)
+		}
+		if data.Source.Type != fusion.SourceTypeListing {
+			t.Errorf("Expected source type 'listing', got '%s'", data.Source.Type)
+		}
+		if data.Source.Name != "GameStop" {
+			t.Errorf("Expected source name 'GameStop', got '%s'", data.Source.Name)
 		}
-		// if data.Source.Type != fusion.SourceTypeListing {
-		// 	t.Errorf("Expected source type 'LISTING', got '%s'", data.Source.Type)
-		// }
-		// if data.Source.Name != "GameStop" {
-		// 	t.Errorf("Expected source name 'GameStop', got '%s'", data.Source.Name)
-		// }
 	}
 }
 
@@ -117,14 +111,37 @@ func TestConvertToPriceDataByGrade(t *testing.T) {
 	if len(gradeData["psa10"]) != 2 {
 		t.Errorf("Expected 2 PSA 10 items, got %d", len(gradeData["psa10"]))
 	}
-	if len(gradeData["cgc95"]) != 1 {
-		t.Errorf("Expected 1 BGS 9.5 item, got %d", len(gradeData["cgc95"]))
+	if len(gradeData["grade95"]) != 1 {
+		t.Errorf("Expected 1 BGS 9.5 item, got %d", len(gradeData["grade95"]))
 	}
 	if len(gradeData["raw"]) != 1 {
 		t.Errorf("Expected 1 raw item, got %d", len(gradeData["raw"]))
 	}
 }
 
+func TestMergeWithFusionEngine(t *testing.T) {
+	listingData := &ListingData{
+		ActiveList: []Listing{
+			{Price: 150.00, Grade: "PSA 10", Title: "Pokemon Charizard PSA 10", InStock: true},
+			{Price: 155.00, Grade: "PSA 10", Title: "Pokemon Charizard PSA 10 Gem", InStock: true},
+		},
+		ListingCount: 2,
+		LastUpdated:  time.Now(),
+	}
+	other := map[string][]fusion.PriceData{
+		"psa10": {{Value: 140, Currency: "USD", Grade: "psa10", Source: fusion.DataSource{Name: "PriceCharting", Type: fusion.SourceTypeGuide}}},
+	}
+
+	fused := MergeWithFusionEngine(fusion.NewFusionEngine(), listingData, other)
+	psa10, ok := fused["psa10"]
+	if !ok || len(fused) != 1 {
+		t.Fatalf("expected only a PSA 10 price, got %v", fused)
+	}
+	if psa10.Value < 140 || psa10.Value > 155 || len(psa10.Sources) != 2 {
+		t.Errorf("expected GameStop and PriceCharting fused, got %+v", psa10)
+	}
+}
+
 func TestGetLowestPriceByGrade(t *testing.T) {
 	listingData := &ListingData{
 		ActiveList: []Listing{
@@ -141,8 +158,8 @@ func TestGetLowestPriceByGrade(t *testing.T) {
 	if prices["psa10"] != 150.00 {
 		t.Errorf("Expected PSA 10 lowest price 150.00, got %.2f", prices["psa10"])
 	}
-	if prices["cgc95"] != 120.00 {
-		t.Errorf("Expected BGS 9.5 lowest price 120.00, got %.2f", prices["cgc95"])
+	if prices["grade95"] != 120.00 {
+		t.Errorf("Expected BGS 9.5 lowest price 120.00, got %.2f", prices["grade95"])
 	}
 }
 
@@ -203,8 +220,8 @@ func TestGetGradeKey(t *testing.T) {
 		{"PSA 10", "Pokemon Charizard PSA 10", "psa10"},
 		{"BGS 10", "Pokemon Charizard BGS 10", "bgs10"},
 		{"PSA 9", "Pokemon Charizard PSA 9", "psa9"},
-		{"BGS 9.5", "Pokemon Charizard BGS 9.5", "cgc95"},
-		{"CGC 9.5", "Pokemon Charizard CGC 9.5", "cgc95"},
+		{"BGS 9.5", "Pokemon Charizard BGS 9.5", "grade95"},
+		{"CGC 9.5", "Pokemon Charizard CGC 9.5", "grade95"},
 		{"Raw", "Pokemon Charizard Raw", "raw"},
 		{"Unknown", "Pokemon Charizard", "raw"},
 		{"PSA 8", "Pokemon Charizard PSA 8", "other"},
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -289,33 +290,25 @@ func TestMockProviders(t *testing.T) {
 	})
 
 	t.Run("CSVProvider", func(t *testing.T) {
-		// Test CSV provider with mock data
-		config := population.CSVConfig{
-			DataPath:     "test_population.csv",
-			AutoDownload: false,
+		path := filepath.Join(t.TempDir(), "population.csv")
+		csvData := "set,card,number,psa10,psa9,psa8\nTest Set,Mew,151,40,120,200\n"
+		if err := os.WriteFile(path, []byte(csvData), 0o644); err != nil {
+			t.Fatal(err)
 		}
+		provider := population.NewCSVProvider(population.CSVConfig{DataPath: path})
 
-		provider := population.NewCSVProvider(config)
-		// Provider should work even without data (returns fallback)
-
-		card := model.Card{
-			Name:    "Mew",
-			SetName: "Test Set",
-			Number:  "151",
-		}
-
-		data, err := provider.LookupPopulation(ctx, card)
+		data, err := provider.LookupPopulation(ctx, model.Card{Name: "Mew", SetName: "Test Set", Number: "151"})
 		if err != nil {
 			t.Fatalf("CSV provider failed: %v", err)
 		}
-
-		// Should return fallback data
-		if data.PSA10Population == 0 {
-			t.Error("CSV provider should return fallback data")
+		if data.PSA10Population != 40 || data.Source != "PSA CSV" || data.FetchedAt.IsZero() {
+			t.Errorf("expected the imported counts attributed to the CSV, got %+v", data)
 		}
 
-		// Clean up
-		os.Remove("test_population.csv")
+		// A card missing from the import is an error, not made-up numbers
+		if data, err := provider.LookupPopulation(ctx, model.Card{Name: "Mewtwo", SetName: "Test Set", Number: "150"}); err == nil {
+			t.Errorf("expected an error for a card not in the CSV, got %+v", data)
+		}
 	})
 }
 
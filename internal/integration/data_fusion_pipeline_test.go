@@ -334,10 +334,9 @@ func TestMultiLayerCacheSystem(t *testing.T) {
 	// Test cache stats
 	stats := mlCache.GetStats()
 	t.Logf("Cache Stats:")
-	t.Logf("  L1 Hits: %d", stats.L1Hits)
-	t.Logf("  L1 Misses: %d", stats.L1Misses)
-	t.Logf("  L1 Hit Rate: %.2f%%", stats.L1HitRate*100)
-	t.Logf("  Overall Hit Rate: %.2f%%", stats.OverallHitRate*100)
+	t.Logf("  Hits: %d", stats.Hits)
+	t.Logf("  Misses: %d", stats.Misses)
+	t.Logf("  Hit Rate: %.2f%%", stats.HitRate()*100)
 
 	// Test cache prediction (simulate some access patterns)
 	for i := 0; i < 5; i++ {
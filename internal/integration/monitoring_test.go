@@ -152,7 +152,7 @@ func TestAlertGeneration(t *testing.T) {
 		VolatilityHighThreshold: 0.13,
 		MinSeverity:             "LOW",
 	}
-	engine := monitoring.NewAlertEngine(alertConfig)
+	engine := monitoring.NewAlertEngine(alertConfig, nil)
 
 	// First compare snapshots to get deltas
 	deltas := monitoring.CompareSnapshots(oldSnapshot, newSnapshot, 10.0, 5.0)
@@ -284,7 +284,7 @@ func TestBulkOptimizer(t *testing.T) {
 		}
 	}
 
-	optimizer := monitoring.NewBulkOptimizer(0.13, 20.0)
+	optimizer := monitoring.NewBulkOptimizer(0.13, 20.0, nil)
 	batches := optimizer.OptimizeSubmission(submissionCards)
 
 	// Debug: Log the batch count and card values
@@ -335,7 +335,7 @@ func TestMarketTiming(t *testing.T) {
 		},
 	}
 
-	analyzer := monitoring.NewMarketAnalyzer(snapshots)
+	analyzer := monitoring.NewMarketAnalyzer(snapshots, nil)
 	recommendation := analyzer.AnalyzeCard("001-Pikachu ex")
 
 	if recommendation == nil {
@@ -419,7 +419,7 @@ func TestEndToEndMonitoringWorkflow(t *testing.T) {
 		VolatilityHighThreshold: 0.13,
 		MinSeverity:             "LOW",
 	}
-	engine := monitoring.NewAlertEngine(alertConfig)
+	engine := monitoring.NewAlertEngine(alertConfig, nil)
 
 	// First compare snapshots to get deltas
 	deltas := monitoring.CompareSnapshots(loaded1, loaded2, 10.0, 5.0)
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/guarzo/pkmgradegap/internal/cache"
 	"github.com/guarzo/pkmgradegap/internal/model"
 	"github.com/guarzo/pkmgradegap/internal/population"
 )
@@ -73,13 +74,10 @@ func TestPSAScraperWithMockHTML(t *testing.T) {
 	}))
 	defer server.Close()
 
-	// Create a mock cache
-	cache := &mockCache{
-		data: make(map[string]interface{}),
-	}
+	store := cache.NewMemory()
 
 	// Create scraper with mock server URL
-	scraper := population.NewPSAScraper(cache)
+	scraper := population.NewPSAScraper(store)
 
 	// Override the base URLs in the scraper (would need to expose these or use dependency injection)
 	// For now, we'll test the parsing functions directly
@@ -107,18 +105,16 @@ func TestPSAScraperWithMockHTML(t *testing.T) {
 	})
 }
 
-// TestPSAProviderWithScraperFallback tests the PSA provider with scraper fallback
+// TestPSAProviderWithScraperFallback tests the PSA API chained to the scraper
 func TestPSAProviderWithScraperFallback(t *testing.T) {
 	// Create a mock rate limiter
 	limiter := &mockRateLimiter{}
 
-	// Create a mock cache
-	cache := &mockCache{
-		data: make(map[string]interface{}),
-	}
+	store := cache.NewMemory()
 
-	// Create PSA provider with no API key (should fall back to scraper)
-	provider := population.NewPSAAPIProvider("", limiter, cache)
+	// Chain the PSA API with no API key to the scraper (should fall back to it)
+	api := population.NewPSAAPIProvider("", limiter, store)
+	provider := population.NewChainProvider(api, population.NewPSAScraper(store))
 
 	ctx := context.Background()
 
@@ -133,8 +129,8 @@ func TestPSAProviderWithScraperFallback(t *testing.T) {
 		// This should use the scraper since no API key is provided
 		popData, err := provider.LookupPopulation(ctx, card)
 
-		// We expect this to return nil without error (scraper returns nil on failure)
-		// This is by design to allow fallback to mock provider
+		// The scraper can't reach PSA here, so the chain reports an error
+		// rather than making numbers up
 		if err != nil {
 			t.Logf("Lookup failed (expected for integration test): %v", err)
 		}
@@ -145,10 +141,14 @@ func TestPSAProviderWithScraperFallback(t *testing.T) {
 	})
 
 	t.Run("Available_CheckStatus", func(t *testing.T) {
-		// Provider should report as unavailable without API key
-		if provider.Available() {
+		// The API should report as unavailable without API key, the chain
+		// as available through the scraper
+		if api.Available() {
 			t.Error("Provider should be unavailable without API key")
 		}
+		if !provider.Available() {
+			t.Error("Chain should be available through the scraper")
+		}
 	})
 }
 
@@ -190,9 +190,9 @@ func TestPSAProviderHealthReporting(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			limiter := &mockRateLimiter{}
-			cache := &mockCache{data: make(map[string]interface{})}
+			store := cache.NewMemory()
 
-			provider := population.NewPSAAPIProvider(tt.apiKey, limiter, cache)
+			provider := population.NewPSAAPIProvider(tt.apiKey, limiter, store)
 
 			// Test the Available method
 			avail := provider.Available()
@@ -208,43 +208,6 @@ func TestPSAProviderHealthReporting(t *testing.T) {
 
 // Mock implementations for testing
 
-type mockCache struct {
-	data map[string]interface{}
-}
-
-func (m *mockCache) Get(key string) (*population.PopulationData, bool) {
-	if val, exists := m.data[key]; exists {
-		if pd, ok := val.(*population.PopulationData); ok {
-			return pd, true
-		}
-	}
-	return nil, false
-}
-
-func (m *mockCache) Set(key string, data *population.PopulationData, ttl time.Duration) error {
-	m.data[key] = data
-	return nil
-}
-
-func (m *mockCache) GetSet(key string) (*population.SetPopulationData, bool) {
-	if val, exists := m.data[key]; exists {
-		if sd, ok := val.(*population.SetPopulationData); ok {
-			return sd, true
-		}
-	}
-	return nil, false
-}
-
-func (m *mockCache) SetSet(key string, data *population.SetPopulationData, ttl time.Duration) error {
-	m.data[key] = data
-	return nil
-}
-
-func (m *mockCache) Clear() error {
-	m.data = make(map[string]interface{})
-	return nil
-}
-
 type mockRateLimiter struct{}
 
 func (m *mockRateLimiter) Wait(ctx context.Context) error {
@@ -321,8 +284,8 @@ func TestPSAScraperHTMLParsing(t *testing.T) {
 
 // TestPSAScraperRateLimiting tests that the PSA scraper respects rate limits
 func TestPSAScraperRateLimiting(t *testing.T) {
-	cache := &mockCache{data: make(map[string]interface{})}
-	scraper := population.NewPSAScraper(cache)
+	store := cache.NewMemory()
+	scraper := population.NewPSAScraper(store)
 
 	ctx := context.Background()
 
@@ -347,8 +310,8 @@ func TestPSAScraperRateLimiting(t *testing.T) {
 
 // TestPSAScraperErrorHandling tests error handling in the PSA scraper
 func TestPSAScraperErrorHandling(t *testing.T) {
-	cache := &mockCache{data: make(map[string]interface{})}
-	scraper := population.NewPSAScraper(cache)
+	store := cache.NewMemory()
+	scraper := population.NewPSAScraper(store)
 
 	ctx := context.Background()
 
@@ -381,10 +344,10 @@ func TestPSAScraperErrorHandling(t *testing.T) {
 
 // TestPSAProviderCacheUsage tests that PSA provider caching works correctly
 func TestPSAProviderCacheUsage(t *testing.T) {
-	cache := &mockCache{data: make(map[string]interface{})}
+	store := cache.NewMemory()
 	limiter := &mockRateLimiter{}
 
-	provider := population.NewPSAAPIProvider("test-key", limiter, cache)
+	provider := population.NewPSAAPIProvider("test-key", limiter, store)
 
 	ctx := context.Background()
 
@@ -406,11 +369,14 @@ func TestPSAProviderCacheUsage(t *testing.T) {
 
 // TestPSAProviderAPIToScraperFallback tests the fallback from API to scraper
 func TestPSAProviderAPIToScraperFallback(t *testing.T) {
-	cache := &mockCache{data: make(map[string]interface{})}
+	store := cache.NewMemory()
 	limiter := &mockRateLimiter{}
 
-	// Create provider with invalid API key
-	provider := population.NewPSAAPIProvider("invalid-key", limiter, cache)
+	// Chain the PSA API with an invalid API key to the scraper
+	provider := population.NewChainProvider(
+		population.NewPSAAPIProvider("invalid-key", limiter, store),
+		population.NewPSAScraper(store),
+	)
 
 	ctx := context.Background()
 
@@ -436,8 +402,8 @@ func TestPSAProviderAPIToScraperFallback(t *testing.T) {
 
 // BenchmarkPSAScraperHTMLParsing benchmarks PSA scraper HTML parsing performance
 func BenchmarkPSAScraperHTMLParsing(b *testing.B) {
-	cache := &mockCache{data: make(map[string]interface{})}
-	scraper := population.NewPSAScraper(cache)
+	store := cache.NewMemory()
+	scraper := population.NewPSAScraper(store)
 
 	ctx := context.Background()
 
@@ -0,0 +1,332 @@
+// Package listing reads marketplace listing titles. Parse turns a title such
+// as "Charizard 4/102 Base Set Shadowless Holo PSA 9 OC" into who graded the
+// card, the grade and any qualifier, or marks it raw, and flags lots,
+// proxies, the card's language and its printing variants. eBay, GameStop and
+// auction code all classify titles through it so a slab is recognised the
+// same way everywhere.
+package listing
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Graders
+const (
+	PSA = "PSA"
+	BGS = "BGS"
+	CGC = "CGC"
+	SGC = "SGC"
+	TAG = "TAG"
+	ACE = "ACE"
+)
+
+// Qualifiers PSA adds to a grade for a card that is otherwise in better
+// condition; a qualified slab sells well below the plain grade
+const (
+	QualifierOC = "OC" // off-center
+	QualifierMC = "MC" // miscut
+	QualifierST = "ST" // stain
+	QualifierPD = "PD" // print defect
+	QualifierOF = "OF" // out of focus
+	QualifierMK = "MK" // marks
+)
+
+// Printing variants and card mechanics recognised in titles
+const (
+	VariantFirstEdition = "1st Edition"
+	VariantShadowless   = "Shadowless"
+	VariantUnlimited    = "Unlimited"
+	VariantReverseHolo  = "Reverse Holo"
+	VariantHolo         = "Holo"
+	VariantFullArt      = "Full Art"
+	VariantAltArt       = "Alt Art"
+	VariantSecretRare   = "Secret Rare"
+	VariantPromo        = "Promo"
+	VariantEX           = "EX"
+	VariantGX           = "GX"
+	VariantV            = "V"
+	VariantVMAX         = "VMAX"
+	VariantVSTAR        = "VSTAR"
+)
+
+// English is the language of a title that doesn't name one
+const English = "English"
+
+// Listing is what a title says about the card on offer
+type Listing struct {
+	Title      string
+	Graded     bool     // the card is in a grader's slab
+	Grader     string   // PSA, BGS, CGC, SGC, TAG or ACE; "" when none is named
+	Grade      float64  // 0 when raw or when the slab's grade isn't stated
+	Qualifier  string   // PSA qualifier such as OC, "" when none
+	Lot        bool     // more than one card, or a bundle
+	Proxy      bool     // proxy, reprint, custom or fake card
+	Language   string   // English unless the title names another
+	Variants   []string // printing variants and mechanics, e.g. Holo, 1st Edition, VMAX
+	Confidence float64  // 0..1, how firmly the title supports the graded/raw call and grade
+}
+
+// Label names the grade the way sales feeds do: "PSA 10", "BGS 9.5",
+// "PSA 9 OC", "Raw", or just the grader when the grade isn't stated
+func (l Listing) Label() string {
+	if !l.Graded {
+		return "Raw"
+	}
+	grader := l.Grader
+	if grader == "" {
+		grader = "Graded"
+	}
+	if l.Grade == 0 {
+		return grader
+	}
+	label := grader + " " + strconv.FormatFloat(l.Grade, 'f', -1, 64)
+	if l.Qualifier != "" {
+		label += " " + l.Qualifier
+	}
+	return label
+}
+
+// Has reports whether the title names the variant
+func (l Listing) Has(variant string) bool {
+	for _, v := range l.Variants {
+		if v == variant {
+			return true
+		}
+	}
+	return false
+}
+
+// SingleRaw reports whether the listing is one genuine ungraded card, the
+// only kind worth buying to send for grading
+func (l Listing) SingleRaw() bool {
+	return !l.Graded && !l.Lot && !l.Proxy
+}
+
+var (
+	// graderGrade finds "PSA 10", "psa10", "PSA GEM MT 10", "Beckett Black
+	// Label 10" and similar, allowing a few condition words between the
+	// grader and the number
+	graderGrade = regexp.MustCompile(`(?i)\b(psa|bgs|beckett|cgc|sgc|tag|ace)(?:[\s\-:]*(?:gem|mint|mt|nm|near|ex|vg|good|fair|poor|pristine|perfect|black|gold|label|grade|graded)\b)*[\s\-:#]*(10|[1-9](?:\.[05])?)`)
+
+	// conditionGrade finds a grade named only by its condition word, such
+	// as "Gem Mint 10" or "Perfect 10"
+	conditionGrade = regexp.MustCompile(`(?i)\b(?:gem[\s\-]*mint|gem[\s\-]*mt|pristine|perfect|black[\s\-]*label|mint|nm[\s\-]*mt)[\s\-:]*(10|[1-9](?:\.5)?)`)
+
+	// qualifier reads a PSA qualifier straight after the grade
+	qualifier = regexp.MustCompile(`(?i)^\s*\(?\s*(oc|mc|st|pd|of|mk)\b`)
+
+	// slabWords mark a graded card without saying the grade; TAG and ACE
+	// only count with a grade since "ace spec" and "tag team" are card types
+	slabWords = regexp.MustCompile(`(?i)\b(psa|bgs|beckett|cgc|sgc|graded|slab|slabbed|encapsulated|authenticated)\b`)
+
+	rawWords = regexp.MustCompile(`(?i)\b(raw|ungraded|not\s+graded|non[\s\-]?graded|unslabbed)\b`)
+
+	// candidateWords describe a raw card by the grade it might get
+	candidateWords = regexp.MustCompile(`(?i)\b(candidate|ready|worthy|potential|possible|prospect|contender|gradable|gradeable|quality|for\s+grading|to\s+grade|pre[\s\-]?grade[d]?)\b`)
+
+	lotWords = regexp.MustCompile(`(?i)\b(lot|lots|bundle|bulk|binder|mystery|repack|playset|complete\s+set|set\s+of\s+\d+|\d+\s*(?:cards|pcs|pieces)|(?:[2-9]|[1-9]\d)\s?x)\b`)
+
+	proxyWords = regexp.MustCompile(`(?i)\b(proxy|proxies|reprint|reproduction|replica|custom|fan[\s\-]?made|fan[\s\-]?art|orica|unofficial|fake|novelty|metal\s+card|gold\s+metal)\b`)
+)
+
+var languages = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{"Japanese", regexp.MustCompile(`(?i)\b(japanese|japan|jpn|jp)\b`)},
+	{"Korean", regexp.MustCompile(`(?i)\b(korean|kor)\b`)},
+	{"Chinese", regexp.MustCompile(`(?i)\b(chinese|s-chinese|t-chinese)\b`)},
+	{"German", regexp.MustCompile(`(?i)\b(german|deutsch)\b`)},
+	{"French", regexp.MustCompile(`(?i)\b(french|francais|français)\b`)},
+	{"Italian", regexp.MustCompile(`(?i)\b(italian|italiano)\b`)},
+	{"Spanish", regexp.MustCompile(`(?i)\b(spanish|espanol|español)\b`)},
+	{"Portuguese", regexp.MustCompile(`(?i)\b(portuguese|portugues)\b`)},
+	{"Dutch", regexp.MustCompile(`(?i)\bdutch\b`)},
+	{"Thai", regexp.MustCompile(`(?i)\bthai\b`)},
+	{"Indonesian", regexp.MustCompile(`(?i)\bindonesian\b`)},
+}
+
+var (
+	reverseHolo = regexp.MustCompile(`(?i)\brev(?:erse)?[\s\-]*holo(?:foil)?\b|\breverse[\s\-]*foil\b|\brh\b`)
+	nonHolo     = regexp.MustCompile(`(?i)\bnon[\s\-]*holo\b`)
+	// conditionEX is the EX condition grade, not the card mechanic
+	conditionEX = regexp.MustCompile(`(?i)\bex[\s\-]*(?:mt|mint|nm|condition)\b`)
+)
+
+// variants are checked in order against the title with the grade, reverse
+// holo and non-holo mentions already blanked out
+var variants = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{VariantFirstEdition, regexp.MustCompile(`(?i)\b1st\b|\bfirst[\s\-]+ed(?:ition)?\b|\b1ed\b`)},
+	{VariantShadowless, regexp.MustCompile(`(?i)\bshadowless\b`)},
+	{VariantUnlimited, regexp.MustCompile(`(?i)\bunlimited\b`)},
+	{VariantHolo, regexp.MustCompile(`(?i)\bholo(?:foil|graphic)?\b`)},
+	{VariantFullArt, regexp.MustCompile(`(?i)\bfull[\s\-]*art\b|\bfa\b`)},
+	{VariantAltArt, regexp.MustCompile(`(?i)\balt(?:ernate|ernative)?[\s\-]*art\b|\baa\b`)},
+	{VariantSecretRare, regexp.MustCompile(`(?i)\bsecret\b|\bhyper[\s\-]*rare\b|\brainbow[\s\-]*rare\b`)},
+	{VariantPromo, regexp.MustCompile(`(?i)\bpromo\b|\bblack\s+star\b`)},
+	{VariantVMAX, regexp.MustCompile(`(?i)\bvmax\b`)},
+	{VariantVSTAR, regexp.MustCompile(`(?i)\bvstar\b`)},
+	{VariantGX, regexp.MustCompile(`(?i)\bgx\b`)},
+	{VariantEX, regexp.MustCompile(`(?i)\bex\b`)},
+	{VariantV, regexp.MustCompile(`(?i)\bv\b`)},
+}
+
+// Parse classifies a listing title. A title with a grader and grade is
+// graded; words like "raw", "ungraded" or "PSA 10 candidate" make it raw
+// even when a grade is named; a bare grader name or "slab" marks it graded
+// with no grade. Anything else is taken as raw at lower confidence.
+func Parse(title string) Listing {
+	l := Listing{Title: title, Language: English}
+	rest := title
+
+	grades := findGrades(title)
+	switch {
+	case len(grades) > 0:
+		g := grades[0]
+		l.Graded, l.Grader, l.Grade, l.Qualifier = true, g.grader, g.grade, g.qualifier
+		l.Confidence = 0.95
+		if g.grader == "" {
+			l.Confidence = 0.6
+		}
+		for _, other := range grades[1:] {
+			if other.grader != g.grader || other.grade != g.grade {
+				l.Confidence = 0.5 // the title names two different grades
+			}
+		}
+		for i := len(grades) - 1; i >= 0; i-- {
+			rest = blank(rest, grades[i].start, grades[i].end)
+		}
+	case slabWords.MatchString(title):
+		l.Graded = true
+		l.Confidence = 0.5
+		if m := slabWords.FindStringSubmatch(title); m != nil {
+			if grader := graderName(m[1]); grader != "" {
+				l.Grader = grader
+				l.Confidence = 0.6
+			}
+		}
+	default:
+		l.Confidence = 0.7
+	}
+
+	if rawWords.MatchString(title) || candidateWords.MatchString(title) || (len(grades) > 0 && grades[0].question) {
+		if l.Graded && l.Grade > 0 {
+			l.Confidence = 0.85
+		} else {
+			l.Confidence = 0.95
+		}
+		l.Graded, l.Grader, l.Grade, l.Qualifier = false, "", 0, ""
+	}
+
+	l.Lot = lotWords.MatchString(title)
+	l.Proxy = proxyWords.MatchString(title)
+	if l.Lot && l.Confidence > 0.5 {
+		l.Confidence = 0.5 // a lot's grade may describe only some of its cards
+	}
+
+	for _, lang := range languages {
+		if lang.pattern.MatchString(title) {
+			l.Language = lang.name
+			break
+		}
+	}
+
+	if reverseHolo.MatchString(rest) {
+		l.Variants = append(l.Variants, VariantReverseHolo)
+		rest = reverseHolo.ReplaceAllString(rest, " ")
+	}
+	rest = nonHolo.ReplaceAllString(rest, " ")
+	rest = conditionEX.ReplaceAllString(rest, " ")
+	for _, v := range variants {
+		if v.pattern.MatchString(rest) {
+			l.Variants = append(l.Variants, v.name)
+		}
+	}
+
+	return l
+}
+
+// gradeMatch is one grade named in a title
+type gradeMatch struct {
+	grader     string
+	grade      float64
+	qualifier  string
+	start, end int
+	question   bool // followed by "?", as in "PSA 10?"
+}
+
+// findGrades returns the grades a title names, graders first. Numbers that
+// run on into card numbers ("PSA 4/102") or other digits ("PSA 100th") are
+// not grades.
+func findGrades(title string) []gradeMatch {
+	var found []gradeMatch
+	for _, m := range graderGrade.FindAllStringSubmatchIndex(title, -1) {
+		if g, ok := readGrade(title, m[0], m[4], m[5]); ok {
+			g.grader = graderName(title[m[2]:m[3]])
+			found = append(found, g)
+		}
+	}
+	if len(found) > 0 {
+		return found
+	}
+	for _, m := range conditionGrade.FindAllStringSubmatchIndex(title, -1) {
+		if g, ok := readGrade(title, m[0], m[2], m[3]); ok {
+			found = append(found, g)
+		}
+	}
+	return found
+}
+
+// readGrade reads the number at title[numStart:numEnd] and the qualifier
+// after it, rejecting numbers that continue past the match
+func readGrade(title string, start, numStart, numEnd int) (gradeMatch, bool) {
+	if numEnd < len(title) {
+		switch c := title[numEnd]; {
+		case c >= '0' && c <= '9', c == '/', c == '.' && numEnd+1 < len(title) && title[numEnd+1] >= '0' && title[numEnd+1] <= '9':
+			return gradeMatch{}, false
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			return gradeMatch{}, false
+		}
+	}
+	grade, err := strconv.ParseFloat(title[numStart:numEnd], 64)
+	if err != nil || grade < 1 || grade > 10 {
+		return gradeMatch{}, false
+	}
+	g := gradeMatch{grade: grade, start: start, end: numEnd}
+	tail := title[numEnd:]
+	if q := qualifier.FindStringSubmatchIndex(tail); q != nil {
+		g.qualifier = strings.ToUpper(tail[q[2]:q[3]])
+		g.end = numEnd + q[1]
+	}
+	g.question = strings.HasPrefix(strings.TrimSpace(tail), "?")
+	return g, true
+}
+
+// graderName maps a grader as written in a title onto its short name
+func graderName(s string) string {
+	switch strings.ToUpper(s) {
+	case "PSA":
+		return PSA
+	case "BGS", "BECKETT":
+		return BGS
+	case "CGC":
+		return CGC
+	case "SGC":
+		return SGC
+	case "TAG":
+		return TAG
+	case "ACE":
+		return ACE
+	}
+	return ""
+}
+
+// blank replaces s[start:end] with spaces so later patterns skip it
+func blank(s string, start, end int) string {
+	return s[:start] + strings.Repeat(" ", end-start) + s[end:]
+}
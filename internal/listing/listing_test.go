@@ -0,0 +1,202 @@
+package listing
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse_Grades(t *testing.T) {
+	tests := []struct {
+		title  string
+		graded bool
+		label  string
+	}{
+		// Grader and grade
+		{"Pokemon Charizard PSA 10", true, "PSA 10"},
+		{"Charizard 4/102 Base Set Holo PSA 9", true, "PSA 9"},
+		{"PSA10 Umbreon VMAX 215/203", true, "PSA 10"},
+		{"psa-9 pikachu illustrator", true, "PSA 9"},
+		{"Lugia Neo Genesis PSA GEM MT 10", true, "PSA 10"},
+		{"Blastoise 2/102 PSA NM-MT 8", true, "PSA 8"},
+		{"Venusaur PSA EX 5 Base Set", true, "PSA 5"},
+		{"Mewtwo PSA 1.5 Fair", true, "PSA 1.5"},
+		{"BGS 9.5 Pikachu Card", true, "BGS 9.5"},
+		{"Beckett 9.5 Gem Mint Rayquaza", true, "BGS 9.5"},
+		{"Beckett Black Label 10 Gengar", true, "BGS 10"},
+		{"Charizard BGS 10 PRISTINE", true, "BGS 10"},
+		{"bgs 8.5 Dark Charizard", true, "BGS 8.5"},
+		{"CGC 9 Mint Card", true, "CGC 9"},
+		{"CGC Pristine 10 Mew ex", true, "CGC 10"},
+		{"CGC 9.5 Gem Mint Gyarados", true, "CGC 9.5"},
+		{"SGC 9 Mint Charizard 4/102", true, "SGC 9"},
+		{"SGC 10 Gold Label Pikachu", true, "SGC 10"},
+		{"TAG 10 Moonbreon", true, "TAG 10"},
+		{"ACE 10 Charizard UPC Promo", true, "ACE 10"},
+		{"PSA #10 Eevee", true, "PSA 10"},
+		{"Charizard PSA 10.", true, "PSA 10"},
+		{"BGS 9.0 Mewtwo", true, "BGS 9"},
+
+		// Qualifiers
+		{"Charizard Base Set PSA 9 OC", true, "PSA 9 OC"},
+		{"Pikachu Illustrator PSA 8 (MC)", true, "PSA 8 MC"},
+		{"Blastoise PSA 7 ST holo", true, "PSA 7 ST"},
+		{"Mewtwo psa 6 pd", true, "PSA 6 PD"},
+		{"Gengar PSA 9 mk Fossil", true, "PSA 9 MK"},
+
+		// Condition-only grades
+		{"Perfect 10 Gem Mint", true, "Graded 10"},
+		{"Umbreon Gem Mint 10", true, "Graded 10"},
+		{"Pristine 10 Lugia", true, "Graded 10"},
+
+		// Slabs without a readable grade
+		{"Authentic slab card", true, "Graded"},
+		{"Graded by professional", true, "Graded"},
+		{"Charizard PSA Authentic", true, "PSA"},
+		{"Pikachu Beckett slab", true, "BGS"},
+		{"CGC encapsulated Eevee", true, "CGC"},
+
+		// Raw
+		{"Pokemon Raw Charizard NM", false, "Raw"},
+		{"Ungraded Pikachu Card", false, "Raw"},
+		{"Pokemon Charizard Ungraded", false, "Raw"},
+		{"Near Mint Card", false, "Raw"},
+		{"Charizard 4/102 Near Mint", false, "Raw"},
+		{"Umbreon VMAX 215/203 Evolving Skies NM", false, "Raw"},
+		{"Charizard not graded LP", false, "Raw"},
+		{"Gem Mint Charizard pack fresh", false, "Raw"},
+		{"Pikachu Mint 9/102", false, "Raw"},
+
+		// Grading candidates are raw
+		{"PSA Ready Card", false, "Raw"},
+		{"Charizard PSA 10 candidate", false, "Raw"},
+		{"Umbreon VMAX psa 10 worthy", false, "Raw"},
+		{"Potential PSA 10 Lugia", false, "Raw"},
+		{"Moonbreon PSA 10?", false, "Raw"},
+		{"Charizard Gem Mint quality BGS 10 possible", false, "Raw"},
+		{"Raw Lugia, would grade PSA 9", false, "Raw"},
+		{"Mewtwo pre-graded 10", false, "Raw"},
+
+		// Numbers that aren't grades
+		{"Pikachu PSA 100th anniversary", true, "PSA"},
+		{"Charizard PSA 4/102", true, "PSA"},
+		{"Mew ex 151 Japanese", false, "Raw"},
+		{"Ace Spec Prime Catcher 157/162", false, "Raw"},
+		{"Tag Team Pikachu & Zekrom GX 33/181", false, "Raw"},
+		{"Charizard ex 223/197 Obsidian Flames", false, "Raw"},
+	}
+	for _, tt := range tests {
+		l := Parse(tt.title)
+		if l.Graded != tt.graded || l.Label() != tt.label {
+			t.Errorf("Parse(%q): graded %v label %q, want %v %q", tt.title, l.Graded, l.Label(), tt.graded, tt.label)
+		}
+	}
+}
+
+func TestParse_Flags(t *testing.T) {
+	tests := []struct {
+		title      string
+		lot, proxy bool
+		language   string
+	}{
+		{"Pokemon Cards Lot", true, false, English},
+		{"Pokemon card bundle 50 holos", true, false, English},
+		{"100 Cards Bulk Pokemon", true, false, English},
+		{"Job lot vintage WOTC", true, false, English},
+		{"Charizard 4/102 x 1", false, false, English},
+		{"3x Pikachu V playset", true, false, English},
+		{"Binder collection 1999", true, false, English},
+		{"Mystery Pack Charizard guaranteed", true, false, English},
+		{"Complete Set Base Set 102/102", true, false, English},
+		{"Charizard Proxy Card Gold", false, true, English},
+		{"Custom Fan Made Mewtwo", false, true, English},
+		{"Pikachu Illustrator reprint", false, true, English},
+		{"Orica Umbreon Gold Star", false, true, English},
+		{"Charizard Gold Metal Card", false, true, English},
+		{"Japanese Pikachu Card", false, false, "Japanese"},
+		{"Charizard JPN Promo", false, false, "Japanese"},
+		{"Pokemon Card Japan Exclusive", false, false, "Japanese"},
+		{"Korean Umbreon VMAX", false, false, "Korean"},
+		{"Chinese Gem Pack Pikachu", false, false, "Chinese"},
+		{"Glurak German 1st Edition", false, false, "German"},
+		{"Dracaufeu Français holo", false, false, "French"},
+		{"Italian Charizard base", false, false, "Italian"},
+		{"Charizard Spanish Holo", false, false, "Spanish"},
+		{"Charizard ENG NM", false, false, English},
+	}
+	for _, tt := range tests {
+		l := Parse(tt.title)
+		if l.Lot != tt.lot || l.Proxy != tt.proxy || l.Language != tt.language {
+			t.Errorf("Parse(%q): lot %v proxy %v language %s, want %v %v %s",
+				tt.title, l.Lot, l.Proxy, l.Language, tt.lot, tt.proxy, tt.language)
+		}
+	}
+}
+
+func TestParse_Variants(t *testing.T) {
+	tests := []struct {
+		title    string
+		variants string
+	}{
+		{"Charizard 4/102 Base Set 1st Edition Holo PSA 9", "1st Edition,Holo"},
+		{"Charizard Shadowless Holo Base Set", "Shadowless,Holo"},
+		{"Blastoise First Edition Base", "1st Edition"},
+		{"Charizard Base Set Unlimited Holo Rare", "Unlimited,Holo"},
+		{"Pikachu Reverse Holo Evolving Skies", "Reverse Holo"},
+		{"Eevee rev holo 125/203", "Reverse Holo"},
+		{"Lapras non-holo Fossil", ""},
+		{"Venusaur Holo Rare", "Holo"},
+		{"Umbreon VMAX Alt Art 215/203", "Alt Art,VMAX"},
+		{"Giratina V Alternate Art", "Alt Art,V"},
+		{"Charizard GX Rainbow Rare Hidden Fates", "Secret Rare,GX"},
+		{"Mew ex Full Art 232/091", "Full Art,EX"},
+		{"Arceus VSTAR Gold Secret", "Secret Rare,VSTAR"},
+		{"Pikachu Black Star Promo SWSH020", "Promo"},
+		{"Charizard EX-MT condition raw", ""},
+		{"Blastoise PSA EX 5", ""},
+		{"Lugia V 186/195 Silver Tempest", "V"},
+	}
+	for _, tt := range tests {
+		if got := strings.Join(Parse(tt.title).Variants, ","); got != tt.variants {
+			t.Errorf("Parse(%q) variants %q, want %q", tt.title, got, tt.variants)
+		}
+	}
+}
+
+func TestParse_Confidence(t *testing.T) {
+	tests := []struct {
+		title    string
+		min, max float64
+	}{
+		{"Charizard PSA 10", 0.9, 1},
+		{"Ungraded Charizard", 0.9, 1},
+		{"Charizard PSA 10 candidate", 0.8, 0.9},
+		{"Charizard Base Set", 0.6, 0.8},
+		{"Perfect 10 Gem Mint", 0.5, 0.7},
+		{"Charizard graded", 0.4, 0.6},
+		{"Charizard PSA 9 PSA 10", 0.4, 0.6},
+		{"PSA 10 lot of Pikachu", 0.4, 0.6},
+	}
+	for _, tt := range tests {
+		if c := Parse(tt.title).Confidence; c < tt.min || c > tt.max {
+			t.Errorf("Parse(%q) confidence %.2f, want %.2f-%.2f", tt.title, c, tt.min, tt.max)
+		}
+	}
+}
+
+func TestListing_SingleRaw(t *testing.T) {
+	tests := map[string]bool{
+		"Charizard 4/102 Base Set Holo":  true,
+		"Charizard PSA 9":                false,
+		"Pokemon Cards Lot":              false,
+		"Charizard Proxy":                false,
+		"Pikachu PSA 10 candidate NM":    true,
+		"Japanese Mew 151 Promo":         true,
+		"Bulk 200 cards commons":         false,
+		"Umbreon VMAX 215/203 Near Mint": true,
+	}
+	for title, want := range tests {
+		if got := Parse(title).SingleRaw(); got != want {
+			t.Errorf("Parse(%q).SingleRaw() = %v, want %v", title, got, want)
+		}
+	}
+}
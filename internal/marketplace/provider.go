@@ -18,12 +18,12 @@ type PriceChartingMarketplace struct {
 	apiKey      string
 	httpClient  *http.Client
 	baseURL     string
-	cache       *cache.Cache
+	listings    cache.Typed[*MarketListings]
 	rateLimiter *rate.Limiter
 }
 
 // NewPriceChartingMarketplace creates a new PriceCharting marketplace provider
-func NewPriceChartingMarketplace(apiKey string, c *cache.Cache) *PriceChartingMarketplace {
+func NewPriceChartingMarketplace(apiKey string, c cache.Store) *PriceChartingMarketplace {
 	if apiKey == "" || apiKey == "test" || apiKey == "mock" {
 		return nil
 	}
@@ -34,7 +34,7 @@ func NewPriceChartingMarketplace(apiKey string, c *cache.Cache) *PriceChartingMa
 			Timeout: 30 * time.Second,
 		},
 		baseURL:     "https://www.pricecharting.com",
-		cache:       c,
+		listings:    cache.NewTyped[*MarketListings](c, "marketplace", "listings"),
 		rateLimiter: rate.NewLimiter(rate.Every(time.Second), 5), // 5 requests per second
 	}
 }
@@ -56,11 +56,8 @@ func (p *PriceChartingMarketplace) GetActiveListings(productID string) (*MarketL
 	}
 
 	// Check cache first
-	cacheKey := fmt.Sprintf("marketplace:listings:%s", productID)
-	if cached := p.getCached(cacheKey); cached != nil {
-		if listings, ok := cached.(*MarketListings); ok {
-			return listings, nil
-		}
+	if listings, found := p.listings.Get(productID); found && listings != nil {
+		return listings, nil
 	}
 
 	// Wait for rate limiter with proper context
@@ -165,7 +162,7 @@ func (p *PriceChartingMarketplace) GetActiveListings(productID string) (*MarketL
 	}
 
 	// Cache the result
-	p.setCache(cacheKey, listings, 15*time.Minute)
+	_ = p.listings.Set(productID, listings, 15*time.Minute)
 
 	return listings, nil
 }
@@ -344,23 +341,3 @@ func convertToSellerMetrics(sellers []*SellerMetric) []SellerMetric {
 	}
 	return result
 }
-
-// getCached retrieves a cached value
-func (p *PriceChartingMarketplace) getCached(key string) interface{} {
-	if p.cache == nil {
-		return nil
-	}
-
-	var data interface{}
-	if found, _ := p.cache.Get(key, &data); found {
-		return data
-	}
-	return nil
-}
-
-// setCache stores a value in cache
-func (p *PriceChartingMarketplace) setCache(key string, value interface{}, ttl time.Duration) {
-	if p.cache != nil {
-		_ = p.cache.Put(key, value, ttl)
-	}
-}
@@ -53,5 +53,36 @@ type PSAPopulation struct {
 	PSA10       int
 	PSA9        int
 	PSA8        int
+	Grades      map[string]int // Full breakdown, e.g. "PSA 10" → count, when the provider has it
 	LastUpdated time.Time
+
+	// Growth read from the population history; zero without one
+	Trend              string  // "INCREASING", "STABLE", "DECREASING"; empty when unknown
+	PSA10PerMonth      float64 // new PSA 10s a month
+	GemRateDrift       float64 // change in the PSA 10 share of graded copies a month
+	MonthsToSaturation float64 // until the PSA 10 population turns common; 0 = already, -1 = not growing
+
+	// Supply across PSA, CGC and BGS when the other companies' census was
+	// looked up; zero otherwise
+	CombinedGraded int
+	CombinedGem    int // 10s at every company, PSA 10s included
+
+	// Where the counts came from and when that source fetched them
+	Source    string // e.g. "PSA API", "PSA Scraper", "Population Mock"
+	FetchedAt time.Time
+	Estimated bool // made-up counts, such as a mock's, rather than a census
+}
+
+// PSA10After projects the PSA 10 population days from now at its current pace
+func (p *PSAPopulation) PSA10After(days int) int {
+	if p.PSA10PerMonth <= 0 || days <= 0 {
+		return p.PSA10
+	}
+	return p.PSA10 + int(p.PSA10PerMonth*float64(days)/30)
+}
+
+// GemSupplyAfter is the 10s on the market days from now: the projected PSA
+// 10s plus the other companies' 10s as they stand
+func (p *PSAPopulation) GemSupplyAfter(days int) int {
+	return p.PSA10After(days) + max(p.CombinedGem-p.PSA10, 0)
 }
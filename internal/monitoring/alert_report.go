@@ -218,6 +218,12 @@ func FormatAlertReport(report *AlertReport) string {
 	if report.Metadata.AlertConfig.VolatilityLowThreshold > 0 {
 		output += fmt.Sprintf("- Low Volatility Threshold: %.1f%%\n", report.Metadata.AlertConfig.VolatilityLowThreshold)
 	}
+	if report.Metadata.AlertConfig.PopJumpThresholdPct > 0 {
+		output += fmt.Sprintf("- PSA 10 Population Jump Threshold: %.1f%%\n", report.Metadata.AlertConfig.PopJumpThresholdPct)
+	}
+	if report.Metadata.AlertConfig.GemRateDropThresholdPts > 0 {
+		output += fmt.Sprintf("- Gem Rate Drop Threshold: %.1f points\n", report.Metadata.AlertConfig.GemRateDropThresholdPts)
+	}
 	if report.Metadata.AlertConfig.MinSeverity != "" {
 		output += fmt.Sprintf("- Minimum Severity: %s\n", report.Metadata.AlertConfig.MinSeverity)
 	}
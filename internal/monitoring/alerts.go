@@ -5,7 +5,9 @@ import (
 	"sort"
 	"time"
 
+	"github.com/guarzo/pkmgradegap/internal/fees"
 	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/population"
 )
 
 // AlertType represents different types of price alerts
@@ -18,8 +20,14 @@ const (
 	AlertVolatilitySpike    AlertType = "VOLATILITY_SPIKE"
 	AlertVolatilityLow      AlertType = "VOLATILITY_LOW"
 	AlertAuctionOpportunity AlertType = "AUCTION_OPPORTUNITY"
+	AlertPopulationJump     AlertType = "POPULATION_JUMP"
+	AlertGemRateDrop        AlertType = "GEM_RATE_DROP"
 )
 
+// minGradedForGemRate is the graded population below which a gem rate is
+// too noisy to alert on
+const minGradedForGemRate = 50
+
 // Alert represents a significant market event
 type Alert struct {
 	Type        AlertType
@@ -38,17 +46,21 @@ type AlertConfig struct {
 	OpportunityThresholdROI float64 // Min ROI to trigger opportunity alert
 	VolatilityHighThreshold float64 // Trigger high volatility alert above this %
 	VolatilityLowThreshold  float64 // Trigger low volatility alert below this %
+	PopJumpThresholdPct     float64 // Trigger alert if the PSA 10 population grows by this % between checks
+	GemRateDropThresholdPts float64 // Trigger alert if the gem rate falls by this many percentage points between checks
 	MinSeverity             string  // Only show alerts at or above this severity ("HIGH", "MEDIUM", "LOW")
 }
 
 // AlertEngine processes snapshots and generates alerts
 type AlertEngine struct {
 	config AlertConfig
+	fees   *fees.Book
 }
 
-// NewAlertEngine creates a new alert engine with the given config
-func NewAlertEngine(config AlertConfig) *AlertEngine {
-	return &AlertEngine{config: config}
+// NewAlertEngine creates a new alert engine with the given config, pricing
+// grading with book's PSA schedules; a nil book uses the built-in ones
+func NewAlertEngine(config AlertConfig, book *fees.Book) *AlertEngine {
+	return &AlertEngine{config: config, fees: book}
 }
 
 // GenerateAlerts analyzes price deltas and creates relevant alerts
@@ -117,19 +129,23 @@ func (ae *AlertEngine) GenerateAlerts(deltas []PriceDelta) []Alert {
 	return alerts
 }
 
-// CheckNewOpportunities identifies cards that have entered profitable grading range
+// CheckNewOpportunities identifies cards that have entered profitable grading
+// range. A gradingCost of 0 prices each snapshot with the PSA fee schedule in
+// effect when it was taken.
 func (ae *AlertEngine) CheckNewOpportunities(old, new *Snapshot, gradingCost, shippingCost, feePct float64) []Alert {
 	var alerts []Alert
 
 	for key, newCard := range new.Cards {
-		oldCard, exists := old.Cards[key]
+		oldCard, exists := old.lookup(key, newCard.Card)
 		if !exists {
 			continue
 		}
 
 		// Calculate old and new ROI
-		oldROI := calculateROI(oldCard.RawUSD, oldCard.PSA10Price, gradingCost, shippingCost, feePct)
-		newROI := calculateROI(newCard.RawUSD, newCard.PSA10Price, gradingCost, shippingCost, feePct)
+		oldROI := calculateROI(oldCard.RawUSD, oldCard.PSA10Price, gradingFee(ae.fees, gradingCost, oldCard.PSA10Price, old.Timestamp), shippingCost, feePct)
+		newFee := gradingFee(ae.fees, gradingCost, newCard.PSA10Price, new.Timestamp)
+		newROI := calculateROI(newCard.RawUSD, newCard.PSA10Price, newFee, shippingCost, feePct)
+		profit := newCard.PSA10Price - newCard.RawUSD - newFee - shippingCost - (newCard.PSA10Price * feePct)
 
 		// Check if card crossed into profitable territory
 		if oldROI < ae.config.OpportunityThresholdROI && newROI >= ae.config.OpportunityThresholdROI {
@@ -144,11 +160,11 @@ func (ae *AlertEngine) CheckNewOpportunities(old, new *Snapshot, gradingCost, sh
 					"new_roi":     newROI,
 					"raw_price":   newCard.RawUSD,
 					"psa10_price": newCard.PSA10Price,
-					"profit_est":  newCard.PSA10Price - newCard.RawUSD - gradingCost - shippingCost - (newCard.PSA10Price * feePct),
+					"profit_est":  profit,
 				},
 				ActionItems: []string{
 					fmt.Sprintf("Buy raw at $%.2f", newCard.RawUSD),
-					fmt.Sprintf("Expected profit: $%.2f", newCard.PSA10Price-newCard.RawUSD-gradingCost-shippingCost-(newCard.PSA10Price*feePct)),
+					fmt.Sprintf("Expected profit: $%.2f", profit),
 					"Submit for grading with next batch",
 				},
 			}
@@ -164,7 +180,7 @@ func (ae *AlertEngine) CheckVolatilityAlerts(old, new *Snapshot) []Alert {
 	var alerts []Alert
 
 	for key, newCard := range new.Cards {
-		oldCard, exists := old.Cards[key]
+		oldCard, exists := old.lookup(key, newCard.Card)
 		if !exists {
 			continue
 		}
@@ -225,6 +241,109 @@ func (ae *AlertEngine) CheckVolatilityAlerts(old, new *Snapshot) []Alert {
 	return ae.filterBySeverity(alerts)
 }
 
+// PopulationChange is a card's population at the last check and at this one
+type PopulationChange struct {
+	Card   model.Card
+	Before population.Observation
+	After  population.Observation
+}
+
+// PopulationChanges pairs each card in the new snapshot with its population
+// as last observed by the old snapshot and by the new one. Cards without an
+// observation on both sides are left out.
+func PopulationChanges(h *population.History, old, new *Snapshot) []PopulationChange {
+	var changes []PopulationChange
+	seen := make(map[string]bool)
+	for _, data := range new.Cards {
+		card := data.Card
+		if card.SetName == "" {
+			card.SetName = new.SetName
+		}
+		// Printings of a card share its population
+		key := card.SetName + "|" + card.Name + "|" + card.Number
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if before, after, ok := h.Between(card, old.Timestamp, new.Timestamp); ok {
+			changes = append(changes, PopulationChange{Card: card, Before: before, After: after})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Card.Number < changes[j].Card.Number })
+	return changes
+}
+
+// CheckPopulationAlerts flags supply shocks: a jump in the PSA 10
+// population, which usually runs ahead of a fall in the PSA 10 price, and a
+// drop in the gem rate
+func (ae *AlertEngine) CheckPopulationAlerts(changes []PopulationChange) []Alert {
+	var alerts []Alert
+
+	for _, change := range changes {
+		before, after := change.Before, change.After
+		details := map[string]interface{}{
+			"old_psa10":    before.PSA10,
+			"new_psa10":    after.PSA10,
+			"old_total":    before.Total,
+			"new_total":    after.Total,
+			"old_gem_rate": before.GemRate() * 100,
+			"new_gem_rate": after.GemRate() * 100,
+			"observed_at":  after.At.Format("2006-01-02"),
+		}
+
+		if ae.config.PopJumpThresholdPct > 0 && before.PSA10 > 0 {
+			jumpPct := float64(after.PSA10-before.PSA10) / float64(before.PSA10) * 100
+			if jumpPct >= ae.config.PopJumpThresholdPct {
+				alerts = append(alerts, Alert{
+					Type:     AlertPopulationJump,
+					Severity: ae.getSeverity(jumpPct),
+					Card:     change.Card,
+					Message: fmt.Sprintf("PSA 10 population jumped %.1f%% (%d to %d) since %s",
+						jumpPct, before.PSA10, after.PSA10, before.At.Format("2006-01-02")),
+					Timestamp: time.Now(),
+					Details:   withDetail(details, "pop_jump_pct", jumpPct),
+					ActionItems: []string{
+						"Consider selling graded PSA 10 copies before the price adjusts",
+						"Hold off on buying raw copies to grade",
+						"Watch the PSA 10 price over the next few checks",
+					},
+				})
+			}
+		}
+
+		if ae.config.GemRateDropThresholdPts > 0 && before.Total >= minGradedForGemRate && after.Total >= minGradedForGemRate {
+			dropPts := (before.GemRate() - after.GemRate()) * 100
+			if dropPts >= ae.config.GemRateDropThresholdPts {
+				alerts = append(alerts, Alert{
+					Type:     AlertGemRateDrop,
+					Severity: ae.getGemRateSeverity(dropPts),
+					Card:     change.Card,
+					Message: fmt.Sprintf("Gem rate dropped %.1f points (%.1f%% to %.1f%%) since %s",
+						dropPts, before.GemRate()*100, after.GemRate()*100, before.At.Format("2006-01-02")),
+					Timestamp: time.Now(),
+					Details:   withDetail(details, "gem_rate_drop_pts", dropPts),
+					ActionItems: []string{
+						"Fewer submissions are coming back PSA 10; recheck grading odds",
+						"Raise the condition bar for raw copies you submit",
+					},
+				})
+			}
+		}
+	}
+
+	return ae.filterBySeverity(alerts)
+}
+
+// withDetail copies details with one more entry, so alerts don't share a map
+func withDetail(details map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(details)+1)
+	for k, v := range details {
+		out[k] = v
+	}
+	out[key] = value
+	return out
+}
+
 // filterBySeverity removes alerts below the configured minimum severity
 func (ae *AlertEngine) filterBySeverity(alerts []Alert) []Alert {
 	if ae.config.MinSeverity == "" {
@@ -246,6 +365,15 @@ func (ae *AlertEngine) filterBySeverity(alerts []Alert) []Alert {
 	return filtered
 }
 
+// gradingFee returns gradingCost when set, otherwise the PSA fee for the
+// declared value from book's schedule in effect at t
+func gradingFee(book *fees.Book, gradingCost, declaredValue float64, t time.Time) float64 {
+	if gradingCost > 0 {
+		return gradingCost
+	}
+	return book.PSA(t).Fee(declaredValue)
+}
+
 func calculateROI(rawPrice, psa10Price, gradingCost, shippingCost, feePct float64) float64 {
 	totalCost := rawPrice + gradingCost + shippingCost
 	netRevenue := psa10Price * (1 - feePct)
@@ -272,6 +400,15 @@ func (ae *AlertEngine) getVolatilitySeverity(volatility float64) string {
 	return "LOW"
 }
 
+func (ae *AlertEngine) getGemRateSeverity(dropPts float64) string {
+	if dropPts >= 10 {
+		return "HIGH"
+	} else if dropPts >= 5 {
+		return "MEDIUM"
+	}
+	return "LOW"
+}
+
 func severityRank(severity string) int {
 	switch severity {
 	case "HIGH":
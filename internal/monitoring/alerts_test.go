@@ -1,11 +1,14 @@
 package monitoring
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/guarzo/pkmgradegap/internal/fees"
 	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/population"
 )
 
 func TestAlertGeneration(t *testing.T) {
@@ -15,7 +18,7 @@ func TestAlertGeneration(t *testing.T) {
 		OpportunityThresholdROI: 20.0,
 	}
 
-	alertEngine := NewAlertEngine(config)
+	alertEngine := NewAlertEngine(config, nil)
 
 	deltas := []PriceDelta{
 		{
@@ -94,3 +97,96 @@ func TestFormatAlert(t *testing.T) {
 		t.Error("Expected formatted alert to contain action items")
 	}
 }
+
+func TestCheckNewOpportunities_UsesSnapshotFees(t *testing.T) {
+	book, err := fees.Parse([]byte(`{"version": 1, "schedules": [
+		{"grader": "PSA", "effective": "2020-01-01", "levels": [{"name": "Any", "costPerCard": 200, "turnaroundDays": 30}]},
+		{"grader": "PSA", "effective": "2024-01-01", "levels": [{"name": "Any", "costPerCard": 20, "turnaroundDays": 30}]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	card := &SnapshotCardData{Card: model.Card{Name: "Pikachu", Number: "25"}, RawUSD: 50, PSA10Price: 200}
+	old := &Snapshot{Timestamp: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Cards: map[string]*SnapshotCardData{"25": card}}
+	newer := &Snapshot{Timestamp: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC), Cards: map[string]*SnapshotCardData{"25": card}}
+
+	// Same prices, but the fee cut makes the card newly profitable
+	engine := NewAlertEngine(AlertConfig{OpportunityThresholdROI: 20}, book)
+	alerts := engine.CheckNewOpportunities(old, newer, 0, 10, 0.1)
+	if len(alerts) != 1 {
+		t.Fatalf("expected one opportunity from the fee change, got %d", len(alerts))
+	}
+	if got := alerts[0].Details["profit_est"].(float64); got != 200-50-20-10-20 {
+		t.Errorf("expected profit at the new fee, got %.2f", got)
+	}
+
+	// An explicit grading cost ignores the schedules
+	if alerts := engine.CheckNewOpportunities(old, newer, 25, 10, 0.1); len(alerts) != 0 {
+		t.Errorf("expected no opportunity with a fixed fee, got %d", len(alerts))
+	}
+}
+
+func TestCheckPopulationAlerts(t *testing.T) {
+	history, err := population.OpenHistory(filepath.Join(t.TempDir(), "pop.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	pikachu := model.Card{Name: "Pikachu ex", Number: "238", SetName: "Surging Sparks"}
+	sprigatito := model.Card{Name: "Sprigatito", Number: "001", SetName: "Surging Sparks"}
+	eevee := model.Card{Name: "Eevee", Number: "143", SetName: "Surging Sparks"}
+	record := func(card model.Card, daysAgo, psa10, total int) {
+		t.Helper()
+		data := &population.PopulationData{PSA10Population: psa10, TotalGraded: total, LastUpdated: now.AddDate(0, 0, -daysAgo)}
+		if err := history.Record(card, data, "PSA"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record(pikachu, 10, 100, 400) // 40% more PSA 10s by the new snapshot
+	record(pikachu, 1, 140, 600)
+	record(sprigatito, 10, 50, 100) // gem rate falls from 50% to 36%
+	record(sprigatito, 1, 54, 150)
+	record(eevee, 1, 10, 20) // nothing from before the old snapshot
+
+	snapshotCard := func(card model.Card) *SnapshotCardData { return &SnapshotCardData{Card: card} }
+	cards := map[string]*SnapshotCardData{
+		"238-Pikachu ex (Holo)":         snapshotCard(pikachu),
+		"238-Pikachu ex (Reverse Holo)": snapshotCard(pikachu),
+		"001-Sprigatito":                snapshotCard(sprigatito),
+		"143-Eevee":                     snapshotCard(eevee),
+	}
+	old := &Snapshot{Timestamp: now.AddDate(0, 0, -5), SetName: "Surging Sparks", Cards: cards}
+	newer := &Snapshot{Timestamp: now, SetName: "Surging Sparks", Cards: cards}
+
+	changes := PopulationChanges(history, old, newer)
+	if len(changes) != 2 {
+		t.Fatalf("expected a change for Pikachu and Sprigatito only, got %+v", changes)
+	}
+
+	engine := NewAlertEngine(AlertConfig{PopJumpThresholdPct: 10, GemRateDropThresholdPts: 5}, nil)
+	alerts := engine.CheckPopulationAlerts(changes)
+	byType := make(map[AlertType]Alert)
+	for _, a := range alerts {
+		byType[a.Type] = a
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected a population jump and a gem rate drop, got %+v", alerts)
+	}
+	jump := byType[AlertPopulationJump]
+	if jump.Card.Name != "Pikachu ex" || jump.Severity != "HIGH" || jump.Details["pop_jump_pct"].(float64) != 40 {
+		t.Errorf("unexpected population jump alert: %+v", jump)
+	}
+	if !strings.Contains(jump.Message, "100 to 140") {
+		t.Errorf("expected the counts in the message, got %q", jump.Message)
+	}
+	drop := byType[AlertGemRateDrop]
+	if drop.Card.Name != "Sprigatito" || drop.Severity != "HIGH" {
+		t.Errorf("unexpected gem rate drop alert: %+v", drop)
+	}
+
+	// Zero thresholds turn the checks off
+	if alerts := NewAlertEngine(AlertConfig{}, nil).CheckPopulationAlerts(changes); len(alerts) != 0 {
+		t.Errorf("expected no alerts without thresholds, got %+v", alerts)
+	}
+}
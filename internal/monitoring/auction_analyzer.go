@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/guarzo/pkmgradegap/internal/ebay"
+	"github.com/guarzo/pkmgradegap/internal/listing"
 )
 
 // AuctionOpportunity represents a profitable auction opportunity
@@ -197,8 +198,14 @@ func (aa *AuctionAnalyzer) estimateCardValue(auction ebay.Auction) float64 {
 	baseValue := auction.CurrentBid
 	multiplier := 1.0
 
+	card := listing.Parse(auction.Title)
 	titleLower := strings.ToLower(auction.Title)
 
+	// Slabs, lots and proxies can't be bought and sent for grading as one card
+	if !card.SingleRaw() {
+		return 0
+	}
+
 	// Skip very low value cards that aren't worth grading
 	if containsAnySubstring(titleLower, []string{"energy", "trainer", "basic"}) && baseValue < 5.0 {
 		return 0 // Not worth grading
@@ -207,23 +214,35 @@ func (aa *AuctionAnalyzer) estimateCardValue(auction ebay.Auction) float64 {
 	// Higher multipliers for valuable card types
 	if containsAnySubstring(titleLower, []string{"charizard", "pikachu", "mew", "lugia"}) {
 		multiplier *= 3.0
-	} else if containsAnySubstring(titleLower, []string{"holo", "rare", "ex", "gx", "v", "vmax"}) {
+	} else if containsAnySubstring(titleLower, []string{"rare"}) || hasAnyVariant(card, listing.VariantHolo,
+		listing.VariantEX, listing.VariantGX, listing.VariantV, listing.VariantVMAX, listing.VariantVSTAR,
+		listing.VariantFullArt, listing.VariantAltArt, listing.VariantSecretRare) {
 		multiplier *= 2.0
 	}
 
 	// First edition bonus
-	if containsAnySubstring(titleLower, []string{"1st edition", "shadowless", "base set"}) {
+	if hasAnyVariant(card, listing.VariantFirstEdition, listing.VariantShadowless) || strings.Contains(titleLower, "base set") {
 		multiplier *= 1.5
 	}
 
 	// Japanese cards premium
-	if containsAnySubstring(titleLower, []string{"japanese", "japan", "jpn"}) {
+	if card.Language == "Japanese" {
 		multiplier *= 1.2
 	}
 
 	return baseValue * multiplier
 }
 
+// hasAnyVariant reports whether the listing names any of the variants
+func hasAnyVariant(card listing.Listing, variants ...string) bool {
+	for _, v := range variants {
+		if card.Has(v) {
+			return true
+		}
+	}
+	return false
+}
+
 // assessRisk evaluates the risk level of an auction opportunity
 func (aa *AuctionAnalyzer) assessRisk(auction ebay.Auction, profitScore float64) string {
 	riskFactors := 0
@@ -261,10 +280,12 @@ func (aa *AuctionAnalyzer) assessRisk(auction ebay.Auction, profitScore float64)
 	}
 }
 
-// containsAnySubstring checks if any of the substrings exist in the target string
+// containsAnySubstring checks if any of the substrings exist in the target
+// string, ignoring case
 func containsAnySubstring(target string, substrings []string) bool {
+	target = strings.ToLower(target)
 	for _, substr := range substrings {
-		if strings.Contains(target, substr) {
+		if strings.Contains(target, strings.ToLower(substr)) {
 			return true
 		}
 	}
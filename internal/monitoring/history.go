@@ -22,6 +22,7 @@ type HistoryEntry struct {
 	DeltaUSD  float64
 	Score     float64
 	Notes     string
+	NetROI    *float64 // net profit over the all-in cost, 0.5 = 50%; nil in rows written before it was recorded
 }
 
 // HistoryAnalyzer analyzes historical tracking data
@@ -49,6 +50,7 @@ func (ha *HistoryAnalyzer) LoadHistory(path string) error {
 	defer file.Close()
 
 	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1 // older files have fewer columns than the rows appended to them
 	records, err := reader.ReadAll()
 	if err != nil {
 		return fmt.Errorf("reading CSV: %w", err)
@@ -71,6 +73,11 @@ func (ha *HistoryAnalyzer) LoadHistory(path string) error {
 	return nil
 }
 
+// Entries returns the entries loaded or appended so far
+func (ha *HistoryAnalyzer) Entries() []HistoryEntry {
+	return ha.entries
+}
+
 // AppendHistory adds new entries to the history file
 func (ha *HistoryAnalyzer) AppendHistory(path string, entries []HistoryEntry) error {
 	// Check if file exists to determine if we need header
@@ -92,7 +99,7 @@ func (ha *HistoryAnalyzer) AppendHistory(path string, entries []HistoryEntry) er
 	if needsHeader {
 		header := []string{
 			"Timestamp", "Card", "Number", "Set",
-			"RawUSD", "PSA10USD", "DeltaUSD", "Score", "Notes",
+			"RawUSD", "PSA10USD", "DeltaUSD", "Score", "Notes", "NetROI",
 		}
 		if err := writer.Write(reportpkg.EscapeCSVRow(header)); err != nil {
 			return fmt.Errorf("writing header: %w", err)
@@ -101,6 +108,10 @@ func (ha *HistoryAnalyzer) AppendHistory(path string, entries []HistoryEntry) er
 
 	// Write entries
 	for _, entry := range entries {
+		netROI := ""
+		if entry.NetROI != nil {
+			netROI = fmt.Sprintf("%.4f", *entry.NetROI)
+		}
 		record := []string{
 			entry.Timestamp.Format(time.RFC3339),
 			entry.Card,
@@ -111,6 +122,7 @@ func (ha *HistoryAnalyzer) AppendHistory(path string, entries []HistoryEntry) er
 			fmt.Sprintf("%.2f", entry.DeltaUSD),
 			fmt.Sprintf("%.2f", entry.Score),
 			entry.Notes,
+			netROI,
 		}
 		if err := writer.Write(reportpkg.EscapeCSVRow(record)); err != nil {
 			return fmt.Errorf("writing record: %w", err)
@@ -317,12 +329,20 @@ func (ha *HistoryAnalyzer) parseHistoryEntry(record []string) (HistoryEntry, err
 
 	// Notes field is optional
 	notes := ""
-	if len(record) >= 9 && len(record) == 9 {
+	if len(record) >= 9 {
 		notes = record[8]
 	} else if len(record) == 8 {
 		notes = record[7]
 	}
 
+	// Net ROI is only in rows written since it was recorded
+	var netROI *float64
+	if len(record) >= 10 && record[9] != "" {
+		if roi, err := strconv.ParseFloat(record[9], 64); err == nil {
+			netROI = &roi
+		}
+	}
+
 	return HistoryEntry{
 		Timestamp: timestamp,
 		Card:      record[1],
@@ -333,6 +353,7 @@ func (ha *HistoryAnalyzer) parseHistoryEntry(record []string) (HistoryEntry, err
 		DeltaUSD:  delta,
 		Score:     score,
 		Notes:     notes,
+		NetROI:    netROI,
 	}, nil
 }
 
@@ -5,26 +5,13 @@ import (
 	"sort"
 	"time"
 
+	"github.com/guarzo/pkmgradegap/internal/analysis"
+	"github.com/guarzo/pkmgradegap/internal/fees"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
 // PSAServiceLevel represents different PSA grading service tiers
-type PSAServiceLevel struct {
-	Name             string
-	MaxDeclaredValue float64
-	CostPerCard      float64
-	TurnaroundDays   int
-	MinCards         int
-}
-
-var PSAServiceLevels = []PSAServiceLevel{
-	{"Value", 199, 19, 65, 20},
-	{"Value Plus", 499, 25, 45, 20},
-	{"Regular", 999, 39, 30, 20},
-	{"Express", 2499, 75, 15, 10},
-	{"Super Express", 4999, 150, 10, 5},
-	{"Walk Through", 9999, 300, 5, 2},
-}
+type PSAServiceLevel = fees.ServiceLevel
 
 // SubmissionBatch represents a group of cards for a specific service level
 type SubmissionBatch struct {
@@ -32,8 +19,9 @@ type SubmissionBatch struct {
 	Cards           []SubmissionCard
 	TotalValue      float64
 	TotalCost       float64
-	EstimatedProfit float64
+	EstimatedProfit float64 // discounted to today at the optimizer's cost of capital
 	EstimatedROI    float64
+	AnnualizedROI   float64 // undiscounted ROI compounded to a year over the turnaround
 }
 
 // SubmissionCard represents a card to be submitted for grading
@@ -44,76 +32,304 @@ type SubmissionCard struct {
 	PSA9Price     float64
 	ExpectedGrade float64
 	ExpectedValue float64
+
+	// Optional inputs for RiskSimulator
+	Grade8Price float64
+	GradeOdds   analysis.GradeDistribution // zero means derive from ExpectedGrade
+	PriceSpread float64                    // relative sale-price spread; zero uses DefaultPriceSpread
+
+	PSA10Forecast30d float64 // predicted PSA 10 price in 30 days; zero assumes prices hold
 }
 
 // BulkOptimizer optimizes card submissions across PSA service levels
 type BulkOptimizer struct {
 	feePct               float64
 	shippingCostPerBatch float64
+	costOfCapital        float64
+	gradingCost          float64 // per card at every level, replacing the schedule's fees; 0 keeps them
+	levels               []PSAServiceLevel
 }
 
-// NewBulkOptimizer creates a new bulk submission optimizer
-func NewBulkOptimizer(feePct, shippingCostPerBatch float64) *BulkOptimizer {
+// NewBulkOptimizer creates a bulk submission optimizer using book's PSA fee
+// schedule in effect now; a nil book uses the built-in schedules
+func NewBulkOptimizer(feePct, shippingCostPerBatch float64, book *fees.Book) *BulkOptimizer {
+	return NewBulkOptimizerWithSchedule(feePct, shippingCostPerBatch, book.PSA(time.Now()))
+}
+
+// NewBulkOptimizerWithSchedule creates a bulk submission optimizer for a
+// specific fee schedule, e.g. the one in effect when a snapshot was taken
+func NewBulkOptimizerWithSchedule(feePct, shippingCostPerBatch float64, schedule fees.Schedule) *BulkOptimizer {
 	return &BulkOptimizer{
 		feePct:               feePct,
 		shippingCostPerBatch: shippingCostPerBatch,
+		levels:               schedule.Levels,
+	}
+}
+
+// SetCostOfCapital sets the annual rate expected proceeds are discounted at
+// over each service level's turnaround, so a slow level's lower fee is
+// weighed against the longer wait for the money
+func (bo *BulkOptimizer) SetCostOfCapital(annualRate float64) {
+	bo.costOfCapital = annualRate
+}
+
+// SetGradingCost charges perCard at every service level in place of the
+// fee schedule's fees, as --grading-cost does elsewhere; 0 uses the schedule
+func (bo *BulkOptimizer) SetGradingCost(perCard float64) {
+	bo.gradingCost = perCard
+}
+
+// level returns service level i with the grading cost override applied
+func (bo *BulkOptimizer) level(i int) PSAServiceLevel {
+	l := bo.levels[i]
+	if bo.gradingCost > 0 {
+		l.CostPerCard = bo.gradingCost
+	}
+	return l
+}
+
+// SubmissionLimits caps what a submission plan may include. Zero values mean
+// no limit.
+type SubmissionLimits struct {
+	BudgetUSD float64 // raw purchases, grading fees and per-batch shipping
+	MaxCards  int
+}
+
+// Reasons a card is left out of a submission plan
+const (
+	ExcludedOverDeclaredValue = "declared value exceeds every service level"
+	ExcludedUnprofitable      = "not profitable at any eligible service level"
+	ExcludedMaxCards          = "over the card limit"
+	ExcludedBudget            = "over budget"
+	ExcludedBelowMinimum      = "service level minimum not met"
+)
+
+// ExcludedCard is a candidate the plan leaves out, with the reason
+type ExcludedCard struct {
+	Card   SubmissionCard
+	Reason string
+}
+
+// SubmissionPlan is the result of packing candidates into service levels
+type SubmissionPlan struct {
+	Batches     []SubmissionBatch
+	Excluded    []ExcludedCard
+	ShippingUSD float64 // shipping for every batch
+}
+
+// TotalCost returns the plan's all-in cost, including shipping
+func (p SubmissionPlan) TotalCost() float64 {
+	total := p.ShippingUSD
+	for _, b := range p.Batches {
+		total += b.TotalCost
 	}
+	return total
 }
 
-// OptimizeSubmission groups cards into optimal batches by service level
+// OptimizeSubmission groups cards into batches by service level with no
+// budget or card limit. See Plan.
 func (bo *BulkOptimizer) OptimizeSubmission(cards []SubmissionCard) []SubmissionBatch {
-	// Sort cards by PSA10 value (highest first)
-	sort.Slice(cards, func(i, j int) bool {
-		return cards[i].PSA10Price > cards[j].PSA10Price
+	return bo.Plan(cards, SubmissionLimits{}).Batches
+}
+
+// Plan packs cards into service-level batches. Each card's declared value is
+// its PSA 10 price, so it may only go to a level whose MaxDeclaredValue covers
+// it. Cards start at their cheapest eligible level; a level short of its
+// MinCards is then fixed by whichever costs least: promoting its cards to the
+// cheapest higher level they would fill, filling it with surplus cards
+// promoted from cheaper levels, or leaving its cards out. Limits are applied
+// by dropping the least profitable cards and packing again.
+func (bo *BulkOptimizer) Plan(cards []SubmissionCard, limits SubmissionLimits) SubmissionPlan {
+	var plan SubmissionPlan
+	var pool []SubmissionCard
+	for _, c := range cards {
+		floor := bo.levelIndex(c.PSA10Price)
+		switch {
+		case floor < 0:
+			plan.Excluded = append(plan.Excluded, ExcludedCard{c, ExcludedOverDeclaredValue})
+		case bo.cardProfit(c, floor) <= 0:
+			plan.Excluded = append(plan.Excluded, ExcludedCard{c, ExcludedUnprofitable})
+		default:
+			pool = append(pool, c)
+		}
+	}
+
+	// Most profitable first, so limits cut from the tail
+	sort.SliceStable(pool, func(i, j int) bool {
+		return bo.cardProfit(pool[i], bo.levelIndex(pool[i].PSA10Price)) > bo.cardProfit(pool[j], bo.levelIndex(pool[j].PSA10Price))
+	})
+	if limits.MaxCards > 0 && len(pool) > limits.MaxCards {
+		for _, c := range pool[limits.MaxCards:] {
+			plan.Excluded = append(plan.Excluded, ExcludedCard{c, ExcludedMaxCards})
+		}
+		pool = pool[:limits.MaxCards]
+	}
+
+	for {
+		levels, dropped := bo.pack(pool)
+		plan.Batches = bo.buildBatches(levels)
+		plan.ShippingUSD = bo.shippingCostPerBatch * float64(len(plan.Batches))
+		if limits.BudgetUSD <= 0 || plan.TotalCost() <= limits.BudgetUSD || len(pool) == 0 {
+			for _, c := range dropped {
+				plan.Excluded = append(plan.Excluded, ExcludedCard{c, ExcludedBelowMinimum})
+			}
+			break
+		}
+		// Over budget: give up the least profitable card and repack
+		last := pool[len(pool)-1]
+		plan.Excluded = append(plan.Excluded, ExcludedCard{last, ExcludedBudget})
+		pool = pool[:len(pool)-1]
+	}
+
+	sort.Slice(plan.Batches, func(i, j int) bool {
+		return plan.Batches[i].EstimatedROI > plan.Batches[j].EstimatedROI
 	})
+	return plan
+}
 
-	batches := make(map[string]*SubmissionBatch)
+// pack assigns cards to service levels, returning the cards at each level
+// and the cards that could not be placed in a batch meeting its minimum.
+func (bo *BulkOptimizer) pack(cards []SubmissionCard) ([][]SubmissionCard, []SubmissionCard) {
+	levels := make([][]SubmissionCard, len(bo.levels))
+	for _, c := range cards {
+		i := bo.levelIndex(c.PSA10Price)
+		levels[i] = append(levels[i], c)
+	}
 
-	for _, card := range cards {
-		// Find appropriate service level
-		serviceLevel := bo.findServiceLevel(card.PSA10Price)
+	var dropped []SubmissionCard
+	for i := range levels {
+		n := len(levels[i])
+		level := bo.levels[i]
+		if n == 0 || n >= level.MinCards {
+			continue
+		}
+		need := level.MinCards - n
+
+		// Each option is scored by its change in plan profit, which weighs
+		// a dearer level's fee against its quicker return
+		up := bo.promoteTarget(levels, i)
+		promoteUp := 0.0
+		if up >= 0 {
+			for _, c := range levels[i] {
+				promoteUp += bo.cardProfit(c, up) - bo.cardProfit(c, i)
+			}
+		}
+		fill, donors := bo.fillFromBelow(levels, i, need)
+		leaveOut := bo.shippingCostPerBatch
+		for _, c := range levels[i] {
+			leaveOut -= bo.cardProfit(c, i)
+		}
 
-		// Get or create batch for this service level
-		batchKey := serviceLevel.Name
-		batch, exists := batches[batchKey]
-		if !exists {
-			batch = &SubmissionBatch{
-				ServiceLevel: serviceLevel,
-				Cards:        []SubmissionCard{},
+		switch {
+		case donors != nil && fill >= leaveOut && (up < 0 || fill >= promoteUp):
+			for j, k := range donors {
+				levels[i] = append(levels[i], levels[j][:k]...)
+				levels[j] = levels[j][k:]
 			}
-			batches[batchKey] = batch
+		case up >= 0 && promoteUp >= leaveOut:
+			levels[up] = append(levels[up], levels[i]...)
+			levels[i] = nil
+		default:
+			dropped = append(dropped, levels[i]...)
+			levels[i] = nil
 		}
+	}
+	return levels, dropped
+}
 
-		// Add card to batch
-		batch.Cards = append(batch.Cards, card)
-		batch.TotalValue += card.PSA10Price
-		batch.TotalCost += card.RawUSD + serviceLevel.CostPerCard
+// promoteTarget returns the cheapest level above i that would meet its
+// minimum with level i's cards added, or -1 if there is none
+func (bo *BulkOptimizer) promoteTarget(levels [][]SubmissionCard, i int) int {
+	for j := i + 1; j < len(levels); j++ {
+		if len(levels[j])+len(levels[i]) >= bo.levels[j].MinCards {
+			return j
+		}
 	}
+	return -1
+}
 
-	// Calculate profitability for each batch
-	var result []SubmissionBatch
-	for serviceName, batch := range batches {
-		// Skip batches that don't meet minimum card requirements
-		if len(batch.Cards) < batch.ServiceLevel.MinCards {
-			// Try to combine with next service level down
-			// fmt.Printf("Skipping %s: %d cards < %d minimum\n", serviceName, len(batch.Cards), batch.ServiceLevel.MinCards)
-			_ = serviceName // avoid unused variable warning
+// fillFromBelow plans promoting need surplus cards from cheaper levels into
+// level i, nearest level first. It returns the change in profit and how many
+// cards to take from each cheaper level, or nil if there isn't enough
+// surplus. Donors give up their highest-value cards, which sit closest to the
+// next declared-value cap.
+func (bo *BulkOptimizer) fillFromBelow(levels [][]SubmissionCard, i, need int) (float64, []int) {
+	donors := make([]int, i)
+	delta := 0.0
+	for j := i - 1; j >= 0 && need > 0; j-- {
+		surplus := len(levels[j]) - bo.levels[j].MinCards
+		if len(levels[j]) == 0 || surplus <= 0 {
 			continue
 		}
+		take := min(surplus, need)
+		sortByValue(levels[j])
+		donors[j] = take
+		for _, c := range levels[j][:take] {
+			delta += bo.cardProfit(c, i) - bo.cardProfit(c, j)
+		}
+		need -= take
+	}
+	if need > 0 {
+		return 0, nil
+	}
+	return delta, donors
+}
 
-		// Calculate expected profit
-		batch.EstimatedProfit = bo.calculateBatchProfit(batch)
+// buildBatches totals each non-empty level into a batch
+func (bo *BulkOptimizer) buildBatches(levels [][]SubmissionCard) []SubmissionBatch {
+	var result []SubmissionBatch
+	for i, cards := range levels {
+		if len(cards) == 0 {
+			continue
+		}
+		batch := SubmissionBatch{ServiceLevel: bo.level(i), Cards: cards}
+		sortByValue(batch.Cards)
+		for _, c := range batch.Cards {
+			batch.TotalValue += c.PSA10Price
+			batch.TotalCost += c.RawUSD + batch.ServiceLevel.CostPerCard
+		}
+		batch.EstimatedProfit = bo.calculateBatchProfit(&batch)
 		batch.EstimatedROI = (batch.EstimatedProfit / batch.TotalCost) * 100
+		days := batch.ServiceLevel.TurnaroundDays
+		proceeds := 0.0
+		for _, c := range batch.Cards {
+			proceeds += bo.saleProceeds(c, days)
+		}
+		roi := proceeds/(batch.TotalCost+bo.shippingCostPerBatch) - 1
+		batch.AnnualizedROI = analysis.AnnualizedROI(roi, days) * 100
+		result = append(result, batch)
+	}
+	return result
+}
+
+// cardProfit is a card's expected net profit at service level i, before
+// shipping
+func (bo *BulkOptimizer) cardProfit(c SubmissionCard, i int) float64 {
+	level := bo.level(i)
+	return bo.presentValue(c, level.TurnaroundDays) - c.RawUSD - level.CostPerCard
+}
 
-		result = append(result, *batch)
+// saleProceeds is what selling the card returns after selling fees once it
+// is back from grading in days, with its value moved along the PSA 10
+// forecast
+func (bo *BulkOptimizer) saleProceeds(c SubmissionCard, days int) float64 {
+	value := c.ExpectedValue
+	if c.PSA10Price > 0 {
+		value *= analysis.ProjectPrice(c.PSA10Price, c.PSA10Forecast30d, days) / c.PSA10Price
 	}
+	return value * (1 - bo.feePct)
+}
 
-	// Sort batches by ROI
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].EstimatedROI > result[j].EstimatedROI
-	})
+// presentValue discounts saleProceeds to today at the cost of capital
+func (bo *BulkOptimizer) presentValue(c SubmissionCard, days int) float64 {
+	return bo.saleProceeds(c, days) * analysis.DiscountFactor(bo.costOfCapital, days)
+}
 
-	return result
+// sortByValue orders cards by PSA 10 price, highest first
+func sortByValue(cards []SubmissionCard) {
+	sort.SliceStable(cards, func(i, j int) bool {
+		return cards[i].PSA10Price > cards[j].PSA10Price
+	})
 }
 
 // GenerateSubmissionForm creates a submission summary for PSA
@@ -146,6 +362,7 @@ func (bo *BulkOptimizer) GenerateSubmissionForm(batch SubmissionBatch) string {
 	output += fmt.Sprintf("Total Expected Value: $%.2f\n", batch.TotalValue)
 	output += fmt.Sprintf("Estimated Profit: $%.2f\n", batch.EstimatedProfit)
 	output += fmt.Sprintf("Estimated ROI: %.1f%%\n", batch.EstimatedROI)
+	output += fmt.Sprintf("Annualized ROI: %.1f%% over %d days\n", batch.AnnualizedROI, batch.ServiceLevel.TurnaroundDays)
 
 	return output
 }
@@ -187,13 +404,22 @@ func (bo *BulkOptimizer) SuggestBulkDiscounts(totalCards int) string {
 }
 
 func (bo *BulkOptimizer) findServiceLevel(declaredValue float64) PSAServiceLevel {
-	for _, level := range PSAServiceLevels {
-		if declaredValue <= level.MaxDeclaredValue {
-			return level
-		}
+	if i := bo.levelIndex(declaredValue); i >= 0 {
+		return bo.level(i)
 	}
 	// Return highest service level if value exceeds all
-	return PSAServiceLevels[len(PSAServiceLevels)-1]
+	return bo.level(len(bo.levels) - 1)
+}
+
+// levelIndex returns the cheapest service level accepting the declared
+// value, or -1 if it exceeds every level's cap
+func (bo *BulkOptimizer) levelIndex(declaredValue float64) int {
+	for i, level := range bo.levels {
+		if level.MaxDeclaredValue <= 0 || declaredValue <= level.MaxDeclaredValue {
+			return i
+		}
+	}
+	return -1
 }
 
 func (bo *BulkOptimizer) calculateBatchProfit(batch *SubmissionBatch) float64 {
@@ -202,8 +428,7 @@ func (bo *BulkOptimizer) calculateBatchProfit(batch *SubmissionBatch) float64 {
 
 	for _, card := range batch.Cards {
 		// Use expected value based on likely grade
-		expectedRevenue := card.ExpectedValue * (1 - bo.feePct)
-		totalRevenue += expectedRevenue
+		totalRevenue += bo.presentValue(card, batch.ServiceLevel.TurnaroundDays)
 	}
 
 	return totalRevenue - totalCost
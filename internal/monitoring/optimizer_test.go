@@ -1,6 +1,7 @@
 package monitoring
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/guarzo/pkmgradegap/internal/model"
@@ -31,7 +32,7 @@ func TestOptimizeSubmission(t *testing.T) {
 		},
 	}
 
-	optimizer := NewBulkOptimizer(0.13, 20.0)
+	optimizer := NewBulkOptimizer(0.13, 20.0, nil)
 	batches := optimizer.OptimizeSubmission(cards)
 
 	// Should create batches based on PSA10 value thresholds
@@ -50,8 +51,131 @@ func TestOptimizeSubmission(t *testing.T) {
 	}
 }
 
+// planCards makes n identical-value candidates for testing the packer
+func planCards(prefix string, n int, psa10, raw, ev float64) []SubmissionCard {
+	cards := make([]SubmissionCard, n)
+	for i := range cards {
+		cards[i] = SubmissionCard{
+			Card:          model.Card{Name: fmt.Sprintf("%s %d", prefix, i+1), Number: fmt.Sprintf("%03d", i+1)},
+			RawUSD:        raw,
+			PSA10Price:    psa10,
+			ExpectedGrade: 9.5,
+			ExpectedValue: ev,
+		}
+	}
+	return cards
+}
+
+func batchSizes(plan SubmissionPlan) map[string]int {
+	sizes := map[string]int{}
+	for _, b := range plan.Batches {
+		sizes[b.ServiceLevel.Name] = len(b.Cards)
+	}
+	return sizes
+}
+
+func TestPlan_PromotesShortLevelUp(t *testing.T) {
+	// 12 Value cards can't make the 20-card minimum alone, but together with
+	// the Value Plus cards they can
+	cards := append(planCards("Value", 12, 150, 5, 120), planCards("Plus", 15, 300, 20, 250)...)
+	plan := NewBulkOptimizer(0.13, 20, nil).Plan(cards, SubmissionLimits{})
+
+	sizes := batchSizes(plan)
+	if len(sizes) != 1 || sizes["Value Plus"] != 27 {
+		t.Errorf("expected one Value Plus batch of 27, got %v", sizes)
+	}
+	if len(plan.Excluded) != 0 {
+		t.Errorf("expected nothing left out, got %+v", plan.Excluded)
+	}
+}
+
+func TestPlan_FillsShortLevelFromBelow(t *testing.T) {
+	// Value has 20 spare cards; promoting 17 of them fills Value Plus far more
+	// cheaply than sending the Value Plus cards to Walk Through
+	cards := append(planCards("Value", 40, 150, 5, 120), planCards("Plus", 3, 300, 20, 250)...)
+	plan := NewBulkOptimizer(0.13, 20, nil).Plan(cards, SubmissionLimits{})
+
+	sizes := batchSizes(plan)
+	if sizes["Value"] != 23 || sizes["Value Plus"] != 20 {
+		t.Errorf("expected 23 Value and 20 Value Plus, got %v", sizes)
+	}
+	for _, b := range plan.Batches {
+		for _, c := range b.Cards {
+			if c.PSA10Price > b.ServiceLevel.MaxDeclaredValue {
+				t.Errorf("%s declared at $%.0f exceeds %s cap", c.Card.Name, c.PSA10Price, b.ServiceLevel.Name)
+			}
+		}
+	}
+}
+
+func TestPlan_LeavesOutUnfillableLevel(t *testing.T) {
+	cards := planCards("Value", 5, 150, 10, 60)
+	plan := NewBulkOptimizer(0.13, 20, nil).Plan(cards, SubmissionLimits{})
+
+	if len(plan.Batches) != 0 {
+		t.Errorf("expected no batches, got %v", batchSizes(plan))
+	}
+	if len(plan.Excluded) != 5 || plan.Excluded[0].Reason != ExcludedBelowMinimum {
+		t.Errorf("expected 5 cards left out below minimum, got %+v", plan.Excluded)
+	}
+}
+
+func TestPlan_ExcludesIneligibleCards(t *testing.T) {
+	cards := []SubmissionCard{
+		{Card: model.Card{Name: "Grail"}, RawUSD: 5000, PSA10Price: 12000, ExpectedValue: 11000},
+		{Card: model.Card{Name: "Loser"}, RawUSD: 100, PSA10Price: 150, ExpectedValue: 90},
+	}
+	plan := NewBulkOptimizer(0.13, 20, nil).Plan(cards, SubmissionLimits{})
+
+	reasons := map[string]string{}
+	for _, e := range plan.Excluded {
+		reasons[e.Card.Card.Name] = e.Reason
+	}
+	if reasons["Grail"] != ExcludedOverDeclaredValue {
+		t.Errorf("expected Grail over declared value, got %q", reasons["Grail"])
+	}
+	if reasons["Loser"] != ExcludedUnprofitable {
+		t.Errorf("expected Loser unprofitable, got %q", reasons["Loser"])
+	}
+}
+
+func TestPlan_Limits(t *testing.T) {
+	cards := planCards("Value", 30, 150, 5, 120)
+	// Make the last cards the least profitable so limits cut them first
+	for i := range cards {
+		cards[i].ExpectedValue -= float64(i)
+	}
+	optimizer := NewBulkOptimizer(0.13, 20, nil)
+
+	plan := optimizer.Plan(cards, SubmissionLimits{MaxCards: 22})
+	if sizes := batchSizes(plan); sizes["Value"] != 22 {
+		t.Errorf("expected 22 cards with MaxCards, got %v", sizes)
+	}
+	if len(plan.Excluded) != 8 || plan.Excluded[0].Reason != ExcludedMaxCards {
+		t.Errorf("expected 8 cards over the limit, got %+v", plan.Excluded)
+	}
+
+	// Each card costs $24 all-in plus $20 shipping: 20 cards fit in $500
+	plan = optimizer.Plan(cards, SubmissionLimits{MaxCards: 22, BudgetUSD: 520})
+	if got := plan.TotalCost(); got > 520 || len(plan.Batches) != 1 || len(plan.Batches[0].Cards) != 20 {
+		t.Errorf("expected 20 cards within budget, got %v costing $%.2f", batchSizes(plan), got)
+	}
+	budget := 0
+	for _, e := range plan.Excluded {
+		if e.Reason == ExcludedBudget {
+			budget++
+			if e.Card.Card.Name != "Value 21" && e.Card.Card.Name != "Value 22" {
+				t.Errorf("expected the least profitable cards cut for budget, got %s", e.Card.Card.Name)
+			}
+		}
+	}
+	if budget != 2 {
+		t.Errorf("expected 2 cards cut for budget, got %d", budget)
+	}
+}
+
 func TestFindServiceLevel(t *testing.T) {
-	optimizer := NewBulkOptimizer(0.13, 20.0)
+	optimizer := NewBulkOptimizer(0.13, 20.0, nil)
 
 	tests := []struct {
 		value    float64
@@ -92,3 +216,48 @@ func TestEstimateExpectedGrade(t *testing.T) {
 		}
 	}
 }
+
+func TestPlan_CostOfCapital(t *testing.T) {
+	cards := planCards("Value", 20, 150, 5, 120)
+	optimizer := NewBulkOptimizer(0.13, 20, nil)
+	undiscounted := optimizer.Plan(cards, SubmissionLimits{}).Batches[0]
+
+	optimizer.SetCostOfCapital(0.08)
+	discounted := optimizer.Plan(cards, SubmissionLimits{}).Batches[0]
+	if discounted.EstimatedProfit >= undiscounted.EstimatedProfit {
+		t.Errorf("expected discounting to cut profit, got $%.2f vs $%.2f", discounted.EstimatedProfit, undiscounted.EstimatedProfit)
+	}
+	if discounted.AnnualizedROI != undiscounted.AnnualizedROI {
+		t.Error("expected annualized ROI to ignore the cost of capital")
+	}
+	if discounted.AnnualizedROI <= discounted.EstimatedROI {
+		t.Errorf("expected a 65-day return to annualize higher, got %.1f%% vs %.1f%%", discounted.AnnualizedROI, discounted.EstimatedROI)
+	}
+
+	// A falling PSA 10 forecast lowers what the slabs fetch on return
+	for i := range cards {
+		cards[i].PSA10Forecast30d = 135
+	}
+	falling := optimizer.Plan(cards, SubmissionLimits{}).Batches[0]
+	if falling.EstimatedProfit >= discounted.EstimatedProfit {
+		t.Errorf("expected a falling forecast to cut profit, got $%.2f vs $%.2f", falling.EstimatedProfit, discounted.EstimatedProfit)
+	}
+}
+
+func TestPlan_GradingCostOverride(t *testing.T) {
+	cards := planCards("Value", 20, 150, 5, 120)
+	optimizer := NewBulkOptimizer(0.13, 20, nil)
+
+	optimizer.SetGradingCost(10)
+	plan := optimizer.Plan(cards, SubmissionLimits{})
+	if len(plan.Batches) != 1 || plan.Batches[0].ServiceLevel.CostPerCard != 10 || plan.Batches[0].TotalCost != 20*(5+10) {
+		t.Fatalf("expected the override charged per card, got %+v", plan.Batches)
+	}
+
+	// A fee the cards can't cover leaves them all out
+	optimizer.SetGradingCost(100)
+	plan = optimizer.Plan(cards, SubmissionLimits{})
+	if len(plan.Batches) != 0 || len(plan.Excluded) != 20 || plan.Excluded[0].Reason != ExcludedUnprofitable {
+		t.Errorf("expected every card unprofitable at $100 a card, got %d batches, %d excluded", len(plan.Batches), len(plan.Excluded))
+	}
+}
@@ -0,0 +1,198 @@
+package monitoring
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/guarzo/pkmgradegap/internal/analysis"
+)
+
+// DefaultPriceSpread is the relative sale-price spread assumed for cards
+// without enough recent sales to measure one
+const DefaultPriceSpread = 0.15
+
+// SimulationCard is one card in a simulated submission. Payouts line up with
+// the outcomes of analysis.GradeDistribution: PSA 10, 9, 8 and lower.
+type SimulationCard struct {
+	Name        string
+	CostUSD     float64 // raw purchase plus grading fee
+	Odds        analysis.GradeDistribution
+	Payouts     [4]float64
+	PriceSpread float64 // relative std dev of the sale price; 0 uses DefaultPriceSpread
+}
+
+// SimulationCardFromScoredRow builds a simulation card from a ranked row,
+// using its population-based grade odds and the raw price as the floor.
+func SimulationCardFromScoredRow(sr analysis.ScoredRow, gradingFee float64) SimulationCard {
+	odds := sr.Distribution
+	if odds == (analysis.GradeDistribution{}) {
+		odds = analysis.EstimateGradeDistribution(sr.Population)
+	}
+	floor := sr.RawUSD
+	return SimulationCard{
+		Name:    fmt.Sprintf("%s #%s", sr.Card.Name, sr.Card.Number),
+		CostUSD: sr.RawUSD + gradingFee,
+		Odds:    odds,
+		Payouts: [4]float64{
+			math.Max(sr.Grades.PSA10, floor),
+			math.Max(sr.Grades.Grade9, floor),
+			math.Max(sr.Grades.Grade8, floor),
+			floor,
+		},
+		PriceSpread: sr.PriceSpread,
+	}
+}
+
+// SimulationCardFromSubmission builds a simulation card from a bulk
+// submission card. Without explicit odds, the expected grade is read as a
+// split between PSA 10 and PSA 9, the same interpolation used for
+// SubmissionCard.ExpectedValue.
+func SimulationCardFromSubmission(c SubmissionCard, gradingFee float64) SimulationCard {
+	odds := c.GradeOdds
+	if odds == (analysis.GradeDistribution{}) {
+		p10 := math.Min(math.Max(c.ExpectedGrade-9, 0), 1)
+		odds = analysis.GradeDistribution{PSA10: p10, PSA9: 1 - p10}
+	}
+	psa9 := c.PSA9Price
+	if psa9 <= 0 {
+		psa9 = c.PSA10Price * 0.5
+	}
+	floor := c.RawUSD
+	return SimulationCard{
+		Name:    fmt.Sprintf("%s #%s", c.Card.Name, c.Card.Number),
+		CostUSD: c.RawUSD + gradingFee,
+		Odds:    odds,
+		Payouts: [4]float64{
+			math.Max(c.PSA10Price, floor),
+			math.Max(psa9, floor),
+			math.Max(c.Grade8Price, floor),
+			floor,
+		},
+		PriceSpread: c.PriceSpread,
+	}
+}
+
+// SimulationCardsFromBatch converts a service-level batch using its per-card fee
+func SimulationCardsFromBatch(batch SubmissionBatch) []SimulationCard {
+	cards := make([]SimulationCard, len(batch.Cards))
+	for i, c := range batch.Cards {
+		cards[i] = SimulationCardFromSubmission(c, batch.ServiceLevel.CostPerCard)
+	}
+	return cards
+}
+
+// RiskReport summarises the simulated distribution of batch profit
+type RiskReport struct {
+	Trials          int
+	Cards           int
+	TotalCost       float64 // purchase, grading and shipping
+	MeanProfit      float64
+	P5Profit        float64
+	P50Profit       float64
+	P95Profit       float64
+	ProbabilityLoss float64 // share of trials with negative profit
+}
+
+// RiskSimulator runs Monte Carlo trials over a submission: each trial draws
+// every card's grade from its odds and its sale price from a log-normal
+// around that grade's payout.
+type RiskSimulator struct {
+	trials       int
+	feePct       float64
+	shippingCost float64
+	rng          *rand.Rand
+}
+
+// NewRiskSimulator creates a simulator. A fixed seed makes runs reproducible.
+func NewRiskSimulator(trials int, feePct, shippingCost float64, seed int64) *RiskSimulator {
+	if trials <= 0 {
+		trials = 10000
+	}
+	return &RiskSimulator{
+		trials:       trials,
+		feePct:       feePct,
+		shippingCost: shippingCost,
+		rng:          rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Simulate returns the profit distribution for submitting cards together
+func (s *RiskSimulator) Simulate(cards []SimulationCard) RiskReport {
+	report := RiskReport{Trials: s.trials, Cards: len(cards), TotalCost: s.shippingCost}
+	for _, c := range cards {
+		report.TotalCost += c.CostUSD
+	}
+	if len(cards) == 0 {
+		return report
+	}
+
+	profits := make([]float64, s.trials)
+	losses := 0
+	sum := 0.0
+	for t := range profits {
+		revenue := 0.0
+		for _, c := range cards {
+			revenue += s.drawSale(c) * (1 - s.feePct)
+		}
+		profit := revenue - report.TotalCost
+		profits[t] = profit
+		sum += profit
+		if profit < 0 {
+			losses++
+		}
+	}
+
+	sort.Float64s(profits)
+	report.MeanProfit = sum / float64(s.trials)
+	report.P5Profit = percentile(profits, 0.05)
+	report.P50Profit = percentile(profits, 0.50)
+	report.P95Profit = percentile(profits, 0.95)
+	report.ProbabilityLoss = float64(losses) / float64(s.trials)
+	return report
+}
+
+// drawSale picks a grade outcome and a sale price for one card
+func (s *RiskSimulator) drawSale(c SimulationCard) float64 {
+	u := s.rng.Float64()
+	odds := [4]float64{c.Odds.PSA10, c.Odds.PSA9, c.Odds.PSA8, c.Odds.Lower}
+	outcome := len(odds) - 1
+	for i, p := range odds {
+		if u < p {
+			outcome = i
+			break
+		}
+		u -= p
+	}
+
+	price := c.Payouts[outcome]
+	spread := c.PriceSpread
+	if spread <= 0 {
+		spread = DefaultPriceSpread
+	}
+	// Log-normal noise with mean 1 keeps prices positive and unbiased
+	sigma := math.Sqrt(math.Log(1 + spread*spread))
+	return price * math.Exp(sigma*s.rng.NormFloat64()-sigma*sigma/2)
+}
+
+// percentile reads the p-th quantile from sorted values
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Round(p * float64(len(sorted)-1)))
+	return sorted[idx]
+}
+
+// FormatRiskReport renders a risk report for the terminal
+func FormatRiskReport(r RiskReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "SUBMISSION RISK (%d cards, %d trials)\n", r.Cards, r.Trials)
+	fmt.Fprintf(&b, "Total Cost: $%.2f\n", r.TotalCost)
+	fmt.Fprintf(&b, "Mean Profit: $%.2f\n", r.MeanProfit)
+	fmt.Fprintf(&b, "Profit P5 / P50 / P95: $%.2f / $%.2f / $%.2f\n", r.P5Profit, r.P50Profit, r.P95Profit)
+	fmt.Fprintf(&b, "Probability of Loss: %.1f%%\n", r.ProbabilityLoss*100)
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package monitoring
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/guarzo/pkmgradegap/internal/analysis"
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+func TestRiskSimulator_CertainOutcome(t *testing.T) {
+	// A guaranteed PSA 10 with a tiny price spread should land on its expected profit
+	cards := []SimulationCard{{
+		Name:        "Sure Thing",
+		CostUSD:     100,
+		Odds:        analysis.GradeDistribution{PSA10: 1},
+		Payouts:     [4]float64{300, 150, 100, 50},
+		PriceSpread: 0.001,
+	}}
+
+	report := NewRiskSimulator(2000, 0.1, 20, 42).Simulate(cards)
+	want := 300*0.9 - 100 - 20
+	if math.Abs(report.P50Profit-want) > 1 {
+		t.Errorf("expected median profit near %.2f, got %.2f", want, report.P50Profit)
+	}
+	if report.ProbabilityLoss != 0 {
+		t.Errorf("expected no losses, got %.3f", report.ProbabilityLoss)
+	}
+	if report.TotalCost != 120 {
+		t.Errorf("expected total cost 120, got %.2f", report.TotalCost)
+	}
+}
+
+func TestRiskSimulator_CoinFlip(t *testing.T) {
+	// Half the time a big win, half the time a loss
+	cards := []SimulationCard{{
+		CostUSD:     100,
+		Odds:        analysis.GradeDistribution{PSA10: 0.5, Lower: 0.5},
+		Payouts:     [4]float64{400, 0, 0, 50},
+		PriceSpread: 0.01,
+	}}
+
+	report := NewRiskSimulator(10000, 0, 0, 7).Simulate(cards)
+	if report.ProbabilityLoss < 0.45 || report.ProbabilityLoss > 0.55 {
+		t.Errorf("expected ~50%% probability of loss, got %.3f", report.ProbabilityLoss)
+	}
+	if report.P5Profit > -40 || report.P95Profit < 290 {
+		t.Errorf("expected tails near -50 and +300, got P5 %.2f P95 %.2f", report.P5Profit, report.P95Profit)
+	}
+	if math.Abs(report.MeanProfit-125) > 10 {
+		t.Errorf("expected mean profit near 125, got %.2f", report.MeanProfit)
+	}
+}
+
+func TestRiskSimulator_Reproducible(t *testing.T) {
+	cards := []SimulationCard{{
+		CostUSD: 50,
+		Odds:    analysis.DefaultGradeDistribution,
+		Payouts: [4]float64{200, 90, 60, 30},
+	}}
+	a := NewRiskSimulator(500, 0.13, 20, 99).Simulate(cards)
+	b := NewRiskSimulator(500, 0.13, 20, 99).Simulate(cards)
+	if a != b {
+		t.Errorf("expected identical reports for the same seed, got %+v and %+v", a, b)
+	}
+}
+
+func TestSimulationCardFromSubmission(t *testing.T) {
+	sc := SubmissionCard{
+		Card:          model.Card{Name: "Pikachu", Number: "25"},
+		RawUSD:        20,
+		PSA10Price:    200,
+		ExpectedGrade: 9.25,
+	}
+	card := SimulationCardFromSubmission(sc, 19)
+
+	if card.CostUSD != 39 {
+		t.Errorf("expected cost of raw plus fee, got %.2f", card.CostUSD)
+	}
+	if math.Abs(card.Odds.PSA10-0.25) > 1e-9 || math.Abs(card.Odds.PSA9-0.75) > 1e-9 {
+		t.Errorf("expected 25/75 split from expected grade, got %+v", card.Odds)
+	}
+	if card.Payouts[1] != 100 {
+		t.Errorf("expected missing PSA 9 price to default to half of PSA 10, got %.2f", card.Payouts[1])
+	}
+	if card.Payouts[2] != 20 || card.Payouts[3] != 20 {
+		t.Errorf("expected raw floor for PSA 8 and lower, got %v", card.Payouts)
+	}
+}
+
+func TestSimulationCardFromScoredRow(t *testing.T) {
+	sr := analysis.ScoredRow{Row: analysis.Row{
+		Card:        model.Card{Name: "Eevee", Number: "133"},
+		RawUSD:      10,
+		Grades:      analysis.Grades{PSA10: 80, Grade9: 30, Grade8: 5},
+		PriceSpread: 0.2,
+	}}
+	card := SimulationCardFromScoredRow(sr, 25)
+	if card.Odds != analysis.DefaultGradeDistribution {
+		t.Errorf("expected default odds without population, got %+v", card.Odds)
+	}
+	if card.Payouts != [4]float64{80, 30, 10, 10} {
+		t.Errorf("unexpected payouts %v", card.Payouts)
+	}
+	if card.PriceSpread != 0.2 {
+		t.Errorf("expected price spread to carry over, got %v", card.PriceSpread)
+	}
+}
+
+func TestFormatRiskReport(t *testing.T) {
+	out := FormatRiskReport(RiskReport{Trials: 100, Cards: 2, P5Profit: -12.5, ProbabilityLoss: 0.123})
+	for _, want := range []string{"2 cards, 100 trials", "$-12.50", "12.3%"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in report:\n%s", want, out)
+		}
+	}
+}
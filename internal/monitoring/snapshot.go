@@ -19,12 +19,17 @@ type Snapshot struct {
 
 // SnapshotCardData contains price data for a card at a point in time
 type SnapshotCardData struct {
-	Card         model.Card `json:"card"`
-	RawUSD       float64    `json:"raw_price_usd"`
-	PSA10Price   float64    `json:"psa10_price"`
-	PSA9Price    float64    `json:"psa9_price"`
-	Grade95Price float64    `json:"grade95_price"`
-	BGS10Price   float64    `json:"bgs10_price"`
+	Card         model.Card           `json:"card"`
+	Printing     string               `json:"printing,omitempty"`
+	RawUSD       float64              `json:"raw_price_usd"`
+	PSA10Price   float64              `json:"psa10_price"`
+	PSA9Price    float64              `json:"psa9_price"`
+	Grade95Price float64              `json:"grade95_price"`
+	Grade8Price  float64              `json:"grade8_price,omitempty"`
+	BGS10Price   float64              `json:"bgs10_price"`
+	CGC10Price   float64              `json:"cgc10_price,omitempty"`
+	SGC10Price   float64              `json:"sgc10_price,omitempty"`
+	Population   *model.PSAPopulation `json:"population,omitempty"`
 }
 
 // LoadSnapshot loads a snapshot from a JSON file
@@ -65,20 +70,36 @@ func CreateSnapshotFromRows(setName string, rows []analysis.Row) *Snapshot {
 	}
 
 	for _, row := range rows {
-		key := fmt.Sprintf("%s-%s", row.Card.Number, row.Card.Name)
+		key := fmt.Sprintf("%s-%s", row.Card.Number, row.Label())
 		snapshot.Cards[key] = &SnapshotCardData{
 			Card:         row.Card,
+			Printing:     row.Printing,
 			RawUSD:       row.RawUSD,
 			PSA10Price:   row.Grades.PSA10,
 			PSA9Price:    row.Grades.Grade9,
 			Grade95Price: row.Grades.Grade95,
+			Grade8Price:  row.Grades.Grade8,
 			BGS10Price:   row.Grades.BGS10,
+			CGC10Price:   row.Grades.CGC10,
+			SGC10Price:   row.Grades.SGC10,
+			Population:   row.Population,
 		}
 	}
 
 	return snapshot
 }
 
+// lookup finds the card filed under key. Snapshots taken before printings
+// were priced separately keyed cards by number and name, so a card missing
+// under key is looked up under that older key too.
+func (s *Snapshot) lookup(key string, card model.Card) (*SnapshotCardData, bool) {
+	if c, ok := s.Cards[key]; ok {
+		return c, true
+	}
+	c, ok := s.Cards[fmt.Sprintf("%s-%s", card.Number, card.Name)]
+	return c, ok
+}
+
 // PriceDelta represents a price change between snapshots
 type PriceDelta struct {
 	Card        model.Card
@@ -96,7 +117,7 @@ func CompareSnapshots(old, new *Snapshot, thresholdPct, thresholdUSD float64) []
 	var deltas []PriceDelta
 
 	for key, newCard := range new.Cards {
-		oldCard, exists := old.Cards[key]
+		oldCard, exists := old.lookup(key, newCard.Card)
 		if !exists {
 			continue // Card not in old snapshot
 		}
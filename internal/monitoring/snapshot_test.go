@@ -1,6 +1,8 @@
 package monitoring
 
 import (
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -61,6 +63,54 @@ func TestCreateSnapshotFromRows(t *testing.T) {
 	}
 }
 
+func TestCreateSnapshotFromRows_Printings(t *testing.T) {
+	card := model.Card{Name: "Pikachu", Number: "025"}
+	rows := []analysis.Row{
+		{Card: card, Printing: "Normal", RawUSD: 0.25},
+		{Card: card, Printing: "Reverse Holo", RawUSD: 1.50},
+	}
+
+	snapshot := CreateSnapshotFromRows("Test Set", rows)
+	if len(snapshot.Cards) != 2 {
+		t.Fatalf("expected a snapshot entry per printing, got %d", len(snapshot.Cards))
+	}
+	reverse, ok := snapshot.Cards["025-Pikachu (Reverse Holo)"]
+	if !ok || reverse.RawUSD != 1.50 || reverse.Printing != "Reverse Holo" {
+		t.Errorf("unexpected reverse holo entry %+v", reverse)
+	}
+}
+
+func TestSnapshot_RoundTrip(t *testing.T) {
+	pop := &model.PSAPopulation{TotalGraded: 400, PSA10: 60, PSA9: 150, PSA8: 90, Source: "PSA API"}
+	row := analysis.Row{
+		Card:       model.Card{Name: "Charizard", Number: "004"},
+		RawUSD:     300,
+		Grades:     analysis.Grades{PSA10: 5000, Grade9: 1200, Grade95: 1800, Grade8: 700, BGS10: 9000, CGC10: 3500, SGC10: 2500},
+		Population: pop,
+	}
+	path := filepath.Join(t.TempDir(), "snap.json")
+	if err := SaveSnapshot(path, CreateSnapshotFromRows("Base Set", []analysis.Row{row})); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	card := loaded.Cards["004-Charizard"]
+	if card == nil {
+		t.Fatalf("card missing from loaded snapshot: %+v", loaded.Cards)
+	}
+	got := analysis.Grades{PSA10: card.PSA10Price, Grade9: card.PSA9Price, Grade95: card.Grade95Price, Grade8: card.Grade8Price,
+		BGS10: card.BGS10Price, CGC10: card.CGC10Price, SGC10: card.SGC10Price}
+	if got != row.Grades {
+		t.Errorf("grades = %+v, want %+v", got, row.Grades)
+	}
+	if !reflect.DeepEqual(card.Population, pop) {
+		t.Errorf("population = %+v, want %+v", card.Population, pop)
+	}
+}
+
 func TestCompareSnapshots(t *testing.T) {
 	old := &Snapshot{
 		Timestamp: time.Now().Add(-24 * time.Hour),
@@ -116,3 +166,25 @@ func TestCompareSnapshots(t *testing.T) {
 		t.Error("Expected to find PSA10 price increase delta")
 	}
 }
+
+func TestCompareSnapshots_OldKeys(t *testing.T) {
+	// Snapshots written before printings were keyed separately file cards
+	// under number and name
+	old := &Snapshot{
+		Timestamp: time.Now().Add(-24 * time.Hour),
+		Cards: map[string]*SnapshotCardData{
+			"025-Pikachu": {Card: model.Card{Name: "Pikachu", Number: "025"}, RawUSD: 10, PSA10Price: 50},
+		},
+	}
+	new := CreateSnapshotFromRows("Test Set", []analysis.Row{
+		{Card: model.Card{Name: "Pikachu", Number: "025"}, Printing: "Holofoil", RawUSD: 5, Grades: analysis.Grades{PSA10: 50}},
+	})
+	if _, ok := new.Cards["025-Pikachu"]; ok {
+		t.Fatal("expected the new snapshot keyed by printing")
+	}
+
+	deltas := CompareSnapshots(old, new, 10.0, 1.0)
+	if len(deltas) != 1 || deltas[0].Field != "Raw" || deltas[0].OldPrice != 10 || deltas[0].NewPrice != 5 {
+		t.Errorf("expected the raw drop matched across key formats, got %+v", deltas)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/guarzo/pkmgradegap/internal/fees"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
@@ -32,15 +33,17 @@ type TimingRecommendation struct {
 // MarketAnalyzer provides timing recommendations based on historical data
 type MarketAnalyzer struct {
 	snapshots []*Snapshot // Historical snapshots in chronological order
+	fees      *fees.Book
 }
 
-// NewMarketAnalyzer creates a new market analyzer
-func NewMarketAnalyzer(snapshots []*Snapshot) *MarketAnalyzer {
+// NewMarketAnalyzer creates a new market analyzer that prices grading with
+// book's PSA schedules; a nil book uses the built-in ones
+func NewMarketAnalyzer(snapshots []*Snapshot, book *fees.Book) *MarketAnalyzer {
 	// Sort snapshots by timestamp
 	sort.Slice(snapshots, func(i, j int) bool {
 		return snapshots[i].Timestamp.Before(snapshots[j].Timestamp)
 	})
-	return &MarketAnalyzer{snapshots: snapshots}
+	return &MarketAnalyzer{snapshots: snapshots, fees: book}
 }
 
 // AnalyzeCard provides timing recommendations for a specific card
@@ -49,13 +52,18 @@ func (ma *MarketAnalyzer) AnalyzeCard(cardKey string) *TimingRecommendation {
 		return nil // Need at least 2 snapshots for analysis
 	}
 
+	latest := ma.snapshots[len(ma.snapshots)-1].Cards[cardKey]
+	if latest == nil {
+		return nil
+	}
+
 	// Extract price history for this card
 	var rawPrices []float64
 	var psa10Prices []float64
 	var timestamps []time.Time
 
 	for _, snapshot := range ma.snapshots {
-		if card, exists := snapshot.Cards[cardKey]; exists {
+		if card, exists := snapshot.lookup(cardKey, latest.Card); exists {
 			rawPrices = append(rawPrices, card.RawUSD)
 			psa10Prices = append(psa10Prices, card.PSA10Price)
 			timestamps = append(timestamps, snapshot.Timestamp)
@@ -66,11 +74,6 @@ func (ma *MarketAnalyzer) AnalyzeCard(cardKey string) *TimingRecommendation {
 		return nil // Not enough data points
 	}
 
-	latest := ma.snapshots[len(ma.snapshots)-1].Cards[cardKey]
-	if latest == nil {
-		return nil
-	}
-
 	// Calculate trends
 	rawTrend := calculateTrend(rawPrices)
 	psa10Trend := calculateTrend(psa10Prices)
@@ -112,7 +115,8 @@ func (ma *MarketAnalyzer) AnalyzeCard(cardKey string) *TimingRecommendation {
 	return rec
 }
 
-// AnalyzeMarket provides overall market timing recommendations
+// AnalyzeMarket provides overall market timing recommendations. A
+// gradingCost of 0 uses the PSA fee schedule in effect at the latest snapshot.
 func (ma *MarketAnalyzer) AnalyzeMarket(gradingCost, shippingCost, feePct float64) []TimingRecommendation {
 	if len(ma.snapshots) < 2 {
 		return nil
@@ -126,7 +130,8 @@ func (ma *MarketAnalyzer) AnalyzeMarket(gradingCost, shippingCost, feePct float6
 		if rec != nil && rec.Action != "HOLD" {
 			// Calculate ROI for context
 			card := latest.Cards[cardKey]
-			roi := calculateROI(card.RawUSD, card.PSA10Price, gradingCost, shippingCost, feePct)
+			fee := gradingFee(ma.fees, gradingCost, card.PSA10Price, latest.Timestamp)
+			roi := calculateROI(card.RawUSD, card.PSA10Price, fee, shippingCost, feePct)
 
 			// Only include if profitable
 			if roi > 20 && rec.Action == "BUY" {
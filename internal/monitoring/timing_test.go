@@ -83,7 +83,7 @@ func TestMarketAnalyzer(t *testing.T) {
 		},
 	}
 
-	analyzer := NewMarketAnalyzer(snapshots)
+	analyzer := NewMarketAnalyzer(snapshots, nil)
 	rec := analyzer.AnalyzeCard("001-Test Card")
 
 	if rec == nil {
@@ -7,8 +7,12 @@ import (
 	"time"
 
 	"github.com/guarzo/pkmgradegap/internal/analysis"
-	// "github.com/guarzo/pkmgradegap/internal/fusion" // TODO: Update when fusion package is refactored
+	"github.com/guarzo/pkmgradegap/internal/ebay"
+	"github.com/guarzo/pkmgradegap/internal/fusion"
+	"github.com/guarzo/pkmgradegap/internal/gamestop"
 	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/prices"
+	"github.com/guarzo/pkmgradegap/internal/sales"
 )
 
 // Pipeline processes cards through multiple stages concurrently
@@ -29,11 +33,11 @@ type Stage interface {
 
 // StageData carries data between pipeline stages
 type StageData struct {
-	Card      model.Card
-	CardData  interface{}
-	PriceData interface{}
-	PopData   interface{}
-	// FusedData   *fusion.FusedData // TODO: Update when fusion package is refactored
+	Card        model.Card
+	CardData    interface{}
+	PriceData   interface{}
+	PopData     interface{}
+	FusedData   *fusion.FusedData
 	AnalysisRow *analysis.Row
 	Error       error
 	Metadata    map[string]interface{}
@@ -413,20 +417,27 @@ func (s *PopulationFetchStage) Process(ctx context.Context, input <-chan StageDa
 	return output
 }
 
-// DataFusionStage combines data from multiple sources
-// TODO: Update when fusion package is refactored
+// DataFusionStage combines prices from every source the earlier stages
+// fetched into one consensus price per grade
 type DataFusionStage struct {
-	// fusionEngine *fusion.FusionEngine
+	fusionEngine *fusion.FusionEngine
 }
 
-// func NewDataFusionStage(engine *fusion.FusionEngine) *DataFusionStage {
-// 	return &DataFusionStage{fusionEngine: engine}
-// }
+// NewDataFusionStage fuses with engine; prices in other currencies, such as
+// Cardmarket's EUR, only count when the engine has Rates
+func NewDataFusionStage(engine *fusion.FusionEngine) *DataFusionStage {
+	return &DataFusionStage{fusionEngine: engine}
+}
+
+// PriceObserver is implemented by stage payloads that carry prices the
+// fusion stage can't recognise by type
+type PriceObserver interface {
+	Observations() []fusion.PriceData
+}
 
 func (s *DataFusionStage) Name() string   { return "data_fusion" }
 func (s *DataFusionStage) Parallel() bool { return true }
 
-// TODO: Update when fusion package is refactored
 func (s *DataFusionStage) Process(ctx context.Context, input <-chan StageData) <-chan StageData {
 	output := make(chan StageData, 100)
 
@@ -440,16 +451,9 @@ func (s *DataFusionStage) Process(ctx context.Context, input <-chan StageData) <
 					return
 				}
 
-				// if s.fusionEngine != nil {
-				// 	// Convert raw data to fusion format and fuse
-				// 	// This is a simplified example - real implementation would convert
-				// 	// the various data types to fusion.PriceData format
-				// 	fusedData := &fusion.FusedData{
-				// 		Card: data.Card,
-				// 		// Would populate based on actual data types
-				// 	}
-				// 	data.FusedData = fusedData
-				// }
+				if s.fusionEngine != nil {
+					data.FusedData = s.fusionEngine.Fuse(data.Card, stageObservations(data))
+				}
 
 				select {
 				case output <- data:
@@ -466,13 +470,54 @@ func (s *DataFusionStage) Process(ctx context.Context, input <-chan StageData) <
 	return output
 }
 
+// stageObservations collects prices from the stage payloads. The card's own
+// TCGPlayer and Cardmarket prices are used unless a fetched card replaces it.
+func stageObservations(data StageData) []fusion.PriceData {
+	var obs []fusion.PriceData
+	if _, fetched := data.CardData.(model.Card); !fetched {
+		obs = append(obs, observations(data.Card)...)
+	}
+	for _, payload := range []interface{}{data.CardData, data.PriceData, data.PopData} {
+		obs = append(obs, observations(payload)...)
+	}
+	return obs
+}
+
+// observations converts a payload from one of the known providers
+func observations(payload interface{}) []fusion.PriceData {
+	switch v := payload.(type) {
+	case []fusion.PriceData:
+		return v
+	case PriceObserver:
+		return v.Observations()
+	case model.Card:
+		return append(fusion.FromTCGPlayer(v), fusion.FromCardmarket(v)...)
+	case *prices.PCMatch:
+		return fusion.FromPriceCharting(v, time.Now())
+	case *sales.SalesData:
+		return fusion.FromSales(v)
+	case *gamestop.ListingData:
+		return gamestop.ConvertToPriceData(v)
+	case []ebay.Listing:
+		return fusion.FromEbayListings(v)
+	case []interface{}:
+		var obs []fusion.PriceData
+		for _, item := range v {
+			obs = append(obs, observations(item)...)
+		}
+		return obs
+	}
+	return nil
+}
+
 // AnalysisStage performs final analysis and scoring
 type AnalysisStage struct {
 	analyzer AnalysisEngine
 }
 
+// AnalysisEngine scores a card. It receives the *fusion.FusedData when a
+// DataFusionStage ran, and the card data otherwise.
 type AnalysisEngine interface {
-	// Analyze(ctx context.Context, fusedData *fusion.FusedData) (*analysis.Row, error) // TODO: Update when fusion package is refactored
 	Analyze(ctx context.Context, data interface{}) (*analysis.Row, error)
 }
 
@@ -496,10 +541,12 @@ func (s *AnalysisStage) Process(ctx context.Context, input <-chan StageData) <-c
 					return
 				}
 
-				// if s.analyzer != nil && data.FusedData != nil {
-				// 	analysisRow, err := s.analyzer.Analyze(ctx, data.FusedData)
 				if s.analyzer != nil {
-					analysisRow, err := s.analyzer.Analyze(ctx, data.CardData)
+					var input interface{} = data.CardData
+					if data.FusedData != nil {
+						input = data.FusedData
+					}
+					analysisRow, err := s.analyzer.Analyze(ctx, input)
 					if err != nil {
 						data.Error = fmt.Errorf("analysis failed: %w", err)
 					} else {
@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/currency"
+	"github.com/guarzo/pkmgradegap/internal/ebay"
+	"github.com/guarzo/pkmgradegap/internal/fusion"
+	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/prices"
+	"github.com/guarzo/pkmgradegap/internal/sales"
+)
+
+func TestDataFusionStage_MixedSources(t *testing.T) {
+	card := model.Card{Name: "Pikachu", Number: "58", Cardmarket: &model.CardmarketBlock{}}
+	trend := 9.0 // EUR
+	card.Cardmarket.Prices.TrendPrice = &trend
+
+	data := StageData{
+		Card: card,
+		PriceData: []interface{}{
+			&prices.PCMatch{LooseCents: 1050, PSA10Cents: 15000},
+			&sales.SalesData{DataSource: "PokemonPriceTracker", RecentSales: []sales.SaleRecord{
+				{Grade: "Raw", Price: 10, Date: time.Now()},
+				{Grade: "PSA 10", Price: 152, Date: time.Now()},
+			}},
+			// Asking prices far above what the card sells for
+			[]ebay.Listing{
+				{Title: "Pikachu 58/102 Base Set NM", Price: 55},
+				{Title: "Pikachu 58/102 Base Set PSA 10", Price: 900},
+			},
+		},
+	}
+
+	engine := fusion.NewFusionEngine()
+	engine.Rates = currency.Fixed()
+	input := make(chan StageData, 1)
+	input <- data
+	close(input)
+	out := <-NewDataFusionStage(engine).Process(context.Background(), input)
+	if out.FusedData == nil {
+		t.Fatal("expected fused data")
+	}
+
+	raw := out.FusedData.Prices[fusion.GradeRaw]
+	if raw.Outliers != 1 || raw.Used != 3 || raw.Value < 9.5 || raw.Value > 10.5 {
+		t.Errorf("expected the eBay asking price discarded from raw, got %+v", raw)
+	}
+	if !slices.Contains(raw.Sources, "Cardmarket") {
+		t.Errorf("expected Cardmarket's EUR price converted and used, got sources %v", raw.Sources)
+	}
+	psa10 := out.FusedData.Prices[fusion.GradePSA10]
+	if psa10.Outliers != 1 || psa10.Value < 150 || psa10.Value > 152 {
+		t.Errorf("expected the eBay PSA 10 asking price discarded, got %+v", psa10)
+	}
+}
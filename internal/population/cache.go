@@ -0,0 +1,51 @@
+package population
+
+import (
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+// popCache files population data in a shared cache.Store, cards under
+// "pop|card|<set>|<name>|<number>" and whole sets under "pop|set|<set>", so
+// a set's or card's entries can be invalidated by prefix. A nil store
+// caches nothing.
+type popCache struct {
+	cards cache.Typed[*PopulationData]
+	sets  cache.Typed[*SetPopulationData]
+}
+
+func newPopCache(store cache.Store) *popCache {
+	return &popCache{
+		cards: cache.NewTyped[*PopulationData](store, "pop", "card"),
+		sets:  cache.NewTyped[*SetPopulationData](store, "pop", "set"),
+	}
+}
+
+// cardCacheKey keys a card's population data by set, name and number
+func cardCacheKey(card model.Card) string {
+	return cache.BuildKey(card.SetName, card.Name, card.Number)
+}
+
+// Get returns cached population data for a card key
+func (c *popCache) Get(key string) (*PopulationData, bool) {
+	data, found := c.cards.Get(key)
+	return data, found && data != nil
+}
+
+// Set stores population data for a card key
+func (c *popCache) Set(key string, data *PopulationData, ttl time.Duration) error {
+	return c.cards.Set(key, data, ttl)
+}
+
+// GetSet returns cached population data for a whole set
+func (c *popCache) GetSet(key string) (*SetPopulationData, bool) {
+	data, found := c.sets.Get(key)
+	return data, found && data != nil
+}
+
+// SetSet stores population data for a whole set
+func (c *popCache) SetSet(key string, data *SetPopulationData, ttl time.Duration) error {
+	return c.sets.Set(key, data, ttl)
+}
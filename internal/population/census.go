@@ -0,0 +1,271 @@
+package population
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"maps"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+// Grading companies with a population census
+const (
+	GraderPSA = "PSA"
+	GraderCGC = "CGC"
+	GraderBGS = "BGS"
+)
+
+// CensusGraders are the companies besides PSA whose census can be looked up
+var CensusGraders = []string{GraderCGC, GraderBGS}
+
+// GraderPopulation is one grading company's census for a card
+type GraderPopulation struct {
+	Grader      string         `json:"grader"`
+	Total       int            `json:"total"`
+	Gem         int            `json:"gem"`              // copies graded a 10, pristine and black label included
+	Grades      map[string]int `json:"grades,omitempty"` // label as the census shows it, e.g. "CGC Pristine 10" → count
+	LastUpdated time.Time      `json:"last_updated"`
+	Source      string         `json:"source,omitempty"`
+}
+
+// CombinedGraded returns the graded supply across every company in the
+// data: PSA's total plus each other company's
+func (d *PopulationData) CombinedGraded() int {
+	total := d.TotalGraded
+	for _, g := range d.Graders {
+		total += g.Total
+	}
+	return total
+}
+
+// CombinedGem returns the 10s across every company in the data: PSA 10s
+// plus each other company's
+func (d *PopulationData) CombinedGem() int {
+	gem := d.PSA10Population
+	for _, g := range d.Graders {
+		gem += g.Gem
+	}
+	return gem
+}
+
+// censusRow is one card's line in a company's census
+type censusRow struct {
+	Name   string         `json:"name"`
+	Number string         `json:"number"`
+	Total  int            `json:"total"`
+	Grades map[string]int `json:"grades"`
+}
+
+// population totals the row into a census for grader
+func (r censusRow) population(grader, source string, at time.Time) *GraderPopulation {
+	g := &GraderPopulation{
+		Grader:      grader,
+		Total:       r.Total,
+		Grades:      make(map[string]int, len(r.Grades)),
+		LastUpdated: at,
+		Source:      source,
+	}
+	sum := 0
+	for label, count := range r.Grades {
+		g.Grades[grader+" "+label] = count
+		sum += count
+		if gradeValue(label) == 10 {
+			g.Gem += count
+		}
+	}
+	if g.Total == 0 {
+		g.Total = sum
+	}
+	return g
+}
+
+// censusData wraps one company's census of card as population data. The
+// PSA fields stay zero; CombinedProvider merges it with PSA's.
+func censusData(card model.Card, g *GraderPopulation) *PopulationData {
+	return &PopulationData{
+		Card:          card,
+		SetName:       card.SetName,
+		CardNumber:    card.Number,
+		LastUpdated:   g.LastUpdated,
+		ScarcityLevel: calculateScarcity(g.Gem),
+		Graders:       map[string]*GraderPopulation{g.Grader: g},
+		Source:        g.Source,
+		FetchedAt:     g.LastUpdated,
+	}
+}
+
+var gradeValuePattern = regexp.MustCompile(`\d+(\.\d+)?`)
+
+// gradeValue reads the numeric grade from a census label such as
+// "Pristine 10", "10 Black Label" or "Mint+ 9.5", and 0 when it has none
+func gradeValue(label string) float64 {
+	v, err := strconv.ParseFloat(gradeValuePattern.FindString(label), 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+// censusColumn classifies a census header as "set", "name", "number",
+// "total" or "grade", or "" for columns that aren't read
+func censusColumn(header string) string {
+	h := strings.ToLower(strings.TrimSpace(header))
+	switch h {
+	case "set", "set name", "set_name":
+		return "set"
+	case "card", "name", "card name", "card_name", "player/card", "description":
+		return "name"
+	case "#", "no", "no.", "number", "card #", "card number", "card_number":
+		return "number"
+	case "total", "total graded", "total_graded", "graded":
+		return "total"
+	}
+	if gradeValue(h) > 0 {
+		return "grade"
+	}
+	return ""
+}
+
+// censusNumber normalizes a card number for matching: "#004/102" → "4"
+func censusNumber(n string) string {
+	n = strings.TrimPrefix(strings.TrimSpace(n), "#")
+	n, _, _ = strings.Cut(n, "/")
+	n = strings.ToLower(strings.TrimLeft(n, "0"))
+	if n == "" {
+		return "0"
+	}
+	return n
+}
+
+// findCensusRow finds card's row by number, using the name to choose
+// between rows sharing a number: an exact name first, then the one row
+// whose name contains the card's or is contained in it. Variants it can't
+// tell apart, such as two printings both named "Pikachu ...", match nothing.
+func findCensusRow(rows []censusRow, card model.Card) (censusRow, bool) {
+	number := censusNumber(card.Number)
+	name := normalizeString(card.Name)
+	var byNumber, byName []censusRow
+	for _, r := range rows {
+		if censusNumber(r.Number) != number {
+			continue
+		}
+		rowName := normalizeString(r.Name)
+		if rowName == name {
+			return r, true
+		}
+		byNumber = append(byNumber, r)
+		if strings.Contains(rowName, name) || strings.Contains(name, rowName) {
+			byName = append(byName, r)
+		}
+	}
+	switch {
+	case len(byName) == 1:
+		return byName[0], true
+	case len(byName) == 0 && len(byNumber) == 1:
+		return byNumber[0], true
+	}
+	return censusRow{}, false
+}
+
+// CombinedProvider looks a card up with a PSA provider and adds each other
+// company's census to what it finds, so scarcity is judged on the whole
+// graded market rather than on PSA alone
+type CombinedProvider struct {
+	Provider
+	others []Provider
+}
+
+// NewCombinedProvider adds the census from others to psa's lookups
+func NewCombinedProvider(psa Provider, others ...Provider) *CombinedProvider {
+	return &CombinedProvider{Provider: psa, others: others}
+}
+
+// Available returns true if any of the providers is
+func (p *CombinedProvider) Available() bool {
+	if p.Provider.Available() {
+		return true
+	}
+	for _, o := range p.others {
+		if o.Available() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetProviderName names every provider combined
+func (p *CombinedProvider) GetProviderName() string {
+	names := []string{p.Provider.GetProviderName()}
+	for _, o := range p.others {
+		names = append(names, o.GetProviderName())
+	}
+	return strings.Join(names, " + ")
+}
+
+// LookupPopulation looks the card up with every provider and merges the
+// results. It fails only when none of them has the card.
+func (p *CombinedProvider) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
+	var merged PopulationData
+	psa, err := p.Provider.LookupPopulation(ctx, card)
+	psaFound := err == nil && psa != nil
+	found := psaFound
+	if psaFound {
+		merged = *psa
+	} else {
+		merged = PopulationData{Card: card, SetName: card.SetName, CardNumber: card.Number}
+	}
+	merged.Graders = maps.Clone(merged.Graders)
+	if merged.Graders == nil {
+		merged.Graders = make(map[string]*GraderPopulation)
+	}
+
+	for _, o := range p.others {
+		if !o.Available() {
+			continue
+		}
+		data, oerr := o.LookupPopulation(ctx, card)
+		if oerr != nil || data == nil {
+			if oerr != nil {
+				log.Printf("%s lookup for %s #%s: %v", o.GetProviderName(), card.Name, card.Number, oerr)
+			}
+			continue
+		}
+		for grader, g := range data.Graders {
+			merged.Graders[grader] = g
+		}
+		if !psaFound && data.LastUpdated.After(merged.LastUpdated) {
+			merged.LastUpdated = data.LastUpdated
+		}
+		if merged.Source == "" {
+			merged.Source, merged.FetchedAt = data.Source, data.FetchedAt
+		}
+		found = true
+	}
+
+	if !found {
+		if err == nil {
+			err = fmt.Errorf("no population data found for %s #%s", card.Name, card.Number)
+		}
+		return nil, err
+	}
+	if len(merged.Graders) > 0 {
+		merged.ScarcityLevel = calculateScarcity(merged.CombinedGem())
+	}
+	return &merged, nil
+}
+
+// BatchLookupPopulation looks each card up with every provider
+func (p *CombinedProvider) BatchLookupPopulation(ctx context.Context, cards []model.Card) (map[string]*PopulationData, error) {
+	results := make(map[string]*PopulationData)
+	for _, card := range cards {
+		if data, err := p.LookupPopulation(ctx, card); err == nil {
+			results[fmt.Sprintf("%s-%s", card.Number, card.Name)] = data
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,137 @@
+package population
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+// CensusCSVProvider serves one grading company's census from a CSV export
+// with set, card and number columns, a column per grade ("Pristine 10",
+// "9.5", ...) and an optional total
+type CensusCSVProvider struct {
+	grader string
+	path   string
+	sets   map[string]*censusSet // by normalized set name
+}
+
+// NewCensusCSVProvider loads grader's census from the CSV at path
+func NewCensusCSVProvider(grader, path string) (*CensusCSVProvider, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %s census: %w", grader, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("open %s census: %w", grader, err)
+	}
+
+	p := &CensusCSVProvider{grader: grader, path: path, sets: make(map[string]*censusSet)}
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("read %s census: %w", grader, err)
+	}
+	columns := make(map[int]string, len(header))
+	for i, h := range header {
+		columns[i] = censusColumn(h)
+	}
+	if !hasCensusColumns(columns) || !hasColumn(columns, "set") {
+		return nil, fmt.Errorf("read %s census: want set, card, number and grade columns, got %s",
+			grader, strings.Join(header, ","))
+	}
+
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read %s census: %w", grader, err)
+		}
+		var setName string
+		row := censusRow{Grades: make(map[string]int)}
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			switch columns[i] {
+			case "set":
+				setName = value
+			case "name":
+				row.Name = value
+			case "number":
+				row.Number = value
+			case "total":
+				row.Total = parsePopulationCount(value)
+			case "grade":
+				if n := parsePopulationCount(value); n > 0 {
+					row.Grades[strings.TrimSpace(header[i])] = n
+				}
+			}
+		}
+		if setName == "" || row.Name == "" {
+			return nil, fmt.Errorf("read %s census: line %d: set and card are required", grader, line)
+		}
+		key := normalizeSetName(setName)
+		if p.sets[key] == nil {
+			p.sets[key] = &censusSet{Fetched: info.ModTime()}
+		}
+		p.sets[key].Rows = append(p.sets[key].Rows, row)
+	}
+	return p, nil
+}
+
+// Available returns true if the CSV had any cards
+func (p *CensusCSVProvider) Available() bool {
+	return len(p.sets) > 0
+}
+
+// GetProviderName returns the name of the provider
+func (p *CensusCSVProvider) GetProviderName() string {
+	return p.grader + " Census CSV"
+}
+
+// IsMockMode returns false since the counts are imported, not generated
+func (p *CensusCSVProvider) IsMockMode() bool {
+	return false
+}
+
+// LookupPopulation finds the card in the imported census, dated by the
+// file's modification time
+func (p *CensusCSVProvider) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
+	set := p.sets[normalizeSetName(card.SetName)]
+	if set == nil {
+		return nil, fmt.Errorf("no %s census for set %s in %s", p.grader, card.SetName, p.path)
+	}
+	row, ok := findCensusRow(set.Rows, card)
+	if !ok {
+		return nil, fmt.Errorf("no %s census for %s #%s in %s", p.grader, card.Name, card.Number, p.path)
+	}
+	return censusData(card, row.population(p.grader, p.GetProviderName(), set.Fetched)), nil
+}
+
+// BatchLookupPopulation looks each card up in the imported census
+func (p *CensusCSVProvider) BatchLookupPopulation(ctx context.Context, cards []model.Card) (map[string]*PopulationData, error) {
+	results := make(map[string]*PopulationData)
+	for _, card := range cards {
+		if data, err := p.LookupPopulation(ctx, card); err == nil {
+			results[fmt.Sprintf("%s-%s", card.Number, card.Name)] = data
+		}
+	}
+	return results, nil
+}
+
+// GetSetPopulation returns the imported census of every card in the set
+func (p *CensusCSVProvider) GetSetPopulation(ctx context.Context, setName string) (*SetPopulationData, error) {
+	set := p.sets[normalizeSetName(setName)]
+	if set == nil {
+		return nil, fmt.Errorf("no %s census for set %s in %s", p.grader, setName, p.path)
+	}
+	return censusSetData(setName, p.grader, p.GetProviderName(), set), nil
+}
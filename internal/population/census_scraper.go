@@ -0,0 +1,255 @@
+package population
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/model"
+	"golang.org/x/time/rate"
+)
+
+const (
+	cgcCensusURL = "https://www.cgccards.com/population/pokemon/"       // ?set=<set name>
+	bgsCensusURL = "https://www.beckett.com/grading/pop-report/pokemon" // ?set=<set name>
+
+	censusCacheTTL = 24 * time.Hour
+)
+
+// CensusScraper reads CGC's or Beckett's census from their set population
+// pages. A set's page lists every card with a column per grade, so each set
+// is fetched once, cached under the set's "pop|set" key, and its cards are
+// matched by number and name.
+type CensusScraper struct {
+	grader  string
+	baseURL string
+	client  *http.Client
+	limiter *rate.Limiter
+	sets    cache.Typed[*censusSet]
+}
+
+// censusSet is a scraped set page
+type censusSet struct {
+	Fetched time.Time   `json:"fetched"`
+	Rows    []censusRow `json:"rows"`
+}
+
+// NewCensusScraper creates a scraper for grader's census (CGC or BGS) that
+// caches set pages in store, or in memory when store is nil
+func NewCensusScraper(grader string, store cache.Store) (*CensusScraper, error) {
+	var baseURL string
+	switch grader {
+	case GraderCGC:
+		baseURL = cgcCensusURL
+	case GraderBGS:
+		baseURL = bgsCensusURL
+	default:
+		return nil, fmt.Errorf("no census scraper for %q: want one of %s", grader, strings.Join(CensusGraders, ", "))
+	}
+	if store == nil {
+		store = cache.NewMemory()
+	}
+	return &CensusScraper{
+		grader:  grader,
+		baseURL: baseURL,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		limiter: rate.NewLimiter(rate.Limit(scrapeRateLimit), 1),
+		sets:    cache.NewTyped[*censusSet](store, "pop", "set"),
+	}, nil
+}
+
+// Available returns true; the census pages need no credentials
+func (s *CensusScraper) Available() bool {
+	return true
+}
+
+// GetProviderName returns the name of the provider
+func (s *CensusScraper) GetProviderName() string {
+	return s.grader + " Census"
+}
+
+// IsMockMode returns false since this is a real provider
+func (s *CensusScraper) IsMockMode() bool {
+	return false
+}
+
+// LookupPopulation finds the card on its set's census page
+func (s *CensusScraper) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
+	if card.SetName == "" || card.Name == "" {
+		return nil, fmt.Errorf("invalid card parameters: SetName and Name are required")
+	}
+	set, err := s.set(ctx, card.SetName)
+	if err != nil {
+		return nil, err
+	}
+	row, ok := findCensusRow(set.Rows, card)
+	if !ok {
+		return nil, fmt.Errorf("no %s census for %s #%s in %s", s.grader, card.Name, card.Number, card.SetName)
+	}
+	return censusData(card, row.population(s.grader, s.GetProviderName(), set.Fetched)), nil
+}
+
+// BatchLookupPopulation looks the cards up, fetching each set once
+func (s *CensusScraper) BatchLookupPopulation(ctx context.Context, cards []model.Card) (map[string]*PopulationData, error) {
+	results := make(map[string]*PopulationData)
+	for _, card := range cards {
+		if data, err := s.LookupPopulation(ctx, card); err == nil {
+			results[fmt.Sprintf("%s-%s", card.Number, card.Name)] = data
+		}
+	}
+	return results, nil
+}
+
+// GetSetPopulation returns the census of every card on the set's page
+func (s *CensusScraper) GetSetPopulation(ctx context.Context, setName string) (*SetPopulationData, error) {
+	set, err := s.set(ctx, setName)
+	if err != nil {
+		return nil, err
+	}
+	return censusSetData(setName, s.grader, s.GetProviderName(), set), nil
+}
+
+// set returns the set's census page, from the cache when it can
+func (s *CensusScraper) set(ctx context.Context, setName string) (*censusSet, error) {
+	key := cache.BuildKey(setName, strings.ToLower(s.grader))
+	if set, found := s.sets.Get(key); found && set != nil {
+		return set, nil
+	}
+
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, fmt.Errorf("rate limiter error: %w", err)
+	}
+	pageURL := s.baseURL + "?set=" + url.QueryEscape(setName)
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s census request: %w", s.grader, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s census returned status %d", s.grader, resp.StatusCode)
+	}
+
+	rows, err := parseCensusTable(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s census for %s: %w", s.grader, setName, err)
+	}
+	set := &censusSet{Fetched: time.Now(), Rows: rows}
+	_ = s.sets.Set(key, set, censusCacheTTL)
+	return set, nil
+}
+
+// parseCensusTable reads the first table on a census page with name,
+// number and grade columns
+func parseCensusTable(r io.Reader) ([]censusRow, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []censusRow
+	doc.Find("table").EachWithBreak(func(_ int, table *goquery.Selection) bool {
+		header := table.Find("thead tr").First()
+		if header.Length() == 0 {
+			header = table.Find("tr").First()
+		}
+		columns := make(map[int]string)
+		labels := make(map[int]string)
+		header.Find("th, td").Each(func(i int, cell *goquery.Selection) {
+			label := strings.Join(strings.Fields(cell.Text()), " ")
+			columns[i] = censusColumn(label)
+			labels[i] = label
+		})
+		if !hasCensusColumns(columns) {
+			return true
+		}
+
+		table.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+			if tr.Find("td").Length() == 0 || tr.IsSelection(header) {
+				return
+			}
+			row := censusRow{Grades: make(map[string]int)}
+			tr.Find("th, td").Each(func(i int, cell *goquery.Selection) {
+				text := strings.TrimSpace(cell.Text())
+				switch columns[i] {
+				case "name":
+					row.Name = strings.Join(strings.Fields(text), " ")
+				case "number":
+					row.Number = text
+				case "total":
+					row.Total = parsePopulationCount(text)
+				case "grade":
+					if n := parsePopulationCount(text); n > 0 {
+						row.Grades[labels[i]] = n
+					}
+				}
+			})
+			if row.Name != "" || row.Number != "" {
+				rows = append(rows, row)
+			}
+		})
+		return false
+	})
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no census table found")
+	}
+	return rows, nil
+}
+
+// hasCensusColumns reports whether a header has the columns a census needs
+func hasCensusColumns(columns map[int]string) bool {
+	return hasColumn(columns, "name") && hasColumn(columns, "number") && hasColumn(columns, "grade")
+}
+
+func hasColumn(columns map[int]string, kind string) bool {
+	for _, c := range columns {
+		if c == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// censusSetData summarizes a set's census rows
+func censusSetData(setName, grader, source string, set *censusSet) *SetPopulationData {
+	data := &SetPopulationData{
+		SetName:     setName,
+		LastUpdated: set.Fetched,
+		TotalCards:  len(set.Rows),
+		CardData:    make(map[string]*PopulationData),
+		SetStatistics: &SetStatistics{
+			GradeDistribution: make(map[string]int),
+			ScarcityBreakdown: make(map[string]int),
+		},
+	}
+	total := 0
+	for _, row := range set.Rows {
+		card := model.Card{Name: row.Name, Number: row.Number, SetName: setName}
+		g := row.population(grader, source, set.Fetched)
+		cardData := censusData(card, g)
+		data.CardData[fmt.Sprintf("%s-%s", row.Number, row.Name)] = cardData
+		if g.Total > 0 {
+			data.CardsGraded++
+		}
+		total += g.Total
+		for label, count := range g.Grades {
+			data.SetStatistics.GradeDistribution[label] += count
+		}
+		data.SetStatistics.ScarcityBreakdown[cardData.ScarcityLevel]++
+	}
+	if len(set.Rows) > 0 {
+		data.SetStatistics.AveragePopulation = float64(total) / float64(len(set.Rows))
+	}
+	return data
+}
@@ -0,0 +1,182 @@
+package population
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+// newFixtureScraper serves a census fixture to a scraper for grader and
+// counts the requests it gets
+func newFixtureScraper(t *testing.T, grader, fixture string) (*CensusScraper, *int) {
+	t.Helper()
+	page, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Query().Get("set") != "Base Set" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(page)
+	}))
+	t.Cleanup(srv.Close)
+
+	s, err := NewCensusScraper(grader, cache.NewMemory())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.baseURL = srv.URL
+	return s, &requests
+}
+
+func TestCensusScraper_CGC(t *testing.T) {
+	s, requests := newFixtureScraper(t, GraderCGC, "cgc_census.html")
+	ctx := context.Background()
+
+	data, err := s.LookupPopulation(ctx, model.Card{SetName: "Base Set", Name: "Charizard", Number: "4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cgc := data.Graders[GraderCGC]
+	if cgc == nil || cgc.Total != 10121 || cgc.Gem != 12+1204 {
+		t.Fatalf("expected Charizard's census with pristine and gem mint 10s as gems, got %+v", cgc)
+	}
+	if cgc.Grades["CGC Mint+ 9.5"] != 2310 || data.PSA10Population != 0 || data.TotalGraded != 0 {
+		t.Errorf("expected the grades by label and no PSA counts, got %+v / %+v", cgc.Grades, data)
+	}
+
+	// Blank and "-" cells count as none; the set page is fetched once
+	data, err = s.LookupPopulation(ctx, model.Card{SetName: "Base Set", Name: "Pikachu", Number: "058"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := data.Graders[GraderCGC]; g.Gem != 85 || g.Total != 648 {
+		t.Errorf("unexpected Pikachu census %+v", g)
+	}
+	if *requests != 1 {
+		t.Errorf("expected the set page to be fetched once, got %d requests", *requests)
+	}
+
+	if _, err := s.LookupPopulation(ctx, model.Card{SetName: "Base Set", Name: "Mewtwo", Number: "10"}); err == nil {
+		t.Error("expected an error for a card missing from the census")
+	}
+}
+
+func TestCensusScraper_BGS(t *testing.T) {
+	s, _ := newFixtureScraper(t, GraderBGS, "bgs_pop_report.html")
+	ctx := context.Background()
+
+	data, err := s.LookupPopulation(ctx, model.Card{SetName: "Base Set", Name: "Charizard", Number: "4/102"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bgs := data.Graders[GraderBGS]
+	if bgs.Gem != 2+31 || bgs.Total != 2+31+388+902+640+455 {
+		t.Errorf("expected black label and pristine 10s as gems and a summed total, got %+v", bgs)
+	}
+
+	// Two Pikachu #58 printings can't be told apart by "Pikachu" alone
+	if _, err := s.LookupPopulation(ctx, model.Card{SetName: "Base Set", Name: "Pikachu", Number: "58"}); err == nil {
+		t.Error("expected an ambiguous match to fail")
+	}
+	data, err = s.LookupPopulation(ctx, model.Card{SetName: "Base Set", Name: "Pikachu Yellow Cheeks", Number: "58"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := data.Graders[GraderBGS]; g.Gem != 10 {
+		t.Errorf("expected the yellow cheeks row, got %+v", g)
+	}
+
+	set, err := s.GetSetPopulation(ctx, "Base Set")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if set.TotalCards != 3 || set.SetStatistics.GradeDistribution["BGS 10 Pristine"] != 31+4+9 {
+		t.Errorf("unexpected set census %+v", set.SetStatistics)
+	}
+
+	if _, err := NewCensusScraper(GraderPSA, nil); err == nil {
+		t.Error("expected no census scraper for PSA")
+	}
+}
+
+func TestCensusCSVProvider(t *testing.T) {
+	p, err := NewCensusCSVProvider(GraderCGC, filepath.Join("testdata", "cgc_census.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := p.LookupPopulation(context.Background(), model.Card{SetName: "base set", Name: "Blastoise", Number: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g := data.Graders[GraderCGC]; g.Gem != 44 || g.Total != 612 || g.Source != "CGC Census CSV" {
+		t.Errorf("unexpected imported census %+v", g)
+	}
+	if _, err := p.LookupPopulation(context.Background(), model.Card{SetName: "Fossil", Name: "Zapdos", Number: "15"}); err == nil {
+		t.Error("expected an error for a set not in the import")
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.csv")
+	os.WriteFile(bad, []byte("Card,Total\nCharizard,10\n"), 0o644)
+	if _, err := NewCensusCSVProvider(GraderBGS, bad); err == nil {
+		t.Error("expected an error for a CSV without grade columns")
+	}
+}
+
+// failingProvider has no population for any card
+type failingProvider struct{ MockProvider }
+
+func (f *failingProvider) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
+	return nil, errors.New("not found")
+}
+
+func TestCombinedProvider(t *testing.T) {
+	cgc, err := NewCensusCSVProvider(GraderCGC, filepath.Join("testdata", "cgc_census.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bgs, _ := newFixtureScraper(t, GraderBGS, "bgs_pop_report.html")
+	psa := &fixedProvider{data: PopulationData{TotalGraded: 20000, PSA10Population: 400, ScarcityLevel: "UNCOMMON"}}
+	ctx := context.Background()
+	charizard := model.Card{SetName: "Base Set", Name: "Charizard", Number: "4"}
+
+	data, err := NewCombinedProvider(psa, cgc, bgs).LookupPopulation(ctx, charizard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.PSA10Population != 400 || len(data.Graders) != 2 {
+		t.Fatalf("expected PSA's counts with CGC's and BGS's census, got %+v", data)
+	}
+	if got, want := data.CombinedGem(), 400+1216+33; got != want {
+		t.Errorf("CombinedGem = %d, want %d", got, want)
+	}
+	if got, want := data.CombinedGraded(), 20000+10121+2418; got != want {
+		t.Errorf("CombinedGraded = %d, want %d", got, want)
+	}
+	if data.ScarcityLevel != "COMMON" {
+		t.Errorf("expected scarcity judged on every company's 10s, got %s", data.ScarcityLevel)
+	}
+	if psa.data.Graders != nil {
+		t.Error("expected the PSA provider's data to be left alone")
+	}
+
+	// Without PSA's counts, the other companies' census still answers
+	data, err = NewCombinedProvider(&failingProvider{}, cgc).LookupPopulation(ctx, charizard)
+	if err != nil || data.TotalGraded != 0 || data.CombinedGem() != 1216 {
+		t.Errorf("expected CGC's census alone, got %+v, %v", data, err)
+	}
+	if _, err := NewCombinedProvider(&failingProvider{}, cgc).LookupPopulation(ctx, model.Card{SetName: "Fossil", Name: "Zapdos", Number: "15"}); err == nil {
+		t.Error("expected an error when no provider has the card")
+	}
+}
@@ -0,0 +1,207 @@
+package population
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+// ChainProvider looks a card up with each of its providers in turn, such as
+// the PSA API, then the scraper, then a CSV import, then the last counts
+// found, and answers with the first that has the card. Each answer records
+// the provider it came from and when that provider fetched it; the chain
+// never makes numbers up, so only a mock link gives Estimated data.
+type ChainProvider struct {
+	links []Provider
+}
+
+// NewChainProvider chains links in the order they should be tried
+func NewChainProvider(links ...Provider) *ChainProvider {
+	return &ChainProvider{links: links}
+}
+
+// Available returns true if any link is
+func (c *ChainProvider) Available() bool {
+	for _, link := range c.links {
+		if link.Available() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetProviderName names the links in the order they are tried
+func (c *ChainProvider) GetProviderName() string {
+	names := make([]string, len(c.links))
+	for i, link := range c.links {
+		names[i] = link.GetProviderName()
+	}
+	return strings.Join(names, " → ")
+}
+
+// IsMockMode returns true when every link is a mock
+func (c *ChainProvider) IsMockMode() bool {
+	for _, link := range c.links {
+		if !link.IsMockMode() {
+			return false
+		}
+	}
+	return len(c.links) > 0
+}
+
+// LookupPopulation returns the first link's population for the card,
+// attributed to it, and remembers it in any CacheProvider in the chain
+func (c *ChainProvider) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
+	var errs []error
+	for _, link := range c.links {
+		if !link.Available() {
+			continue
+		}
+		data, err := link.LookupPopulation(ctx, card)
+		if err == nil && data == nil {
+			err = errors.New("no data")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", link.GetProviderName(), err))
+			continue
+		}
+		data = attribute(link, data)
+		c.remember(link, card, data)
+		return data, nil
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no population provider available for %s #%s", card.Name, card.Number)
+	}
+	return nil, fmt.Errorf("no population data for %s #%s: %w", card.Name, card.Number, errors.Join(errs...))
+}
+
+// BatchLookupPopulation looks each card up along the chain
+func (c *ChainProvider) BatchLookupPopulation(ctx context.Context, cards []model.Card) (map[string]*PopulationData, error) {
+	results := make(map[string]*PopulationData)
+	for _, card := range cards {
+		if data, err := c.LookupPopulation(ctx, card); err == nil {
+			results[fmt.Sprintf("%s-%s", card.Number, card.Name)] = data
+		}
+	}
+	return results, nil
+}
+
+// GetSetPopulation returns the first link's population for the set
+func (c *ChainProvider) GetSetPopulation(ctx context.Context, setName string) (*SetPopulationData, error) {
+	var errs []error
+	for _, link := range c.links {
+		if !link.Available() {
+			continue
+		}
+		data, err := link.GetSetPopulation(ctx, setName)
+		if err == nil && data != nil {
+			return data, nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", link.GetProviderName(), err))
+		}
+	}
+	return nil, fmt.Errorf("no population data for set %s: %w", setName, errors.Join(errs...))
+}
+
+// attribute returns a copy of data marked with the link it came from, when
+// the link didn't say, and flagged Estimated when the link is a mock
+func attribute(link Provider, data *PopulationData) *PopulationData {
+	stamped := *data
+	if stamped.Source == "" {
+		stamped.Source = link.GetProviderName()
+	}
+	if stamped.FetchedAt.IsZero() {
+		stamped.FetchedAt = stamped.LastUpdated
+		if stamped.FetchedAt.IsZero() {
+			stamped.FetchedAt = time.Now()
+		}
+	}
+	if link.IsMockMode() {
+		stamped.Estimated = true
+	}
+	return &stamped
+}
+
+// remember hands data found by from to the chain's other CacheProviders
+func (c *ChainProvider) remember(from Provider, card model.Card, data *PopulationData) {
+	for _, link := range c.links {
+		if cp, ok := link.(*CacheProvider); ok && link != from {
+			cp.Remember(card, data)
+		}
+	}
+}
+
+// CacheProvider serves the population a chain last found for a card, as
+// the chain's last resort when the live sources fail. Entries keep the
+// source and fetch time they were found with and are dropped maxAge after
+// that fetch.
+type CacheProvider struct {
+	known  cache.Typed[*PopulationData]
+	maxAge time.Duration
+}
+
+// NewCacheProvider remembers populations in store, under
+// "pop|known|<set>|<name>|<number>", for up to maxAge
+func NewCacheProvider(store cache.Store, maxAge time.Duration) *CacheProvider {
+	return &CacheProvider{
+		known:  cache.NewTyped[*PopulationData](store, "pop", "known"),
+		maxAge: maxAge,
+	}
+}
+
+// Available returns true; a miss is reported by LookupPopulation
+func (p *CacheProvider) Available() bool {
+	return true
+}
+
+// GetProviderName returns the name of the provider
+func (p *CacheProvider) GetProviderName() string {
+	return "Population Cache"
+}
+
+// IsMockMode returns false since it serves what real providers found
+func (p *CacheProvider) IsMockMode() bool {
+	return false
+}
+
+// Remember keeps data as the card's last known population. Estimated data
+// and data already older than maxAge are not kept.
+func (p *CacheProvider) Remember(card model.Card, data *PopulationData) {
+	if data.Estimated {
+		return
+	}
+	if ttl := p.maxAge - time.Since(data.FetchedAt); ttl > 0 {
+		_ = p.known.Set(cardCacheKey(card), data, ttl)
+	}
+}
+
+// LookupPopulation returns the card's last known population
+func (p *CacheProvider) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
+	data, found := p.known.Get(cardCacheKey(card))
+	if !found || data == nil {
+		return nil, fmt.Errorf("no cached population for %s #%s", card.Name, card.Number)
+	}
+	return data, nil
+}
+
+// BatchLookupPopulation returns each card's last known population
+func (p *CacheProvider) BatchLookupPopulation(ctx context.Context, cards []model.Card) (map[string]*PopulationData, error) {
+	results := make(map[string]*PopulationData)
+	for _, card := range cards {
+		if data, err := p.LookupPopulation(ctx, card); err == nil {
+			results[fmt.Sprintf("%s-%s", card.Number, card.Name)] = data
+		}
+	}
+	return results, nil
+}
+
+// GetSetPopulation is not supported; populations are remembered by card
+func (p *CacheProvider) GetSetPopulation(ctx context.Context, setName string) (*SetPopulationData, error) {
+	return nil, fmt.Errorf("set population not supported by the population cache")
+}
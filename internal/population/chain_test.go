@@ -0,0 +1,96 @@
+package population
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+// namedProvider answers with fixed data under its own name
+type namedProvider struct {
+	fixedProvider
+	name  string
+	calls int
+}
+
+func (n *namedProvider) Available() bool         { return true }
+func (n *namedProvider) GetProviderName() string { return n.name }
+
+func (n *namedProvider) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
+	n.calls++
+	return n.fixedProvider.LookupPopulation(ctx, card)
+}
+
+func TestChainProvider(t *testing.T) {
+	ctx := context.Background()
+	card := model.Card{SetName: "Base Set", Name: "Charizard", Number: "4"}
+	fetched := time.Now().Add(-time.Hour).Truncate(time.Second)
+	scraper := &namedProvider{name: "PSA Scraper", fixedProvider: fixedProvider{data: PopulationData{TotalGraded: 900, PSA10Population: 110, LastUpdated: fetched}}}
+	csv := &namedProvider{name: "PSA CSV", fixedProvider: fixedProvider{data: PopulationData{TotalGraded: 800, PSA10Population: 100}}}
+	known := NewCacheProvider(cache.NewMemory(), 30*24*time.Hour)
+	down := &failingProvider{MockProvider{enabled: true}}
+
+	chain := NewChainProvider(down, scraper, csv, known)
+	data, err := chain.LookupPopulation(ctx, card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.PSA10Population != 110 || data.Source != "PSA Scraper" || !data.FetchedAt.Equal(fetched) || data.Estimated {
+		t.Errorf("expected the scraper's counts attributed to it, got %+v", data)
+	}
+	if csv.calls != 0 {
+		t.Error("expected the chain to stop at the first provider with the card")
+	}
+	if got := chain.GetProviderName(); got != "Population Mock → PSA Scraper → PSA CSV → Population Cache" {
+		t.Errorf("unexpected chain name %q", got)
+	}
+
+	// With every live source failing, the last counts found still answer,
+	// attributed to the source that found them
+	data, err = NewChainProvider(down, known).LookupPopulation(ctx, card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.PSA10Population != 110 || data.Source != "PSA Scraper" || !data.FetchedAt.Equal(fetched) {
+		t.Errorf("expected the remembered scraper counts, got %+v", data)
+	}
+
+	// Nothing is made up when no provider has the card
+	if data, err := NewChainProvider(down, known).LookupPopulation(ctx, model.Card{SetName: "Fossil", Name: "Zapdos", Number: "15"}); err == nil {
+		t.Errorf("expected an error when no provider has the card, got %+v", data)
+	}
+	if _, err := NewChainProvider().LookupPopulation(ctx, card); err == nil {
+		t.Error("expected an error from an empty chain")
+	}
+}
+
+func TestChainProvider_Estimated(t *testing.T) {
+	ctx := context.Background()
+	card := model.Card{SetName: "Base Set", Name: "Blastoise", Number: "2"}
+	known := NewCacheProvider(cache.NewMemory(), 30*24*time.Hour)
+
+	chain := NewChainProvider(NewMockProvider(), known)
+	data, err := chain.LookupPopulation(ctx, card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !data.Estimated || data.Source != "Population Mock" || data.FetchedAt.IsZero() {
+		t.Errorf("expected mock data flagged as estimated, got %+v", data)
+	}
+	if _, err := known.LookupPopulation(ctx, card); err == nil {
+		t.Error("expected estimated data not to be remembered")
+	}
+	if chain.IsMockMode() {
+		t.Error("expected a chain with a real provider not to be a mock")
+	}
+
+	// Data fetched longer ago than the cache keeps it isn't remembered either
+	old := &PopulationData{PSA10Population: 5, FetchedAt: time.Now().AddDate(0, -2, 0)}
+	known.Remember(card, old)
+	if _, err := known.LookupPopulation(ctx, card); err == nil {
+		t.Error("expected data older than the cache's max age not to be remembered")
+	}
+}
@@ -60,6 +60,16 @@ func (c *CSVProvider) Available() bool {
 	return len(c.data) > 0
 }
 
+// GetProviderName returns the name of the provider
+func (c *CSVProvider) GetProviderName() string {
+	return "PSA CSV"
+}
+
+// IsMockMode returns false since the counts are imported, not generated
+func (c *CSVProvider) IsMockMode() bool {
+	return false
+}
+
 // LookupPopulation retrieves population data for a specific card
 func (c *CSVProvider) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
 	// Reload data if it's stale (older than 7 days)
@@ -93,8 +103,7 @@ func (c *CSVProvider) LookupPopulation(ctx context.Context, card model.Card) (*P
 		}
 	}
 
-	// Return mock data as fallback
-	return c.generateFallbackData(card), nil
+	return nil, fmt.Errorf("no population data for %s #%s in %s", card.Name, card.Number, c.dataPath)
 }
 
 // BatchLookupPopulation retrieves population data for multiple cards
@@ -190,6 +199,10 @@ func (c *CSVProvider) loadFromFile(filepath string) error {
 		return err
 	}
 	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
 
 	reader := csv.NewReader(file)
 	// Read header
@@ -216,6 +229,8 @@ func (c *CSVProvider) loadFromFile(filepath string) error {
 
 		popData := c.parseCSVRow(record, columnMap)
 		if popData != nil {
+			popData.Source = c.GetProviderName()
+			popData.FetchedAt = info.ModTime()
 			key := fmt.Sprintf("%s_%s_%s", popData.SetName, popData.Card.Name, popData.CardNumber)
 			c.data[key] = popData
 		}
@@ -354,26 +369,6 @@ func (c *CSVProvider) downloadFromURL(url string) error {
 	return err
 }
 
-// generateFallbackData generates mock population data when real data is unavailable
-func (c *CSVProvider) generateFallbackData(card model.Card) *PopulationData {
-	// Use deterministic mock data based on card properties
-	hash := simpleHash(card.Name + card.Number)
-	psa10Pop := 100 + (hash % 500)
-
-	return &PopulationData{
-		Card:            card,
-		SetName:         card.SetName,
-		CardNumber:      card.Number,
-		PSA10Population: psa10Pop,
-		PSA9Population:  psa10Pop * 2,
-		PSA8Population:  psa10Pop * 3,
-		TotalGraded:     psa10Pop * 8,
-		ScarcityLevel:   c.calculateScarcity(psa10Pop),
-		LastUpdated:     time.Now(),
-		PopulationTrend: "STABLE",
-	}
-}
-
 // calculateScarcity determines scarcity level based on PSA 10 population
 func (c *CSVProvider) calculateScarcity(psa10Count int) string {
 	switch {
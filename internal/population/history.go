@@ -0,0 +1,332 @@
+package population
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+// Population trend directions
+const (
+	TrendIncreasing = "INCREASING"
+	TrendStable     = "STABLE"
+	TrendDecreasing = "DECREASING"
+)
+
+const (
+	// SaturationPSA10 is the PSA 10 population past which a card is common,
+	// where the ranker's scarcity bonus runs out
+	SaturationPSA10 = 1000
+
+	// trendWindow is how far back from the latest observation a trend looks
+	trendWindow = 180 * 24 * time.Hour
+	// minTrendSpan is the shortest stretch of observations a trend is read from
+	minTrendSpan = 7 * 24 * time.Hour
+	// increasingGrowth is the monthly PSA 10 growth, as a share of the
+	// population, at which a population counts as increasing
+	increasingGrowth = 0.02
+
+	daysPerMonth = 30.0
+)
+
+// Observation is a card's population as one lookup saw it
+type Observation struct {
+	Set    string    `json:"set"`
+	Card   string    `json:"card"`
+	Number string    `json:"number"`
+	At     time.Time `json:"at"`
+	Total  int       `json:"total"`
+	PSA10  int       `json:"psa10"`
+	PSA9   int       `json:"psa9"`
+	PSA8   int       `json:"psa8"`
+	Source string    `json:"source,omitempty"`
+}
+
+// GemRate returns the share of graded copies that got a PSA 10
+func (o Observation) GemRate() float64 {
+	if o.Total == 0 {
+		return 0
+	}
+	return float64(o.PSA10) / float64(o.Total)
+}
+
+// Trend is what a card's observations say about how its population grows.
+// A zero Trend, with no Direction, means too few observations to tell.
+type Trend struct {
+	Direction      string  `json:"direction"`
+	Observations   int     `json:"observations"`
+	SpanDays       float64 `json:"span_days"`
+	PSA10PerMonth  float64 `json:"psa10_per_month"`  // new PSA 10s a month
+	GradedPerMonth float64 `json:"graded_per_month"` // new graded copies a month
+	GemRate        float64 `json:"gem_rate"`         // PSA 10 share at the latest observation
+	GemRateDrift   float64 `json:"gem_rate_drift"`   // change in the gem rate a month
+	// MonthsToSaturation is how long until the PSA 10 population reaches
+	// SaturationPSA10 at the current pace: 0 when it already has, -1 when
+	// it isn't growing
+	MonthsToSaturation float64 `json:"months_to_saturation"`
+}
+
+// Known reports whether there were enough observations for a trend
+func (t Trend) Known() bool {
+	return t.Direction != ""
+}
+
+// TrendOf reads a trend from a card's observations, oldest first
+func TrendOf(obs []Observation) Trend {
+	if len(obs) < 2 {
+		return Trend{Observations: len(obs)}
+	}
+	last := obs[len(obs)-1]
+	first := obs[0]
+	for _, o := range obs {
+		if last.At.Sub(o.At) <= trendWindow {
+			first = o
+			break
+		}
+	}
+	span := last.At.Sub(first.At)
+	if span < minTrendSpan {
+		return Trend{Observations: len(obs)}
+	}
+
+	months := span.Hours() / 24 / daysPerMonth
+	t := Trend{
+		Observations:   len(obs),
+		SpanDays:       span.Hours() / 24,
+		PSA10PerMonth:  float64(last.PSA10-first.PSA10) / months,
+		GradedPerMonth: float64(last.Total-first.Total) / months,
+		GemRate:        last.GemRate(),
+		GemRateDrift:   (last.GemRate() - first.GemRate()) / months,
+	}
+
+	switch {
+	case t.PSA10PerMonth < 0:
+		t.Direction = TrendDecreasing
+	case t.PSA10PerMonth >= increasingGrowth*float64(max(first.PSA10, 1)):
+		t.Direction = TrendIncreasing
+	default:
+		t.Direction = TrendStable
+	}
+
+	switch {
+	case last.PSA10 >= SaturationPSA10:
+		t.MonthsToSaturation = 0
+	case t.PSA10PerMonth <= 0:
+		t.MonthsToSaturation = -1
+	default:
+		t.MonthsToSaturation = float64(SaturationPSA10-last.PSA10) / t.PSA10PerMonth
+	}
+	return t
+}
+
+// Apply copies a known trend onto population data, replacing the
+// provider's guess at its direction
+func (t Trend) Apply(data *PopulationData) {
+	if !t.Known() {
+		return
+	}
+	data.PopulationTrend = t.Direction
+	data.TrendObservations = t.Observations
+	data.PSA10PerMonth = t.PSA10PerMonth
+	data.GemRateDrift = t.GemRateDrift
+	data.MonthsToSaturation = t.MonthsToSaturation
+}
+
+// History is an append-only log of population observations, one JSON line
+// per observation, that trends are read from
+type History struct {
+	path   string
+	mu     sync.RWMutex
+	byCard map[string][]Observation // by cardCacheKey, oldest first
+}
+
+// OpenHistory loads the history at path; a missing file is an empty history
+func OpenHistory(path string) (*History, error) {
+	h := &History{path: path, byCard: make(map[string][]Observation)}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open population history: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var o Observation
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			return nil, fmt.Errorf("read population history: line %d: %w", line, err)
+		}
+		h.add(o)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read population history: %w", err)
+	}
+	return h, nil
+}
+
+func observationKey(o Observation) string {
+	return cardCacheKey(model.Card{SetName: o.Set, Name: o.Card, Number: o.Number})
+}
+
+func (h *History) add(o Observation) {
+	key := observationKey(o)
+	obs := append(h.byCard[key], o)
+	sort.SliceStable(obs, func(i, j int) bool { return obs[i].At.Before(obs[j].At) })
+	h.byCard[key] = obs
+}
+
+// Record adds a lookup's population for card, dated by the data's
+// LastUpdated (or now). It skips data no newer than the latest observation
+// and repeats of the same counts on the same day, so cached lookups don't
+// pile up.
+func (h *History) Record(card model.Card, data *PopulationData, source string) error {
+	now := time.Now()
+	at := data.LastUpdated
+	if at.IsZero() || at.After(now) {
+		at = now
+	}
+	o := Observation{
+		Set:    card.SetName,
+		Card:   card.Name,
+		Number: card.Number,
+		At:     at.UTC(),
+		Total:  data.TotalGraded,
+		PSA10:  data.PSA10Population,
+		PSA9:   data.PSA9Population,
+		PSA8:   data.PSA8Population,
+		Source: source,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if obs := h.byCard[observationKey(o)]; len(obs) > 0 {
+		latest := obs[len(obs)-1]
+		sameCounts := latest.Total == o.Total && latest.PSA10 == o.PSA10 && latest.PSA9 == o.PSA9 && latest.PSA8 == o.PSA8
+		sameDay := latest.At.Format("2006-01-02") == o.At.Format("2006-01-02")
+		if !o.At.After(latest.At) || (sameCounts && sameDay) {
+			return nil
+		}
+	}
+
+	line, err := json.Marshal(o)
+	if err != nil {
+		return fmt.Errorf("record population: %w", err)
+	}
+	if dir := filepath.Dir(h.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("record population: %w", err)
+		}
+	}
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("record population: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("record population: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("record population: %w", err)
+	}
+	h.add(o)
+	return nil
+}
+
+// Observations returns a card's observations, oldest first
+func (h *History) Observations(card model.Card) []Observation {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]Observation(nil), h.byCard[cardCacheKey(card)]...)
+}
+
+// Between returns a card's population as last seen by from and as last seen
+// by to, and false unless both exist and the second is newer
+func (h *History) Between(card model.Card, from, to time.Time) (before, after Observation, ok bool) {
+	var haveBefore, haveAfter bool
+	for _, o := range h.Observations(card) {
+		if !o.At.After(from) {
+			before, haveBefore = o, true
+		}
+		if !o.At.After(to) {
+			after, haveAfter = o, true
+		}
+	}
+	return before, after, haveBefore && haveAfter && after.At.After(before.At)
+}
+
+// Trend reads the trend from a card's observations
+func (h *History) Trend(card model.Card) Trend {
+	return TrendOf(h.Observations(card))
+}
+
+// HistoryProvider records the population each lookup through another
+// provider finds, and fills in the trend its history shows. Mock or
+// estimated data, and data without PSA counts such as another company's
+// census alone, is neither recorded nor given a trend.
+type HistoryProvider struct {
+	Provider
+	history *History
+}
+
+// NewHistoryProvider wraps p so its lookups are recorded in h
+func NewHistoryProvider(p Provider, h *History) *HistoryProvider {
+	return &HistoryProvider{Provider: p, history: h}
+}
+
+// History returns the history lookups are recorded in
+func (p *HistoryProvider) History() *History {
+	return p.history
+}
+
+// LookupPopulation looks the card up, records it and applies its trend
+func (p *HistoryProvider) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
+	data, err := p.Provider.LookupPopulation(ctx, card)
+	if err != nil || data == nil {
+		return data, err
+	}
+	p.observe(card, data)
+	return data, nil
+}
+
+// BatchLookupPopulation looks the cards up, recording and applying each
+// one's trend
+func (p *HistoryProvider) BatchLookupPopulation(ctx context.Context, cards []model.Card) (map[string]*PopulationData, error) {
+	results, err := p.Provider.BatchLookupPopulation(ctx, cards)
+	if err != nil {
+		return results, err
+	}
+	for _, card := range cards {
+		if data := results[fmt.Sprintf("%s-%s", card.Number, card.Name)]; data != nil {
+			p.observe(card, data)
+		}
+	}
+	return results, nil
+}
+
+func (p *HistoryProvider) observe(card model.Card, data *PopulationData) {
+	if p.IsMockMode() || data.Estimated || data.TotalGraded == 0 {
+		return
+	}
+	if card.SetName == "" {
+		card.SetName = data.SetName
+	}
+	if err := p.history.Record(card, data, p.GetProviderName()); err != nil {
+		log.Printf("population history: %v", err)
+	}
+	p.history.Trend(card).Apply(data)
+}
@@ -0,0 +1,136 @@
+package population
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+func TestTrendOf(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	obs := []Observation{
+		{At: start, Total: 1000, PSA10: 100},
+		{At: start.AddDate(0, 0, 30), Total: 1300, PSA10: 160},
+		{At: start.AddDate(0, 0, 60), Total: 1600, PSA10: 220},
+	}
+	trend := TrendOf(obs)
+	if trend.Direction != TrendIncreasing || trend.Observations != 3 {
+		t.Fatalf("unexpected trend %+v", trend)
+	}
+	if abs(trend.PSA10PerMonth-60) > 1e-9 || abs(trend.GradedPerMonth-300) > 1e-9 {
+		t.Errorf("velocity = %.2f PSA 10s, %.2f graded a month; want 60, 300", trend.PSA10PerMonth, trend.GradedPerMonth)
+	}
+	if wantDrift := (220.0/1600 - 0.1) / 2; abs(trend.GemRateDrift-wantDrift) > 1e-9 {
+		t.Errorf("gem rate drift = %.4f, want %.4f", trend.GemRateDrift, wantDrift)
+	}
+	if want := float64(SaturationPSA10-220) / 60; abs(trend.MonthsToSaturation-want) > 1e-9 {
+		t.Errorf("months to saturation = %.2f, want %.2f", trend.MonthsToSaturation, want)
+	}
+
+	flat := TrendOf([]Observation{{At: start, PSA10: 100}, {At: start.AddDate(0, 1, 0), PSA10: 100}})
+	if flat.Direction != TrendStable || flat.MonthsToSaturation != -1 {
+		t.Errorf("expected a stable, unsaturating trend, got %+v", flat)
+	}
+	if short := TrendOf(obs[:1]); short.Known() {
+		t.Errorf("expected no trend from one observation, got %+v", short)
+	}
+	if hourApart := TrendOf([]Observation{{At: start}, {At: start.Add(time.Hour), PSA10: 5}}); hourApart.Known() {
+		t.Errorf("expected no trend from observations an hour apart, got %+v", hourApart)
+	}
+}
+
+func abs(f float64) float64 {
+	if f < 0 {
+		return -f
+	}
+	return f
+}
+
+func TestHistory_RecordAndReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pop", "history.jsonl")
+	h, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := model.Card{SetName: "Base Set", Name: "Charizard", Number: "4"}
+	month := time.Now().AddDate(0, -1, 0)
+
+	record := func(at time.Time, psa10 int) {
+		t.Helper()
+		data := &PopulationData{LastUpdated: at, TotalGraded: psa10 * 10, PSA10Population: psa10}
+		if err := h.Record(card, data, "PSA"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	record(month, 100)
+	record(month, 100)                // a cached repeat
+	record(month.Add(-time.Hour), 90) // older than what's recorded
+	record(time.Time{}, 130)          // undated, so now
+	if n := len(h.Observations(card)); n != 2 {
+		t.Fatalf("recorded %d observations, want 2", n)
+	}
+
+	reloaded, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trend := reloaded.Trend(card)
+	if trend.Direction != TrendIncreasing || trend.Observations != 2 {
+		t.Errorf("unexpected trend after reload %+v", trend)
+	}
+
+	if err := os.WriteFile(path, []byte("not json\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenHistory(path); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("expected a line-numbered error for a corrupt history, got %v", err)
+	}
+}
+
+// fixedProvider answers every lookup with the same population
+type fixedProvider struct {
+	MockProvider
+	data PopulationData
+	mock bool
+}
+
+func (f *fixedProvider) IsMockMode() bool { return f.mock }
+
+func (f *fixedProvider) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
+	data := f.data
+	return &data, nil
+}
+
+func TestHistoryProvider(t *testing.T) {
+	h, err := OpenHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	card := model.Card{SetName: "Base Set", Name: "Charizard", Number: "4"}
+	_ = h.Record(card, &PopulationData{LastUpdated: time.Now().AddDate(0, -2, 0), TotalGraded: 500, PSA10Population: 50}, "PSA")
+
+	inner := &fixedProvider{data: PopulationData{TotalGraded: 900, PSA10Population: 110, PopulationTrend: TrendStable}}
+	p := NewHistoryProvider(inner, h)
+	data, err := p.LookupPopulation(context.Background(), card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.PopulationTrend != TrendIncreasing || data.TrendObservations != 2 || abs(data.PSA10PerMonth-30) > 1 {
+		t.Errorf("expected the recorded history's trend, got %s over %d observations at %.1f a month",
+			data.PopulationTrend, data.TrendObservations, data.PSA10PerMonth)
+	}
+
+	inner.mock = true
+	mockCard := model.Card{SetName: "Base Set", Name: "Blastoise", Number: "2"}
+	if _, err := p.LookupPopulation(context.Background(), mockCard); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(h.Observations(mockCard)); n != 0 {
+		t.Errorf("expected mock data to go unrecorded, got %d observations", n)
+	}
+}
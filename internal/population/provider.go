@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/guarzo/pkmgradegap/internal/cache"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
@@ -46,6 +47,21 @@ type PopulationData struct {
 	QualifierCounts map[string]int `json:"qualifier_counts"` // OC, MC, etc. → count
 	ScarcityLevel   string         `json:"scarcity_level"`   // "COMMON", "UNCOMMON", "RARE", "ULTRA_RARE"
 	PopulationTrend string         `json:"population_trend"` // "INCREASING", "STABLE", "DECREASING"
+
+	// From the population history (see HistoryProvider); zero without one
+	TrendObservations  int     `json:"trend_observations,omitempty"`   // 0 when PopulationTrend is the provider's guess
+	PSA10PerMonth      float64 `json:"psa10_per_month,omitempty"`      // new PSA 10s a month
+	GemRateDrift       float64 `json:"gem_rate_drift,omitempty"`       // change in the PSA 10 share a month
+	MonthsToSaturation float64 `json:"months_to_saturation,omitempty"` // see Trend
+
+	// Other companies' census by grader (see CombinedProvider); the fields
+	// above are PSA's alone
+	Graders map[string]*GraderPopulation `json:"graders,omitempty"`
+
+	// Where the counts came from and when they were fetched (see ChainProvider)
+	Source    string    `json:"source,omitempty"` // provider name, e.g. "PSA API" or "PSA Scraper"
+	FetchedAt time.Time `json:"fetched_at,omitempty"`
+	Estimated bool      `json:"estimated,omitempty"` // made-up counts from a mock, not a census
 }
 
 // SetPopulationData represents population data for an entire set
@@ -73,7 +89,7 @@ type PSAProvider struct {
 	apiKey      string
 	baseURL     string
 	httpClient  HTTPClient
-	cache       Cache
+	cache       *popCache
 	rateLimiter RateLimiter
 }
 
@@ -90,15 +106,6 @@ type HTTPResponse struct {
 	Headers    map[string]string
 }
 
-// Cache interface for caching population data
-type Cache interface {
-	Get(key string) (*PopulationData, bool)
-	Set(key string, data *PopulationData, ttl time.Duration) error
-	GetSet(key string) (*SetPopulationData, bool)
-	SetSet(key string, data *SetPopulationData, ttl time.Duration) error
-	Clear() error
-}
-
 // RateLimiter interface for controlling request rates
 type RateLimiter interface {
 	Wait(ctx context.Context) error
@@ -124,12 +131,12 @@ type PSASpecWithPop struct {
 }
 
 // NewPSAProvider creates a new PSA population provider
-func NewPSAProvider(apiKey string, httpClient HTTPClient, cache Cache, rateLimiter RateLimiter) *PSAProvider {
+func NewPSAProvider(apiKey string, httpClient HTTPClient, store cache.Store, rateLimiter RateLimiter) *PSAProvider {
 	return &PSAProvider{
 		apiKey:      apiKey,
 		baseURL:     "https://api.psacard.com/publicapi/population", // Hypothetical API endpoint
 		httpClient:  httpClient,
-		cache:       cache,
+		cache:       newPopCache(store),
 		rateLimiter: rateLimiter,
 	}
 }
@@ -164,10 +171,7 @@ func (p *PSAProvider) LookupPopulation(ctx context.Context, card model.Card) (*P
 	}
 
 	// Create cache key
-	cacheKey := fmt.Sprintf("psa_pop_%s_%s_%s",
-		strings.ReplaceAll(card.SetName, " ", "_"),
-		strings.ReplaceAll(card.Name, " ", "_"),
-		card.Number)
+	cacheKey := cardCacheKey(card)
 
 	log.Printf("Looking up PSA population for %s #%s from set '%s'",
 		card.Name, card.Number, card.SetName)
@@ -272,7 +276,7 @@ func (p *PSAProvider) BatchLookupPopulation(ctx context.Context, cards []model.C
 
 	// Check cache for each card using standardized cache key format
 	for _, card := range cards {
-		cacheKey := fmt.Sprintf("psa_pop_%s_%s_%s", card.SetName, card.Name, card.Number)
+		cacheKey := cardCacheKey(card)
 		cardKey := fmt.Sprintf("%s-%s", card.Number, card.Name)
 
 		if cached, found := p.cache.Get(cacheKey); found {
@@ -305,7 +309,7 @@ func (p *PSAProvider) GetSetPopulation(ctx context.Context, setName string) (*Se
 	}
 
 	// Check cache first
-	cacheKey := fmt.Sprintf("psa_set_%s", setName)
+	cacheKey := setName
 	if cached, found := p.cache.GetSet(cacheKey); found {
 		return cached, nil
 	}
@@ -669,6 +673,9 @@ func (m *MockProvider) LookupPopulation(ctx context.Context, card model.Card) (*
 		PSA8Population:  gradePopulation["PSA 8"],
 		ScarcityLevel:   scarcityLevel,
 		PopulationTrend: "STABLE",
+		Source:          m.GetProviderName(),
+		FetchedAt:       time.Now(),
+		Estimated:       true,
 	}, nil
 }
 
@@ -2,10 +2,12 @@ package population
 
 import (
 	"context"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/guarzo/pkmgradegap/internal/cache"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
@@ -24,43 +26,6 @@ func (m *mockHTTPClient) Post(url string, data []byte) (*HTTPResponse, error) {
 	return m.response, m.err
 }
 
-type mockCache struct {
-	data map[string]*PopulationData
-	sets map[string]*SetPopulationData
-}
-
-func (m *mockCache) Get(key string) (*PopulationData, bool) {
-	data, found := m.data[key]
-	return data, found
-}
-
-func (m *mockCache) Set(key string, data *PopulationData, ttl time.Duration) error {
-	if m.data == nil {
-		m.data = make(map[string]*PopulationData)
-	}
-	m.data[key] = data
-	return nil
-}
-
-func (m *mockCache) GetSet(key string) (*SetPopulationData, bool) {
-	data, found := m.sets[key]
-	return data, found
-}
-
-func (m *mockCache) SetSet(key string, data *SetPopulationData, ttl time.Duration) error {
-	if m.sets == nil {
-		m.sets = make(map[string]*SetPopulationData)
-	}
-	m.sets[key] = data
-	return nil
-}
-
-func (m *mockCache) Clear() error {
-	m.data = make(map[string]*PopulationData)
-	m.sets = make(map[string]*SetPopulationData)
-	return nil
-}
-
 type mockRateLimiter struct{}
 
 func (m *mockRateLimiter) Wait(ctx context.Context) error {
@@ -385,10 +350,10 @@ func TestPSAProvider_LookupPopulation_Integration(t *testing.T) {
 		},
 	}
 
-	cache := &mockCache{}
+	store := cache.NewMemory()
 	rateLimiter := &mockRateLimiter{}
 
-	provider := NewPSAProvider("test-api-key", httpClient, cache, rateLimiter)
+	provider := NewPSAProvider("test-api-key", httpClient, store, rateLimiter)
 
 	card := model.Card{
 		Name:    "Charizard VMAX",
@@ -408,8 +373,8 @@ func TestPSAProvider_LookupPopulation_Integration(t *testing.T) {
 	}
 
 	// Verify the data was cached
-	cacheKey := "psa_pop_VMAX_Climax_Charizard_VMAX_003"
-	if cached, found := cache.Get(cacheKey); !found {
+	cacheKey := cardCacheKey(card)
+	if cached, found := newPopCache(store).Get(cacheKey); !found {
 		t.Error("Expected data to be cached")
 	} else if cached.PSA10Population != 1250 {
 		t.Errorf("Expected cached PSA 10 population 1250, got %d", cached.PSA10Population)
@@ -422,19 +387,16 @@ func TestPSAProvider_LookupPopulation_CacheHit(t *testing.T) {
 		err: testError{Message: "HTTP client should not be called"},
 	}
 
-	cache := &mockCache{
-		data: map[string]*PopulationData{
-			"psa_pop_Test_Set_Test_Card_001": {
-				Card:            model.Card{Name: "Test Card", SetName: "Test Set", Number: "001"},
-				PSA10Population: 500,
-				TotalGraded:     2000,
-				ScarcityLevel:   "UNCOMMON",
-			},
-		},
-	}
+	store := cache.NewMemory()
+	_ = newPopCache(store).Set(cache.BuildKey("Test Set", "Test Card", "001"), &PopulationData{
+		Card:            model.Card{Name: "Test Card", SetName: "Test Set", Number: "001"},
+		PSA10Population: 500,
+		TotalGraded:     2000,
+		ScarcityLevel:   "UNCOMMON",
+	}, time.Hour)
 
 	rateLimiter := &mockRateLimiter{}
-	provider := NewPSAProvider("test-api-key", httpClient, cache, rateLimiter)
+	provider := NewPSAProvider("test-api-key", httpClient, store, rateLimiter)
 
 	card := model.Card{
 		Name:    "Test Card",
@@ -471,3 +433,47 @@ func (e testError) Error() string {
 func newTestError(message string) error {
 	return testError{Message: message}
 }
+
+func TestPopCache_RoundTrip(t *testing.T) {
+	store, err := cache.New(filepath.Join(t.TempDir(), "cache.json"))
+	if err != nil {
+		t.Fatalf("Failed to create cache: %v", err)
+	}
+	fc := newPopCache(store)
+
+	if _, found := fc.Get("001-Pikachu"); found {
+		t.Fatal("Expected empty cache miss")
+	}
+
+	data := &PopulationData{CardNumber: "001", TotalGraded: 120, PSA10Population: 30}
+	if err := fc.Set("001-Pikachu", data, time.Hour); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	got, found := fc.Get("001-Pikachu")
+	if !found {
+		t.Fatal("Expected cache hit after Set")
+	}
+	if got.TotalGraded != 120 || got.PSA10Population != 30 {
+		t.Errorf("Unexpected cached data: %+v", got)
+	}
+
+	// Card and set entries live in separate key spaces
+	if _, found := fc.GetSet("001-Pikachu"); found {
+		t.Error("Card entry should not be visible as a set entry")
+	}
+	if err := fc.SetSet("Surging Sparks", &SetPopulationData{SetName: "Surging Sparks"}, time.Hour); err != nil {
+		t.Fatalf("SetSet failed: %v", err)
+	}
+	if set, found := fc.GetSet("Surging Sparks"); !found || set.SetName != "Surging Sparks" {
+		t.Errorf("Expected set entry, got %+v (found=%v)", set, found)
+	}
+
+	// Population entries share the store's population namespace
+	if n, _ := store.InvalidatePrefix("pop|set|"); n != 1 {
+		t.Errorf("Expected one set entry invalidated, got %d", n)
+	}
+	if _, found := fc.Get("001-Pikachu"); !found {
+		t.Error("Expected card entries to survive invalidating sets")
+	}
+}
@@ -9,18 +9,18 @@ import (
 	"strings"
 	"time"
 
+	"github.com/guarzo/pkmgradegap/internal/cache"
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
-// PSAAPIProvider implements population data access through PSA's API
-// Falls back to web scraping when API is not available
+// PSAAPIProvider implements population data access through PSA's API.
+// Chain it ahead of the PSAScraper to scrape when the API can't answer.
 type PSAAPIProvider struct {
 	apiKey      string
 	baseURL     string
 	client      *http.Client
 	rateLimiter RateLimiter
-	cache       Cache
-	scraper     *PSAScraper // Fallback web scraper
+	cache       *popCache
 }
 
 // PSAAPIResponse represents the structure of PSA API responses
@@ -67,15 +67,14 @@ type PSASpec struct {
 	SetName     string `json:"setName"`
 }
 
-// NewPSAAPIProvider creates a new PSA API provider with web scraper fallback
-func NewPSAAPIProvider(apiKey string, rateLimiter RateLimiter, cache Cache) *PSAAPIProvider {
+// NewPSAAPIProvider creates a new PSA API provider
+func NewPSAAPIProvider(apiKey string, rateLimiter RateLimiter, store cache.Store) *PSAAPIProvider {
 	return &PSAAPIProvider{
 		apiKey:      apiKey,
 		baseURL:     "https://api.psacard.com/publicapi",
 		client:      &http.Client{Timeout: 30 * time.Second},
 		rateLimiter: rateLimiter,
-		cache:       cache,
-		scraper:     NewPSAScraper(cache), // Pass cache directly to scraper
+		cache:       newPopCache(store),
 	}
 }
 
@@ -96,32 +95,17 @@ func (p *PSAAPIProvider) IsMockMode() bool {
 
 // LookupPopulation retrieves population data for a specific card
 func (p *PSAAPIProvider) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
-	// Try API first if available
-	if p.Available() {
-		popData, err := p.lookupViaAPI(ctx, card)
-		if err == nil && popData != nil {
-			return popData, nil
-		}
-		// Log API failure but continue to scraper
-		fmt.Printf("PSA API lookup failed, falling back to scraper: %v\n", err)
-	}
-
-	// Fall back to web scraping
-	if p.scraper != nil {
-		return p.lookupViaScraper(ctx, card)
+	if !p.Available() {
+		return nil, fmt.Errorf("PSA API not available (no API key)")
 	}
-
-	return nil, fmt.Errorf("PSA data not available (no API key and scraper disabled)")
+	return p.lookupViaAPI(ctx, card)
 }
 
 // lookupViaAPI uses the PSA API to get population data
 func (p *PSAAPIProvider) lookupViaAPI(ctx context.Context, card model.Card) (*PopulationData, error) {
 
 	// Check cache first
-	cacheKey := fmt.Sprintf("psa_api_%s_%s_%s",
-		normalizeSetName(card.SetName),
-		strings.ReplaceAll(card.Name, " ", "_"),
-		card.Number)
+	cacheKey := cardCacheKey(card)
 
 	if cached, found := p.cache.Get(cacheKey); found {
 		return cached, nil
@@ -143,6 +127,8 @@ func (p *PSAAPIProvider) lookupViaAPI(ctx context.Context, card model.Card) (*Po
 	if err != nil {
 		return nil, fmt.Errorf("failed to get population data: %w", err)
 	}
+	popData.Source = p.GetProviderName()
+	popData.FetchedAt = time.Now()
 
 	// Cache the result for 24 hours
 	if err := p.cache.Set(cacheKey, popData, 24*time.Hour); err != nil {
@@ -153,46 +139,6 @@ func (p *PSAAPIProvider) lookupViaAPI(ctx context.Context, card model.Card) (*Po
 	return popData, nil
 }
 
-// lookupViaScraper uses web scraping to get population data
-func (p *PSAAPIProvider) lookupViaScraper(ctx context.Context, card model.Card) (*PopulationData, error) {
-	if p.scraper == nil {
-		return nil, fmt.Errorf("scraper not initialized")
-	}
-
-	// Use scraper to get population data
-	psaPop, err := p.scraper.GetCardPopulation(ctx, card.SetName, card.Number, card.Name)
-	if err != nil {
-		return nil, fmt.Errorf("scraper failed: %w", err)
-	}
-
-	if psaPop == nil {
-		return nil, fmt.Errorf("no population data found")
-	}
-
-	// Convert model.PSAPopulation to PopulationData
-	scarcity := calculateScarcity(psaPop.PSA10)
-
-	// Build grade population map
-	gradePopulation := make(map[string]int)
-	gradePopulation["PSA 10"] = psaPop.PSA10
-	gradePopulation["PSA 9"] = psaPop.PSA9
-	gradePopulation["PSA 8"] = psaPop.PSA8
-
-	return &PopulationData{
-		Card:            card,
-		SetName:         card.SetName,
-		CardNumber:      card.Number,
-		LastUpdated:     psaPop.LastUpdated,
-		GradePopulation: gradePopulation,
-		TotalGraded:     psaPop.TotalGraded,
-		PSA10Population: psaPop.PSA10,
-		PSA9Population:  psaPop.PSA9,
-		PSA8Population:  psaPop.PSA8,
-		ScarcityLevel:   scarcity,
-		PopulationTrend: "STABLE", // Would need historical data
-	}, nil
-}
-
 // BatchLookupPopulation retrieves population data for multiple cards
 func (p *PSAAPIProvider) BatchLookupPopulation(ctx context.Context, cards []model.Card) (map[string]*PopulationData, error) {
 	results := make(map[string]*PopulationData)
@@ -220,7 +166,7 @@ func (p *PSAAPIProvider) BatchLookupPopulation(ctx context.Context, cards []mode
 // GetSetPopulation retrieves population summary for an entire set
 func (p *PSAAPIProvider) GetSetPopulation(ctx context.Context, setName string) (*SetPopulationData, error) {
 	// Check cache first
-	cacheKey := fmt.Sprintf("psa_set_%s", normalizeSetName(setName))
+	cacheKey := setName
 	if cached, found := p.cache.GetSet(cacheKey); found {
 		return cached, nil
 	}
@@ -9,10 +9,10 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/guarzo/pkmgradegap/internal/cache"
 	"github.com/guarzo/pkmgradegap/internal/model"
 	"golang.org/x/time/rate"
 )
@@ -24,134 +24,24 @@ const (
 	scrapeRateLimit      = 1 // request per second
 	cacheExpiration      = 24 * time.Hour
 	searchCacheTTL       = 1 * time.Hour // Search results cache for 1 hour
-	maxCacheSize         = 1000          // Maximum number of cached search results
 )
 
-// searchCacheEntry represents a cached search result with expiration
-type searchCacheEntry struct {
-	URL       string
-	ExpiresAt time.Time
-}
-
-// searchCacheStats tracks cache hit/miss metrics
-type searchCacheStats struct {
-	Hits      int64
-	Misses    int64
-	Evictions int64
-}
-
-// stringCache implements a simple LRU cache for search results
-type stringCache struct {
-	entries map[string]*searchCacheEntry
-	order   []string // LRU order, most recently used at end
-	stats   searchCacheStats
-	mu      sync.RWMutex
-	maxSize int
-}
-
-// newStringCache creates a new string cache with the specified max size
-func newStringCache(maxSize int) *stringCache {
-	return &stringCache{
-		entries: make(map[string]*searchCacheEntry),
-		order:   make([]string, 0, maxSize),
-		maxSize: maxSize,
-	}
-}
-
-// get retrieves a value from the cache
-func (sc *stringCache) get(key string) (string, bool) {
-	sc.mu.Lock()
-	defer sc.mu.Unlock()
-
-	entry, exists := sc.entries[key]
-	if !exists {
-		sc.stats.Misses++
-		return "", false
-	}
-
-	// Check if expired
-	if time.Now().After(entry.ExpiresAt) {
-		delete(sc.entries, key)
-		sc.removeFromOrder(key)
-		sc.stats.Misses++
-		return "", false
-	}
-
-	// Move to end (most recently used)
-	sc.moveToEnd(key)
-	sc.stats.Hits++
-	return entry.URL, true
-}
-
-// set stores a value in the cache
-func (sc *stringCache) set(key, value string, ttl time.Duration) {
-	sc.mu.Lock()
-	defer sc.mu.Unlock()
-
-	// Remove existing entry if present
-	if _, exists := sc.entries[key]; exists {
-		sc.removeFromOrder(key)
-	}
-
-	// Evict if at capacity
-	if len(sc.entries) >= sc.maxSize {
-		sc.evictOldest()
-	}
-
-	// Add new entry
-	sc.entries[key] = &searchCacheEntry{
-		URL:       value,
-		ExpiresAt: time.Now().Add(ttl),
-	}
-	sc.order = append(sc.order, key)
-}
-
-// evictOldest removes the least recently used entry
-func (sc *stringCache) evictOldest() {
-	if len(sc.order) == 0 {
-		return
-	}
-
-	oldest := sc.order[0]
-	delete(sc.entries, oldest)
-	sc.order = sc.order[1:]
-	sc.stats.Evictions++
-}
-
-// removeFromOrder removes a key from the order slice
-func (sc *stringCache) removeFromOrder(key string) {
-	for i, k := range sc.order {
-		if k == key {
-			sc.order = append(sc.order[:i], sc.order[i+1:]...)
-			break
-		}
-	}
-}
-
-// moveToEnd moves a key to the end of the order slice (most recently used)
-func (sc *stringCache) moveToEnd(key string) {
-	sc.removeFromOrder(key)
-	sc.order = append(sc.order, key)
-}
-
-// getStats returns cache hit/miss statistics
-func (sc *stringCache) getStats() searchCacheStats {
-	sc.mu.RLock()
-	defer sc.mu.RUnlock()
-	return sc.stats
-}
-
 // PSAScraper implements web scraping for PSA population data
 type PSAScraper struct {
 	client      *http.Client
-	cache       Cache        // Use the Cache interface for population data
-	searchCache *stringCache // String cache for search results
+	store       cache.Store
+	searchCache cache.Typed[string]               // population report URLs by search
+	popCache    cache.Typed[*model.PSAPopulation] // scraped reports by URL or spec
 	limiter     *rate.Limiter
 	debug       bool
 }
 
-// NewPSAScraper creates a new PSA web scraper
-func NewPSAScraper(cacheInstance Cache) *PSAScraper {
+// NewPSAScraper creates a new PSA web scraper that caches searches and
+// reports in store, or in memory when store is nil
+func NewPSAScraper(store cache.Store) *PSAScraper {
+	if store == nil {
+		store = cache.NewMemory()
+	}
 	return &PSAScraper{
 		client: &http.Client{
 			Timeout: 30 * time.Second,
@@ -162,8 +52,9 @@ func NewPSAScraper(cacheInstance Cache) *PSAScraper {
 				return nil
 			},
 		},
-		cache:       cacheInstance,
-		searchCache: newStringCache(maxCacheSize),
+		store:       store,
+		searchCache: cache.NewTyped[string](store, "pop", "search"),
+		popCache:    cache.NewTyped[*model.PSAPopulation](store, "pop", "scrape"),
 		limiter:     rate.NewLimiter(rate.Limit(scrapeRateLimit), 1),
 		debug:       false,
 	}
@@ -174,9 +65,9 @@ func (s *PSAScraper) SetDebug(debug bool) {
 	s.debug = debug
 }
 
-// GetSearchCacheStats returns current search cache statistics for monitoring
-func (s *PSAScraper) GetSearchCacheStats() searchCacheStats {
-	return s.searchCache.getStats()
+// CacheStats returns the population cache's hits and misses for monitoring
+func (s *PSAScraper) CacheStats() cache.Counts {
+	return s.store.Stats().Namespaces[cache.NamespacePopulation]
 }
 
 // SearchCard searches for a card and returns the population report URL or identifier
@@ -188,19 +79,19 @@ func (s *PSAScraper) SearchCard(ctx context.Context, set, number, name string) (
 		normalizeString(name))
 
 	// Check search cache first
-	if cachedURL, found := s.searchCache.get(cacheKey); found {
+	if cachedURL, found := s.searchCache.Get(cacheKey); found {
 		if s.debug {
-			stats := s.searchCache.getStats()
-			log.Printf("PSAScraper: Cache HIT for search '%s %s %s' (Stats: %d hits, %d misses, %d evictions)",
-				set, number, name, stats.Hits, stats.Misses, stats.Evictions)
+			stats := s.CacheStats()
+			log.Printf("PSAScraper: Cache HIT for search '%s %s %s' (Stats: %d hits, %d misses)",
+				set, number, name, stats.Hits, stats.Misses)
 		}
 		return cachedURL, nil
 	}
 
 	if s.debug {
-		stats := s.searchCache.getStats()
-		log.Printf("PSAScraper: Cache MISS for search '%s %s %s' (Stats: %d hits, %d misses, %d evictions)",
-			set, number, name, stats.Hits, stats.Misses, stats.Evictions)
+		stats := s.CacheStats()
+		log.Printf("PSAScraper: Cache MISS for search '%s %s %s' (Stats: %d hits, %d misses)",
+			set, number, name, stats.Hits, stats.Misses)
 	}
 
 	// Rate limit
@@ -275,12 +166,12 @@ func (s *PSAScraper) SearchCard(ctx context.Context, set, number, name string) (
 	}
 
 	// Cache the search result for future lookups
-	s.searchCache.set(cacheKey, popURL, searchCacheTTL)
+	_ = s.searchCache.Set(cacheKey, popURL, searchCacheTTL)
 
 	if s.debug {
-		stats := s.searchCache.getStats()
-		log.Printf("PSAScraper: Cached search result for '%s %s %s' -> %s (Stats: %d hits, %d misses, %d evictions)",
-			set, number, name, popURL, stats.Hits, stats.Misses, stats.Evictions)
+		stats := s.CacheStats()
+		log.Printf("PSAScraper: Cached search result for '%s %s %s' -> %s (Stats: %d hits, %d misses)",
+			set, number, name, popURL, stats.Hits, stats.Misses)
 	}
 
 	return popURL, nil
@@ -288,8 +179,9 @@ func (s *PSAScraper) SearchCard(ctx context.Context, set, number, name string) (
 
 // ScrapePopulation scrapes population data from a PSA population report page
 func (s *PSAScraper) ScrapePopulation(ctx context.Context, cardIdentifier string) (*model.PSAPopulation, error) {
-	// Note: Can't use the PopulationData cache here since we return model.PSAPopulation
-	// The cache interface is specific to PopulationData type
+	if pop, found := s.popCache.Get(cardIdentifier); found && pop != nil {
+		return pop, nil
+	}
 
 	// Rate limit
 	if err := s.limiter.Wait(ctx); err != nil {
@@ -337,7 +229,7 @@ func (s *PSAScraper) ScrapePopulation(ctx context.Context, cardIdentifier string
 		return nil, fmt.Errorf("parsing population table: %w", err)
 	}
 
-	// Can't cache model.PSAPopulation with the PopulationData cache interface
+	_ = s.popCache.Set(cardIdentifier, pop, cacheExpiration)
 
 	return pop, nil
 }
@@ -546,3 +438,66 @@ func (s *PSAScraper) GetCardPopulation(ctx context.Context, set, number, name st
 
 	return pop, nil
 }
+
+// Available returns true; the population pages need no credentials
+func (s *PSAScraper) Available() bool {
+	return true
+}
+
+// GetProviderName returns the name of the provider
+func (s *PSAScraper) GetProviderName() string {
+	return "PSA Scraper"
+}
+
+// IsMockMode returns false since this is a real provider
+func (s *PSAScraper) IsMockMode() bool {
+	return false
+}
+
+// LookupPopulation scrapes the card's population report, so the scraper can
+// stand in a ChainProvider behind the PSA API
+func (s *PSAScraper) LookupPopulation(ctx context.Context, card model.Card) (*PopulationData, error) {
+	psaPop, err := s.GetCardPopulation(ctx, card.SetName, card.Number, card.Name)
+	if err != nil {
+		return nil, fmt.Errorf("scraper failed: %w", err)
+	}
+	if psaPop == nil {
+		return nil, fmt.Errorf("no PSA population report found for %s #%s", card.Name, card.Number)
+	}
+
+	return &PopulationData{
+		Card:        card,
+		SetName:     card.SetName,
+		CardNumber:  card.Number,
+		LastUpdated: psaPop.LastUpdated,
+		GradePopulation: map[string]int{
+			"PSA 10": psaPop.PSA10,
+			"PSA 9":  psaPop.PSA9,
+			"PSA 8":  psaPop.PSA8,
+		},
+		TotalGraded:     psaPop.TotalGraded,
+		PSA10Population: psaPop.PSA10,
+		PSA9Population:  psaPop.PSA9,
+		PSA8Population:  psaPop.PSA8,
+		ScarcityLevel:   calculateScarcity(psaPop.PSA10),
+		PopulationTrend: TrendStable,
+		Source:          s.GetProviderName(),
+		FetchedAt:       psaPop.LastUpdated,
+	}, nil
+}
+
+// BatchLookupPopulation scrapes each card's population report
+func (s *PSAScraper) BatchLookupPopulation(ctx context.Context, cards []model.Card) (map[string]*PopulationData, error) {
+	results := make(map[string]*PopulationData)
+	for _, card := range cards {
+		if data, err := s.LookupPopulation(ctx, card); err == nil {
+			results[fmt.Sprintf("%s-%s", card.Number, card.Name)] = data
+		}
+	}
+	return results, nil
+}
+
+// GetSetPopulation is not supported; PSA's reports are scraped a card at a time
+func (s *PSAScraper) GetSetPopulation(ctx context.Context, setName string) (*SetPopulationData, error) {
+	return nil, fmt.Errorf("set population not supported by the PSA scraper")
+}
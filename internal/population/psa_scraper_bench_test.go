@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"testing"
 	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
 )
 
-// BenchmarkStringCache benchmarks the string cache implementation
-func BenchmarkStringCache(b *testing.B) {
-	cache := newStringCache(1000)
+// BenchmarkSearchCache benchmarks the scraper's search cache
+func BenchmarkSearchCache(b *testing.B) {
+	searches := cache.NewTyped[string](cache.NewMemory(), "pop", "search")
 
 	// Test data
 	keys := make([]string, 100)
@@ -25,19 +27,19 @@ func BenchmarkStringCache(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			key := keys[i%len(keys)]
 			value := values[i%len(values)]
-			cache.set(key, value, time.Hour)
+			_ = searches.Set(key, value, time.Hour)
 		}
 	})
 
 	// Pre-populate cache for get benchmarks
 	for i := 0; i < len(keys); i++ {
-		cache.set(keys[i], values[i], time.Hour)
+		_ = searches.Set(keys[i], values[i], time.Hour)
 	}
 
 	b.Run("Get", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
 			key := keys[i%len(keys)]
-			_, _ = cache.get(key)
+			_, _ = searches.Get(key)
 		}
 	})
 
@@ -47,10 +49,10 @@ func BenchmarkStringCache(b *testing.B) {
 			if i%4 == 0 {
 				// 25% writes
 				value := values[i%len(values)]
-				cache.set(key, value, time.Hour)
+				_ = searches.Set(key, value, time.Hour)
 			} else {
 				// 75% reads
-				_, _ = cache.get(key)
+				_, _ = searches.Get(key)
 			}
 		}
 	})
@@ -58,9 +60,7 @@ func BenchmarkStringCache(b *testing.B) {
 
 // BenchmarkSearchCardCaching benchmarks search card performance with caching
 func BenchmarkSearchCardCaching(b *testing.B) {
-	// Create a mock cache that always returns data to avoid HTTP calls
-	mockCache := &MockPopulationCache{}
-	scraper := NewPSAScraper(mockCache)
+	scraper := NewPSAScraper(cache.NewMemory())
 	scraper.SetDebug(false) // Disable debug logging for benchmarks
 
 	ctx := context.Background()
@@ -87,7 +87,7 @@ func BenchmarkSearchCardCaching(b *testing.B) {
 
 	b.Run("ColdCache", func(b *testing.B) {
 		// Reset cache for each run
-		scraper.searchCache = newStringCache(maxCacheSize)
+		_, _ = scraper.searchCache.Invalidate()
 
 		for i := 0; i < b.N; i++ {
 			card := testCards[i%len(testCards)]
@@ -103,7 +103,7 @@ func BenchmarkSearchCardCaching(b *testing.B) {
 				normalizeString(card.set),
 				normalizeString(card.number),
 				normalizeString(card.name))
-			scraper.searchCache.set(cacheKey, "https://psacard.com/pop/cached", time.Hour)
+			_ = scraper.searchCache.Set(cacheKey, "https://psacard.com/pop/cached", time.Hour)
 		}
 
 		b.ResetTimer()
@@ -115,26 +115,3 @@ func BenchmarkSearchCardCaching(b *testing.B) {
 		}
 	})
 }
-
-// MockPopulationCache is a simple mock that implements the Cache interface
-type MockPopulationCache struct{}
-
-func (m *MockPopulationCache) Get(key string) (*PopulationData, bool) {
-	return nil, false
-}
-
-func (m *MockPopulationCache) Set(key string, data *PopulationData, ttl time.Duration) error {
-	return nil
-}
-
-func (m *MockPopulationCache) GetSet(key string) (*SetPopulationData, bool) {
-	return nil, false
-}
-
-func (m *MockPopulationCache) SetSet(key string, data *SetPopulationData, ttl time.Duration) error {
-	return nil
-}
-
-func (m *MockPopulationCache) Clear() error {
-	return nil
-}
@@ -18,6 +18,11 @@ type TargetingEngine struct {
 	historicalData  map[string]float64 // card key -> historical ROI
 	alwaysFetch     []string           // patterns to always fetch
 	neverFetch      []string           // patterns to never fetch
+
+	// Learned from past runs' top picks (see Learn)
+	minFetchPriority float64
+	learned          map[string]*learnedCard // by learnedKey
+	runs             map[string]int          // runs learned from, by normalized set name
 }
 
 // TargetingConfig holds configuration for the targeting engine
@@ -28,6 +33,7 @@ type TargetingConfig struct {
 	AlwaysFetch      []string // Card name patterns to always fetch
 	NeverFetch       []string // Card name patterns to never fetch
 	EnableHeuristics bool     // Use card name/type heuristics
+	MinFetchPriority *float64 // Lowest FetchPriority worth a lookup; nil uses 0.3, 0 fetches every card
 }
 
 // PatternMatcher contains regex patterns for identifying valuable cards
@@ -52,6 +58,10 @@ func NewTargetingEngine(config TargetingConfig) *TargetingEngine {
 		historicalData:  make(map[string]float64),
 		alwaysFetch:     config.AlwaysFetch,
 		neverFetch:      config.NeverFetch,
+
+		minFetchPriority: defaultMinFetchPriority,
+		learned:          make(map[string]*learnedCard),
+		runs:             make(map[string]int),
 	}
 
 	if config.EnableHeuristics {
@@ -65,6 +75,9 @@ func NewTargetingEngine(config TargetingConfig) *TargetingEngine {
 	if engine.minPredictedROI == 0 {
 		engine.minPredictedROI = 0.2 // 20% ROI minimum
 	}
+	if config.MinFetchPriority != nil {
+		engine.minFetchPriority = *config.MinFetchPriority
+	}
 	if len(engine.rarityFilter) == 0 {
 		engine.rarityFilter = []string{
 			"Secret Rare", "Ultra Rare", "Special Illustration Rare",
@@ -75,8 +88,15 @@ func NewTargetingEngine(config TargetingConfig) *TargetingEngine {
 	return engine
 }
 
-// ShouldFetchPopulation determines if population data should be fetched for a card
+// ShouldFetchPopulation determines if population data should be fetched for
+// a card: whether its FetchPriority reaches the minimum
 func (t *TargetingEngine) ShouldFetchPopulation(card model.Card) bool {
+	return t.FetchPriority(card).Fetch
+}
+
+// matchesHeuristics reports whether the patterns, rarity and historical ROI
+// make a card worth fetching, before anything learned from past runs
+func (t *TargetingEngine) matchesHeuristics(card model.Card) bool {
 	// Always fetch patterns
 	for _, pattern := range t.alwaysFetch {
 		if matched, _ := regexp.MatchString(pattern, card.Name); matched {
@@ -380,6 +400,8 @@ func (t *TargetingEngine) GetStats() map[string]interface{} {
 		"rarity_filters":    t.rarityFilter,
 		"always_fetch":      len(t.alwaysFetch),
 		"never_fetch":       len(t.neverFetch),
+		"learned_cards":     len(t.learned),
+		"learned_runs":      t.learnedRuns(),
 	}
 }
 
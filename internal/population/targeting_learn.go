@@ -0,0 +1,179 @@
+package population
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+const (
+	// defaultMinFetchPriority is the FetchPriority a card needs for a lookup
+	defaultMinFetchPriority = 0.3
+
+	// heuristicPriority and heuristicSkipPriority are a card's priority
+	// before it has been learned about, when the heuristics would and
+	// wouldn't fetch it
+	heuristicPriority     = 0.5
+	heuristicSkipPriority = 0.2
+
+	// missedRunsHalving is how many runs a card must miss the top picks in
+	// for its priority to halve
+	missedRunsHalving = 3.0
+)
+
+// PastPick is a card that made a run's top picks, as the rank command's
+// history records them
+type PastPick struct {
+	Set    string
+	Name   string
+	Number string
+	At     time.Time // when the run was; a run's picks share it
+	ROI    float64   // the pick's net return on its all-in cost, 0.5 = 50%
+}
+
+// FetchPriority is how worth a population lookup a card is
+type FetchPriority struct {
+	Priority float64 // 0 to 1
+	Fetch    bool    // Priority reaches the engine's minimum
+	// Reason is "always_fetch_pattern", "never_fetch_pattern",
+	// "learned_top_pick", "learned_missed", "heuristic_match" or
+	// "heuristic_skip"
+	Reason   string
+	TopPicks int     // runs of its set the card made the top picks in
+	Runs     int     // runs of its set learned from
+	ROI      float64 // the card's ROI when it last made the top picks
+}
+
+// learnedCard is what past runs say about one card
+type learnedCard struct {
+	picks int
+	last  time.Time
+	roi   float64
+}
+
+// learnedKey keys a card by set and number, which past runs record
+// reliably; the name may carry a printing, e.g. "Pikachu (Reverse Holo)"
+func learnedKey(set, number string) string {
+	return cache.BuildKey(normalizeSetName(set), censusNumber(number))
+}
+
+// Learn takes in the top picks of past runs. A run is a set's picks
+// sharing a time; a card that makes many of its set's runs, at a good ROI,
+// is prioritized, and one that keeps missing them is deprioritized. Each
+// pick also feeds UpdateHistoricalData.
+func (t *TargetingEngine) Learn(picks []PastPick) {
+	runs := make(map[string]map[time.Time]bool)
+	counted := make(map[string]bool) // card key and run, so printings count once
+	for _, p := range picks {
+		set := normalizeSetName(p.Set)
+		if runs[set] == nil {
+			runs[set] = make(map[time.Time]bool)
+		}
+		runs[set][p.At] = true
+
+		key := learnedKey(p.Set, p.Number)
+		c := t.learned[key]
+		if c == nil {
+			c = &learnedCard{}
+			t.learned[key] = c
+		}
+		if run := key + "|" + p.At.String(); !counted[run] {
+			counted[run] = true
+			c.picks++
+		}
+		if !p.At.Before(c.last) {
+			c.last, c.roi = p.At, p.ROI
+		}
+		t.UpdateHistoricalData(fmt.Sprintf("%s-%s", p.Number, p.Name), p.ROI)
+	}
+	for set, times := range runs {
+		t.runs[set] += len(times)
+	}
+}
+
+// FetchPriority rates how worth a population lookup card is. Name patterns
+// decide outright; otherwise the heuristics give a prior, which past runs
+// raise for a card that made their top picks and lower for each run it
+// missed.
+func (t *TargetingEngine) FetchPriority(card model.Card) FetchPriority {
+	fp := t.priority(card)
+	fp.Fetch = fp.Priority >= t.minFetchPriority
+	return fp
+}
+
+func (t *TargetingEngine) priority(card model.Card) FetchPriority {
+	for _, pattern := range t.alwaysFetch {
+		if matched, _ := regexp.MatchString(pattern, card.Name); matched {
+			return FetchPriority{Priority: 1, Reason: "always_fetch_pattern"}
+		}
+	}
+	for _, pattern := range t.neverFetch {
+		if matched, _ := regexp.MatchString(pattern, card.Name); matched {
+			return FetchPriority{Priority: 0, Reason: "never_fetch_pattern"}
+		}
+	}
+
+	fp := FetchPriority{Runs: t.runs[normalizeSetName(card.SetName)]}
+	if c := t.learned[learnedKey(card.SetName, card.Number)]; c != nil && fp.Runs > 0 {
+		fp.TopPicks, fp.ROI = c.picks, c.roi
+		hitRate := math.Min(float64(c.picks)/float64(fp.Runs), 1)
+		roiWeight := 1.0
+		if c.roi < t.minPredictedROI {
+			roiWeight = math.Max(c.roi, 0) / t.minPredictedROI
+		}
+		fp.Priority = heuristicPriority + (1-heuristicPriority)*hitRate*roiWeight
+		fp.Reason = "learned_top_pick"
+		return fp
+	}
+
+	prior, reason := heuristicSkipPriority, "heuristic_skip"
+	if t.matchesHeuristics(card) {
+		prior, reason = heuristicPriority, "heuristic_match"
+	}
+	fp.Priority, fp.Reason = prior, reason
+	if fp.Runs > 0 {
+		fp.Priority = prior * math.Pow(0.5, float64(fp.Runs)/missedRunsHalving)
+		fp.Reason = "learned_missed"
+	}
+	return fp
+}
+
+func (t *TargetingEngine) learnedRuns() int {
+	total := 0
+	for _, n := range t.runs {
+		total += n
+	}
+	return total
+}
+
+// TargetingSavings tallies a run's targeting: the lookups it skipped, and
+// the top picks among them that ranked without their population
+type TargetingSavings struct {
+	Cards           int      // cards targeting decided on
+	Skipped         int      // lookups, and the API calls behind them, saved
+	TopPicks        int      // cards in the run's top picks
+	SkippedTopPicks []string // top picks whose lookup was skipped
+}
+
+// SavedRate returns the share of lookups skipped
+func (s TargetingSavings) SavedRate() float64 {
+	if s.Cards == 0 {
+		return 0
+	}
+	return float64(s.Skipped) / float64(s.Cards)
+}
+
+// String summarizes the savings on one line
+func (s TargetingSavings) String() string {
+	line := fmt.Sprintf("population targeting saved %d of %d lookups (%.0f%%); %d of %d top picks were skipped",
+		s.Skipped, s.Cards, s.SavedRate()*100, len(s.SkippedTopPicks), s.TopPicks)
+	if len(s.SkippedTopPicks) > 0 {
+		line += ": " + strings.Join(s.SkippedTopPicks, ", ")
+	}
+	return line
+}
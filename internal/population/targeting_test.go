@@ -0,0 +1,70 @@
+package population
+
+import (
+	"testing"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+)
+
+func TestTargetingEngine_Learn(t *testing.T) {
+	engine := NewTargetingEngine(TargetingConfig{EnableHeuristics: true})
+	charizard := model.Card{SetName: "Base Set", Name: "Charizard", Number: "4"}
+	pikachu := model.Card{SetName: "Base Set", Name: "Pikachu", Number: "58"}
+	weedle := model.Card{SetName: "Base Set", Name: "Weedle", Number: "69"}
+
+	// Before any run, the heuristics decide
+	if fp := engine.FetchPriority(charizard); !fp.Fetch || fp.Reason != "heuristic_match" {
+		t.Errorf("expected a chase card fetched on heuristics, got %+v", fp)
+	}
+	if fp := engine.FetchPriority(weedle); fp.Fetch || fp.Reason != "heuristic_skip" {
+		t.Errorf("expected a plain common skipped on heuristics, got %+v", fp)
+	}
+
+	// Weedle made the top picks in all three runs, Charizard in one at a
+	// poor ROI, and Pikachu in none
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	var picks []PastPick
+	for i := range 3 {
+		at := start.AddDate(0, 0, 7*i)
+		picks = append(picks, PastPick{Set: "Base Set", Name: "Weedle", Number: "069", At: at, ROI: 1.5})
+		// A second printing of the same card in the same run counts once
+		picks = append(picks, PastPick{Set: "base set", Name: "Weedle (Reverse Holo)", Number: "69", At: at, ROI: 1.5})
+	}
+	picks = append(picks, PastPick{Set: "Base Set", Name: "Charizard", Number: "4", At: start, ROI: 0.1})
+	engine.Learn(picks)
+
+	fp := engine.FetchPriority(weedle)
+	if !fp.Fetch || fp.Reason != "learned_top_pick" || fp.TopPicks != 3 || fp.Runs != 3 || fp.Priority != 1 {
+		t.Errorf("expected a card in every top picks fetched first, got %+v", fp)
+	}
+	fp = engine.FetchPriority(charizard)
+	if want := 0.5 + 0.5*(1.0/3)*(0.1/0.2); !fp.Fetch || abs(fp.Priority-want) > 1e-9 {
+		t.Errorf("expected priority %.3f for an occasional low-ROI pick, got %+v", want, fp)
+	}
+	fp = engine.FetchPriority(pikachu)
+	if fp.Fetch || fp.Reason != "learned_missed" || abs(fp.Priority-0.25) > 1e-9 {
+		t.Errorf("expected a card missing three runs halved below the cut, got %+v", fp)
+	}
+
+	// Other sets aren't affected, and name patterns still decide outright
+	if fp := engine.FetchPriority(model.Card{SetName: "Fossil", Name: "Zapdos", Number: "15"}); fp.Runs != 0 || fp.Reason != "heuristic_skip" {
+		t.Errorf("expected a set without runs left to the heuristics, got %+v", fp)
+	}
+	strict := NewTargetingEngine(TargetingConfig{NeverFetch: []string{"^Weedle$"}})
+	strict.Learn(picks)
+	if strict.ShouldFetchPopulation(weedle) {
+		t.Error("expected a never-fetch pattern to win over learned picks")
+	}
+}
+
+func TestTargetingSavings(t *testing.T) {
+	s := TargetingSavings{Cards: 200, Skipped: 120, TopPicks: 10, SkippedTopPicks: []string{"Weedle #69"}}
+	if s.SavedRate() != 0.6 {
+		t.Errorf("SavedRate = %v, want 0.6", s.SavedRate())
+	}
+	want := "population targeting saved 120 of 200 lookups (60%); 1 of 10 top picks were skipped: Weedle #69"
+	if s.String() != want {
+		t.Errorf("got %q, want %q", s.String(), want)
+	}
+}
@@ -0,0 +1,242 @@
+// Package portfolio records the cards we actually own: what we paid and
+// where, the grading submission, the grade that came back and the sale. The
+// store is a single JSON file, and positions are marked to market against
+// current PriceCharting prices.
+package portfolio
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatVersion is the store file version this package reads and writes
+const FormatVersion = 1
+
+// Status is where a holding is in the buy, grade, sell cycle
+type Status string
+
+const (
+	StatusRaw       Status = "raw"       // bought, not submitted
+	StatusSubmitted Status = "submitted" // at the grader
+	StatusGraded    Status = "graded"    // back in a slab
+	StatusSold      Status = "sold"
+)
+
+// Purchase is how a card was acquired
+type Purchase struct {
+	Date    time.Time `json:"date"`
+	CostUSD float64   `json:"costUSD"`
+	Source  string    `json:"source,omitempty"` // e.g. "eBay", "LCS"
+}
+
+// Submission is a card's trip to a grading company
+type Submission struct {
+	Grader       string    `json:"grader"`
+	Tier         string    `json:"tier,omitempty"`
+	SubmissionID string    `json:"submissionID,omitempty"`
+	FeeUSD       float64   `json:"feeUSD"`
+	ShippingUSD  float64   `json:"shippingUSD,omitempty"` // this card's share
+	Submitted    time.Time `json:"submitted"`
+	Returned     time.Time `json:"returned,omitzero"`
+	Grade        string    `json:"grade,omitempty"` // e.g. "PSA 10", "BGS 9.5"
+}
+
+// Sale is how a card left the portfolio
+type Sale struct {
+	Date     time.Time `json:"date"`
+	PriceUSD float64   `json:"priceUSD"`
+	FeesUSD  float64   `json:"feesUSD,omitempty"` // marketplace and payment fees
+	Venue    string    `json:"venue,omitempty"`
+}
+
+// Holding is one physical card
+type Holding struct {
+	ID         int         `json:"id"`
+	SetName    string      `json:"setName"`
+	CardName   string      `json:"cardName"`
+	Number     string      `json:"number"`
+	Purchase   Purchase    `json:"purchase"`
+	Submission *Submission `json:"submission,omitempty"`
+	Sale       *Sale       `json:"sale,omitempty"`
+}
+
+// Status derives the holding's status from what has been recorded
+func (h Holding) Status() Status {
+	switch {
+	case h.Sale != nil:
+		return StatusSold
+	case h.Submission == nil:
+		return StatusRaw
+	case h.Submission.Grade == "":
+		return StatusSubmitted
+	default:
+		return StatusGraded
+	}
+}
+
+// Grade returns the grade the card came back with, or ""
+func (h Holding) Grade() string {
+	if h.Submission == nil {
+		return ""
+	}
+	return h.Submission.Grade
+}
+
+// CostBasis is the purchase price plus grading fees and shipping
+func (h Holding) CostBasis() float64 {
+	basis := h.Purchase.CostUSD
+	if h.Submission != nil {
+		basis += h.Submission.FeeUSD + h.Submission.ShippingUSD
+	}
+	return basis
+}
+
+// Store is the portfolio file
+type Store struct {
+	Version  int       `json:"version"`
+	Holdings []Holding `json:"holdings"`
+
+	path string
+}
+
+// Open loads the store at path. A missing file is an empty portfolio.
+func Open(path string) (*Store, error) {
+	s := &Store{Version: FormatVersion, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read portfolio: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parse portfolio %s: %w", path, err)
+	}
+	if s.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported portfolio version %d (want %d)", s.Version, FormatVersion)
+	}
+	return s, nil
+}
+
+// Save writes the store back to its file, replacing it atomically
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal portfolio: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("create portfolio dir: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write portfolio: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("write portfolio: %w", err)
+	}
+	return nil
+}
+
+// Add records a purchase and returns the new holding with its ID
+func (s *Store) Add(h Holding) (Holding, error) {
+	if h.CardName == "" || h.SetName == "" {
+		return Holding{}, fmt.Errorf("a holding needs a set and card name")
+	}
+	if h.Purchase.CostUSD < 0 {
+		return Holding{}, fmt.Errorf("purchase cost cannot be negative")
+	}
+	h.ID = 1
+	for _, existing := range s.Holdings {
+		h.ID = max(h.ID, existing.ID+1)
+	}
+	h.Submission, h.Sale = nil, nil
+	s.Holdings = append(s.Holdings, h)
+	return h, nil
+}
+
+// Get returns the holding with the given ID
+func (s *Store) Get(id int) (*Holding, error) {
+	for i := range s.Holdings {
+		if s.Holdings[i].ID == id {
+			return &s.Holdings[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no holding with id %d", id)
+}
+
+// Submit records sending a raw card to a grader
+func (s *Store) Submit(id int, sub Submission) error {
+	h, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if st := h.Status(); st != StatusRaw {
+		return fmt.Errorf("holding %d is %s; only raw cards can be submitted", id, st)
+	}
+	if sub.Grader == "" {
+		return fmt.Errorf("submission needs a grader")
+	}
+	sub.Returned, sub.Grade = time.Time{}, ""
+	h.Submission = &sub
+	return nil
+}
+
+// Return records the grade a submitted card came back with
+func (s *Store) Return(id int, grade string, date time.Time) error {
+	h, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	if st := h.Status(); st != StatusSubmitted {
+		return fmt.Errorf("holding %d is %s; only submitted cards can be returned", id, st)
+	}
+	grader, _, err := ParseGrade(grade)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(grader, h.Submission.Grader) {
+		return fmt.Errorf("holding %d was submitted to %s, not %s", id, h.Submission.Grader, grader)
+	}
+	h.Submission.Grade = grade
+	h.Submission.Returned = date
+	return nil
+}
+
+// Sell records a sale. Cards still at the grader can't be sold.
+func (s *Store) Sell(id int, sale Sale) error {
+	h, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+	switch h.Status() {
+	case StatusSold:
+		return fmt.Errorf("holding %d is already sold", id)
+	case StatusSubmitted:
+		return fmt.Errorf("holding %d is still at %s", id, h.Submission.Grader)
+	}
+	if sale.PriceUSD < 0 || sale.FeesUSD < 0 {
+		return fmt.Errorf("sale price and fees cannot be negative")
+	}
+	h.Sale = &sale
+	return nil
+}
+
+// ParseGrade splits a grade such as "PSA 10" or "BGS 9.5" into the grader
+// and the numeric grade
+func ParseGrade(grade string) (string, float64, error) {
+	fields := strings.Fields(grade)
+	if len(fields) != 2 {
+		return "", 0, fmt.Errorf("grade %q: want GRADER NUMBER, e.g. \"PSA 10\"", grade)
+	}
+	n, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil || n < 1 || n > 10 {
+		return "", 0, fmt.Errorf("grade %q: want a number from 1 to 10", grade)
+	}
+	return strings.ToUpper(fields[0]), n, nil
+}
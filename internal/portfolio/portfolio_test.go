@@ -0,0 +1,104 @@
+package portfolio
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func day(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestStore_Lifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "portfolio", "cards.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("opening a missing file should give an empty portfolio: %v", err)
+	}
+
+	h, err := s.Add(Holding{SetName: "Evolving Skies", CardName: "Umbreon VMAX", Number: "215",
+		Purchase: Purchase{Date: day("2024-03-01"), CostUSD: 300, Source: "eBay"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.ID != 1 || h.Status() != StatusRaw {
+		t.Fatalf("expected raw holding 1, got %d %s", h.ID, h.Status())
+	}
+
+	if err := s.Return(h.ID, "PSA 10", day("2024-05-01")); err == nil {
+		t.Error("expected error returning a card that was never submitted")
+	}
+	if err := s.Submit(h.ID, Submission{Grader: "PSA", Tier: "Value Plus", SubmissionID: "123456", FeeUSD: 25, ShippingUSD: 2, Submitted: day("2024-03-05")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Sell(h.ID, Sale{PriceUSD: 500}); err == nil {
+		t.Error("expected error selling a card still at the grader")
+	}
+	if err := s.Return(h.ID, "BGS 9.5", day("2024-05-01")); err == nil {
+		t.Error("expected error returning a grade from another grader")
+	}
+	if err := s.Return(h.ID, "PSA 10", day("2024-05-01")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	s, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status() != StatusGraded || got.Grade() != "PSA 10" || got.CostBasis() != 327 {
+		t.Errorf("unexpected holding after reload: %s %q basis %.2f", got.Status(), got.Grade(), got.CostBasis())
+	}
+
+	if err := s.Sell(1, Sale{Date: day("2024-06-01"), PriceUSD: 650, FeesUSD: 80}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Sell(1, Sale{PriceUSD: 700}); err == nil {
+		t.Error("expected error selling twice")
+	}
+
+	next, _ := s.Add(Holding{SetName: "Base", CardName: "Charizard", Number: "4"})
+	if next.ID != 2 {
+		t.Errorf("expected the next ID to be 2, got %d", next.ID)
+	}
+}
+
+func TestOpen_RejectsUnknownVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "p.json")
+	if err := os.WriteFile(path, []byte(`{"version": 9, "holdings": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("expected version error, got %v", err)
+	}
+}
+
+func TestParseGrade(t *testing.T) {
+	tests := []struct {
+		in     string
+		grader string
+		n      float64
+		ok     bool
+	}{
+		{"PSA 10", "PSA", 10, true},
+		{"bgs 9.5", "BGS", 9.5, true},
+		{"PSA10", "", 0, false},
+		{"CGC 11", "", 0, false},
+	}
+	for _, tt := range tests {
+		grader, n, err := ParseGrade(tt.in)
+		if (err == nil) != tt.ok || grader != tt.grader || n != tt.n {
+			t.Errorf("ParseGrade(%q) = %s %v %v", tt.in, grader, n, err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package portfolio
+
+import (
+	"fmt"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/prices"
+	"github.com/guarzo/pkmgradegap/internal/report"
+)
+
+// PriceSource looks up current prices for a card; *prices.PriceCharting
+// satisfies it
+type PriceSource interface {
+	LookupCard(setName string, c model.Card) (*prices.PCMatch, error)
+}
+
+// Position is a holding valued at current prices
+type Position struct {
+	Holding
+	CostBasisUSD  float64
+	MarketUSD     float64 // current price for the card's state; 0 when sold or unpriced
+	RealizedUSD   float64 // sold: proceeds after fees less cost basis
+	UnrealizedUSD float64 // held and priced: market value less cost basis
+	Note          string  // why a held card has no market value
+}
+
+// Summary totals a set of positions
+type Summary struct {
+	CostBasisUSD  float64
+	MarketUSD     float64
+	RealizedUSD   float64
+	UnrealizedUSD float64
+	Unpriced      int // held cards without a market price
+}
+
+// Mark values every holding. Sold cards realise their sale; held cards are
+// marked at the PriceCharting price for their state: ungraded for raw and
+// submitted cards, the matching grade for slabs. Market values are before
+// selling fees. A failed lookup leaves the position unpriced with a note
+// rather than failing the whole portfolio.
+func Mark(holdings []Holding, src PriceSource) []Position {
+	positions := make([]Position, len(holdings))
+	for i, h := range holdings {
+		p := Position{Holding: h, CostBasisUSD: h.CostBasis()}
+		if h.Sale != nil {
+			p.RealizedUSD = h.Sale.PriceUSD - h.Sale.FeesUSD - p.CostBasisUSD
+			positions[i] = p
+			continue
+		}
+
+		match, err := src.LookupCard(h.SetName, model.Card{Name: h.CardName, Number: h.Number})
+		switch {
+		case err != nil:
+			p.Note = fmt.Sprintf("price lookup failed: %v", err)
+		case match == nil:
+			p.Note = "not found on PriceCharting"
+		default:
+			p.MarketUSD = marketPrice(match, h.Grade())
+			if p.MarketUSD > 0 {
+				p.UnrealizedUSD = p.MarketUSD - p.CostBasisUSD
+			} else {
+				p.Note = "no price for " + describe(h)
+			}
+		}
+		positions[i] = p
+	}
+	return positions
+}
+
+// marketPrice picks the PriceCharting price for a grade; an empty grade is
+// the ungraded price
+func marketPrice(m *prices.PCMatch, grade string) float64 {
+	cents := m.LooseCents
+	if grade != "" {
+		cents = gradeCents(m, grade)
+	}
+	return float64(cents) / 100.0
+}
+
+// gradeCents maps a grade onto PriceCharting's columns. Tens are priced per
+// grader; lower grades share one column across graders, and anything below 8
+// sells near ungraded.
+func gradeCents(m *prices.PCMatch, grade string) int {
+	grader, n, err := ParseGrade(grade)
+	if err != nil {
+		return 0
+	}
+	switch {
+	case n == 10:
+		switch grader {
+		case "PSA":
+			return m.PSA10Cents
+		case "BGS":
+			return m.BGS10Cents
+		case "CGC":
+			return m.CGC10Cents
+		case "SGC":
+			return m.SGC10Cents
+		}
+		return 0
+	case n >= 9.5:
+		return m.Grade95Cents
+	case n >= 9:
+		return m.Grade9Cents
+	case n >= 8:
+		return m.NewPriceCents
+	default:
+		return m.LooseCents
+	}
+}
+
+func describe(h Holding) string {
+	if g := h.Grade(); g != "" {
+		return g
+	}
+	return "ungraded copy"
+}
+
+// Summarize totals positions
+func Summarize(positions []Position) Summary {
+	var s Summary
+	for _, p := range positions {
+		s.RealizedUSD += p.RealizedUSD
+		if p.Sale != nil {
+			continue
+		}
+		s.CostBasisUSD += p.CostBasisUSD
+		s.MarketUSD += p.MarketUSD
+		s.UnrealizedUSD += p.UnrealizedUSD
+		if p.MarketUSD <= 0 {
+			s.Unpriced++
+		}
+	}
+	return s
+}
+
+// ListTable lays out holdings as recorded, without prices
+func ListTable(holdings []Holding) *report.Table {
+	t := &report.Table{Columns: []report.Column{
+		{Name: "ID", Kind: report.Integer},
+		{Name: "Card"},
+		{Name: "No"},
+		{Name: "Set"},
+		{Name: "Status"},
+		{Name: "Bought"},
+		{Name: "Source"},
+		{Name: "Grader"},
+		{Name: "Tier"},
+		{Name: "SubmissionID"},
+		{Name: "Grade"},
+		{Name: "CostBasisUSD", Kind: report.Money},
+		{Name: "SoldUSD", Kind: report.Money},
+	}}
+	if len(holdings) == 0 {
+		t.Notice = "Portfolio is empty"
+	}
+	for _, h := range holdings {
+		var grader, tier, subID string
+		if h.Submission != nil {
+			grader, tier, subID = h.Submission.Grader, h.Submission.Tier, h.Submission.SubmissionID
+		}
+		var sold any
+		if h.Sale != nil {
+			sold = h.Sale.PriceUSD
+		}
+		t.AddRow(h.ID, h.CardName, h.Number, h.SetName, string(h.Status()), h.Purchase.Date.Format("2006-01-02"),
+			h.Purchase.Source, grader, tier, subID, h.Grade(), h.CostBasis(), sold)
+	}
+	return t
+}
+
+// MarkTable lays out marked positions with their profit and loss
+func MarkTable(positions []Position) *report.Table {
+	t := &report.Table{Columns: []report.Column{
+		{Name: "ID", Kind: report.Integer},
+		{Name: "Card"},
+		{Name: "No"},
+		{Name: "Set"},
+		{Name: "Status"},
+		{Name: "Grade"},
+		{Name: "CostBasisUSD", Kind: report.Money},
+		{Name: "MarketUSD", Kind: report.Money},
+		{Name: "UnrealizedUSD", Kind: report.Number, Precision: 2},
+		{Name: "RealizedUSD", Kind: report.Number, Precision: 2},
+		{Name: "Notes"},
+	}}
+	if len(positions) == 0 {
+		t.Notice = "Portfolio is empty"
+	}
+	for _, p := range positions {
+		var unrealized, realized any
+		switch {
+		case p.Sale != nil:
+			realized = p.RealizedUSD
+		case p.MarketUSD > 0:
+			unrealized = p.UnrealizedUSD
+		}
+		t.AddRow(p.ID, p.CardName, p.Number, p.SetName, string(p.Status()), p.Grade(),
+			p.CostBasisUSD, p.MarketUSD, unrealized, realized, p.Note)
+	}
+	return t
+}
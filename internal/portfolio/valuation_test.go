@@ -0,0 +1,76 @@
+package portfolio
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/prices"
+)
+
+// stubPrices returns fixed matches keyed by card name
+type stubPrices map[string]*prices.PCMatch
+
+func (s stubPrices) LookupCard(setName string, c model.Card) (*prices.PCMatch, error) {
+	if c.Name == "Broken" {
+		return nil, errors.New("timeout")
+	}
+	return s[c.Name], nil
+}
+
+func TestMark(t *testing.T) {
+	src := stubPrices{
+		"Raw":    {LooseCents: 4000},
+		"Slab":   {LooseCents: 4000, PSA10Cents: 25000, Grade95Cents: 12000},
+		"Nine":   {LooseCents: 4000, Grade95Cents: 9000},
+		"AtPSA":  {LooseCents: 3000, PSA10Cents: 20000},
+		"Broken": nil,
+	}
+	holdings := []Holding{
+		{ID: 1, CardName: "Raw", Purchase: Purchase{CostUSD: 30}},
+		{ID: 2, CardName: "Slab", Purchase: Purchase{CostUSD: 50}, Submission: &Submission{Grader: "PSA", FeeUSD: 25, Grade: "PSA 10"}},
+		{ID: 3, CardName: "Nine", Purchase: Purchase{CostUSD: 50}, Submission: &Submission{Grader: "CGC", FeeUSD: 15, Grade: "CGC 9.5"}},
+		{ID: 4, CardName: "AtPSA", Purchase: Purchase{CostUSD: 40}, Submission: &Submission{Grader: "PSA", FeeUSD: 19}},
+		{ID: 5, CardName: "Sold", Purchase: Purchase{CostUSD: 20}, Sale: &Sale{PriceUSD: 60, FeesUSD: 8}},
+		{ID: 6, CardName: "Broken", Purchase: Purchase{CostUSD: 10}},
+		{ID: 7, CardName: "Missing", Purchase: Purchase{CostUSD: 10}},
+	}
+
+	positions := Mark(holdings, src)
+	want := []struct {
+		market, unrealized, realized float64
+	}{
+		{40, 10, 0},
+		{250, 175, 0},
+		{90, 25, 0},
+		{30, -29, 0}, // still ungraded while at PSA
+		{0, 0, 32},
+		{0, 0, 0},
+		{0, 0, 0},
+	}
+	for i, w := range want {
+		p := positions[i]
+		if p.MarketUSD != w.market || p.UnrealizedUSD != w.unrealized || p.RealizedUSD != w.realized {
+			t.Errorf("holding %d: market %.2f unrealized %.2f realized %.2f, want %+v", p.ID, p.MarketUSD, p.UnrealizedUSD, p.RealizedUSD, w)
+		}
+	}
+	if positions[5].Note == "" || positions[6].Note == "" {
+		t.Error("expected notes on unpriced holdings")
+	}
+
+	sum := Summarize(positions)
+	if sum.RealizedUSD != 32 || sum.UnrealizedUSD != 181 || sum.Unpriced != 2 {
+		t.Errorf("unexpected summary %+v", sum)
+	}
+	if sum.CostBasisUSD != 30+75+65+59+10+10 {
+		t.Errorf("expected cost basis of held cards only, got %.2f", sum.CostBasisUSD)
+	}
+
+	tbl := MarkTable(positions)
+	if len(tbl.Rows) != len(holdings) {
+		t.Fatalf("expected a row per holding, got %d", len(tbl.Rows))
+	}
+	if rec := tbl.Records()[4]; rec[8] != "-29.00" {
+		t.Errorf("expected a negative unrealized P&L to render, got %q", rec[8])
+	}
+}
@@ -13,7 +13,7 @@ type MarketplaceEnricher struct {
 }
 
 // NewMarketplaceEnricher creates a new marketplace enricher
-func NewMarketplaceEnricher(apiKey string, c *cache.Cache) *MarketplaceEnricher {
+func NewMarketplaceEnricher(apiKey string, c cache.Store) *MarketplaceEnricher {
 	provider := marketplace.NewPriceChartingMarketplace(apiKey, c)
 
 	if provider == nil || !provider.Available() {
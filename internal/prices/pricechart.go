@@ -18,8 +18,8 @@ import (
 
 type PriceCharting struct {
 	token          string
-	cache          *cache.Cache
-	multiCache     *cache.MultiLayerCache
+	cache          cache.Store
+	multiCache     *cache.MultiLayerCache // optional hot layer in front of cache
 	queryDedup     *QueryDeduplicator
 	batchSize      int
 	workerPool     int
@@ -34,9 +34,12 @@ type PriceCharting struct {
 
 	// Sprint 5: Historical Analysis Configuration
 	enableHistoricalEnrichment bool
+
+	// Stale cache entries queued for a background refresh
+	revalidation *revalidator
 }
 
-func NewPriceCharting(token string, c *cache.Cache) *PriceCharting {
+func NewPriceCharting(token string, c cache.Store) *PriceCharting {
 	pc := &PriceCharting{
 		token:       token,
 		cache:       c,
@@ -45,23 +48,9 @@ func NewPriceCharting(token string, c *cache.Cache) *PriceCharting {
 		rateLimiter: time.NewTicker(100 * time.Millisecond), // 10 requests per second
 	}
 
-	// Initialize advanced caching if enabled
-	if c != nil {
-		multiCacheConfig := cache.CacheConfig{
-			L1MaxSize:     2000,              // Hot cache for 2000 items
-			L1TTL:         30 * time.Minute,  // Short TTL for volatile prices
-			L2MaxSize:     100 * 1024 * 1024, // 100MB disk cache
-			L2TTL:         24 * time.Hour,    // Longer TTL for stable data
-			L2Path:        "./data/cache/pricecharting",
-			EnablePredict: true,
-			CompressL2:    true,
-		}
-		multiCache, _ := cache.NewMultiLayerCache(multiCacheConfig)
-		pc.multiCache = multiCache
-	}
-
 	// Initialize query deduplicator
 	pc.queryDedup = NewQueryDeduplicator()
+	pc.revalidation = newRevalidator()
 
 	// Initialize marketplace enricher (Sprint 3)
 	if token != "" && token != "test" && token != "mock" {
@@ -114,9 +103,11 @@ type PCMatch struct {
 	Grade95Cents int // "box-only-price" (Grade 9.5)
 	PSA10Cents   int // "manual-only-price" (PSA 10)
 	BGS10Cents   int // "bgs-10-price" (BGS 10)
+	CGC10Cents   int // "condition-17-price" (CGC 10)
+	SGC10Cents   int // "condition-18-price" (SGC 10)
 
 	// New price fields from Sprint 1
-	NewPriceCents    int // "new-price" (Sealed product price)
+	NewPriceCents    int // "new-price" (Sealed product price; Grade 8 for trading cards)
 	CIBPriceCents    int // "cib-price" (Complete In Box)
 	ManualPriceCents int // "manual-price" (Manual only - separate field)
 	BoxPriceCents    int // "box-price" (Box only - separate field)
@@ -166,6 +157,10 @@ type PCMatch struct {
 
 	// Sprint 6 - UI specific
 	SparklineData []int // Simplified data for inline charts
+
+	// Stale is set when the match came from a cache entry past its TTL; a
+	// fresh one is being fetched in the background
+	Stale bool `json:"-"`
 }
 
 // SaleData represents a single sale tracked by PriceCharting
@@ -221,7 +216,16 @@ type PredictionModel struct {
 }
 
 func (p *PriceCharting) LookupCard(setName string, c model.Card) (*PCMatch, error) {
+	return p.LookupCardVariant(setName, c, "")
+}
+
+// LookupCardVariant looks up one printing of a card, such as "reverse holo"
+// or "1st edition". An empty variant finds the card's base product.
+func (p *PriceCharting) LookupCardVariant(setName string, c model.Card, variant string) (*PCMatch, error) {
 	key := cache.PriceChartingKey(setName, c.Name, c.Number)
+	if variant != "" {
+		key = cache.BuildKey("pc", setName, c.Name, c.Number, strings.ToLower(variant))
+	}
 
 	// Try multi-layer cache first
 	if p.multiCache != nil {
@@ -233,11 +237,19 @@ func (p *PriceCharting) LookupCard(setName string, c model.Card) (*PCMatch, erro
 		}
 	}
 
-	// Fallback to regular cache
+	// Fallback to regular cache, which serves an expired match while its
+	// namespace's stale policy allows and a fresh one is fetched
 	if p.cache != nil {
 		var match PCMatch
-		if found, _ := p.cache.Get(key, &match); found {
+		if found, stale, _ := p.cache.GetStale(key, &match); found {
 			p.incrementCachedRequests()
+			if stale {
+				p.revalidation.enqueue(key, func() {
+					_, _ = p.fetchCardVariant(key, setName, c, variant)
+				})
+				match.Stale = true
+				return &match, nil
+			}
 			// Promote to multi-layer cache if available
 			if p.multiCache != nil {
 				p.multiCache.Put(key, &match, cache.CachePriority{
@@ -250,8 +262,14 @@ func (p *PriceCharting) LookupCard(setName string, c model.Card) (*PCMatch, erro
 		}
 	}
 
-	// Sprint 4: Try UPC lookup first if available
-	if p.upcDatabase != nil {
+	return p.fetchCardVariant(key, setName, c, variant)
+}
+
+// fetchCardVariant looks a printing up live and caches the match under key
+func (p *PriceCharting) fetchCardVariant(key, setName string, c model.Card, variant string) (*PCMatch, error) {
+	// Sprint 4: Try UPC lookup first if available. UPCs identify a card,
+	// not one of its printings.
+	if p.upcDatabase != nil && variant == "" {
 		// Check if card has UPC information
 		upcMappings := p.upcDatabase.FindByCardInfo(setName, c.Number)
 		if len(upcMappings) > 0 {
@@ -270,12 +288,14 @@ func (p *PriceCharting) LookupCard(setName string, c model.Card) (*PCMatch, erro
 	}
 
 	// Build optimized query with advanced options
-	options := QueryOptions{}
-	// Check for variant information in card name
-	if strings.Contains(strings.ToLower(c.Name), "1st edition") {
-		options.Variant = "1st Edition"
-	} else if strings.Contains(strings.ToLower(c.Name), "shadowless") {
-		options.Variant = "Shadowless"
+	options := QueryOptions{Variant: variant}
+	// Without a named printing, check for variant information in card name
+	if options.Variant == "" {
+		if strings.Contains(strings.ToLower(c.Name), "1st edition") {
+			options.Variant = "1st Edition"
+		} else if strings.Contains(strings.ToLower(c.Name), "shadowless") {
+			options.Variant = "Shadowless"
+		}
 	}
 
 	q := p.BuildAdvancedQuery(setName, c.Name, c.Number, options)
@@ -322,7 +342,7 @@ func (p *PriceCharting) LookupCard(setName string, c model.Card) (*PCMatch, erro
 
 		// Store in regular cache
 		if p.cache != nil {
-			_ = p.cache.Put(key, match, 2*time.Hour)
+			_ = p.cache.Put(key, match, p.calculateCachePriority(match).TTL)
 		}
 
 		// Store in deduplicator
@@ -540,6 +560,8 @@ func pcFrom(m map[string]any) *PCMatch {
 		Grade95Cents: get("box-only-price"),
 		PSA10Cents:   get("manual-only-price"),
 		BGS10Cents:   get("bgs-10-price"),
+		CGC10Cents:   get("condition-17-price"),
+		SGC10Cents:   get("condition-18-price"),
 
 		// New price fields
 		NewPriceCents:    get("new-price"),
@@ -774,6 +796,7 @@ func (p *PriceCharting) GetStats() map[string]interface{} {
 	defer p.mu.RUnlock()
 
 	totalRequests := p.requestCount + p.cachedRequests
+	pending, refreshed := p.revalidation.counts()
 	cacheHitRate := float64(0)
 	if totalRequests > 0 {
 		cacheHitRate = float64(p.cachedRequests) / float64(totalRequests) * 100
@@ -785,9 +808,25 @@ func (p *PriceCharting) GetStats() map[string]interface{} {
 		"total_requests":  totalRequests,
 		"cache_hit_rate":  fmt.Sprintf("%.2f%%", cacheHitRate),
 		"reduction":       fmt.Sprintf("%.2f%%", float64(p.cachedRequests)/float64(totalRequests)*100),
+		"stale_pending":   pending,
+		"stale_refreshed": refreshed,
 	}
 }
 
+// StopRevalidation drops the background refreshes of stale matches still
+// queued and waits for the one in progress. Call it before closing the
+// cache; later stale matches are served without a refresh.
+func (p *PriceCharting) StopRevalidation() {
+	p.revalidation.close()
+}
+
+// FinishRevalidation refreshes the stale matches still queued, waiting up to
+// timeout, then stops as StopRevalidation does. A one-shot run calls it
+// before closing the cache so the refreshes it queued are saved.
+func (p *PriceCharting) FinishRevalidation(timeout time.Duration) {
+	p.revalidation.drain(timeout)
+}
+
 // QueryDeduplicator prevents duplicate queries within a batch
 type QueryDeduplicator struct {
 	cache map[string]*PCMatch
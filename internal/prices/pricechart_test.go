@@ -345,13 +345,15 @@ func TestPcFrom(t *testing.T) {
 		{
 			name: "complete data with all new fields",
 			data: map[string]interface{}{
-				"id":                "12345",
-				"product-name":      "Pokemon Card",
-				"loose-price":       850,
-				"graded-price":      1500,
-				"box-only-price":    1800,
-				"manual-only-price": 2500,
-				"bgs-10-price":      3000,
+				"id":                 "12345",
+				"product-name":       "Pokemon Card",
+				"loose-price":        850,
+				"graded-price":       1500,
+				"box-only-price":     1800,
+				"manual-only-price":  2500,
+				"bgs-10-price":       3000,
+				"condition-17-price": 2200,
+				"condition-18-price": 2100,
 				// New price fields
 				"new-price":    1200,
 				"cib-price":    950,
@@ -372,6 +374,8 @@ func TestPcFrom(t *testing.T) {
 				Grade95Cents: 1800,
 				PSA10Cents:   2500,
 				BGS10Cents:   3000,
+				CGC10Cents:   2200,
+				SGC10Cents:   2100,
 				// New fields
 				NewPriceCents:    1200,
 				CIBPriceCents:    950,
@@ -545,6 +549,12 @@ func TestPcFrom(t *testing.T) {
 			if result.BGS10Cents != tt.expected.BGS10Cents {
 				t.Errorf("BGS10Cents: expected %d, got %d", tt.expected.BGS10Cents, result.BGS10Cents)
 			}
+			if result.CGC10Cents != tt.expected.CGC10Cents {
+				t.Errorf("CGC10Cents: expected %d, got %d", tt.expected.CGC10Cents, result.CGC10Cents)
+			}
+			if result.SGC10Cents != tt.expected.SGC10Cents {
+				t.Errorf("SGC10Cents: expected %d, got %d", tt.expected.SGC10Cents, result.SGC10Cents)
+			}
 
 			// New price fields
 			if result.NewPriceCents != tt.expected.NewPriceCents {
@@ -851,6 +861,57 @@ func TestPriceCharting_LookupCard_ComprehensiveAPI(t *testing.T) {
 }
 
 // mockPriceChartingTransport replaces PriceCharting API calls with test server calls
+func TestPriceCharting_LookupCardVariant(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		query := r.URL.Query().Get("q")
+		queries = append(queries, query)
+		if strings.Contains(query, "reverse holo") {
+			w.Write([]byte(`{"status": "success", "id": "rev", "product-name": "Pikachu [Reverse Holo] #25", "manual-only-price": 4000}`))
+			return
+		}
+		w.Write([]byte(`{"status": "success", "id": "base", "product-name": "Pikachu #25", "manual-only-price": 2500}`))
+	}))
+	defer server.Close()
+
+	originalTransport := http.DefaultTransport
+	defer func() { http.DefaultTransport = originalTransport }()
+	http.DefaultTransport = &mockPriceChartingTransport{testServerURL: server.URL, original: originalTransport}
+
+	// The multi-layer cache writes under ./data
+	t.Chdir(t.TempDir())
+	testCache, err := cache.New("cache.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pc := NewPriceCharting(testutil.GetTestPriceChartingToken(), testCache)
+	card := model.Card{Name: "Pikachu", Number: "25"}
+
+	base, err := pc.LookupCard("Base Set", card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverse, err := pc.LookupCardVariant("Base Set", card, "reverse holo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.ID != "base" || reverse.ID != "rev" || reverse.PSA10Cents != 4000 {
+		t.Errorf("expected separate products per printing, got %s and %s", base.ID, reverse.ID)
+	}
+	if !strings.Contains(reverse.QueryUsed, "reverse holo") {
+		t.Errorf("expected the variant in the query, got %q", reverse.QueryUsed)
+	}
+
+	// Each printing is cached under its own key
+	if again, _ := pc.LookupCardVariant("Base Set", card, "reverse holo"); again == nil || again.ID != "rev" {
+		t.Errorf("expected the cached reverse holo, got %+v", again)
+	}
+	if len(queries) != 2 {
+		t.Errorf("expected one request per printing, got %v", queries)
+	}
+}
+
 type mockPriceChartingTransport struct {
 	testServerURL string
 	original      http.RoundTripper
@@ -0,0 +1,128 @@
+package prices
+
+import (
+	"sync"
+	"time"
+)
+
+// maxQueuedRevalidations bounds the stale entries waiting for a refresh;
+// past it new ones are skipped and refreshed when next served stale
+const maxQueuedRevalidations = 1000
+
+// revalidator refreshes stale cache entries one at a time in the
+// background, so a scan served from stale entries isn't held up by them.
+// Each refresh is a live lookup and so waits its turn at the rate limiter.
+type revalidator struct {
+	mu       sync.Mutex
+	queued   map[string]bool
+	queue    chan revalidation
+	stop     chan struct{}
+	running  bool
+	stopped  bool
+	wg       sync.WaitGroup
+	finished int64
+	emptied  chan struct{} // closed when the queue runs dry, while drain waits
+}
+
+type revalidation struct {
+	key     string
+	refresh func()
+}
+
+func newRevalidator() *revalidator {
+	return &revalidator{
+		queued: make(map[string]bool),
+		queue:  make(chan revalidation, maxQueuedRevalidations),
+		stop:   make(chan struct{}),
+	}
+}
+
+// enqueue schedules refresh for key unless it is already waiting
+func (r *revalidator) enqueue(key string, refresh func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.stopped || r.queued[key] {
+		return
+	}
+	select {
+	case r.queue <- revalidation{key: key, refresh: refresh}:
+		r.queued[key] = true
+	default:
+		return
+	}
+	if !r.running {
+		r.running = true
+		r.wg.Add(1)
+		go r.run()
+	}
+}
+
+func (r *revalidator) run() {
+	defer r.wg.Done()
+	for {
+		// Stopping wins over a queued refresh
+		select {
+		case <-r.stop:
+			return
+		default:
+		}
+		select {
+		case <-r.stop:
+			return
+		case job := <-r.queue:
+			job.refresh()
+			r.mu.Lock()
+			delete(r.queued, job.key)
+			r.finished++
+			if len(r.queued) == 0 && r.emptied != nil {
+				close(r.emptied)
+				r.emptied = nil
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+// close drops the refreshes still queued and waits for the running one
+func (r *revalidator) close() {
+	r.mu.Lock()
+	if r.stopped {
+		r.mu.Unlock()
+		return
+	}
+	r.stopped = true
+	close(r.stop)
+	r.mu.Unlock()
+	r.wg.Wait()
+}
+
+// drain waits up to timeout for the queued refreshes to finish, then stops
+// as close does, dropping any still queued
+func (r *revalidator) drain(timeout time.Duration) {
+	r.mu.Lock()
+	var emptied chan struct{}
+	if !r.stopped && len(r.queued) > 0 {
+		if r.emptied == nil {
+			r.emptied = make(chan struct{})
+		}
+		emptied = r.emptied
+	}
+	r.mu.Unlock()
+
+	if emptied != nil {
+		timer := time.NewTimer(timeout)
+		select {
+		case <-emptied:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+	r.close()
+}
+
+// counts returns how many refreshes are queued and how many have finished
+func (r *revalidator) counts() (pending int, finished int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.queued), r.finished
+}
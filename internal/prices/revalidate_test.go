@@ -0,0 +1,167 @@
+package prices
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"github.com/guarzo/pkmgradegap/internal/model"
+	"github.com/guarzo/pkmgradegap/internal/testutil"
+)
+
+func TestPriceCharting_ServesStaleAndRevalidates(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status": "success", "id": "fresh", "product-name": "Pikachu #25", "manual-only-price": 3000}`))
+	}))
+	defer server.Close()
+
+	originalTransport := http.DefaultTransport
+	defer func() { http.DefaultTransport = originalTransport }()
+	http.DefaultTransport = &mockPriceChartingTransport{testServerURL: server.URL, original: originalTransport}
+
+	t.Chdir(t.TempDir())
+	store := cache.NewMemory()
+	store.SetStalePolicy(cache.StalePolicy{cache.NamespacePriceCharting: 24 * time.Hour})
+	card := model.Card{Name: "Pikachu", Number: "25"}
+	key := cache.PriceChartingKey("Base Set", card.Name, card.Number)
+	data, _ := json.Marshal(PCMatch{ID: "old", PSA10Cents: 2500})
+	_ = store.Backend().Put(cache.NamespaceOf(key), key, cache.Entry{
+		Data: data, Timestamp: time.Now().Add(-3 * time.Hour), TTL: time.Hour,
+	})
+
+	pc := NewPriceCharting(testutil.GetTestPriceChartingToken(), store)
+	defer pc.StopRevalidation()
+
+	match, err := pc.LookupCard("Base Set", card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.ID != "old" || !match.Stale {
+		t.Fatalf("expected the stale match served at once, got %s (stale %v)", match.ID, match.Stale)
+	}
+
+	// The refresh lands in the cache in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var cached PCMatch
+		if found, stale, _ := store.GetStale(key, &cached); found && !stale && cached.ID == "fresh" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the background refresh")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	match, err = pc.LookupCard("Base Set", card)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if match.ID != "fresh" || match.Stale {
+		t.Errorf("expected the refreshed match, got %s (stale %v)", match.ID, match.Stale)
+	}
+	if n := requests.Load(); n != 1 {
+		t.Errorf("expected one request for the refresh, got %d", n)
+	}
+}
+
+func TestPriceCharting_FinishRevalidationSavesRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Slow enough that the run would end before the refresh lands
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status": "success", "id": "fresh", "product-name": "Pikachu #25", "manual-only-price": 3000}`))
+	}))
+	defer server.Close()
+
+	originalTransport := http.DefaultTransport
+	defer func() { http.DefaultTransport = originalTransport }()
+	http.DefaultTransport = &mockPriceChartingTransport{testServerURL: server.URL, original: originalTransport}
+
+	t.Chdir(t.TempDir())
+	store := cache.NewMemory()
+	store.SetStalePolicy(cache.StalePolicy{cache.NamespacePriceCharting: 24 * time.Hour})
+	card := model.Card{Name: "Pikachu", Number: "25"}
+	key := cache.PriceChartingKey("Base Set", card.Name, card.Number)
+	data, _ := json.Marshal(PCMatch{ID: "old", PSA10Cents: 2500})
+	_ = store.Backend().Put(cache.NamespaceOf(key), key, cache.Entry{
+		Data: data, Timestamp: time.Now().Add(-3 * time.Hour), TTL: time.Hour,
+	})
+
+	pc := NewPriceCharting(testutil.GetTestPriceChartingToken(), store)
+	if match, err := pc.LookupCard("Base Set", card); err != nil || !match.Stale {
+		t.Fatalf("expected the stale match served, got %+v, %v", match, err)
+	}
+	pc.FinishRevalidation(5 * time.Second)
+
+	var cached PCMatch
+	if found, stale, _ := store.GetStale(key, &cached); !found || stale || cached.ID != "fresh" {
+		t.Errorf("expected the refresh in the cache once revalidation finished, got %s (stale %v)", cached.ID, stale)
+	}
+}
+
+func TestRevalidator_DrainIsBounded(t *testing.T) {
+	r := newRevalidator()
+	release := make(chan struct{})
+	defer close(release)
+	var runs atomic.Int32
+	r.enqueue("a", func() { runs.Add(1) })
+	r.enqueue("b", func() { runs.Add(1); <-release })
+	r.enqueue("c", func() { runs.Add(1) })
+
+	done := make(chan struct{})
+	go func() {
+		r.drain(50 * time.Millisecond)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("expected drain to wait for the running refresh")
+	case <-time.After(100 * time.Millisecond):
+	}
+	release <- struct{}{}
+	<-done
+	if n := runs.Load(); n != 2 {
+		t.Errorf("expected the refresh queued past the timeout dropped, ran %d", n)
+	}
+}
+
+func TestRevalidator_DedupesAndStops(t *testing.T) {
+	r := newRevalidator()
+	release := make(chan struct{})
+	var runs atomic.Int32
+	refresh := func() {
+		runs.Add(1)
+		<-release
+	}
+	r.enqueue("a", refresh)
+	r.enqueue("a", refresh)
+	r.enqueue("b", refresh)
+	if pending, _ := r.counts(); pending != 2 {
+		t.Errorf("expected the duplicate dropped, %d pending", pending)
+	}
+
+	// Wait for "a" to start, then stop: "b" is dropped
+	for runs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+	}()
+	r.close()
+	if n := runs.Load(); n != 1 {
+		t.Errorf("expected only the running refresh to finish, ran %d", n)
+	}
+	r.enqueue("c", refresh)
+	if _, finished := r.counts(); finished != 1 {
+		t.Errorf("expected no refreshes after close, finished %d", finished)
+	}
+}
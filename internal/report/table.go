@@ -0,0 +1,302 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/guarzo/pkmgradegap/internal/currency"
+)
+
+// Kind says how a column's values are typed and formatted
+type Kind int
+
+const (
+	Text    Kind = iota // string
+	Money               // float64 in the table's currency; zero or negative means no price
+	Percent             // float64 already scaled to 0-100
+	Number              // float64
+	Integer             // int
+)
+
+// Column describes one report column
+type Column struct {
+	Name      string
+	Kind      Kind
+	Precision int // decimal places for Percent and Number
+}
+
+// Table is an analysis report with typed, unformatted values. A nil cell
+// means the value is unknown. Renderers decide presentation.
+type Table struct {
+	Columns  []Column
+	Rows     [][]any
+	Notice   string // explains an empty report, e.g. a set that is too old
+	Currency string // currency of Money columns; "" means USD
+}
+
+// AddRow appends a row; it panics if the row doesn't match the columns,
+// which is always a programming error in the report builder.
+func (t *Table) AddRow(values ...any) {
+	if len(values) != len(t.Columns) {
+		panic(fmt.Sprintf("report: row has %d values for %d columns", len(values), len(t.Columns)))
+	}
+	t.Rows = append(t.Rows, values)
+}
+
+// Header returns the column names
+func (t *Table) Header() []string {
+	names := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// InCurrency returns a copy of a USD table with Money converted at rate,
+// the units of code one dollar buys. Column names mentioning USD are renamed.
+func (t *Table) InCurrency(code string, rate float64) *Table {
+	code = strings.ToUpper(code)
+	out := &Table{Columns: make([]Column, len(t.Columns)), Rows: make([][]any, len(t.Rows)), Notice: t.Notice, Currency: code}
+	for i, c := range t.Columns {
+		c.Name = strings.ReplaceAll(c.Name, currency.USD, code)
+		out.Columns[i] = c
+	}
+	for i, row := range t.Rows {
+		converted := make([]any, len(row))
+		for j, v := range row {
+			if t.Columns[j].Kind == Money && v != nil {
+				v = toFloat(v) * rate
+			}
+			converted[j] = v
+		}
+		out.Rows[i] = converted
+	}
+	return out
+}
+
+// Records renders the table as CSV-style string records, header first. Money
+// is formatted as "$12.34" (or "€12.34" in EUR) and a notice becomes a row of
+// its own.
+func (t *Table) Records() [][]string {
+	code := t.Currency
+	if code == "" {
+		code = currency.USD
+	}
+	out := [][]string{t.Header()}
+	for _, row := range t.Rows {
+		rec := make([]string, len(row))
+		for i, v := range row {
+			rec[i] = formatCell(t.Columns[i], v, code)
+		}
+		out = append(out, rec)
+	}
+	if t.Notice != "" {
+		rec := make([]string, len(t.Columns))
+		rec[0] = t.Notice
+		out = append(out, rec)
+	}
+	return out
+}
+
+func formatCell(c Column, v any, code string) string {
+	if v == nil {
+		return ""
+	}
+	switch c.Kind {
+	case Money:
+		f := toFloat(v)
+		if f <= 0 {
+			return ""
+		}
+		return currency.Format(round2(f), code)
+	case Percent:
+		return strconv.FormatFloat(toFloat(v), 'f', c.Precision, 64) + "%"
+	case Number:
+		return strconv.FormatFloat(toFloat(v), 'f', c.Precision, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// jsonValue converts a cell to its JSON form: money rounded to cents, with
+// missing prices as null.
+func jsonValue(c Column, v any) any {
+	if v == nil {
+		return nil
+	}
+	switch c.Kind {
+	case Money:
+		f := toFloat(v)
+		if f <= 0 {
+			return nil
+		}
+		return round2(f)
+	case Percent, Number:
+		return toFloat(v)
+	default:
+		return v
+	}
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	default:
+		return 0
+	}
+}
+
+func round2(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// object returns row i keyed by column name
+func (t *Table) object(i int) map[string]any {
+	obj := make(map[string]any, len(t.Columns))
+	for j, c := range t.Columns {
+		obj[c.Name] = jsonValue(c, t.Rows[i][j])
+	}
+	return obj
+}
+
+// MarshalJSON encodes the table as {"columns": [...], "rows": [{...}],
+// "notice": "...", "currency": "..."}, with the currency only when not USD
+func (t *Table) MarshalJSON() ([]byte, error) {
+	rows := make([]map[string]any, len(t.Rows))
+	for i := range t.Rows {
+		rows[i] = t.object(i)
+	}
+	return json.Marshal(struct {
+		Columns  []string         `json:"columns"`
+		Rows     []map[string]any `json:"rows"`
+		Notice   string           `json:"notice,omitempty"`
+		Currency string           `json:"currency,omitempty"`
+	}{t.Header(), rows, t.Notice, t.Currency})
+}
+
+// Formats accepted by Write
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatTable  = "table"
+)
+
+// Formats lists the output formats in display order
+var Formats = []string{FormatCSV, FormatJSON, FormatNDJSON, FormatTable}
+
+// Write renders t to w in the named format
+func Write(w io.Writer, format string, t *Table) error {
+	switch format {
+	case FormatCSV, "":
+		return WriteCSV(w, t)
+	case FormatJSON:
+		return WriteJSON(w, t)
+	case FormatNDJSON:
+		return WriteNDJSON(w, t)
+	case FormatTable:
+		return WriteText(w, t)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+}
+
+// WriteCSV writes the table as CSV, escaping cells against formula injection
+func WriteCSV(w io.Writer, t *Table) error {
+	cw := csv.NewWriter(w)
+	if err := cw.WriteAll(EscapeCSVRows(t.Records())); err != nil {
+		return fmt.Errorf("write csv: %w", err)
+	}
+	return nil
+}
+
+// WriteJSON writes the table as a single indented JSON document
+func WriteJSON(w io.Writer, t *Table) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(t); err != nil {
+		return fmt.Errorf("write json: %w", err)
+	}
+	return nil
+}
+
+// WriteNDJSON writes one JSON object per row. A notice is written as a
+// final {"notice": "..."} line so consumers can tell an empty report apart.
+func WriteNDJSON(w io.Writer, t *Table) error {
+	enc := json.NewEncoder(w)
+	for i := range t.Rows {
+		if err := enc.Encode(t.object(i)); err != nil {
+			return fmt.Errorf("write ndjson: %w", err)
+		}
+	}
+	if t.Notice != "" {
+		if err := enc.Encode(map[string]string{"notice": t.Notice}); err != nil {
+			return fmt.Errorf("write ndjson: %w", err)
+		}
+	}
+	return nil
+}
+
+// WriteText writes a fixed-width table for terminals, right-aligning
+// numeric columns.
+func WriteText(w io.Writer, t *Table) error {
+	records := t.Records()
+	if t.Notice != "" {
+		records = records[:len(records)-1]
+	}
+
+	widths := make([]int, len(t.Columns))
+	for _, rec := range records {
+		for i, cell := range rec {
+			if n := utf8.RuneCountInString(cell); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	var b strings.Builder
+	writeLine := func(rec []string) {
+		for i, cell := range rec {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			pad := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(cell))
+			if t.Columns[i].Kind == Text {
+				b.WriteString(cell)
+				if i < len(rec)-1 {
+					b.WriteString(pad)
+				}
+			} else {
+				b.WriteString(pad + cell)
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	writeLine(records[0])
+	rule := make([]string, len(widths))
+	for i, n := range widths {
+		rule[i] = strings.Repeat("-", n)
+	}
+	writeLine(rule)
+	for _, rec := range records[1:] {
+		writeLine(rec)
+	}
+	if t.Notice != "" {
+		b.WriteString(t.Notice + "\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write table: %w", err)
+	}
+	return nil
+}
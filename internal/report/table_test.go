@@ -0,0 +1,151 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testTable() *Table {
+	t := &Table{Columns: []Column{
+		{Name: "Card"},
+		{Name: "RawUSD", Kind: Money},
+		{Name: "Ratio", Kind: Percent, Precision: 1},
+		{Name: "Score", Kind: Number, Precision: 1},
+		{Name: "Listings", Kind: Integer},
+	}}
+	t.AddRow("Pikachu", 12.345, 66.666, 42.25, 3)
+	t.AddRow("=SUM(A1)", 0.0, nil, -1.0, 0)
+	return t
+}
+
+func TestTable_Records(t *testing.T) {
+	got := testTable().Records()
+	want := [][]string{
+		{"Card", "RawUSD", "Ratio", "Score", "Listings"},
+		{"Pikachu", "$12.35", "66.7%", "42.2", "3"},
+		{"=SUM(A1)", "", "", "-1.0", "0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Records() = %v, want %v", got, want)
+	}
+}
+
+func TestTable_InCurrency(t *testing.T) {
+	src := testTable()
+	eur := src.InCurrency("eur", 0.5)
+	got := eur.Records()
+	want := [][]string{
+		{"Card", "RawEUR", "Ratio", "Score", "Listings"},
+		{"Pikachu", "€6.17", "66.7%", "42.2", "3"},
+		{"=SUM(A1)", "", "", "-1.0", "0"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Records() = %v, want %v", got, want)
+	}
+	if src.Rows[0][1] != 12.345 || src.Columns[1].Name != "RawUSD" {
+		t.Error("expected the source table left in USD")
+	}
+
+	data, err := json.Marshal(eur)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"currency":"EUR"`) || !strings.Contains(string(data), `"RawEUR":6.17`) {
+		t.Errorf("expected EUR values and currency in JSON, got %s", data)
+	}
+}
+
+func TestTable_NoticeRow(t *testing.T) {
+	tbl := &Table{Columns: []Column{{Name: "Card"}, {Name: "RawUSD", Kind: Money}}, Notice: "Set is too old"}
+	got := tbl.Records()
+	if len(got) != 2 || got[1][0] != "Set is too old" || got[1][1] != "" {
+		t.Errorf("expected notice as final row, got %v", got)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, tbl); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(buf.String()) != `{"notice":"Set is too old"}` {
+		t.Errorf("unexpected ndjson notice: %s", buf.String())
+	}
+}
+
+func TestWriteCSV_EscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testTable()); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "'=SUM(A1)") {
+		t.Errorf("expected formula cell to be escaped, got:\n%s", buf.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, testTable()); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Columns []string         `json:"columns"`
+		Rows    []map[string]any `json:"rows"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if len(doc.Columns) != 5 || len(doc.Rows) != 2 {
+		t.Fatalf("unexpected shape: %+v", doc)
+	}
+	first := doc.Rows[0]
+	if first["RawUSD"] != 12.35 || first["Listings"] != 3.0 || first["Card"] != "Pikachu" {
+		t.Errorf("unexpected first row: %v", first)
+	}
+	if v, ok := doc.Rows[1]["RawUSD"]; !ok || v != nil {
+		t.Errorf("expected missing money as null, got %v", v)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteNDJSON(&buf, testTable()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per row, got %d", len(lines))
+	}
+	for _, line := range lines {
+		var obj map[string]any
+		if err := json.Unmarshal([]byte(line), &obj); err != nil {
+			t.Errorf("line %q is not a JSON object: %v", line, err)
+		}
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteText(&buf, testTable()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected header, rule and 2 rows, got:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(lines[1], "--------") {
+		t.Errorf("expected rule under header, got %q", lines[1])
+	}
+	// Numeric columns are right-aligned, so every line ends at the same width
+	if len(lines[0]) != len(lines[2]) || len(lines[2]) != len(lines[3]) {
+		t.Errorf("expected fixed-width lines, got:\n%s", buf.String())
+	}
+}
+
+func TestWrite_UnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "xml", testTable()); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
@@ -0,0 +1,34 @@
+package sales
+
+import (
+	"strings"
+
+	"github.com/guarzo/pkmgradegap/internal/listing"
+)
+
+// GradeRaw is the normalised grade of an ungraded sale
+const GradeRaw = "Raw"
+
+// rawLabels are grade values sales feeds use for ungraded cards
+var rawLabels = map[string]bool{
+	"": true, "raw": true, "ungraded": true, "none": true, "n/a": true,
+	"nm": true, "near mint": true, "lp": true, "mp": true, "hp": true,
+}
+
+// NormalizeGrade turns a sale's grade into "GRADER N" form, e.g. "PSA 10"
+// or "BGS 9.5", or GradeRaw. When the grade is empty or a raw condition the
+// listing title is checked for a grade before calling the sale raw. Grades
+// that can't be read are returned trimmed but otherwise unchanged.
+func NormalizeGrade(grade, title string) string {
+	grade = strings.TrimSpace(grade)
+	if l := listing.Parse(grade); l.Graded && l.Grade > 0 {
+		return l.Label()
+	}
+	if !rawLabels[strings.ToLower(grade)] {
+		return grade
+	}
+	if l := listing.Parse(title); l.Graded && l.Grade > 0 {
+		return l.Label()
+	}
+	return GradeRaw
+}
@@ -0,0 +1,233 @@
+package sales
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+	"golang.org/x/time/rate"
+)
+
+// DefaultPokemonPriceTrackerURL is the sales API used when the config has no URL
+const DefaultPokemonPriceTrackerURL = "https://www.pokemonpricetracker.com/api/v1"
+
+// HTTPProvider fetches completed sales from a JSON sales API:
+//
+//	GET {url}/sales?set=<set>&name=<card>&number=<number>
+//	Authorization: Bearer <api key>
+//
+// which answers {"sales": [{"date", "price", "grade", "platform", "title"}]}
+// with dates as RFC 3339 or YYYY-MM-DD and prices in USD. A 404 means the
+// card has no sales.
+type HTTPProvider struct {
+	apiKey     string
+	baseURL    string
+	client     *http.Client
+	limiter    *rate.Limiter
+	cache      cache.Store
+	cacheTTL   time.Duration
+	maxRetries int
+	retryDelay time.Duration // first backoff; doubles on each retry
+	debug      bool
+}
+
+// NewHTTPProvider creates a provider from the config. Without a rate limit
+// requests are unthrottled; caching needs both CacheEnabled and a Cache.
+func NewHTTPProvider(config Config) *HTTPProvider {
+	baseURL := config.PokemonPriceTrackerURL
+	if baseURL == "" {
+		baseURL = DefaultPokemonPriceTrackerURL
+	}
+	timeout := config.RequestTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	limit := rate.Inf
+	if config.RateLimitPerMin > 0 {
+		limit = rate.Every(time.Minute / time.Duration(config.RateLimitPerMin))
+	}
+	p := &HTTPProvider{
+		apiKey:     config.PokemonPriceTrackerAPIKey,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		client:     &http.Client{Timeout: timeout},
+		limiter:    rate.NewLimiter(limit, 1),
+		cacheTTL:   time.Duration(config.CacheTTLMinutes) * time.Minute,
+		maxRetries: max(config.MaxRetries, 0),
+		retryDelay: time.Second,
+		debug:      config.Debug,
+	}
+	if config.CacheEnabled {
+		p.cache = config.Cache
+	}
+	if p.cacheTTL <= 0 {
+		p.cacheTTL = time.Hour
+	}
+	return p
+}
+
+// Available returns true when an API key is configured
+func (p *HTTPProvider) Available() bool {
+	return p.apiKey != ""
+}
+
+// GetProviderName returns the provider name
+func (p *HTTPProvider) GetProviderName() string {
+	return "PokemonPriceTracker"
+}
+
+// IsMockMode returns false; the data comes from the API
+func (p *HTTPProvider) IsMockMode() bool {
+	return false
+}
+
+// salesResponse is the API's response body
+type salesResponse struct {
+	Sales []struct {
+		Date     string  `json:"date"`
+		Price    float64 `json:"price"`
+		Grade    string  `json:"grade"`
+		Platform string  `json:"platform"`
+		Title    string  `json:"title"`
+	} `json:"sales"`
+}
+
+// errNotFound marks a 404, which means the card has no recorded sales
+var errNotFound = errors.New("not found")
+
+// GetSalesData returns a card's recent sales with grades normalised, or nil
+// when the API has none
+func (p *HTTPProvider) GetSalesData(setName, cardName, number string) (*SalesData, error) {
+	return p.GetSalesDataContext(context.Background(), setName, cardName, number)
+}
+
+// GetSalesDataContext is GetSalesData with a context that cancels the
+// request and any backoff between retries
+func (p *HTTPProvider) GetSalesDataContext(ctx context.Context, setName, cardName, number string) (*SalesData, error) {
+	if !p.Available() {
+		return nil, fmt.Errorf("sales provider not configured")
+	}
+
+	key := cache.BuildKey("sales", setName, cardName, number)
+	if p.cache != nil {
+		var cached SalesData
+		if found, _ := p.cache.Get(key, &cached); found {
+			if cached.SaleCount == 0 && len(cached.RecentSales) == 0 {
+				return nil, nil
+			}
+			return &cached, nil
+		}
+	}
+
+	q := url.Values{}
+	q.Set("set", setName)
+	q.Set("name", cardName)
+	q.Set("number", number)
+
+	var resp salesResponse
+	err := p.get(ctx, p.baseURL+"/sales?"+q.Encode(), &resp)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("sales lookup %s #%s: %w", cardName, number, err)
+	}
+
+	var records []SaleRecord
+	for _, s := range resp.Sales {
+		if s.Price <= 0 {
+			continue
+		}
+		date, _ := parseSaleDate(s.Date)
+		records = append(records, SaleRecord{
+			Date:     date,
+			Price:    s.Price,
+			Grade:    NormalizeGrade(s.Grade, s.Title),
+			Platform: s.Platform,
+			Title:    s.Title,
+		})
+	}
+	data := NewSalesData(setName, cardName, number, p.GetProviderName(), records)
+
+	if p.cache != nil {
+		_ = p.cache.Put(key, data, p.cacheTTL)
+	}
+	if data.SaleCount == 0 {
+		return nil, nil
+	}
+	return data, nil
+}
+
+// get fetches a URL into a JSON value, waiting on the rate limiter before
+// every attempt. Network errors, 429 and 5xx responses are retried with
+// exponential backoff; other client errors are not.
+func (p *HTTPProvider) get(ctx context.Context, u string, into any) error {
+	var lastErr error
+	for attempt := 0; attempt <= p.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := p.retryDelay * time.Duration(1<<(attempt-1))
+			if p.debug {
+				log.Printf("sales: retrying in %v after: %v", delay, lastErr)
+			}
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			case <-timer.C:
+			}
+		}
+		if err := p.limiter.Wait(ctx); err != nil {
+			return fmt.Errorf("rate limiter: %w", err)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+		if err != nil {
+			return fmt.Errorf("creating request: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", "pkmgradegap/1.0")
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = fmt.Errorf("reading response: %w", err)
+			continue
+		}
+
+		switch {
+		case resp.StatusCode == http.StatusOK:
+			if err := json.Unmarshal(body, into); err != nil {
+				return fmt.Errorf("decoding response: %w", err)
+			}
+			return nil
+		case resp.StatusCode == http.StatusNotFound:
+			return errNotFound
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			lastErr = fmt.Errorf("HTTP %d", resp.StatusCode)
+		default:
+			return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+	}
+	return fmt.Errorf("after %d attempts: %w", p.maxRetries+1, lastErr)
+}
+
+func parseSaleDate(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
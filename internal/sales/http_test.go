@@ -0,0 +1,174 @@
+package sales
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+)
+
+const salesJSON = `{"sales": [
+	{"date": "2024-05-01", "price": 250, "grade": "psa10", "platform": "eBay", "title": "Umbreon VMAX 215 PSA 10"},
+	{"date": "2024-05-03T10:00:00Z", "price": 40, "grade": "", "platform": "eBay", "title": "Umbreon VMAX 215 NM"},
+	{"date": "2024-05-04", "price": 60, "grade": "Near Mint", "platform": "TCGPlayer", "title": "Umbreon VMAX"},
+	{"date": "2024-05-05", "price": 120, "grade": "", "platform": "eBay", "title": "Umbreon VMAX Beckett 9.5 Gem Mint"},
+	{"date": "2024-05-06", "price": 0, "grade": "PSA 9", "title": "no price"}
+]}`
+
+// newTestProvider points a provider at a handler with retries immediate
+func newTestProvider(t *testing.T, handler http.HandlerFunc, config Config) *HTTPProvider {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	config.PokemonPriceTrackerAPIKey = "key"
+	config.PokemonPriceTrackerURL = srv.URL
+	p := NewHTTPProvider(config)
+	p.retryDelay = 0
+	return p
+}
+
+func TestHTTPProvider_GetSalesData(t *testing.T) {
+	var hits atomic.Int32
+	c, err := cache.New(filepath.Join(t.TempDir(), "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/sales" || r.URL.Query().Get("name") != "Umbreon VMAX" || r.URL.Query().Get("number") != "215" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		w.Write([]byte(salesJSON))
+	}, Config{CacheEnabled: true, Cache: c})
+
+	sd, err := p.GetSalesData("Evolving Skies", "Umbreon VMAX", "215")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sd.SaleCount != 4 {
+		t.Fatalf("expected the unpriced sale dropped, got %d sales", sd.SaleCount)
+	}
+	grades := make([]string, len(sd.RecentSales))
+	for i, s := range sd.RecentSales {
+		grades[i] = s.Grade
+	}
+	if got := strings.Join(grades, ","); got != "PSA 10,Raw,Raw,BGS 9.5" {
+		t.Errorf("unexpected normalised grades %s", got)
+	}
+	if sd.MedianPrice != 50 || sd.RecentSales[1].Date.IsZero() {
+		t.Errorf("expected raw median 50 and parsed dates, got %.2f %v", sd.MedianPrice, sd.RecentSales[1].Date)
+	}
+
+	if _, err := p.GetSalesData("Evolving Skies", "Umbreon VMAX", "215"); err != nil {
+		t.Fatal(err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("expected the second lookup served from cache, got %d requests", hits.Load())
+	}
+}
+
+func TestHTTPProvider_Retries(t *testing.T) {
+	var hits atomic.Int32
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(salesJSON))
+	}, Config{MaxRetries: 2})
+	if sd, err := p.GetSalesData("s", "c", "1"); err != nil || sd == nil {
+		t.Fatalf("expected success on the third attempt, got %v", err)
+	}
+
+	hits.Store(0)
+	p = newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}, Config{MaxRetries: 1})
+	if _, err := p.GetSalesData("s", "c", "1"); err == nil || hits.Load() != 2 {
+		t.Errorf("expected failure after 2 attempts, got %v after %d", err, hits.Load())
+	}
+
+	hits.Store(0)
+	p = newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Error(w, "bad key", http.StatusForbidden)
+	}, Config{MaxRetries: 3})
+	if _, err := p.GetSalesData("s", "c", "1"); err == nil || !strings.Contains(err.Error(), "bad key") || hits.Load() != 1 {
+		t.Errorf("expected client errors not retried, got %v after %d", err, hits.Load())
+	}
+}
+
+func TestHTTPProvider_CancelDuringBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var hits atomic.Int32
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		cancel()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}, Config{MaxRetries: 3})
+	p.retryDelay = time.Hour
+
+	start := time.Now()
+	_, err := p.GetSalesDataContext(ctx, "s", "c", "1")
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the cancellation reported, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second || hits.Load() != 1 {
+		t.Errorf("expected the backoff abandoned, took %v over %d attempts", elapsed, hits.Load())
+	}
+}
+
+func TestHTTPProvider_NotFound(t *testing.T) {
+	p := newTestProvider(t, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	}, Config{})
+	sd, err := p.GetSalesData("s", "c", "1")
+	if err != nil || sd != nil {
+		t.Errorf("expected no sales and no error, got %v %v", sd, err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	if !NewProvider(Config{}).IsMockMode() {
+		t.Error("expected the mock without an API key")
+	}
+	if NewProvider(Config{PokemonPriceTrackerAPIKey: "key"}).IsMockMode() {
+		t.Error("expected the HTTP provider with an API key")
+	}
+}
+
+func TestNormalizeGrade(t *testing.T) {
+	tests := []struct {
+		grade, title, want string
+	}{
+		{"PSA 10", "", "PSA 10"},
+		{"psa10", "", "PSA 10"},
+		{"PSA-9", "", "PSA 9"},
+		{"PSA GEM MT 10", "", "PSA 10"},
+		{"bgs 9.5", "", "BGS 9.5"},
+		{"Beckett Black Label 10", "", "BGS 10"},
+		{"CGC Pristine 10", "", "CGC 10"},
+		{"", "Charizard 4/102 SGC 9 Mint", "SGC 9"},
+		{"", "Charizard 4/102 Near Mint", GradeRaw},
+		{"Ungraded", "", GradeRaw},
+		{"", "Pikachu PSA 100th anniversary", GradeRaw},
+		{"Authentic", "", "Authentic"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeGrade(tt.grade, tt.title); got != tt.want {
+			t.Errorf("NormalizeGrade(%q, %q) = %q, want %q", tt.grade, tt.title, got, tt.want)
+		}
+	}
+}
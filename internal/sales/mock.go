@@ -3,52 +3,12 @@ package sales
 import (
 	"fmt"
 	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/guarzo/pkmgradegap/internal/model"
 )
 
-// SalesData represents sales transaction data for a card
-type SalesData struct {
-	CardName     string       `json:"cardName"`
-	SetName      string       `json:"setName"`
-	CardNumber   string       `json:"cardNumber"`
-	LastUpdated  time.Time    `json:"lastUpdated"`
-	SaleCount    int          `json:"saleCount"`
-	AveragePrice float64      `json:"averagePrice"`
-	MedianPrice  float64      `json:"medianPrice"`
-	RecentSales  []SaleRecord `json:"recentSales"`
-	DataSource   string       `json:"dataSource"`
-}
-
-// SaleRecord represents a single sale transaction
-type SaleRecord struct {
-	Date     time.Time `json:"date"`
-	Price    float64   `json:"price"`
-	Grade    string    `json:"grade,omitempty"`
-	Platform string    `json:"platform,omitempty"`
-	Title    string    `json:"title,omitempty"`
-}
-
-// Provider interface for sales data providers
-type Provider interface {
-	Available() bool
-	GetProviderName() string
-	IsMockMode() bool
-	GetSalesData(setName, cardName, number string) (*SalesData, error)
-}
-
-// Config holds configuration for sales providers
-type Config struct {
-	PokemonPriceTrackerAPIKey string
-	PokemonPriceTrackerURL    string
-	CacheEnabled              bool
-	CacheTTLMinutes           int
-	RequestTimeout            time.Duration
-	MaxRetries                int
-	RateLimitPerMin           int
-}
-
 // MockProvider implements a mock sales data provider for testing
 type MockProvider struct {
 	// Can be configured to simulate different scenarios
@@ -79,11 +39,6 @@ func (m *MockProvider) IsMockMode() bool {
 	return true
 }
 
-// NewProvider creates a new sales provider (returns mock for now)
-func NewProvider(config Config) Provider {
-	return NewMockProvider()
-}
-
 // calculateAverage calculates the average of a slice of prices
 func calculateAverage(prices []float64) float64 {
 	if len(prices) == 0 {
@@ -101,12 +56,13 @@ func calculateMedian(prices []float64) float64 {
 	if len(prices) == 0 {
 		return 0
 	}
-	if len(prices) == 1 {
-		return prices[0]
+	sorted := append([]float64(nil), prices...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
 	}
-
-	// Simple median calculation (would need sorting in real implementation)
-	return prices[len(prices)/2]
+	return sorted[mid]
 }
 
 // GetSalesData returns mock sales data for testing
@@ -0,0 +1,83 @@
+// Package sales provides completed-sale history for cards, from a sales
+// API or a deterministic mock.
+package sales
+
+import (
+	"time"
+
+	"github.com/guarzo/pkmgradegap/internal/cache"
+)
+
+// SalesData represents sales transaction data for a card
+type SalesData struct {
+	CardName     string       `json:"cardName"`
+	SetName      string       `json:"setName"`
+	CardNumber   string       `json:"cardNumber"`
+	LastUpdated  time.Time    `json:"lastUpdated"`
+	SaleCount    int          `json:"saleCount"`
+	AveragePrice float64      `json:"averagePrice"`
+	MedianPrice  float64      `json:"medianPrice"`
+	RecentSales  []SaleRecord `json:"recentSales"`
+	DataSource   string       `json:"dataSource"`
+}
+
+// SaleRecord represents a single sale transaction
+type SaleRecord struct {
+	Date     time.Time `json:"date"`
+	Price    float64   `json:"price"`
+	Grade    string    `json:"grade,omitempty"`
+	Platform string    `json:"platform,omitempty"`
+	Title    string    `json:"title,omitempty"`
+}
+
+// Provider interface for sales data providers
+type Provider interface {
+	Available() bool
+	GetProviderName() string
+	IsMockMode() bool
+	GetSalesData(setName, cardName, number string) (*SalesData, error)
+}
+
+// Config holds configuration for sales providers
+type Config struct {
+	PokemonPriceTrackerAPIKey string
+	PokemonPriceTrackerURL    string // default DefaultPokemonPriceTrackerURL
+	CacheEnabled              bool
+	Cache                     cache.Store // where responses are cached when CacheEnabled
+	CacheTTLMinutes           int
+	RequestTimeout            time.Duration
+	MaxRetries                int
+	RateLimitPerMin           int
+	Debug                     bool // log each retry
+}
+
+// NewProvider returns the HTTP provider when an API key is configured and
+// the mock otherwise
+func NewProvider(config Config) Provider {
+	if config.PokemonPriceTrackerAPIKey == "" {
+		return NewMockProvider()
+	}
+	return NewHTTPProvider(config)
+}
+
+// NewSalesData summarises sale records for a card. The median and average
+// are over raw copies, since graded sales are priced per grade.
+func NewSalesData(setName, cardName, number, source string, records []SaleRecord) *SalesData {
+	var rawPrices []float64
+	for _, r := range records {
+		if r.Grade == GradeRaw {
+			rawPrices = append(rawPrices, r.Price)
+		}
+	}
+	return &SalesData{
+		CardName:     cardName,
+		SetName:      setName,
+		CardNumber:   number,
+		LastUpdated:  time.Now(),
+		SaleCount:    len(records),
+		AveragePrice: calculateAverage(rawPrices),
+		MedianPrice:  calculateMedian(rawPrices),
+		RecentSales:  records,
+		DataSource:   source,
+	}
+}
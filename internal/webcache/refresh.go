@@ -10,6 +10,7 @@ import (
 
 	"github.com/guarzo/pkmgradegap/internal/analysis"
 	"github.com/guarzo/pkmgradegap/internal/cards"
+	"github.com/guarzo/pkmgradegap/internal/fees"
 	"github.com/guarzo/pkmgradegap/internal/model"
 	"github.com/guarzo/pkmgradegap/internal/population"
 	"github.com/guarzo/pkmgradegap/internal/prices"
@@ -23,6 +24,7 @@ type RefreshService struct {
 	priceProv  *prices.PriceCharting
 	popProv    population.Provider
 	volTracker *volatility.Tracker
+	fees       *fees.Book
 }
 
 // RefreshOptions configures the refresh process
@@ -31,7 +33,7 @@ type RefreshOptions struct {
 	MinRawUSD        float64  `json:"minRawUSD"`
 	MinDeltaUSD      float64  `json:"minDeltaUSD"`
 	MaxAgeYears      int      `json:"maxAgeYears"`
-	GradingCost      float64  `json:"gradingCost"`
+	GradingCost      float64  `json:"gradingCost"` // 0 uses the PSA fee schedule
 	ShippingCost     float64  `json:"shippingCost"`
 	FeePct           float64  `json:"feePct"`
 	JapaneseWeight   float64  `json:"japaneseWeight"`
@@ -47,7 +49,7 @@ func DefaultRefreshOptions() RefreshOptions {
 		MinRawUSD:        5.0,
 		MinDeltaUSD:      25.0,
 		MaxAgeYears:      10,
-		GradingCost:      25.0,
+		GradingCost:      0, // from the PSA fee schedule
 		ShippingCost:     20.0,
 		FeePct:           0.13,
 		JapaneseWeight:   1.0,
@@ -56,14 +58,16 @@ func DefaultRefreshOptions() RefreshOptions {
 	}
 }
 
-// NewRefreshService creates a new refresh service
-func NewRefreshService(webCache *WebCache, cardProv *cards.PokeTCGIO, priceProv *prices.PriceCharting, popProv population.Provider, volTracker *volatility.Tracker) *RefreshService {
+// NewRefreshService creates a new refresh service that prices grading with
+// book's fee schedules; a nil book uses the built-in ones
+func NewRefreshService(webCache *WebCache, cardProv *cards.PokeTCGIO, priceProv *prices.PriceCharting, popProv population.Provider, volTracker *volatility.Tracker, book *fees.Book) *RefreshService {
 	return &RefreshService{
 		webCache:   webCache,
 		cardProv:   cardProv,
 		priceProv:  priceProv,
 		popProv:    popProv,
 		volTracker: volTracker,
+		fees:       book,
 	}
 }
 
@@ -254,6 +258,7 @@ func (rs *RefreshService) processSet(ctx context.Context, set model.Set, options
 		MinDeltaUSD:    options.MinDeltaUSD,
 		MaxAgeYears:    options.MaxAgeYears,
 		GradingCost:    options.GradingCost,
+		Fees:           rs.fees,
 		ShippingCost:   options.ShippingCost,
 		FeePct:         options.FeePct,
 		JapaneseWeight: options.JapaneseWeight,
@@ -282,7 +287,10 @@ func (rs *RefreshService) buildAnalysisRow(ctx context.Context, card model.Card,
 				PSA10:   float64(match.PSA10Cents) / 100.0,
 				Grade9:  float64(match.Grade9Cents) / 100.0,
 				Grade95: float64(match.Grade95Cents) / 100.0,
+				Grade8:  float64(match.NewPriceCents) / 100.0,
 				BGS10:   float64(match.BGS10Cents) / 100.0,
+				CGC10:   float64(match.CGC10Cents) / 100.0,
+				SGC10:   float64(match.SGC10Cents) / 100.0,
 			}
 		} else if err != nil {
 			// Log API errors but continue processing
@@ -301,6 +309,7 @@ func (rs *RefreshService) buildAnalysisRow(ctx context.Context, card model.Card,
 				PSA10:       pData.PSA10Population,
 				PSA9:        pData.PSA9Population,
 				PSA8:        pData.PSA8Population,
+				Grades:      pData.GradePopulation,
 				LastUpdated: pData.LastUpdated,
 			}
 		}
@@ -401,7 +410,7 @@ func (rs *RefreshService) scoreRows(rows []analysis.Row, cfg analysis.Config) []
 			targetGrade = row.Grades.BGS10
 		}
 
-		totalCost := row.RawUSD + cfg.GradingCost + cfg.ShippingCost
+		totalCost := row.RawUSD + cfg.GradingFee(row) + cfg.ShippingCost
 		sellingFees := targetGrade * cfg.FeePct
 		netProfit := targetGrade - totalCost - sellingFees
 